@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -13,6 +14,7 @@ import (
 	"runtime"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dweymouth/supersonic/backend/ipc"
@@ -36,6 +38,7 @@ const (
 	savedShuffledQueueFile   = "saved_shuffled_queue.json"
 	themesDir                = "themes"
 	audioCacheSubdir         = "audio"
+	waveformCacheSubdir      = "waveforms"
 )
 
 var (
@@ -46,24 +49,30 @@ var (
 )
 
 type App struct {
-	Config          *Config
-	ServerManager   *ServerManager
-	LyricsManager   *LyricsManager
-	ImageManager    *ImageManager
-	AudioCache      *AudioCache
-	AutoEQManager   *AutoEQManager
-	EQPresetManager *EQPresetManager
-	PlaybackManager *PlaybackManager
-	LocalPlayer     *mpv.Player
-	UpdateChecker   UpdateChecker
-	MPRISHandler    *MPRISHandler
-	WinSMTC         *windows.SMTC
-	ipcServer       ipc.IPCServer
+	Config               *Config
+	ServerManager        *ServerManager
+	LyricsManager        *LyricsManager
+	ImageManager         *ImageManager
+	AudioCache           *AudioCache
+	AutoEQManager        *AutoEQManager
+	EQPresetManager      *EQPresetManager
+	SmartPlaylistManager *SmartPlaylistManager
+	ListeningHistory     *ListeningHistory // nil if the database could not be opened
+	PlaybackManager      *PlaybackManager
+	PCMTap               *PCMTap
+	LocalPlayer          *mpv.Player
+	UpdateChecker        UpdateChecker
+	MPRISHandler         *MPRISHandler
+	UPnPRenderer         *UPnPRenderer
+	UPnPMediaServer      *UPnPMediaServer
+	WinSMTC              *windows.SMTC
+	ipcServer            ipc.IPCServer
 
 	// UI callbacks to be set in main
 	OnReactivate  func()
 	OnExit        func()
 	OnReloadTheme func()
+	OnMiniPlayer  func()
 
 	appName        string
 	displayAppName string
@@ -78,6 +87,9 @@ type App struct {
 
 	lastWrittenCfg Config
 
+	streamProxyLock sync.Mutex
+	socksRelay      *socksRelay // relays mpv's streams to a SOCKS proxy, if set
+
 	logFile *os.File
 }
 
@@ -138,6 +150,8 @@ func StartupApp(appName, displayAppName, appVersion, appVersionTag, latestReleas
 	log.Printf("Using config dir: %s", confDir)
 	log.Printf("Using cache dir: %s", cacheDir)
 
+	SetGlobalProxy(a.Config.Application.ProxyURL)
+
 	if a.Config.Application.EnableAutoUpdateChecker {
 		a.UpdateChecker = NewUpdateChecker(appVersionTag, latestReleaseURL, &a.Config.Application.LastCheckedVersion)
 		a.UpdateChecker.Start(a.bgrndCtx, 24*time.Hour)
@@ -151,6 +165,11 @@ func StartupApp(appName, displayAppName, appVersion, appVersionTag, latestReleas
 	}
 
 	a.ServerManager = NewServerManager(appName, appVersion, a.Config, !portableMode && a.Config.Application.EnablePasswordStorage)
+	a.ServerManager.OnConnectionChanged(func(bool) { a.updateStreamingProxy() })
+	a.ServerManager.OnServerConnected(func(conf *ServerConfig) {
+		a.updateStreamingProxy()
+		a.LocalPlayer.SetStreamOptions(mpvStreamOptions(conf.ServerConnection))
+	})
 	a.ImageManager = NewImageManager(a.bgrndCtx, a.ServerManager, cacheDir)
 	if a.Config.Playback.UseWaveformSeekbar {
 		ac, err := NewAudioCache(a.bgrndCtx, a.ServerManager, filepath.Join(cacheDir, audioCacheSubdir))
@@ -159,14 +178,13 @@ func StartupApp(appName, displayAppName, appVersion, appVersionTag, latestReleas
 		}
 		a.AudioCache = ac
 	}
-	a.PlaybackManager = NewPlaybackManager(a.bgrndCtx, a.ServerManager, a.AudioCache, a.LocalPlayer, &a.Config.Playback, &a.Config.Scrobbling, &a.Config.Transcoding, &a.Config.Application)
-	a.PlaybackManager.CoverArtPathFn = func(coverArtID string) (string, error) {
-		// Ensure the thumbnail is cached on disk, then return its path so
-		// the DLNA player can expose it through the local proxy as
-		// upnp:albumArtURI.
-		a.ImageManager.GetCoverThumbnail(coverArtID)
-		return a.ImageManager.GetCoverArtPath(coverArtID)
-	}
+	a.PlaybackManager = NewPlaybackManager(a.bgrndCtx, a.ServerManager, a.AudioCache, a.LocalPlayer, &a.Config.Playback, &a.Config.Scrobbling, &a.Config.Application)
+	a.PCMTap = NewPCMTap(a.PlaybackManager, a.AudioCache)
+	a.Config.Application.MaxWaveformCacheSizeMB = clamp(a.Config.Application.MaxWaveformCacheSizeMB, 1, 500)
+	a.PlaybackManager.SetWaveformCache(NewWaveformCache(filepath.Join(cacheDir, waveformCacheSubdir),
+		int64(a.Config.Application.MaxWaveformCacheSizeMB)*1_048_576))
+	// lets the DLNA player expose the cover art through its local proxy as upnp:albumArtURI
+	a.PlaybackManager.CoverArtPathFn = a.localCoverArtPath
 	a.Config.Application.MaxImageCacheSizeMB = clamp(a.Config.Application.MaxImageCacheSizeMB, 1, 500)
 	a.ImageManager.SetMaxOnDiskCacheSizeBytes(int64(a.Config.Application.MaxImageCacheSizeMB) * 1_048_576)
 	a.ServerManager.SetPrefetchAlbumCoverCallback(func(coverID string) {
@@ -179,6 +197,21 @@ func StartupApp(appName, displayAppName, appVersion, appVersionTag, latestReleas
 	}
 	a.LyricsManager = NewLyricsManager(a.ServerManager, fetch)
 	a.EQPresetManager = NewEQPresetManager(confDir)
+	a.SmartPlaylistManager = NewSmartPlaylistManager(confDir)
+	a.PlaybackManager.RegisterAutoplayStrategy(smartPlaylistAutoplay(&a.Config.Playback, a.SmartPlaylistManager))
+	if lh, err := NewListeningHistory(confDir); err != nil {
+		log.Printf("failed to open listening history: %s", err.Error())
+	} else {
+		a.ListeningHistory = lh
+		a.PlaybackManager.OnTrackPlayed(a.recordPlay)
+		a.PlaybackManager.SetPlayHistorySource(func(since time.Time) []*PlayRecord {
+			plays, err := lh.Plays(a.ServerManager.ServerID.String(), since)
+			if err != nil {
+				log.Printf("failed to read listening history: %s", err.Error())
+			}
+			return plays
+		})
+	}
 
 	// Initialize AutoEQ manager
 	autoEQTimeout := time.Duration(a.Config.Application.RequestTimeoutSeconds) * time.Second
@@ -239,7 +272,8 @@ func StartupApp(appName, displayAppName, appVersion, appVersionTag, latestReleas
 				a.ServerManager,
 				a.callOnReactivate,
 				func() { _ = a.callOnExit() },
-				a.callOnReloadTheme)
+				a.callOnReloadTheme,
+				a.callOnMiniPlayer)
 			go a.ipcServer.Serve(listener)
 		} else {
 			log.Printf("error starting IPC server: %s", err.Error())
@@ -258,6 +292,13 @@ func StartupApp(appName, displayAppName, appVersion, appVersionTag, latestReleas
 		// Windows SMTC is initialized from main once we have a window HWND.
 	}
 
+	if a.Config.Application.EnableUPnPRenderer {
+		a.setupUPnPRenderer(displayAppName, appVersion)
+	}
+	if a.Config.Application.EnableUPnPMediaServer {
+		a.setupUPnPMediaServer(displayAppName, appVersion)
+	}
+
 	a.startConfigWriter(a.bgrndCtx)
 
 	appInstance = a
@@ -355,6 +396,12 @@ func (a *App) callOnReloadTheme() {
 	}
 }
 
+func (a *App) callOnMiniPlayer() {
+	if a.OnMiniPlayer != nil {
+		a.OnMiniPlayer()
+	}
+}
+
 func (a *App) callOnExit() error {
 	if a.OnExit == nil {
 		return errors.New("no quit handler registered")
@@ -466,6 +513,36 @@ func (a *App) setupMPRIS(mprisAppName string) {
 	a.MPRISHandler.Start()
 }
 
+func (a *App) setupUPnPRenderer(displayAppName, appVersion string) {
+	a.UPnPRenderer = NewUPnPRenderer(upnpFriendlyName(displayAppName), appVersion, a.PlaybackManager)
+	a.UPnPRenderer.CoverArtPathFn = a.localCoverArtPath
+	if err := a.UPnPRenderer.Start(); err != nil {
+		log.Printf("error starting UPnP renderer: %s", err.Error())
+		a.UPnPRenderer = nil
+	}
+}
+
+func (a *App) setupUPnPMediaServer(displayAppName, appVersion string) {
+	a.UPnPMediaServer = NewUPnPMediaServer(upnpFriendlyName(displayAppName), appVersion, a.ServerManager)
+	a.UPnPMediaServer.CoverArtPathFn = a.localCoverArtPath
+	if err := a.UPnPMediaServer.Start(); err != nil {
+		log.Printf("error starting UPnP media server: %s", err.Error())
+		a.UPnPMediaServer = nil
+	}
+}
+
+func (a *App) localCoverArtPath(id string) (string, error) {
+	a.ImageManager.GetCoverThumbnail(id) // ensure image is cached locally
+	return a.ImageManager.GetCoverArtPath(id)
+}
+
+func upnpFriendlyName(displayAppName string) string {
+	if hostname, err := os.Hostname(); err == nil {
+		return fmt.Sprintf("%s (%s)", displayAppName, hostname)
+	}
+	return displayAppName
+}
+
 func (a *App) SetupWindowsSMTC(hwnd uintptr) {
 	smtc, err := windows.InitSMTCForWindow(hwnd)
 	if err != nil {
@@ -601,6 +678,12 @@ func (a *App) Shutdown() {
 	if a.MPRISHandler != nil {
 		a.MPRISHandler.Shutdown()
 	}
+	if a.UPnPRenderer != nil {
+		a.UPnPRenderer.Shutdown()
+	}
+	if a.UPnPMediaServer != nil {
+		a.UPnPMediaServer.Shutdown()
+	}
 	if a.WinSMTC != nil {
 		a.WinSMTC.Shutdown()
 	}
@@ -609,8 +692,71 @@ func (a *App) Shutdown() {
 	if a.AudioCache != nil {
 		a.AudioCache.Shutdown()
 	}
+	if a.ListeningHistory != nil {
+		a.ListeningHistory.Close()
+	}
 	a.cancel()
 	a.LocalPlayer.Destroy()
+	a.streamProxyLock.Lock()
+	a.closeSOCKSRelay()
+	a.streamProxyLock.Unlock()
+}
+
+func (a *App) recordPlay(tr *mediaprovider.Track, start time.Time, played time.Duration, completed bool) {
+	device := a.Config.LocalPlayback.AudioDeviceName
+	if rp := a.PlaybackManager.CurrentRemotePlayer(); rp != nil {
+		device = rp.Name
+	}
+	// called on the playback engine's goroutine, which must not wait for the database
+	a.ListeningHistory.QueuePlay(a.ServerManager.ServerID.String(), tr, start, played, completed, device)
+}
+
+// UpdateProxySettings applies a change to the global proxy setting.
+func (a *App) UpdateProxySettings() {
+	SetGlobalProxy(a.Config.Application.ProxyURL)
+	a.updateStreamingProxy()
+}
+
+// updateStreamingProxy sets the proxy mpv streams through from the active
+// hostname of the current server. mpv supports only HTTP proxies, so
+// streams to a SOCKS proxy go through a local relay.
+func (a *App) updateStreamingProxy() {
+	a.streamProxyLock.Lock()
+	defer a.streamProxyLock.Unlock()
+
+	var u *url.URL
+	if conf := a.ServerManager.CurrentServerConfig(); conf != nil {
+		u = streamingProxy(conf.ServerConnection, a.ServerManager.ConnectedToAltHostname())
+	}
+	if u == nil || slices.Contains(mpvProxySchemes, u.Scheme) {
+		a.closeSOCKSRelay()
+		proxyURL := ""
+		if u != nil {
+			proxyURL = u.String()
+		}
+		a.LocalPlayer.SetHTTPProxy(proxyURL)
+		return
+	}
+
+	if a.socksRelay == nil || a.socksRelay.upstream.String() != u.String() {
+		a.closeSOCKSRelay()
+		r, err := startSOCKSRelay(u)
+		if err != nil {
+			// don't bypass the proxy; streams fail until the setting is changed
+			log.Printf("failed to start relay to SOCKS proxy: %s", err.Error())
+			a.LocalPlayer.SetHTTPProxy("http://127.0.0.1:0")
+			return
+		}
+		a.socksRelay = r
+	}
+	a.LocalPlayer.SetHTTPProxy(a.socksRelay.URL())
+}
+
+func (a *App) closeSOCKSRelay() {
+	if a.socksRelay != nil {
+		a.socksRelay.Close()
+		a.socksRelay = nil
+	}
 }
 
 func (a *App) SavePlayQueueIfEnabled() {
@@ -724,6 +870,8 @@ func (a *App) checkFlagsAndSendIPCMsg(cli *ipc.Client) error {
 		return cli.Show()
 	case *FlagReloadTheme:
 		return cli.ReloadTheme()
+	case *FlagMiniPlayer:
+		return cli.ToggleMiniPlayer()
 	case *FlagCurrentTrack:
 		data, err := cli.CurrentTrack()
 		if err == nil {
@@ -29,6 +29,7 @@ type cacheEntry struct {
 	done            bool
 	refCount        int
 	pendingDeletion bool
+	temporary       bool // fetched only for analysis, not for playback
 	cancel          context.CancelFunc
 }
 
@@ -121,6 +122,22 @@ func (a *AudioCache) CacheFile(id, dlURL string) {
 	defer a.mutex.Unlock()
 
 	a.cacheFile(id, dlURL)
+	a.entries[id].temporary = false
+}
+
+// CacheFileTemporarily begins downloading a file which is needed only until
+// EvictTemporaryFile is called, unless it is also requested for playback.
+func (a *AudioCache) CacheFileTemporarily(id, dlURL string) {
+	if a.s.Server == nil {
+		return
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if _, ok := a.entries[id]; !ok {
+		a.cacheFile(id, dlURL)
+		a.entries[id].temporary = true
+	}
 }
 
 func (a *AudioCache) cacheFile(id, dlURL string) {
@@ -128,7 +145,7 @@ func (a *AudioCache) cacheFile(id, dlURL string) {
 		ctx, cancel := context.WithCancel(a.rootCtx)
 		a.entries[id] = &cacheEntry{cancel: cancel}
 		go func() {
-			ok, err := sharedutil.DownloadFileWithContext(ctx, dlURL, a.pathForID(id))
+			ok, err := sharedutil.DownloadFileWithContext(ctx, a.s.HTTPClient(), dlURL, a.pathForID(id))
 			if ok {
 				a.mutex.Lock()
 				if e, ok := a.entries[id]; ok {
@@ -149,9 +166,9 @@ func (a *AudioCache) CacheOnly(keep string, fetch []AudioCacheRequest) {
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 
-	// delete files we're not keeping
+	// delete files we're not keeping, except those being analyzed
 	for id, e := range a.entries {
-		if id != keep && !slices.ContainsFunc(fetch, func(a AudioCacheRequest) bool {
+		if id != keep && !e.temporary && !slices.ContainsFunc(fetch, func(a AudioCacheRequest) bool {
 			return a.ID == id
 		}) {
 			if e.refCount == 0 {
@@ -164,8 +181,25 @@ func (a *AudioCache) CacheOnly(keep string, fetch []AudioCacheRequest) {
 
 	// start caching the ones from fetch if not already present
 	for _, item := range fetch {
-		if _, ok := a.entries[item.ID]; !ok {
-			a.cacheFile(item.ID, item.DownloadURL)
+		a.cacheFile(item.ID, item.DownloadURL)
+		a.entries[item.ID].temporary = false
+	}
+	if e, ok := a.entries[keep]; ok {
+		e.temporary = false
+	}
+}
+
+// EvictTemporaryFile removes a file added by CacheFileTemporarily from the cache,
+// once any references to it are released, unless it has since been requested for playback.
+func (a *AudioCache) EvictTemporaryFile(id string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if e, ok := a.entries[id]; ok && e.temporary {
+		if e.refCount == 0 {
+			a.deleteEntry(id, e)
+		} else {
+			e.pendingDeletion = true
 		}
 	}
 }
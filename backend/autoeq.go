@@ -174,7 +174,7 @@ func (m *AutoEQManager) fetchIndexFromNetwork(ctx context.Context) ([]AutoEQProf
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := proxyHTTPClient.Do(req)
 	if err != nil {
 		log.Printf("HTTP request failed: %v", err)
 		return nil, fmt.Errorf("fetching index: %w", err)
@@ -369,7 +369,7 @@ func (m *AutoEQManager) fetchProfileFromNetwork(ctx context.Context, path string
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := proxyHTTPClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("fetching profile: %w", err)
 	}
@@ -0,0 +1,212 @@
+package backend
+
+import (
+	"math/rand"
+	"slices"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// Names of the built-in autoplay strategies.
+// These are stored in PlaybackConfig.AutoplayStrategies.
+const (
+	AutoplaySimilarArtists = "Similar artists"
+	AutoplaySongRadio      = "Song radio"
+	AutoplayAlbumArtists   = "More from the album artists"
+	AutoplaySameGenre      = "Same genre"
+	AutoplaySameDecade     = "Same decade"
+	AutoplayFavorites      = "Favorites"
+	AutoplaySimilarBPM     = "Similar BPM"
+	AutoplaySmartPlaylist  = "Smart playlist"
+	AutoplayRandom         = "Random"
+)
+
+// the autoplay behavior before strategies became configurable
+var DefaultAutoplayStrategies = []string{AutoplaySimilarArtists, AutoplaySameGenre, AutoplayRandom}
+
+// AutoplayStrategy finds tracks to enqueue when autoplay reaches the end of the play queue.
+// Strategies are tried in the user's configured order until one returns tracks
+// which are not excluded by the autoplay exclusion rules.
+type AutoplayStrategy struct {
+	Name string
+
+	// Tracks returns up to count candidate tracks to follow the now playing track.
+	// nowPlaying is nil if the last played item was not a track.
+	Tracks func(mp mediaprovider.MediaProvider, nowPlaying *mediaprovider.Track, count int) ([]*mediaprovider.Track, error)
+}
+
+// RegisterAutoplayStrategy adds a strategy to those available for autoplay,
+// replacing any existing strategy with the same name.
+func (p *PlaybackManager) RegisterAutoplayStrategy(s AutoplayStrategy) {
+	if idx := slices.IndexFunc(p.autoplayStrategies, func(a AutoplayStrategy) bool { return a.Name == s.Name }); idx >= 0 {
+		p.autoplayStrategies[idx] = s
+		return
+	}
+	p.autoplayStrategies = append(p.autoplayStrategies, s)
+}
+
+// AutoplayStrategyNames returns the names of all registered autoplay strategies.
+func (p *PlaybackManager) AutoplayStrategyNames() []string {
+	names := make([]string, len(p.autoplayStrategies))
+	for i, s := range p.autoplayStrategies {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// returns the registered strategies in the configured order
+func (p *PlaybackManager) enabledAutoplayStrategies() []AutoplayStrategy {
+	names := p.cfg.AutoplayStrategies
+	if len(names) == 0 {
+		names = DefaultAutoplayStrategies
+	}
+	var strategies []AutoplayStrategy
+	for _, name := range names {
+		if idx := slices.IndexFunc(p.autoplayStrategies, func(a AutoplayStrategy) bool { return a.Name == name }); idx >= 0 {
+			strategies = append(strategies, p.autoplayStrategies[idx])
+		}
+	}
+	return strategies
+}
+
+func builtinAutoplayStrategies() []AutoplayStrategy {
+	return []AutoplayStrategy{
+		{Name: AutoplaySimilarArtists, Tracks: similarArtistsAutoplay},
+		{Name: AutoplaySongRadio, Tracks: songRadioAutoplay},
+		{Name: AutoplayAlbumArtists, Tracks: albumArtistsAutoplay},
+		{Name: AutoplaySameGenre, Tracks: sameGenreAutoplay},
+		{Name: AutoplaySameDecade, Tracks: sameDecadeAutoplay},
+		{Name: AutoplayFavorites, Tracks: favoritesAutoplay},
+		{Name: AutoplaySimilarBPM, Tracks: similarBPMAutoplay},
+		{Name: AutoplayRandom, Tracks: randomAutoplay},
+	}
+}
+
+func similarArtistsAutoplay(mp mediaprovider.MediaProvider, tr *mediaprovider.Track, count int) ([]*mediaprovider.Track, error) {
+	if tr == nil || len(tr.ArtistIDs) == 0 {
+		return nil, nil
+	}
+	return mp.GetSimilarTracks(tr.ArtistIDs[0], count)
+}
+
+func songRadioAutoplay(mp mediaprovider.MediaProvider, tr *mediaprovider.Track, count int) ([]*mediaprovider.Track, error) {
+	if tr == nil {
+		return nil, nil
+	}
+	return mp.GetSongRadio(tr.ID, count)
+}
+
+func albumArtistsAutoplay(mp mediaprovider.MediaProvider, tr *mediaprovider.Track, count int) ([]*mediaprovider.Track, error) {
+	if tr == nil {
+		return nil, nil
+	}
+	artistIDs := tr.AlbumArtistIDs
+	if len(artistIDs) == 0 {
+		artistIDs = tr.ArtistIDs
+	}
+	var tracks []*mediaprovider.Track
+	for _, id := range artistIDs {
+		t, err := mp.GetArtistTracks(id)
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, t...)
+	}
+	return randomSample(tracks, count), nil
+}
+
+func sameGenreAutoplay(mp mediaprovider.MediaProvider, tr *mediaprovider.Track, count int) ([]*mediaprovider.Track, error) {
+	if tr == nil {
+		return nil, nil
+	}
+	for _, g := range tr.Genres {
+		if g == "" {
+			continue
+		}
+		tracks, err := mp.GetRandomTracks(g, count)
+		if err != nil || len(tracks) > 0 {
+			return tracks, err
+		}
+	}
+	return nil, nil
+}
+
+func sameDecadeAutoplay(mp mediaprovider.MediaProvider, tr *mediaprovider.Track, count int) ([]*mediaprovider.Track, error) {
+	if tr == nil || tr.Year == 0 {
+		return nil, nil
+	}
+	decade := tr.Year / 10 * 10
+	filter := mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{MinYear: decade, MaxYear: decade + 9})
+	iter := mp.IterateAlbums(mediaprovider.AlbumSortRandom, filter)
+	var tracks []*mediaprovider.Track
+	// fetch several random albums so the result isn't dominated by a single album
+	for range 10 {
+		al := iter.Next()
+		if al == nil {
+			break
+		}
+		album, err := mp.GetAlbum(al.ID)
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, album.Tracks...)
+		if len(tracks) >= 2*count {
+			break
+		}
+	}
+	return randomSample(tracks, count), nil
+}
+
+func favoritesAutoplay(mp mediaprovider.MediaProvider, _ *mediaprovider.Track, count int) ([]*mediaprovider.Track, error) {
+	fav, err := mp.GetFavorites()
+	if err != nil {
+		return nil, err
+	}
+	return randomSample(fav.Tracks, count), nil
+}
+
+func similarBPMAutoplay(mp mediaprovider.MediaProvider, tr *mediaprovider.Track, count int) ([]*mediaprovider.Track, error) {
+	const bpmRange = 8
+	if tr == nil || tr.BPM == 0 {
+		return nil, nil
+	}
+	// servers can't filter by BPM, so sample a larger random batch
+	tracks, err := mp.GetRandomTracks("", min(count*5, 500))
+	if err != nil {
+		return nil, err
+	}
+	tracks = slices.DeleteFunc(tracks, func(t *mediaprovider.Track) bool {
+		return t.BPM == 0 || t.BPM < tr.BPM-bpmRange || t.BPM > tr.BPM+bpmRange
+	})
+	return randomSample(tracks, count), nil
+}
+
+func randomAutoplay(mp mediaprovider.MediaProvider, _ *mediaprovider.Track, count int) ([]*mediaprovider.Track, error) {
+	return mp.GetRandomTracks("", count)
+}
+
+// smartPlaylistAutoplay returns a strategy which enqueues tracks matching
+// the rules of the smart playlist configured in PlaybackConfig.AutoplaySmartPlaylistID.
+func smartPlaylistAutoplay(cfg *PlaybackConfig, sm *SmartPlaylistManager) AutoplayStrategy {
+	return AutoplayStrategy{
+		Name: AutoplaySmartPlaylist,
+		Tracks: func(mp mediaprovider.MediaProvider, _ *mediaprovider.Track, count int) ([]*mediaprovider.Track, error) {
+			sp := sm.Playlist(cfg.AutoplaySmartPlaylistID)
+			if sp == nil {
+				return nil, nil
+			}
+			tracks, err := sp.Evaluate(mp)
+			if err != nil {
+				return nil, err
+			}
+			return randomSample(tracks, count), nil
+		},
+	}
+}
+
+// returns up to count tracks in random order
+func randomSample(tracks []*mediaprovider.Track, count int) []*mediaprovider.Track {
+	tracks = slices.Clone(tracks)
+	rand.Shuffle(len(tracks), func(i, j int) { tracks[i], tracks[j] = tracks[j], tracks[i] })
+	return tracks[:min(count, len(tracks))]
+}
@@ -33,6 +33,7 @@ var (
 	FlagStartMinimized    = flag.Bool("start-minimized", false, "start app minimized")
 	FlagShow              = flag.Bool("show", false, "show minimized app")
 	FlagReloadTheme       = flag.Bool("reload-theme", false, "reload the current theme")
+	FlagMiniPlayer        = flag.Bool("toggle-mini-player", false, "switch between the main window and the mini player")
 	FlagShuffle           = flag.Bool("shuffle", false, "shuffle the tracklist (to be used with either -play-album-by-id or -play-playlist-by-id)")
 	FlagCurrentTrack      = flag.Bool("current-track", false, "print current track metadata as JSON")
 	FlagVersion           = flag.Bool("version", false, "print app version and exit")
@@ -0,0 +1,338 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+const (
+	// consecutive failed requests before the hostnames are re-raced
+	reconnectFailureThreshold = 3
+
+	// minimum time between attempts to re-race the hostnames
+	reconnectMinInterval = 30 * time.Second
+
+	// interval of the health check request to the connected hostname
+	healthCheckInterval = 30 * time.Second
+)
+
+// connectionHealth tracks consecutive failed requests on a server connection,
+// and requests rejected by the server because the session is no longer valid.
+type connectionHealth struct {
+	failures atomic.Int32
+	closed   atomic.Bool
+
+	// the logged-in session on the connection; nil until logged in
+	session atomic.Pointer[monitoredSession]
+
+	// called on a background goroutine for each failed request
+	// once the failure threshold has been reached
+	onFailing func()
+
+	// called on a background goroutine for each request that
+	// is rejected as unauthorized after logging in
+	onUnauthorized func()
+
+	// reports whether the server rejected the session of a request, in the
+	// server type's own way. It may replace the body of the response.
+	isAuthRejected func(*http.Response) bool
+}
+
+func (h *connectionHealth) record(resp *http.Response, err error) {
+	if h.closed.Load() {
+		return
+	}
+	if err == nil {
+		h.failures.Store(0)
+		if h.session.Load() != nil && h.onUnauthorized != nil && h.isAuthRejected != nil && h.isAuthRejected(resp) {
+			go h.onUnauthorized()
+		}
+		return
+	}
+	if h.failures.Add(1) >= reconnectFailureThreshold && h.onFailing != nil {
+		go h.onFailing()
+	}
+}
+
+func (h *connectionHealth) close() {
+	h.closed.Store(true)
+}
+
+// monitoredSession is a logged-in server connection. The monitor acts only on
+// the session it was given, never on the ServerManager's fields for the
+// current server, which the UI goroutine writes when connecting or logging out.
+type monitoredSession struct {
+	server     mediaprovider.MediaProvider
+	conf       *ServerConfig
+	connection ServerConnection
+	password   string
+}
+
+// Subsonic error codes for rejected credentials
+const (
+	subsonicErrWrongCredentials = 40
+	subsonicErrInvalidAPIKey    = 44
+)
+
+// maximum size of a Subsonic response that is checked for an auth error
+const subsonicErrorPeekSize = 1024
+
+// subsonicAuthRejected reports whether resp is a Subsonic error response
+// for wrong credentials or an invalid API key. Subsonic servers report
+// errors in the response body, so a short body is read and replaced.
+func subsonicAuthRejected(resp *http.Response) bool {
+	mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	isJSON := strings.HasSuffix(mt, "json")
+	if resp.Body == nil || !isJSON && !strings.HasSuffix(mt, "xml") {
+		return false
+	}
+	peek, err := io.ReadAll(io.LimitReader(resp.Body, subsonicErrorPeekSize+1))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peek), resp.Body), resp.Body}
+	if err != nil || len(peek) > subsonicErrorPeekSize {
+		// error responses are short
+		return false
+	}
+
+	var code int
+	if isJSON {
+		var r struct {
+			Response struct {
+				Error *struct {
+					Code int `json:"code"`
+				} `json:"error"`
+			} `json:"subsonic-response"`
+		}
+		if json.Unmarshal(peek, &r) != nil || r.Response.Error == nil {
+			return false
+		}
+		code = r.Response.Error.Code
+	} else {
+		var r struct {
+			Error *struct {
+				Code int `xml:"code,attr"`
+			} `xml:"error"`
+		}
+		if xml.Unmarshal(peek, &r) != nil || r.Error == nil {
+			return false
+		}
+		code = r.Error.Code
+	}
+	return code == subsonicErrWrongCredentials || code == subsonicErrInvalidAPIKey
+}
+
+// jellyfinAuthRejected reports whether resp rejects the access token of
+// its request, rather than e.g. the password of a login request.
+func jellyfinAuthRejected(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusUnauthorized &&
+		resp.Request != nil && resp.Request.Header.Get("X-Emby-Token") != ""
+}
+
+// monitoredTransport is an http.RoundTripper which records the outcome
+// of every request to a connectionHealth. Any response from the server,
+// even an error status, shows that the server is reachable.
+type monitoredTransport struct {
+	base   http.RoundTripper
+	health *connectionHealth
+}
+
+func (t *monitoredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if req.Context().Err() == nil {
+		// don't count requests cancelled by the app as failures
+		t.health.record(resp, err)
+	}
+	return resp, err
+}
+
+func monitorRequests(cli *http.Client, health *connectionHealth) {
+	if health == nil {
+		return
+	}
+	base := cli.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	cli.Transport = &monitoredTransport{base: base, health: health}
+}
+
+// Sets a callback that is invoked when the connection to the current server
+// has been transparently switched, after the connection became unhealthy,
+// to whichever of its hostnames was reachable. It is called on a background goroutine.
+func (s *ServerManager) OnConnectionChanged(cb func(isAltHostname bool)) {
+	s.onConnectionChanged = append(s.onConnectionChanged, cb)
+}
+
+// Sets a callback that is invoked when the session on the current server
+// has expired and could not be renewed, so the user must log in again.
+// It is called on a background goroutine.
+func (s *ServerManager) OnAuthExpired(cb func(*ServerConfig)) {
+	s.onAuthExpired = append(s.onAuthExpired, cb)
+}
+
+// newConnectionHealth returns the health of a new connection to the given server,
+// to which the requests made while logging in are recorded. Failover is only
+// possible if the server has an alternate hostname, so otherwise only rejected
+// (unauthorized) requests are acted upon.
+func (s *ServerManager) newConnectionHealth(conf *ServerConfig) *connectionHealth {
+	s.stopHealthMonitor()
+	health := &connectionHealth{}
+	health.onUnauthorized = func() { s.reauthenticate(health) }
+	health.isAuthRejected = subsonicAuthRejected
+	if conf.ServerType == ServerTypeJellyfin {
+		health.isAuthRejected = jellyfinAuthRejected
+	}
+	if conf.AltHostname != "" {
+		health.onFailing = func() { s.reconnect(health) }
+	}
+	s.health = health
+	return health
+}
+
+// startHealthMonitor begins monitoring the session logged in on a connection.
+func (s *ServerManager) startHealthMonitor(health *connectionHealth, sess *monitoredSession) {
+	health.session.Store(sess)
+	if sess.connection.AltHostname == "" {
+		return
+	}
+
+	checkCli, err := newHTTPClient(sess.connection,
+		time.Second*time.Duration(s.config.Application.RequestTimeoutSeconds))
+	if err != nil {
+		return
+	}
+	monitorRequests(checkCli, health)
+	go func() {
+		// periodically make a request even when the app is idle, so
+		// that a changed network is noticed before playback fails
+		t := time.NewTicker(healthCheckInterval)
+		defer t.Stop()
+		for range t.C {
+			if health.closed.Load() {
+				return
+			}
+			url := NormalizeServerURL(sess.connection.Hostname)
+			if s.connectedToAltHostname.Load() {
+				url = NormalizeServerURL(sess.connection.AltHostname)
+			}
+			if resp, err := checkCli.Get(url); err == nil {
+				resp.Body.Close()
+			}
+		}
+	}()
+}
+
+func (s *ServerManager) stopHealthMonitor() {
+	if s.health != nil {
+		s.health.close()
+		s.health = nil
+	}
+}
+
+// reconnect re-races the primary and alternate hostnames of the current
+// server and rebinds the active MediaProvider to the winner. The provider's
+// session is continued on the new connection, if the server has sessions.
+func (s *ServerManager) reconnect(health *connectionHealth) {
+	if !s.reconnecting.CompareAndSwap(false, true) {
+		return
+	}
+	defer s.reconnecting.Store(false)
+	if time.Since(s.lastReconnect) < reconnectMinInterval {
+		return
+	}
+	s.lastReconnect = time.Now()
+
+	sess := health.session.Load()
+	if sess == nil || health.closed.Load() {
+		return
+	}
+	rebinder, ok := sess.server.(mediaprovider.SupportsRebind)
+	if !ok {
+		return
+	}
+	log.Println("server connection is failing; attempting to reconnect")
+	if err := s.relogin(health, sess, rebinder, rebinder.Session()); err != nil {
+		log.Printf("failed to reconnect to server: %s", err.Error())
+	}
+}
+
+// reauthenticate logs in to the current server again after a request
+// was rejected as unauthorized, e.g. because the server expired the
+// session. If the session cannot be renewed, since an access token
+// rather than a password is stored for the server or the login fails,
+// the OnAuthExpired callbacks are invoked.
+func (s *ServerManager) reauthenticate(health *connectionHealth) {
+	if !s.reconnecting.CompareAndSwap(false, true) {
+		return
+	}
+	defer s.reconnecting.Store(false)
+
+	sess := health.session.Load()
+	if sess == nil || health.closed.Load() {
+		return
+	}
+	rebinder, ok := sess.server.(mediaprovider.SupportsRebind)
+	if ok && !sess.connection.QuickConnect {
+		if time.Since(s.lastReauthenticate) < reconnectMinInterval {
+			// requests made with the previous session may still be rejected
+			return
+		}
+		s.lastReauthenticate = time.Now()
+		log.Println("server session has expired; logging in again")
+		err := s.relogin(health, sess, rebinder, nil)
+		if err == nil || errors.Is(err, ErrUnreachable) {
+			// if unreachable, try again on the next rejected request
+			return
+		}
+		log.Printf("failed to log in again: %s", err.Error())
+	}
+	if health.closed.Load() {
+		return
+	}
+	// stop monitoring, as all following requests will be rejected too
+	health.close()
+	for _, cb := range s.onAuthExpired {
+		cb(sess.conf)
+	}
+}
+
+// relogin connects to the server of the session again, through whichever
+// of its hostnames is reachable, and rebinds the session's MediaProvider.
+// If resume is non-nil, the connection continues that session rather than
+// logging in with the password, which would start a new one.
+func (s *ServerManager) relogin(health *connectionHealth, sess *monitoredSession, rebinder mediaprovider.SupportsRebind, resume *mediaprovider.Session) error {
+	cli, isAlt, err := s.connect(sess.connection, sess.password, resume, health)
+	if err != nil {
+		return err
+	}
+	if health.closed.Load() {
+		// logged out or switched servers while reconnecting
+		return nil
+	}
+	if err := rebinder.Rebind(cli); err != nil {
+		return fmt.Errorf("failed to rebind server connection: %w", err)
+	}
+	health.failures.Store(0)
+	if isAlt == s.connectedToAltHostname.Load() {
+		return nil
+	}
+	s.connectedToAltHostname.Store(isAlt)
+	for _, cb := range s.onConnectionChanged {
+		cb(isAlt)
+	}
+	return nil
+}
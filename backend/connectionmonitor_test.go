@@ -0,0 +1,139 @@
+package backend
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConnectionHealth(t *testing.T) {
+	failing := make(chan struct{}, 10)
+	h := &connectionHealth{onFailing: func() { failing <- struct{}{} }}
+
+	errFail := errors.New("unreachable")
+	h.record(nil, errFail)
+	h.record(nil, errFail)
+	h.record(&http.Response{StatusCode: http.StatusOK}, nil) // success resets the count
+	h.record(nil, errFail)
+	h.record(nil, errFail)
+	select {
+	case <-failing:
+		t.Fatal("onFailing called before reaching the threshold")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	h.record(nil, errFail)
+	select {
+	case <-failing:
+	case <-time.After(time.Second):
+		t.Fatal("onFailing not called after reaching the threshold")
+	}
+
+	h.close()
+	h.failures.Store(0)
+	for range reconnectFailureThreshold {
+		h.record(nil, errFail)
+	}
+	if n := h.failures.Load(); n != 0 {
+		t.Errorf("closed connectionHealth recorded %d failures", n)
+	}
+}
+
+func TestConnectionHealthUnauthorized(t *testing.T) {
+	unauthorized := make(chan struct{}, 10)
+	h := &connectionHealth{
+		onUnauthorized: func() { unauthorized <- struct{}{} },
+		isAuthRejected: jellyfinAuthRejected,
+	}
+	tokenReq := &http.Request{Header: http.Header{"X-Emby-Token": {"token"}}}
+	rejected := &http.Response{StatusCode: http.StatusUnauthorized, Request: tokenReq}
+
+	// a failed login is not an expired session
+	h.record(rejected, nil)
+	select {
+	case <-unauthorized:
+		t.Fatal("onUnauthorized called before logging in")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	h.session.Store(&monitoredSession{})
+	h.record(rejected, nil)
+	select {
+	case <-unauthorized:
+	case <-time.After(time.Second):
+		t.Fatal("onUnauthorized not called for a rejected request")
+	}
+}
+
+func TestJellyfinAuthRejected(t *testing.T) {
+	tokenReq := &http.Request{Header: http.Header{"X-Emby-Token": {"token"}}}
+	loginReq := &http.Request{Header: http.Header{}}
+	if !jellyfinAuthRejected(&http.Response{StatusCode: http.StatusUnauthorized, Request: tokenReq}) {
+		t.Error("rejected token not detected")
+	}
+	if jellyfinAuthRejected(&http.Response{StatusCode: http.StatusUnauthorized, Request: loginReq}) {
+		t.Error("rejected login taken as a rejected token")
+	}
+	if jellyfinAuthRejected(&http.Response{StatusCode: http.StatusForbidden, Request: tokenReq}) {
+		t.Error("forbidden taken as a rejected token")
+	}
+}
+
+func TestSubsonicAuthRejected(t *testing.T) {
+	for _, tt := range []struct {
+		contentType string
+		body        string
+		want        bool
+	}{
+		{"text/xml; charset=utf-8", `<subsonic-response status="failed"><error code="40" message="Wrong username or password"/></subsonic-response>`, true},
+		{"application/json", `{"subsonic-response":{"status":"failed","error":{"code":44,"message":"Invalid API key"}}}`, true},
+		{"application/json", `{"subsonic-response":{"status":"failed","error":{"code":70,"message":"Not found"}}}`, false},
+		{"text/xml", `<subsonic-response status="ok"></subsonic-response>`, false},
+		{"audio/mpeg", `<error code="40"/>`, false},
+	} {
+		resp := &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {tt.contentType}},
+			Body:       io.NopCloser(strings.NewReader(tt.body)),
+		}
+		if got := subsonicAuthRejected(resp); got != tt.want {
+			t.Errorf("subsonicAuthRejected(%s) = %v, want %v", tt.body, got, tt.want)
+		}
+		if b, _ := io.ReadAll(resp.Body); string(b) != tt.body {
+			t.Errorf("body not preserved: %q", b)
+		}
+	}
+}
+
+func TestMonitoredTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	url := srv.URL
+
+	h := &connectionHealth{}
+	cli := &http.Client{}
+	monitorRequests(cli, h)
+
+	h.failures.Store(1)
+	resp, err := cli.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if n := h.failures.Load(); n != 0 {
+		t.Errorf("error status counted as a failure: failures = %d", n)
+	}
+
+	srv.Close()
+	if _, err := cli.Get(url); err == nil {
+		t.Fatal("expected request to closed server to fail")
+	}
+	if n := h.failures.Load(); n != 1 {
+		t.Errorf("failures = %d, want 1", n)
+	}
+}
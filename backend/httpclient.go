@@ -0,0 +1,132 @@
+package backend
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/player/mpv"
+)
+
+// newHTTPClient creates an HTTP client for requests to the server of the given connection,
+// using its proxy and TLS settings (certificate verification, client certificate and CA bundle)
+// and adding its custom headers to requests made to the server's hostnames.
+func newHTTPClient(connection ServerConnection, timeout time.Duration) (*http.Client, error) {
+	tlsConfig, err := connectionTLSConfig(connection)
+	if err != nil {
+		return nil, err
+	}
+	t := newProxyTransport(connectionProxy(connection))
+	t.TLSClientConfig = tlsConfig
+	var transport http.RoundTripper = t
+	if len(connection.CustomHeaders) > 0 {
+		transport = &headerTransport{
+			base:    transport,
+			headers: connection.CustomHeaders,
+			hosts:   connectionHosts(connection),
+		}
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// connectionTLSConfig returns the TLS config for the connection,
+// or nil if the connection uses the default TLS settings.
+func connectionTLSConfig(connection ServerConnection) (*tls.Config, error) {
+	if !connection.SkipSSLVerify && connection.CACertFile == "" &&
+		connection.ClientCertFile == "" && connection.ClientKeyFile == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{InsecureSkipVerify: connection.SkipSSLVerify}
+
+	if connection.CACertFile != "" {
+		pem, err := os.ReadFile(connection.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA certificate file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in CA certificate file")
+		}
+		cfg.RootCAs = pool
+	}
+
+	if connection.ClientCertFile != "" || connection.ClientKeyFile != "" {
+		keyFile := connection.ClientKeyFile
+		if keyFile == "" {
+			// key may be bundled in the same PEM file as the certificate
+			keyFile = connection.ClientCertFile
+		}
+		cert, err := tls.LoadX509KeyPair(connection.ClientCertFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+func connectionHosts(connection ServerConnection) []string {
+	var hosts []string
+	for _, h := range []string{connection.Hostname, connection.AltHostname} {
+		if u, err := url.Parse(NormalizeServerURL(h)); err == nil && u.Host != "" {
+			hosts = append(hosts, strings.ToLower(u.Host))
+		}
+	}
+	return hosts
+}
+
+// mpvStreamOptions returns the settings for streaming from the
+// server of the given connection with mpv, matching newHTTPClient.
+func mpvStreamOptions(connection ServerConnection) mpv.StreamOptions {
+	return mpv.StreamOptions{
+		Hosts:         connectionHosts(connection),
+		Headers:       connection.CustomHeaders,
+		CAFile:        connection.CACertFile,
+		CertFile:      connection.ClientCertFile,
+		KeyFile:       connection.ClientKeyFile,
+		SkipTLSVerify: connection.SkipSSLVerify,
+	}
+}
+
+// headerTransport is an http.RoundTripper which adds custom headers
+// to requests to the server. Requests to other hosts, such as
+// external artist image URLs, are sent unmodified so that
+// credentials in the headers are not leaked.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+	hosts   []string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !slices.Contains(t.hosts, strings.ToLower(req.URL.Host)) {
+		return t.base.RoundTrip(req)
+	}
+	// a RoundTripper must not modify the given request
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// HTTPClient returns an HTTP client for requests to the connected server
+// which are not made through its MediaProvider, such as downloads of
+// audio files. It has no timeout, and uses the server's TLS settings
+// and custom headers. If not connected, a client using the global proxy is returned.
+func (s *ServerManager) HTTPClient() *http.Client {
+	if s.httpClient == nil {
+		return proxyHTTPClient
+	}
+	return s.httpClient
+}
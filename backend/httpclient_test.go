@@ -0,0 +1,49 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewHTTPClientCustomHeaders(t *testing.T) {
+	var gotHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Auth")
+	}))
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		hostname string
+		want     string
+	}{
+		{srv.URL, "secret"},
+		{"http://other.example.com", ""}, // headers only sent to the server's hosts
+	} {
+		cli, err := newHTTPClient(ServerConnection{
+			Hostname:      tt.hostname,
+			CustomHeaders: map[string]string{"X-Auth": "secret"},
+		}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotHeader = ""
+		resp, err := cli.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if gotHeader != tt.want {
+			t.Errorf("hostname %s: X-Auth = %q, want %q", tt.hostname, gotHeader, tt.want)
+		}
+	}
+}
+
+func TestNewHTTPClientTLSFiles(t *testing.T) {
+	if _, err := newHTTPClient(ServerConnection{CACertFile: "does-not-exist.pem"}, 0); err == nil {
+		t.Error("expected error for missing CA certificate file")
+	}
+	if _, err := newHTTPClient(ServerConnection{ClientCertFile: "does-not-exist.crt"}, 0); err == nil {
+		t.Error("expected error for missing client certificate file")
+	}
+}
@@ -1,7 +1,6 @@
 package backend
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -9,6 +8,7 @@ import (
 	"image/jpeg"
 	"io/fs"
 	"log"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
@@ -17,7 +17,6 @@ import (
 	"strings"
 	"time"
 
-	"fyne.io/fyne/v2"
 	"github.com/20after4/configdir"
 	"github.com/google/uuid"
 )
@@ -28,6 +27,7 @@ const (
 	fullSizeCoverExpires  = 5 * time.Minute
 
 	maxConcurrentServerFetches = 5
+	artistImageFetchTimeout    = 30 * time.Second
 	defaultDiskCacheSizeBytes  = 50 * 1_048_576
 )
 
@@ -216,18 +216,28 @@ func (i *ImageManager) ensureArtistCoverCacheDir() string {
 }
 
 func (i *ImageManager) fetchRemoteArtistImage(url string) (image.Image, error) {
-	i.serverFetchSema <- struct{}{} // acquire
-	res, err := fyne.LoadResourceFromURLString(url)
-	<-i.serverFetchSema // release
-
-	if err == nil {
-		im, _, err := image.Decode(bytes.NewReader(res.Content()))
-		if err == nil {
-			return im, nil
-		}
+	i.serverFetchSema <- struct{}{}        // acquire
+	defer func() { <-i.serverFetchSema }() // release
+
+	// artist images may be served by the music server, which could
+	// require the server's TLS settings and custom headers. That client has
+	// no timeout, and a stalled fetch would hold one of the fetch slots.
+	ctx, cancel := context.WithTimeout(context.Background(), artistImageFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
 		return nil, err
 	}
-	return nil, err
+	resp, err := i.s.HTTPClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad status fetching artist image: %s", resp.Status)
+	}
+	im, _, err := image.Decode(resp.Body)
+	return im, err
 }
 
 func (i *ImageManager) fetchAndCacheCoverFromDiskOrServer(ctx context.Context, coverID string, ttl time.Duration, cb func(image.Image, error)) (image.Image, error) {
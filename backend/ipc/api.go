@@ -27,6 +27,7 @@ const (
 	VolumeAdjustPath      = "/volume/adjust"     // ?pct=<+/- percentage>
 	ShowPath              = "/window/show"
 	ReloadThemePath       = "/window/reload-theme"
+	MiniPlayerPath        = "/window/toggle-mini-player"
 	QuitPath              = "/window/quit"
 	CurrentTrackPath      = "/current_track"
 	RateCurrentTrackPath  = "/current_track/rate" // ?r=<rating 0-5>
@@ -137,6 +137,11 @@ func (c *Client) ReloadTheme() error {
 	return err
 }
 
+func (c *Client) ToggleMiniPlayer() error {
+	_, err := c.sendRequest(MiniPlayerPath)
+	return err
+}
+
 func (c *Client) Quit() error {
 	_, err := c.sendRequest(QuitPath)
 	return err
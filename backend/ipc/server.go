@@ -49,15 +49,16 @@ type serverImpl struct {
 	showFn        func()
 	quitFn        func()
 	reloadThemeFn func()
+	miniPlayerFn  func()
 }
 
 func NewServer(
 	pbHandler PlaybackHandler,
 	rateFn func(int),
 	sm ServerManager,
-	showFn, quitFn, reloadThemeFn func(),
+	showFn, quitFn, reloadThemeFn, miniPlayerFn func(),
 ) IPCServer {
-	s := &serverImpl{pbHandler: pbHandler, rateFn: rateFn, sm: sm, showFn: showFn, quitFn: quitFn, reloadThemeFn: reloadThemeFn}
+	s := &serverImpl{pbHandler: pbHandler, rateFn: rateFn, sm: sm, showFn: showFn, quitFn: quitFn, reloadThemeFn: reloadThemeFn, miniPlayerFn: miniPlayerFn}
 	s.server = &http.Server{
 		Handler: s.createHandler(),
 	}
@@ -85,6 +86,7 @@ func (s *serverImpl) createHandler() http.Handler {
 		s.showFn()
 	}))
 	m.HandleFunc(ReloadThemePath, s.makeSimpleEndpointHandler(s.reloadThemeFn))
+	m.HandleFunc(MiniPlayerPath, s.makeSimpleEndpointHandler(s.miniPlayerFn))
 	m.HandleFunc(QuitPath, s.makeSimpleEndpointHandler(func() {
 		s.quitFn()
 	}))
@@ -0,0 +1,285 @@
+package backend
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	bolt "go.etcd.io/bbolt"
+)
+
+const listeningHistoryFile = "listening_history.db"
+
+// plays waiting to be written beyond which further plays are dropped
+const playWriteQueueSize = 64
+
+var playsBucket = []byte("plays")
+
+// PlayRecord is a single (partial or complete) play of a track.
+type PlayRecord struct {
+	TrackID     string        `json:"trackId"`
+	ServerID    string        `json:"serverId"`
+	StartTime   time.Time     `json:"startTime"`
+	PlayedTime  time.Duration `json:"playedTime"`
+	Completed   bool          `json:"completed"` // false if the track was skipped
+	Device      string        `json:"device"`
+	Title       string        `json:"title"`
+	ArtistIDs   []string      `json:"artistIds,omitempty"`
+	ArtistNames []string      `json:"artistNames,omitempty"`
+	AlbumID     string        `json:"albumId,omitempty"`
+	Album       string        `json:"album,omitempty"`
+	Genres      []string      `json:"genres,omitempty"`
+}
+
+// ListeningHistory records every track play in an embedded database
+// so that statistics can be computed locally, independently of the server.
+type ListeningHistory struct {
+	db *bolt.DB
+
+	// plays are written to the database by a background goroutine
+	queueLock sync.Mutex
+	queue     chan PlayRecord
+	closed    bool
+	written   chan struct{} // closed when the queue has been drained
+}
+
+func NewListeningHistory(configDir string) (*ListeningHistory, error) {
+	db, err := bolt.Open(filepath.Join(configDir, listeningHistoryFile), 0o644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(playsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	l := &ListeningHistory{
+		db:      db,
+		queue:   make(chan PlayRecord, playWriteQueueSize),
+		written: make(chan struct{}),
+	}
+	go l.writeQueuedPlays()
+	return l, nil
+}
+
+// RecordPlay stores a play of the given track.
+func (l *ListeningHistory) RecordPlay(serverID string, tr *mediaprovider.Track, start time.Time, played time.Duration, completed bool, device string) error {
+	return l.putPlay(newPlayRecord(serverID, tr, start, played, completed, device))
+}
+
+// QueuePlay stores a play of the given track in the background, so that
+// the caller, such as the playback engine, does not wait for the database.
+func (l *ListeningHistory) QueuePlay(serverID string, tr *mediaprovider.Track, start time.Time, played time.Duration, completed bool, device string) {
+	rec := newPlayRecord(serverID, tr, start, played, completed, device)
+	l.queueLock.Lock()
+	defer l.queueLock.Unlock()
+	if l.closed {
+		return
+	}
+	select {
+	case l.queue <- rec:
+	default:
+		log.Printf("listening history write queue is full; dropping play of %s", rec.TrackID)
+	}
+}
+
+func (l *ListeningHistory) writeQueuedPlays() {
+	defer close(l.written)
+	for rec := range l.queue {
+		if err := l.putPlay(rec); err != nil {
+			log.Printf("failed to record play: %s", err.Error())
+		}
+	}
+}
+
+func newPlayRecord(serverID string, tr *mediaprovider.Track, start time.Time, played time.Duration, completed bool, device string) PlayRecord {
+	return PlayRecord{
+		TrackID:     tr.ID,
+		ServerID:    serverID,
+		StartTime:   start,
+		PlayedTime:  played,
+		Completed:   completed,
+		Device:      device,
+		Title:       tr.Title,
+		ArtistIDs:   tr.ArtistIDs,
+		ArtistNames: tr.ArtistNames,
+		AlbumID:     tr.AlbumID,
+		Album:       tr.Album,
+		Genres:      tr.Genres,
+	}
+}
+
+func (l *ListeningHistory) putPlay(rec PlayRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return l.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(playsBucket)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		return bucket.Put(playKey(rec.StartTime, seq), b)
+	})
+}
+
+// Plays returns the plays on the given server which began at or after since.
+func (l *ListeningHistory) Plays(serverID string, since time.Time) ([]*PlayRecord, error) {
+	var plays []*PlayRecord
+	err := l.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(playsBucket).Cursor()
+		for k, v := c.Seek(playKey(since, 0)); k != nil; k, v = c.Next() {
+			var rec PlayRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			if rec.ServerID == serverID {
+				plays = append(plays, &rec)
+			}
+		}
+		return nil
+	})
+	return plays, err
+}
+
+// Close writes any queued plays and closes the database.
+func (l *ListeningHistory) Close() error {
+	l.queueLock.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.queue)
+	}
+	l.queueLock.Unlock()
+	<-l.written
+	return l.db.Close()
+}
+
+// keys sort by start time, with a sequence number to keep them unique
+func playKey(start time.Time, seq uint64) []byte {
+	k := make([]byte, 16)
+	binary.BigEndian.PutUint64(k, uint64(max(start.UnixNano(), 0)))
+	binary.BigEndian.PutUint64(k[8:], seq)
+	return k
+}
+
+type ListeningStatsEntry struct {
+	ID         string
+	Name       string
+	Plays      int
+	Skips      int
+	PlayedTime time.Duration
+}
+
+func (e *ListeningStatsEntry) SkipRate() float64 {
+	if e.Plays == 0 {
+		return 0
+	}
+	return float64(e.Skips) / float64(e.Plays)
+}
+
+type DailyListening struct {
+	Day        time.Time // midnight, local time
+	PlayedTime time.Duration
+}
+
+// ListeningStats summarizes the plays within a period.
+type ListeningStats struct {
+	ListeningStatsEntry // totals for the whole period
+
+	TopArtists []*ListeningStatsEntry
+	TopAlbums  []*ListeningStatsEntry
+	TopTracks  []*ListeningStatsEntry
+	TopGenres  []*ListeningStatsEntry
+
+	// listening time for each day from the first play to the last
+	Daily []DailyListening
+}
+
+// ComputeListeningStats aggregates the plays, keeping the top n entries of each category.
+func ComputeListeningStats(plays []*PlayRecord, n int) *ListeningStats {
+	stats := &ListeningStats{}
+	artists := make(map[string]*ListeningStatsEntry)
+	albums := make(map[string]*ListeningStatsEntry)
+	tracks := make(map[string]*ListeningStatsEntry)
+	genres := make(map[string]*ListeningStatsEntry)
+	daily := make(map[time.Time]time.Duration)
+
+	for _, p := range plays {
+		add := func(e *ListeningStatsEntry) {
+			e.Plays++
+			e.PlayedTime += p.PlayedTime
+			if !p.Completed {
+				e.Skips++
+			}
+		}
+		add(&stats.ListeningStatsEntry)
+		add(statsEntry(tracks, p.TrackID, p.Title))
+		if p.AlbumID != "" {
+			add(statsEntry(albums, p.AlbumID, p.Album))
+		}
+		for i, name := range p.ArtistNames {
+			id := name
+			if i < len(p.ArtistIDs) && p.ArtistIDs[i] != "" {
+				id = p.ArtistIDs[i]
+			}
+			add(statsEntry(artists, id, name))
+		}
+		for _, g := range p.Genres {
+			add(statsEntry(genres, strings.ToLower(g), g))
+		}
+		y, m, d := p.StartTime.Local().Date()
+		daily[time.Date(y, m, d, 0, 0, 0, 0, time.Local)] += p.PlayedTime
+	}
+
+	stats.TopArtists = topStatsEntries(artists, n)
+	stats.TopAlbums = topStatsEntries(albums, n)
+	stats.TopTracks = topStatsEntries(tracks, n)
+	stats.TopGenres = topStatsEntries(genres, n)
+
+	if len(plays) > 0 {
+		first := plays[0].StartTime.Local()
+		last := plays[len(plays)-1].StartTime.Local()
+		day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.Local)
+		for !day.After(last) {
+			stats.Daily = append(stats.Daily, DailyListening{Day: day, PlayedTime: daily[day]})
+			day = day.AddDate(0, 0, 1)
+		}
+	}
+	return stats
+}
+
+func statsEntry(m map[string]*ListeningStatsEntry, id, name string) *ListeningStatsEntry {
+	e, ok := m[id]
+	if !ok {
+		e = &ListeningStatsEntry{ID: id, Name: name}
+		m[id] = e
+	}
+	return e
+}
+
+func topStatsEntries(m map[string]*ListeningStatsEntry, n int) []*ListeningStatsEntry {
+	entries := make([]*ListeningStatsEntry, 0, len(m))
+	for _, e := range m {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Plays != entries[j].Plays {
+			return entries[i].Plays > entries[j].Plays
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
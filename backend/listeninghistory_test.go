@@ -0,0 +1,81 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+func TestListeningHistory(t *testing.T) {
+	lh, err := NewListeningHistory(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lh.Close()
+
+	tr1 := &mediaprovider.Track{ID: "t1", Title: "One", ArtistIDs: []string{"a1"}, ArtistNames: []string{"A"}, Genres: []string{"Jazz"}}
+	tr2 := &mediaprovider.Track{ID: "t2", Title: "Two", ArtistIDs: []string{"a1"}, ArtistNames: []string{"A"}, Genres: []string{"jazz"}}
+	start := time.Now().Add(-48 * time.Hour)
+	lh.RecordPlay("s1", tr1, start, 3*time.Minute, true, "auto")
+	lh.RecordPlay("s1", tr2, start.Add(time.Hour), 10*time.Second, false, "auto")
+	lh.RecordPlay("s1", tr1, start.Add(24*time.Hour), 3*time.Minute, true, "auto")
+	lh.RecordPlay("s2", tr1, start, 3*time.Minute, true, "auto")
+
+	plays, err := lh.Plays("s1", start.Add(30*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plays) != 2 {
+		t.Fatalf("expected 2 plays since cutoff, got %d", len(plays))
+	}
+
+	plays, _ = lh.Plays("s1", time.Time{})
+	stats := ComputeListeningStats(plays, 10)
+	if stats.Plays != 3 || stats.Skips != 1 {
+		t.Errorf("expected 3 plays and 1 skip, got %d and %d", stats.Plays, stats.Skips)
+	}
+	if len(stats.TopTracks) != 2 || stats.TopTracks[0].ID != "t1" || stats.TopTracks[0].Plays != 2 {
+		t.Errorf("unexpected top tracks: %+v", stats.TopTracks)
+	}
+	if len(stats.TopGenres) != 1 || stats.TopGenres[0].Plays != 3 {
+		t.Errorf("expected genres to be merged case-insensitively: %+v", stats.TopGenres)
+	}
+	if len(stats.TopArtists) != 1 || stats.TopArtists[0].PlayedTime != 6*time.Minute+10*time.Second {
+		t.Errorf("unexpected top artists: %+v", stats.TopArtists)
+	}
+	if len(stats.Daily) < 2 {
+		t.Errorf("expected daily listening for at least 2 days, got %d", len(stats.Daily))
+	}
+}
+
+func TestListeningHistoryQueuePlay(t *testing.T) {
+	dir := t.TempDir()
+	lh, err := NewListeningHistory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := &mediaprovider.Track{ID: "t1", Title: "One"}
+	start := time.Now().Add(-time.Hour)
+	for i := range 3 {
+		lh.QueuePlay("s1", tr, start.Add(time.Duration(i)*time.Minute), time.Minute, true, "auto")
+	}
+	// queued plays are written before closing
+	if err := lh.Close(); err != nil {
+		t.Fatal(err)
+	}
+	lh.QueuePlay("s1", tr, start, time.Minute, true, "auto") // ignored after Close
+
+	lh, err = NewListeningHistory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lh.Close()
+	plays, err := lh.Plays("s1", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plays) != 3 {
+		t.Errorf("expected 3 queued plays to be written, got %d", len(plays))
+	}
+}
@@ -103,7 +103,7 @@ func (l *LrcLibFetcher) fetchFromServer(name, artist, album string, durationSecs
 	q.Add("duration", strconv.Itoa(durationSecs))
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := proxyHTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
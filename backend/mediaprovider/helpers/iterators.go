@@ -47,6 +47,15 @@ func NewTrackIterator(fetchFn TrackFetchFn, cb func(string)) mediaprovider.Track
 	}
 }
 
+type LabelFetchFn func(offset, limit int) ([]*mediaprovider.Label, error)
+
+func NewLabelIterator(fetchFn LabelFetchFn) mediaprovider.LabelIterator {
+	return &baseIter[mediaprovider.Label, nilFilterOptions]{
+		filter:  nilFilter[mediaprovider.Label]{},
+		fetcher: fetchFn,
+	}
+}
+
 func (r *baseIter[M, F]) Next() *M {
 	if r.done {
 		return nil
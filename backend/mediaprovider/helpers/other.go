@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 
@@ -56,3 +57,57 @@ func GetTopTracksFallback(mp mediaprovider.MediaProvider, artistID string, count
 	}
 	return tracks, nil
 }
+
+const (
+	// limits on the folder tree walked by GetFolderTracksRecursive,
+	// so that playing a large folder stays responsive
+	maxFolderDepth    = 16
+	maxFolderRequests = 250
+	maxFolderTracks   = 5000
+)
+
+// ErrFolderTruncated is returned by GetFolderTracksRecursive, along with the
+// tracks that were loaded, if the folder tree was too large to load entirely.
+var ErrFolderTruncated = errors.New("folder has too many tracks or subfolders to load")
+
+// GetFolderTracksRecursive returns the tracks contained in the given folder
+// and its subfolders, in depth-first order. If the folder tree is deeper than
+// maxFolderDepth, or has more than maxFolderRequests folders or maxFolderTracks
+// tracks, the tracks loaded so far are returned with ErrFolderTruncated.
+// The top level of the library cannot be loaded, since it contains every folder.
+func GetFolderTracksRecursive(fp mediaprovider.FolderBrowsingProvider, folderID string) ([]*mediaprovider.Track, error) {
+	if folderID == "" {
+		return nil, errors.New("cannot load the tracks of the entire library by folder")
+	}
+	var tracks []*mediaprovider.Track
+	var requests int
+	var truncated bool
+	var walk func(folderID string, depth int) error
+	walk = func(folderID string, depth int) error {
+		requests++
+		folder, err := fp.GetFolder(folderID)
+		if err != nil {
+			return fmt.Errorf("error loading folder: %v", err.Error())
+		}
+		n := min(len(folder.Tracks), maxFolderTracks-len(tracks))
+		tracks = append(tracks, folder.Tracks[:n]...)
+		truncated = truncated || n < len(folder.Tracks)
+		for _, sub := range folder.Subfolders {
+			if depth == maxFolderDepth || requests == maxFolderRequests || len(tracks) == maxFolderTracks {
+				truncated = true
+				return nil
+			}
+			if err := walk(sub.ID, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(folderID, 0); err != nil {
+		return nil, err
+	}
+	if truncated {
+		return tracks, ErrFolderTruncated
+	}
+	return tracks, nil
+}
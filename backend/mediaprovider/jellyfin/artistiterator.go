@@ -51,7 +51,7 @@ func (j *JellyfinMediaProvider) IterateArtists(sortOrder string, filter mediapro
 			if !disablePagination {
 				paging = jellyfin.Paging{StartIndex: offs, Limit: limit}
 			}
-			return j.client.GetAlbumArtists(jellyfin.QueryOpts{
+			return j.client().GetAlbumArtists(jellyfin.QueryOpts{
 				Sort:   jfSort,
 				Paging: paging,
 				Filter: jellyfin.Filter{ParentID: j.currentLibraryID},
@@ -70,7 +70,7 @@ func (j *JellyfinMediaProvider) SearchArtists(searchQuery string, filter mediapr
 	// fetcher := makeArtistFetchFn(
 	// 	func(offs, limit int) ([]*jellyfin.Artist, error) {
 	// 		log.Printf("Searching for artists: %s", searchQuery)
-	// 		sr, err := j.client.Search(searchQuery, jellyfin.TypeArtist, jellyfin.Paging{StartIndex: offs, Limit: limit})
+	// 		sr, err := j.client().Search(searchQuery, jellyfin.TypeArtist, jellyfin.Paging{StartIndex: offs, Limit: limit})
 	// 		if err != nil {
 	// 			return nil, err
 	// 		}
@@ -91,7 +91,7 @@ func (j *JellyfinMediaProvider) SearchArtists(searchQuery string, filter mediapr
 
 	fetcher := makeArtistFetchFn(
 		func(offs, limit int) ([]*jellyfin.Artist, error) {
-			return j.client.GetAlbumArtists(jellyfin.QueryOpts{
+			return j.client().GetAlbumArtists(jellyfin.QueryOpts{
 				Sort: jellyfin.Sort{
 					Field: jellyfin.SortByName,
 					Mode:  jellyfin.SortAsc,
@@ -0,0 +1,117 @@
+package jellyfin
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/dweymouth/go-jellyfin"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+var (
+	_ mediaprovider.ComposerProvider = (*JellyfinMediaProvider)(nil)
+	_ mediaprovider.LabelProvider    = (*JellyfinMediaProvider)(nil)
+)
+
+// an album along with its studios, which Jellyfin uses to model record labels
+type studioAlbum struct {
+	jellyfin.Album
+	Studios []jellyfin.NameID `json:"Studios"`
+}
+
+const (
+	composerTrackFields = "Genres,DateCreated,MediaSources,UserData,ParentId"
+	labelAlbumFields    = "Genres,DateCreated,ChildCount,UserData,ParentId,Studios"
+)
+
+func (j *JellyfinMediaProvider) GetComposers() ([]*mediaprovider.Artist, error) {
+	params := url.Values{}
+	params.Set("userId", j.userID())
+	params.Set("personTypes", "Composer")
+	params.Set("fields", "ItemCounts")
+	if j.currentLibraryID != "" {
+		params.Set("parentId", j.currentLibraryID)
+	}
+	var resp struct {
+		Items []*jellyfin.Artist `json:"Items"`
+	}
+	if err := j.apiRequest(http.MethodGet, "/Persons", params, nil, &resp); err != nil {
+		return nil, err
+	}
+	return sharedutil.MapSlice(resp.Items, toArtist), nil
+}
+
+func (j *JellyfinMediaProvider) GetComposerTracks(composerID string) ([]*mediaprovider.Track, error) {
+	userID := j.userID()
+	if userID == "" {
+		return nil, fmt.Errorf("jellyfin: not logged in")
+	}
+	params := url.Values{}
+	params.Set("PersonIds", composerID)
+	params.Set("PersonTypes", "Composer")
+	params.Set("IncludeItemTypes", "Audio")
+	params.Set("Recursive", "true")
+	params.Set("SortBy", "Album,ParentIndexNumber,IndexNumber,SortName")
+	params.Set("Fields", composerTrackFields)
+	if j.currentLibraryID != "" {
+		params.Set("ParentId", j.currentLibraryID)
+	}
+	var resp struct {
+		Items []*jellyfin.Song `json:"Items"`
+	}
+	if err := j.apiRequest(http.MethodGet, fmt.Sprintf("/Users/%s/Items", userID), params, nil, &resp); err != nil {
+		return nil, err
+	}
+	return sharedutil.MapSlice(resp.Items, toTrack), nil
+}
+
+func (j *JellyfinMediaProvider) GetLabels() ([]*mediaprovider.Label, error) {
+	params := url.Values{}
+	params.Set("userId", j.userID())
+	params.Set("includeItemTypes", "MusicAlbum")
+	params.Set("fields", "ItemCounts")
+	if j.currentLibraryID != "" {
+		params.Set("parentId", j.currentLibraryID)
+	}
+	var resp struct {
+		Items []*jellyfin.Artist `json:"Items"`
+	}
+	if err := j.apiRequest(http.MethodGet, "/Studios", params, nil, &resp); err != nil {
+		return nil, err
+	}
+	return sharedutil.MapSlice(resp.Items, func(s *jellyfin.Artist) *mediaprovider.Label {
+		// identify labels by name, as for Subsonic servers
+		return &mediaprovider.Label{ID: s.Name, Name: s.Name, AlbumCount: s.AlbumCount}
+	}), nil
+}
+
+func (j *JellyfinMediaProvider) GetLabelAlbums(labelID string) ([]*mediaprovider.Album, error) {
+	userID := j.userID()
+	if userID == "" {
+		return nil, fmt.Errorf("jellyfin: not logged in")
+	}
+	params := url.Values{}
+	params.Set("Studios", labelID)
+	params.Set("IncludeItemTypes", "MusicAlbum")
+	params.Set("Recursive", "true")
+	params.Set("SortBy", "ProductionYear,SortName")
+	params.Set("Fields", labelAlbumFields)
+	if j.currentLibraryID != "" {
+		params.Set("ParentId", j.currentLibraryID)
+	}
+	var resp struct {
+		Items []*studioAlbum `json:"Items"`
+	}
+	if err := j.apiRequest(http.MethodGet, fmt.Sprintf("/Users/%s/Items", userID), params, nil, &resp); err != nil {
+		return nil, err
+	}
+	return sharedutil.MapSlice(resp.Items, func(a *studioAlbum) *mediaprovider.Album {
+		album := toAlbum(&a.Album)
+		for _, s := range a.Studios {
+			album.RecordLabels = append(album.RecordLabels, s.Name)
+		}
+		return album
+	}), nil
+}
@@ -0,0 +1,116 @@
+package jellyfin
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dweymouth/go-jellyfin"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+var _ mediaprovider.FolderBrowsingProvider = (*JellyfinMediaProvider)(nil)
+
+// a Jellyfin item that may be either a folder or an audio track
+type folderItem struct {
+	jellyfin.Song
+	IsFolder bool `json:"IsFolder"`
+}
+
+const folderItemFields = "Genres,DateCreated,MediaSources,UserData,ParentId"
+
+func (j *JellyfinMediaProvider) GetFolder(id string) (*mediaprovider.FolderWithChildren, error) {
+	userID := j.userID()
+	if userID == "" {
+		return nil, fmt.Errorf("jellyfin: not logged in")
+	}
+	if id == "" && j.currentLibraryID == "" {
+		return j.getRootFolder()
+	}
+
+	folder := &mediaprovider.FolderWithChildren{}
+	parentID := id
+	if id == "" {
+		parentID = j.currentLibraryID
+	} else {
+		var item folderItem
+		if err := j.apiRequest(http.MethodGet, fmt.Sprintf("/Users/%s/Items/%s", userID, id), nil, nil, &item); err != nil {
+			return nil, err
+		}
+		folder.Folder = toFolder(&item)
+		ancestors, err := j.getFolderAncestors(id)
+		if err != nil {
+			return nil, err
+		}
+		folder.Ancestors = ancestors
+	}
+
+	params := url.Values{}
+	params.Set("ParentId", parentID)
+	params.Set("SortBy", "IsFolder,SortName")
+	params.Set("SortOrder", "Ascending")
+	params.Set("Fields", folderItemFields)
+	var resp struct {
+		Items []*folderItem `json:"Items"`
+	}
+	if err := j.apiRequest(http.MethodGet, fmt.Sprintf("/Users/%s/Items", userID), params, nil, &resp); err != nil {
+		return nil, err
+	}
+	for _, it := range resp.Items {
+		if it.IsFolder {
+			f := toFolder(it)
+			folder.Subfolders = append(folder.Subfolders, &f)
+		} else if it.Type == "Audio" {
+			folder.Tracks = append(folder.Tracks, toTrack(&it.Song))
+		}
+	}
+	return folder, nil
+}
+
+// the top-level folders when no library is selected are the music libraries themselves
+func (j *JellyfinMediaProvider) getRootFolder() (*mediaprovider.FolderWithChildren, error) {
+	libs, err := j.GetLibraries()
+	if err != nil {
+		return nil, err
+	}
+	folder := &mediaprovider.FolderWithChildren{}
+	for _, l := range libs {
+		folder.Subfolders = append(folder.Subfolders, &mediaprovider.Folder{ID: l.ID, Name: l.Name})
+	}
+	return folder, nil
+}
+
+func (j *JellyfinMediaProvider) getFolderAncestors(id string) ([]*mediaprovider.Folder, error) {
+	params := url.Values{}
+	params.Set("userId", j.userID())
+	var items []*folderItem
+	if err := j.apiRequest(http.MethodGet, fmt.Sprintf("/Items/%s/Ancestors", id), params, nil, &items); err != nil {
+		return nil, err
+	}
+
+	// Jellyfin returns ancestors ordered from the immediate parent up to the root
+	var ancestors []*mediaprovider.Folder
+	for i := len(items) - 1; i >= 0; i-- {
+		it := items[i]
+		if it.Type == "UserRootFolder" || it.Type == "AggregateFolder" {
+			continue
+		}
+		if j.currentLibraryID != "" && strings.EqualFold(it.Id, j.currentLibraryID) {
+			// the selected library is the root of the folder view
+			ancestors = ancestors[:0]
+			continue
+		}
+		f := toFolder(it)
+		ancestors = append(ancestors, &f)
+	}
+	return ancestors, nil
+}
+
+func toFolder(it *folderItem) mediaprovider.Folder {
+	f := mediaprovider.Folder{ID: it.Id, Name: it.Name}
+	if it.ImageTags.Primary != "" {
+		f.CoverArtID = it.Id
+	}
+	return f
+}
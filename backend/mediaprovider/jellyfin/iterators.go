@@ -47,7 +47,7 @@ func (j *JellyfinMediaProvider) IterateAlbums(sortOrder string, filter mediaprov
 	}
 
 	fetcher := func(offs, limit int) ([]*mediaprovider.Album, error) {
-		al, err := j.client.GetAlbums(jellyfin.QueryOpts{
+		al, err := j.client().GetAlbums(jellyfin.QueryOpts{
 			Sort:   jfSort,
 			Filter: jfFilt,
 			Paging: jellyfin.Paging{StartIndex: offs, Limit: limit},
@@ -60,7 +60,7 @@ func (j *JellyfinMediaProvider) IterateAlbums(sortOrder string, filter mediaprov
 
 	if sortOrder == mediaprovider.AlbumSortRandom {
 		determFetcher := func(offs, limit int) ([]*mediaprovider.Album, error) {
-			al, err := j.client.GetAlbums(jellyfin.QueryOpts{
+			al, err := j.client().GetAlbums(jellyfin.QueryOpts{
 				Sort:   jellyfin.Sort{Field: "SortName", Mode: jellyfin.SortAsc},
 				Filter: jfFilt,
 				Paging: jellyfin.Paging{StartIndex: offs, Limit: limit},
@@ -80,7 +80,7 @@ func (j *JellyfinMediaProvider) SearchAlbums(searchQuery string, filter mediapro
 		var opts jellyfin.QueryOpts
 		opts.Paging = jellyfin.Paging{StartIndex: offs, Limit: limit}
 		opts.Filter.ParentID = j.currentLibraryID
-		sr, err := j.client.Search(searchQuery, jellyfin.TypeAlbum, opts)
+		sr, err := j.client().Search(searchQuery, jellyfin.TypeAlbum, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -98,7 +98,7 @@ func (j *JellyfinMediaProvider) IterateTracks(searchQuery string) mediaprovider.
 			if j.currentLibraryID != "" {
 				opts.Filter.ParentID = j.currentLibraryID
 			}
-			s, err := j.client.GetSongs(opts)
+			s, err := j.client().GetSongs(opts)
 			if err != nil {
 				return nil, err
 			}
@@ -109,7 +109,7 @@ func (j *JellyfinMediaProvider) IterateTracks(searchQuery string) mediaprovider.
 			var opts jellyfin.QueryOpts
 			opts.Paging = jellyfin.Paging{StartIndex: offs, Limit: limit}
 			opts.Filter.ParentID = j.currentLibraryID
-			sr, err := j.client.Search(searchQuery, jellyfin.TypeSong, opts)
+			sr, err := j.client().Search(searchQuery, jellyfin.TypeSong, opts)
 			if err != nil {
 				return nil, err
 			}
@@ -1,17 +1,23 @@
 package jellyfin
 
 import (
+	"errors"
+	"fmt"
 	"image"
 	"io"
-	"math"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dweymouth/go-jellyfin"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
 	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
 	"github.com/dweymouth/supersonic/sharedutil"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -21,13 +27,22 @@ const (
 
 type JellyfinServer struct {
 	jellyfin.Client
+
+	// If true, the password given to Login is an access token
+	// obtained from Quick Connect
+	TokenAuth bool
 }
 
 func (j *JellyfinServer) Login(user, pass string) mediaprovider.LoginResponse {
 	if _, err := j.Ping(); err != nil {
 		return mediaprovider.LoginResponse{Error: err}
 	}
-	err := j.Client.Login(user, pass)
+	var err error
+	if j.TokenAuth {
+		err = j.Client.LoginWithToken(pass)
+	} else {
+		err = j.Client.Login(user, pass)
+	}
 	return mediaprovider.LoginResponse{
 		Error:       err,
 		IsAuthError: err != nil,
@@ -41,20 +56,47 @@ func (j *JellyfinServer) MediaProvider() mediaprovider.MediaProvider {
 var _ mediaprovider.MediaProvider = (*JellyfinMediaProvider)(nil)
 
 type JellyfinMediaProvider struct {
-	client          *jellyfin.Client
+	// client of the logged-in session,
+	// swapped by Rebind while requests may be in flight
+	conn            atomic.Pointer[jellyfin.Client]
 	prefetchCoverCB func(coverArtID string)
 
 	currentLibraryID string
 
+	session playSession
+
 	genresCached   []*mediaprovider.Genre
 	genresCachedAt int64 // unix
 }
 
 func newJellyfinMediaProvider(cli *jellyfin.Client) mediaprovider.MediaProvider {
-	return &JellyfinMediaProvider{
-		client:       cli,
+	j := &JellyfinMediaProvider{
 		genresCached: make([]*mediaprovider.Genre, 0),
 	}
+	j.conn.Store(cli)
+	return j
+}
+
+func (j *JellyfinMediaProvider) client() *jellyfin.Client {
+	return j.conn.Load()
+}
+
+// Rebind switches the provider to the base URL and session of the given
+// server. go-jellyfin does not allow changing the base URL of a client,
+// so the server's client, which has logged in to the new URL, is adopted.
+func (j *JellyfinMediaProvider) Rebind(server mediaprovider.Server) error {
+	srv, ok := server.(*JellyfinServer)
+	if !ok {
+		return errors.New("cannot rebind to a non-Jellyfin server")
+	}
+	cli := srv.Client
+	j.conn.Store(&cli)
+	return nil
+}
+
+func (j *JellyfinMediaProvider) Session() *mediaprovider.Session {
+	cli := j.client()
+	return &mediaprovider.Session{Token: cli.Token(), DeviceID: cli.DeviceID()}
 }
 
 func (j *JellyfinMediaProvider) SetPrefetchCoverCallback(cb func(coverArtID string)) {
@@ -62,7 +104,7 @@ func (j *JellyfinMediaProvider) SetPrefetchCoverCallback(cb func(coverArtID stri
 }
 
 func (j *JellyfinMediaProvider) GetLibraries() ([]mediaprovider.Library, error) {
-	v, err := j.client.GetUserViews()
+	v, err := j.client().GetUserViews()
 	if err != nil {
 		return nil, err
 	}
@@ -78,11 +120,11 @@ func (j *JellyfinMediaProvider) SetLibrary(id string) error {
 }
 
 func (j *JellyfinMediaProvider) CreatePlaylistWithTracks(name string, trackIDs []string) error {
-	return j.client.CreatePlaylist(name, "", false, trackIDs)
+	return j.client().CreatePlaylist(name, "", false, trackIDs)
 }
 
 func (j *JellyfinMediaProvider) DeletePlaylist(id string) error {
-	return j.client.DeletePlaylist(id)
+	return j.client().DeletePlaylist(id)
 }
 
 func (j *JellyfinMediaProvider) CanMakePublicPlaylist() bool {
@@ -90,23 +132,23 @@ func (j *JellyfinMediaProvider) CanMakePublicPlaylist() bool {
 }
 
 func (j *JellyfinMediaProvider) EditPlaylist(id, name, description string, public bool) error {
-	return j.client.UpdatePlaylistMetadata(id, name, description, false)
+	return j.client().UpdatePlaylistMetadata(id, name, description, false)
 }
 
 func (j *JellyfinMediaProvider) CreatePlaylist(name, description string, public bool) error {
-	return j.client.CreatePlaylist(name, description, public, nil)
+	return j.client().CreatePlaylist(name, description, public, nil)
 }
 
 func (j *JellyfinMediaProvider) AddPlaylistTracks(id string, trackIDsToAdd []string) error {
-	return j.client.AddSongsToPlaylist(id, trackIDsToAdd)
+	return j.client().AddSongsToPlaylist(id, trackIDsToAdd)
 }
 
 func (j *JellyfinMediaProvider) RemovePlaylistTracks(playlistID string, removeIdxs []int) error {
-	return j.client.RemoveSongsFromPlaylist(playlistID, removeIdxs)
+	return j.client().RemoveSongsFromPlaylist(playlistID, removeIdxs)
 }
 
 func (j *JellyfinMediaProvider) ReplacePlaylistTracks(playlistID string, trackIDs []string) error {
-	pl, err := j.client.GetPlaylist(playlistID)
+	pl, err := j.client().GetPlaylist(playlistID)
 	if err != nil {
 		return err
 	}
@@ -114,20 +156,20 @@ func (j *JellyfinMediaProvider) ReplacePlaylistTracks(playlistID string, trackID
 	for i := range allIndexes {
 		allIndexes[i] = i
 	}
-	if err = j.client.RemoveSongsFromPlaylist(playlistID, allIndexes); err != nil {
+	if err = j.client().RemoveSongsFromPlaylist(playlistID, allIndexes); err != nil {
 		return err
 	}
-	return j.client.AddSongsToPlaylist(playlistID, trackIDs)
+	return j.client().AddSongsToPlaylist(playlistID, trackIDs)
 }
 
 func (j *JellyfinMediaProvider) GetAlbum(albumID string) (*mediaprovider.AlbumWithTracks, error) {
-	al, err := j.client.GetAlbum(albumID)
+	al, err := j.client().GetAlbum(albumID)
 	if err != nil {
 		return nil, err
 	}
 	var opts jellyfin.QueryOpts
 	opts.Filter.ParentID = albumID
-	tr, err := j.client.GetSongs(opts)
+	tr, err := j.client().GetSongs(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -139,7 +181,7 @@ func (j *JellyfinMediaProvider) GetAlbum(albumID string) (*mediaprovider.AlbumWi
 }
 
 func (j *JellyfinMediaProvider) GetAlbumInfo(albumID string) (*mediaprovider.AlbumInfo, error) {
-	al, err := j.client.GetAlbum(albumID)
+	al, err := j.client().GetAlbum(albumID)
 	if err != nil {
 		return nil, err
 	}
@@ -149,13 +191,13 @@ func (j *JellyfinMediaProvider) GetAlbumInfo(albumID string) (*mediaprovider.Alb
 }
 
 func (j *JellyfinMediaProvider) GetArtist(artistID string) (*mediaprovider.ArtistWithAlbums, error) {
-	ar, err := j.client.GetArtist(artistID)
+	ar, err := j.client().GetArtist(artistID)
 	if err != nil {
 		return nil, err
 	}
 	var opts jellyfin.QueryOpts
 	opts.Filter.ArtistID = artistID
-	al, err := j.client.GetAlbums(opts)
+	al, err := j.client().GetAlbums(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -172,11 +214,11 @@ func (j *JellyfinMediaProvider) GetArtistTracks(artistID string) ([]*mediaprovid
 }
 
 func (j *JellyfinMediaProvider) GetArtistInfo(artistID string) (*mediaprovider.ArtistInfo, error) {
-	ar, err := j.client.GetArtist(artistID)
+	ar, err := j.client().GetArtist(artistID)
 	if err != nil {
 		return nil, err
 	}
-	similar, err := j.client.GetSimilarArtists(artistID)
+	similar, err := j.client().GetSimilarArtists(artistID)
 	if err != nil {
 		return nil, err
 	}
@@ -187,7 +229,7 @@ func (j *JellyfinMediaProvider) GetArtistInfo(artistID string) (*mediaprovider.A
 }
 
 func (j *JellyfinMediaProvider) GetTrack(trackID string) (*mediaprovider.Track, error) {
-	tr, err := j.client.GetSong(trackID)
+	tr, err := j.client().GetSong(trackID)
 	if err != nil {
 		return nil, err
 	}
@@ -203,7 +245,7 @@ func (j *JellyfinMediaProvider) GetTopTracks(artist mediaprovider.Artist, limit
 	if j.currentLibraryID != "" {
 		opts.Filter.ParentID = j.currentLibraryID
 	}
-	tr, err := j.client.GetSongs(opts)
+	tr, err := j.client().GetSongs(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -221,23 +263,56 @@ func (j *JellyfinMediaProvider) GetRandomTracks(genreName string, limit int) ([]
 	if j.currentLibraryID != "" {
 		opts.Filter.ParentID = j.currentLibraryID
 	}
-	tr, err := j.client.GetSongs(opts)
+	tr, err := j.client().GetSongs(opts)
 	if err != nil {
 		return nil, err
 	}
 	return sharedutil.MapSlice(tr, toTrack), nil
 }
 
-func (j *JellyfinMediaProvider) GetSimilarTracks(artistID string, limit int) ([]*mediaprovider.Track, error) {
-	tr, err := j.client.GetInstantMix(artistID, jellyfin.TypeArtist, limit)
+// GetSimilarTracks returns an instant mix from the given item. The server
+// mixes from any type of item, so besides artists (the type this is called
+// for by the interface) it works for tracks, albums, playlists and genres.
+func (j *JellyfinMediaProvider) GetSimilarTracks(id string, limit int) ([]*mediaprovider.Track, error) {
+	tr, err := j.client().GetInstantMix(id, jellyfin.ItemType(""), limit)
 	if err != nil {
 		return nil, err
 	}
 	return sharedutil.MapSlice(tr, toTrack), nil
 }
 
+var _ mediaprovider.InstantMixProvider = (*JellyfinMediaProvider)(nil)
+
+func (j *JellyfinMediaProvider) GetAlbumMix(albumID string, count int) ([]*mediaprovider.Track, error) {
+	tr, err := j.client().GetInstantMix(albumID, jellyfin.TypeAlbum, count)
+	if err != nil {
+		return nil, err
+	}
+	return sharedutil.MapSlice(tr, toTrack), nil
+}
+
+// GetGenreMix returns an instant mix from the given genre. Genres are
+// identified by name in the app, so the genre's item ID is looked up first.
+func (j *JellyfinMediaProvider) GetGenreMix(genreName string, count int) ([]*mediaprovider.Track, error) {
+	params := url.Values{}
+	params.Set("searchTerm", genreName)
+	var resp struct {
+		Items []jellyfin.NameID `json:"Items"`
+	}
+	if err := j.apiRequest(http.MethodGet, "/MusicGenres", params, nil, &resp); err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(resp.Items, func(g jellyfin.NameID) bool {
+		return strings.EqualFold(g.Name, genreName)
+	})
+	if idx < 0 {
+		return nil, fmt.Errorf("genre %q not found", genreName)
+	}
+	return j.GetSimilarTracks(resp.Items[idx].ID, count)
+}
+
 func (j *JellyfinMediaProvider) GetCoverArt(id string, size int) (image.Image, error) {
-	return j.client.GetItemImage(id, "Primary", size, 92)
+	return j.client().GetItemImage(id, "Primary", size, 92)
 }
 
 func (j *JellyfinMediaProvider) GetFavorites() (mediaprovider.Favorites, error) {
@@ -249,7 +324,7 @@ func (j *JellyfinMediaProvider) GetFavorites() (mediaprovider.Favorites, error)
 	opts.Filter.ParentID = j.currentLibraryID
 	wg.Add(1)
 	go func() {
-		al, err := j.client.GetAlbums(opts)
+		al, err := j.client().GetAlbums(opts)
 		if err == nil && len(al) > 0 {
 			favorites.Albums = sharedutil.MapSlice(al, toAlbum)
 		}
@@ -258,7 +333,7 @@ func (j *JellyfinMediaProvider) GetFavorites() (mediaprovider.Favorites, error)
 
 	wg.Add(1)
 	go func() {
-		ar, err := j.client.GetAlbumArtists(opts)
+		ar, err := j.client().GetAlbumArtists(opts)
 		if err == nil && len(ar) > 0 {
 			favorites.Artists = sharedutil.MapSlice(ar, toArtist)
 		}
@@ -267,7 +342,7 @@ func (j *JellyfinMediaProvider) GetFavorites() (mediaprovider.Favorites, error)
 
 	wg.Add(1)
 	go func() {
-		tr, err := j.client.GetSongs(opts)
+		tr, err := j.client().GetSongs(opts)
 		if err == nil && len(tr) > 0 {
 			favorites.Tracks = sharedutil.MapSlice(tr, toTrack)
 		}
@@ -283,7 +358,7 @@ func (j *JellyfinMediaProvider) GetGenres() ([]*mediaprovider.Genre, error) {
 		return j.genresCached, nil
 	}
 
-	g, err := j.client.GetGenres(jellyfin.Paging{}, j.currentLibraryID)
+	g, err := j.client().GetGenres(jellyfin.Paging{}, j.currentLibraryID)
 	if err != nil {
 		return nil, err
 	}
@@ -299,7 +374,7 @@ func (j *JellyfinMediaProvider) GetGenres() ([]*mediaprovider.Genre, error) {
 }
 
 func (j *JellyfinMediaProvider) GetPlaylists() ([]*mediaprovider.Playlist, error) {
-	pl, err := j.client.GetPlaylists()
+	pl, err := j.client().GetPlaylists()
 	if err != nil {
 		return nil, err
 	}
@@ -307,11 +382,13 @@ func (j *JellyfinMediaProvider) GetPlaylists() ([]*mediaprovider.Playlist, error
 }
 
 func (j *JellyfinMediaProvider) GetPlaylist(playlistID string) (*mediaprovider.PlaylistWithTracks, error) {
-	tr, err := j.client.GetPlaylistSongs(playlistID)
-	if err != nil {
+	tr, err := j.client().GetPlaylistSongs(playlistID)
+	if errors.Is(err, jellyfin.ErrNotFound) {
+		return nil, mediaprovider.ErrNotFound
+	} else if err != nil {
 		return nil, err
 	}
-	pl, err := j.client.GetPlaylist(playlistID)
+	pl, err := j.client().GetPlaylist(playlistID)
 	if err != nil {
 		return nil, err
 	}
@@ -323,38 +400,70 @@ func (j *JellyfinMediaProvider) GetPlaylist(playlistID string) (*mediaprovider.P
 	return playlist, nil
 }
 
-func (j *JellyfinMediaProvider) SetFavorite(params mediaprovider.RatingFavoriteParameters, favorite bool) error {
-	var allIDs []string
-	allIDs = append(allIDs, params.AlbumIDs...)
-	allIDs = append(allIDs, params.ArtistIDs...)
-	allIDs = append(allIDs, params.TrackIDs...)
-
-	// Jellyfin doesn't allow bulk setting favorites.
-	// To not overwhelm the server with requests, set favorite for
-	// only 5 items at a time concurrently
-	batchSize := 5
-	var err error
-	batchSetFavorite := func(offs int, wg *sync.WaitGroup) {
-		for i := 0; i < batchSize && offs+i < len(allIDs); i++ {
-			wg.Add(1)
-			go func(idx int) {
-				newErr := j.client.SetFavorite(allIDs[idx], favorite)
-				if err == nil && newErr != nil {
-					err = newErr
-				}
-				wg.Done()
-			}(offs + i)
+var _ mediaprovider.SupportsRating = (*JellyfinMediaProvider)(nil)
+
+// SetRating sets the user rating of the given items. Jellyfin ratings
+// are out of 10, so the 1-5 stars are stored as 2-10, and 0 clears the rating.
+func (j *JellyfinMediaProvider) SetRating(params mediaprovider.RatingFavoriteParameters, rating int) error {
+	return forEachItem(params, func(id string) error {
+		return j.setUserRating(id, rating*2)
+	})
+}
+
+func (j *JellyfinMediaProvider) setUserRating(itemID string, rating int) error {
+	body := map[string]any{"Rating": rating}
+	params := url.Values{}
+	params.Set("userId", j.userID())
+	err := j.apiRequest(http.MethodPost, fmt.Sprintf("/UserItems/%s/UserData", itemID), params, body, nil)
+	if err != nil {
+		// servers before Jellyfin 10.9 only have the user-scoped endpoint
+		legacyErr := j.apiRequest(http.MethodPost, fmt.Sprintf("/Users/%s/Items/%s/UserData", j.userID(), itemID), nil, body, nil)
+		if legacyErr == nil {
+			return nil
 		}
 	}
+	return err
+}
 
-	numBatches := int(math.Ceil(float64(len(allIDs)) / float64(batchSize)))
-	for i := range numBatches {
-		var wg sync.WaitGroup
-		batchSetFavorite(i*batchSize, &wg)
-		wg.Wait()
+var _ mediaprovider.SupportsSharing = (*JellyfinMediaProvider)(nil)
+
+// CreateShareURL returns a link to the item in the server's web client.
+// Jellyfin has no public share links, so it can only be opened by users of the server.
+func (j *JellyfinMediaProvider) CreateShareURL(id string) (*url.URL, error) {
+	serverID := j.serverID()
+	if serverID == "" {
+		return nil, errors.New("jellyfin: server ID unknown")
 	}
+	u := j.client().BaseURL().JoinPath("web", "/")
+	u.Fragment = fmt.Sprintf("/details?id=%s&serverId=%s", url.QueryEscape(id), url.QueryEscape(serverID))
+	return u, nil
+}
 
-	return err
+func (j *JellyfinMediaProvider) CanShareArtists() bool {
+	return true
+}
+
+func (j *JellyfinMediaProvider) SetFavorite(params mediaprovider.RatingFavoriteParameters, favorite bool) error {
+	return forEachItem(params, func(id string) error {
+		return j.client().SetFavorite(id, favorite)
+	})
+}
+
+// Jellyfin doesn't allow bulk setting user data. To not overwhelm
+// the server with requests, only this many items are set concurrently.
+const userDataConcurrency = 5
+
+// forEachItem calls fn for the ID of every item in params, concurrently
+// for up to userDataConcurrency items, and returns the first error.
+func forEachItem(params mediaprovider.RatingFavoriteParameters, fn func(id string) error) error {
+	var g errgroup.Group
+	g.SetLimit(userDataConcurrency)
+	for _, ids := range [][]string{params.AlbumIDs, params.ArtistIDs, params.TrackIDs} {
+		for _, id := range ids {
+			g.Go(func() error { return fn(id) })
+		}
+	}
+	return g.Wait()
 }
 
 func (j *JellyfinMediaProvider) GetStreamURL(trackID string, transcode *mediaprovider.TranscodeSettings, forceRaw bool) (string, error) {
@@ -368,15 +477,15 @@ func (j *JellyfinMediaProvider) GetStreamURL(trackID string, transcode *mediapro
 			AudioBitRate: uint32(transcode.BitRateKBPS * 1000),
 		}
 	}
-	return j.client.GetStreamURL(trackID, jfTranscode)
+	return j.client().GetStreamURL(trackID, jfTranscode)
 }
 
 func (j *JellyfinMediaProvider) DownloadTrack(trackID string) (io.Reader, error) {
-	url, err := j.client.GetStreamURL(trackID, nil)
+	url, err := j.client().GetStreamURL(trackID, nil)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := http.Get(url)
+	resp, err := j.client().HTTPClient.Get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -386,21 +495,23 @@ func (j *JellyfinMediaProvider) DownloadTrack(trackID string) (io.Reader, error)
 func (j *JellyfinMediaProvider) ClientDecidesScrobble() bool { return false }
 
 func (j *JellyfinMediaProvider) TrackBeganPlayback(trackID string) error {
-	return j.client.UpdatePlayStatus(trackID, jellyfin.Start, 0)
+	return j.startPlaySession(trackID, 0, true)
 }
 
+// TrackEndedPlayback ends the play session of the track. Since the server
+// decides whether to scrobble, based on the position, submission is ignored.
 func (j *JellyfinMediaProvider) TrackEndedPlayback(trackID string, position int, submission bool) error {
-	return j.client.UpdatePlayStatus(trackID, jellyfin.Stop, int64(position)*runTimeTicksPerMicrosecond*1_000_000)
+	return j.stopPlaySession(trackID, int64(position)*runTimeTicksPerMicrosecond*1_000_000, true)
 }
 
 func (j *JellyfinMediaProvider) RescanLibrary() error {
-	return j.client.RefreshLibrary()
+	return j.client().RefreshLibrary()
 }
 
 var _ mediaprovider.LyricsProvider = (*JellyfinMediaProvider)(nil)
 
 func (j *JellyfinMediaProvider) GetLyrics(tr *mediaprovider.Track) (*mediaprovider.Lyrics, error) {
-	l, err := j.client.GetLyrics(tr.ID)
+	l, err := j.client().GetLyrics(tr.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -451,7 +562,7 @@ func toTrack(ch *jellyfin.Song) *mediaprovider.Track {
 		Album:       ch.Album,
 		AlbumID:     ch.AlbumID,
 		Year:        ch.ProductionYear,
-		Rating:      ch.UserData.Rating,
+		Rating:      (ch.UserData.Rating + 1) / 2, // out of 10
 		Favorite:    ch.UserData.IsFavorite,
 		PlayCount:   ch.UserData.PlayCount,
 		LastPlayed:  lastPlayed,
@@ -529,12 +640,12 @@ func (j *JellyfinMediaProvider) fillPlaylist(p *jellyfin.Playlist, pl *mediaprov
 	pl.TrackCount = p.SongCount
 	pl.Duration = time.Duration(p.RunTimeTicks/runTimeTicksPerMicrosecond) * time.Microsecond
 	// Jellyfin does not have public playlists
-	pl.Owner = j.client.LoggedInUser()
+	pl.Owner = j.client().LoggedInUser()
 	pl.Public = false
 }
 
 func (j *JellyfinMediaProvider) GetSongRadio(trackID string, count int) ([]*mediaprovider.Track, error) {
-	tr, err := j.client.GetInstantMix(trackID, jellyfin.TypeSong, count)
+	tr, err := j.client().GetInstantMix(trackID, jellyfin.TypeSong, count)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,139 @@
+package jellyfin
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// Jellyfin has no play queue API, so the queue is saved in the custom
+// preferences of a display preferences record of this client, which
+// the server stores per user.
+const (
+	playQueuePrefsID = "playqueue"
+
+	playQueueTracksPref   = "tracks"
+	playQueueIndexPref    = "currentIndex"
+	playQueuePositionPref = "position" // milliseconds
+
+	// max number of tracks to fetch per request when restoring the queue
+	playQueueFetchBatchSize = 100
+)
+
+var _ mediaprovider.CanSavePlayQueue = (*JellyfinMediaProvider)(nil)
+
+func (j *JellyfinMediaProvider) SavePlayQueue(trackIDs []string, currentTrackIdx int, timeSeconds int) error {
+	if len(trackIDs) == 0 {
+		return nil // don't save an empty queue
+	}
+	prefs, err := j.getPlayQueuePrefs()
+	if err != nil {
+		return err
+	}
+	custom, _ := prefs["CustomPrefs"].(map[string]any)
+	if custom == nil {
+		custom = make(map[string]any)
+	}
+	custom[playQueueTracksPref] = strings.Join(trackIDs, ",")
+	custom[playQueueIndexPref] = strconv.Itoa(currentTrackIdx)
+	custom[playQueuePositionPref] = strconv.Itoa(timeSeconds * 1000)
+	prefs["CustomPrefs"] = custom
+	return j.apiRequest(http.MethodPost, "/DisplayPreferences/"+playQueuePrefsID, j.playQueuePrefsParams(), prefs, nil)
+}
+
+func (j *JellyfinMediaProvider) GetPlayQueue() (*mediaprovider.SavedPlayQueue, error) {
+	prefs, err := j.getPlayQueuePrefs()
+	if err != nil {
+		return nil, err
+	}
+	savedQueue := &mediaprovider.SavedPlayQueue{}
+	custom, _ := prefs["CustomPrefs"].(map[string]any)
+	tracks, _ := custom[playQueueTracksPref].(string)
+	if tracks == "" {
+		return savedQueue, nil
+	}
+	trackIDs := strings.Split(tracks, ",")
+	idx, _ := custom[playQueueIndexPref].(string)
+	pos, _ := custom[playQueuePositionPref].(string)
+	trackPos, err := strconv.Atoi(idx)
+	if err != nil {
+		trackPos = -1
+	}
+	posMs, _ := strconv.Atoi(pos)
+
+	byID, err := j.getTracksByID(trackIDs)
+	if err != nil {
+		return nil, err
+	}
+	// rebuild the queue in its saved order, dropping
+	// tracks which have since been deleted from the server
+	for i, id := range trackIDs {
+		tr, ok := byID[id]
+		if !ok {
+			if i < trackPos {
+				trackPos--
+			} else if i == trackPos {
+				trackPos, posMs = -1, 0
+			}
+			continue
+		}
+		savedQueue.Tracks = append(savedQueue.Tracks, tr)
+	}
+	if trackPos >= len(savedQueue.Tracks) {
+		trackPos = -1
+	}
+	savedQueue.TrackPos = trackPos
+	savedQueue.TimePos = posMs / 1000
+	return savedQueue, nil
+}
+
+// getPlayQueuePrefs returns the display preferences record holding the
+// saved play queue. The server returns a default record if none is saved.
+func (j *JellyfinMediaProvider) getPlayQueuePrefs() (map[string]any, error) {
+	var prefs map[string]any
+	err := j.apiRequest(http.MethodGet, "/DisplayPreferences/"+playQueuePrefsID, j.playQueuePrefsParams(), nil, &prefs)
+	if err != nil {
+		return nil, err
+	}
+	if prefs == nil {
+		prefs = make(map[string]any)
+	}
+	return prefs, nil
+}
+
+func (j *JellyfinMediaProvider) playQueuePrefsParams() url.Values {
+	params := url.Values{}
+	params.Set("userId", j.userID())
+	params.Set("client", j.client().ClientName)
+	return params
+}
+
+func (j *JellyfinMediaProvider) getTracksByID(ids []string) (map[string]*mediaprovider.Track, error) {
+	userID := j.userID()
+	if userID == "" {
+		return nil, fmt.Errorf("jellyfin: not logged in")
+	}
+	tracks := make(map[string]*mediaprovider.Track, len(ids))
+	for i := 0; i < len(ids); i += playQueueFetchBatchSize {
+		batch := ids[i:min(i+playQueueFetchBatchSize, len(ids))]
+		params := url.Values{}
+		params.Set("Ids", strings.Join(batch, ","))
+		params.Set("Fields", folderItemFields)
+		var resp struct {
+			Items []*folderItem `json:"Items"`
+		}
+		if err := j.apiRequest(http.MethodGet, fmt.Sprintf("/Users/%s/Items", userID), params, nil, &resp); err != nil {
+			return nil, err
+		}
+		for _, it := range resp.Items {
+			if it.Type == "Audio" {
+				tracks[it.Id] = toTrack(&it.Song)
+			}
+		}
+	}
+	return tracks, nil
+}
@@ -0,0 +1,73 @@
+package jellyfin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPlayQueueRoundTrip(t *testing.T) {
+	// the display preferences record, as stored by the server
+	prefs := map[string]any{"Id": playQueuePrefsID, "SortBy": "SortName", "CustomPrefs": map[string]any{"other": "kept"}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/DisplayPreferences/"+playQueuePrefsID, func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("userId") != "user" || q.Get("client") != "Supersonic" {
+			t.Errorf("unexpected display preferences query: %s", r.URL.RawQuery)
+		}
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(prefs)
+		case http.MethodPost:
+			prefs = nil
+			if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+				t.Error(err)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	mux.HandleFunc("/Users/user/Items", func(w http.ResponseWriter, r *http.Request) {
+		// track "b" has been deleted from the server; items are returned in any order
+		var items []map[string]any
+		for _, id := range strings.Split(r.URL.Query().Get("Ids"), ",") {
+			if id != "b" {
+				items = append([]map[string]any{{"Id": id, "Name": "Track " + id, "Type": "Audio"}}, items...)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"Items": items})
+	})
+	j := newTestProvider(t, mux)
+
+	if err := j.SavePlayQueue([]string{"a", "b", "c"}, 2, 42); err != nil {
+		t.Fatal(err)
+	}
+	if prefs["SortBy"] != "SortName" || prefs["CustomPrefs"].(map[string]any)["other"] != "kept" {
+		t.Errorf("other preferences not preserved: %v", prefs)
+	}
+
+	q, err := j.GetPlayQueue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, tr := range q.Tracks {
+		ids = append(ids, tr.ID)
+	}
+	if strings.Join(ids, ",") != "a,c" {
+		t.Errorf("got tracks %v, want [a c]", ids)
+	}
+	if q.TrackPos != 1 || q.TimePos != 42 {
+		t.Errorf("got position %d at %ds, want 1 at 42s", q.TrackPos, q.TimePos)
+	}
+
+	// the position is cleared if the current track was deleted
+	if err := j.SavePlayQueue([]string{"a", "b", "c"}, 1, 42); err != nil {
+		t.Fatal(err)
+	}
+	if q, err = j.GetPlayQueue(); err != nil {
+		t.Fatal(err)
+	}
+	if q.TrackPos != -1 || q.TimePos != 0 {
+		t.Errorf("got position %d at %ds, want -1 at 0s", q.TrackPos, q.TimePos)
+	}
+}
@@ -0,0 +1,193 @@
+package jellyfin
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// interval of the progress reports sent while a track is playing,
+// which keep the session's position current on the server's dashboard
+const progressReportInterval = 10 * time.Second
+
+var _ mediaprovider.CanReportPlayback = (*JellyfinMediaProvider)(nil)
+
+// playSession is the state of the track being played, as reported to the server.
+type playSession struct {
+	mu             sync.Mutex
+	trackID        string
+	positionTicks  int64
+	updatedAt      time.Time
+	paused         bool
+	scrobble       bool // started by TrackBeganPlayback
+	cancelProgress context.CancelFunc
+}
+
+// position returns the current playback position, extrapolated from the last report.
+func (s *playSession) position() int64 {
+	if s.paused {
+		return s.positionTicks
+	}
+	return s.positionTicks + int64(time.Since(s.updatedAt)/100) // 1 tick = 100ns
+}
+
+type playbackInfo struct {
+	ItemId        string
+	PositionTicks int64
+	IsPaused      bool
+	CanSeek       bool
+	EventName     string `json:",omitempty"`
+	Failed        bool   `json:",omitempty"`
+}
+
+// ReportPlayback reports the playback state of a track, as reported to
+// OpenSubsonic servers, to the Jellyfin session of this client.
+func (j *JellyfinMediaProvider) ReportPlayback(trackID string, positionMs int64, state string) error {
+	ticks := positionMs * runTimeTicksPerMicrosecond * 1000
+	switch state {
+	case "starting":
+		return j.startPlaySession(trackID, ticks, false)
+	case "playing":
+		if !j.hasPlaySession(trackID) {
+			return j.startPlaySession(trackID, ticks, false)
+		}
+		return j.reportProgress(trackID, ticks, false, "unpause")
+	case "paused":
+		return j.reportProgress(trackID, ticks, true, "pause")
+	case "stopped":
+		return j.stopPlaySession(trackID, ticks, false)
+	}
+	return nil
+}
+
+func (j *JellyfinMediaProvider) hasPlaySession(trackID string) bool {
+	j.session.mu.Lock()
+	defer j.session.mu.Unlock()
+	return j.session.trackID == trackID
+}
+
+func (j *JellyfinMediaProvider) startPlaySession(trackID string, positionTicks int64, scrobble bool) error {
+	j.session.mu.Lock()
+	if j.session.trackID == trackID {
+		// already started by either the scrobble or playback report
+		j.session.scrobble = j.session.scrobble || scrobble
+		j.session.mu.Unlock()
+		return nil
+	}
+	if j.session.cancelProgress != nil {
+		j.session.cancelProgress()
+	}
+	// the session of a scrobbled track is ended by TrackEndedPlayback,
+	// which may still be on its way; otherwise end it here
+	prevTrackID, prevPos := j.session.trackID, j.session.position()
+	endPrev := prevTrackID != "" && !j.session.scrobble
+	ctx, cancel := context.WithCancel(context.Background())
+	j.session.trackID = trackID
+	j.session.positionTicks = positionTicks
+	j.session.updatedAt = time.Now()
+	j.session.paused = false
+	j.session.scrobble = scrobble
+	j.session.cancelProgress = cancel
+	j.session.mu.Unlock()
+
+	if endPrev {
+		if err := j.sendPlaybackStopped(prevTrackID, prevPos, false); err != nil {
+			log.Printf("error reporting playback stopped: %s", err.Error())
+		}
+	}
+	go j.sendPeriodicProgress(ctx, trackID)
+	return j.apiRequest(http.MethodPost, "/Sessions/Playing", nil, playbackInfo{
+		ItemId:        trackID,
+		PositionTicks: positionTicks,
+		CanSeek:       true,
+	}, nil)
+}
+
+func (j *JellyfinMediaProvider) reportProgress(trackID string, positionTicks int64, paused bool, event string) error {
+	j.session.mu.Lock()
+	if j.session.trackID != trackID {
+		j.session.mu.Unlock()
+		return nil
+	}
+	j.session.positionTicks = positionTicks
+	j.session.updatedAt = time.Now()
+	j.session.paused = paused
+	j.session.mu.Unlock()
+
+	return j.apiRequest(http.MethodPost, "/Sessions/Playing/Progress", nil, playbackInfo{
+		ItemId:        trackID,
+		PositionTicks: positionTicks,
+		IsPaused:      paused,
+		CanSeek:       true,
+		EventName:     event,
+	}, nil)
+}
+
+func (j *JellyfinMediaProvider) sendPeriodicProgress(ctx context.Context, trackID string) {
+	t := time.NewTicker(progressReportInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		j.session.mu.Lock()
+		paused, pos := j.session.paused, j.session.position()
+		j.session.mu.Unlock()
+		if paused {
+			continue
+		}
+		err := j.apiRequest(http.MethodPost, "/Sessions/Playing/Progress", nil, playbackInfo{
+			ItemId:        trackID,
+			PositionTicks: pos,
+			CanSeek:       true,
+			EventName:     "timeupdate",
+		}, nil)
+		if err != nil {
+			log.Printf("error reporting playback progress: %s", err.Error())
+		}
+	}
+}
+
+// stopPlaySession ends the session of the given track. If scrobble is true,
+// it is ended by TrackEndedPlayback, and the server records a play of the
+// track if it played long enough, which must only happen when scrobbling.
+func (j *JellyfinMediaProvider) stopPlaySession(trackID string, positionTicks int64, scrobble bool) error {
+	j.session.mu.Lock()
+	if j.session.trackID == trackID {
+		if j.session.scrobble && !scrobble {
+			// leave ending the session to TrackEndedPlayback,
+			// so that the play is recorded (only) once
+			j.session.mu.Unlock()
+			return nil
+		}
+		if j.session.cancelProgress != nil {
+			j.session.cancelProgress()
+		}
+		j.session.trackID = ""
+		j.session.cancelProgress = nil
+	} else if !scrobble {
+		// already ended, or replaced by the session of the next track
+		j.session.mu.Unlock()
+		return nil
+	}
+	j.session.mu.Unlock()
+	return j.sendPlaybackStopped(trackID, positionTicks, scrobble)
+}
+
+func (j *JellyfinMediaProvider) sendPlaybackStopped(trackID string, positionTicks int64, scrobble bool) error {
+	return j.apiRequest(http.MethodPost, "/Sessions/Playing/Stopped", nil, playbackInfo{
+		ItemId:        trackID,
+		PositionTicks: positionTicks,
+		IsPaused:      true,
+		// Jellyfin does not update the play count and state of
+		// items whose playback failed, so this ends the session
+		// without a play being recorded when not scrobbling
+		Failed: !scrobble,
+	}, nil)
+}
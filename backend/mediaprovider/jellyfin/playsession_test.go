@@ -0,0 +1,116 @@
+package jellyfin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/dweymouth/go-jellyfin"
+)
+
+// newTestProvider returns a provider logged in to a fake server with the given handler.
+func newTestProvider(t *testing.T, handler http.Handler) *JellyfinMediaProvider {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /Users/Me", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Name":"test","Id":"user","ServerId":"server"}`))
+	})
+	mux.Handle("/", handler)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	cli, err := jellyfin.NewClient(srv.URL, "Supersonic", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.LoginWithToken("token"); err != nil {
+		t.Fatal(err)
+	}
+	return newJellyfinMediaProvider(cli).(*JellyfinMediaProvider)
+}
+
+type sessionReport struct {
+	path string
+	info playbackInfo
+}
+
+func TestPlaySessionReports(t *testing.T) {
+	var mu sync.Mutex
+	var reports []sessionReport
+	j := newTestProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Emby-Token") != "token" {
+			t.Errorf("%s: missing access token", r.URL.Path)
+		}
+		var info playbackInfo
+		if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+			t.Errorf("%s: %v", r.URL.Path, err)
+		}
+		mu.Lock()
+		reports = append(reports, sessionReport{path: r.URL.Path, info: info})
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	steps := []struct {
+		do   func() error
+		want []sessionReport
+	}{
+		{
+			do:   func() error { return j.ReportPlayback("t1", 1000, "starting") },
+			want: []sessionReport{{"/Sessions/Playing", playbackInfo{ItemId: "t1", PositionTicks: 10_000_000, CanSeek: true}}},
+		},
+		{
+			do:   func() error { return j.ReportPlayback("t1", 2000, "paused") },
+			want: []sessionReport{{"/Sessions/Playing/Progress", playbackInfo{ItemId: "t1", PositionTicks: 20_000_000, IsPaused: true, CanSeek: true, EventName: "pause"}}},
+		},
+		{
+			do:   func() error { return j.ReportPlayback("t1", 2000, "playing") },
+			want: []sessionReport{{"/Sessions/Playing/Progress", playbackInfo{ItemId: "t1", PositionTicks: 20_000_000, CanSeek: true, EventName: "unpause"}}},
+		},
+		{
+			// the unscrobbled session of the previous track is ended without recording a play
+			do: func() error { return j.ReportPlayback("t2", 0, "starting") },
+			want: []sessionReport{
+				{"/Sessions/Playing/Stopped", playbackInfo{ItemId: "t1", PositionTicks: 20_000_000, IsPaused: true, Failed: true}},
+				{"/Sessions/Playing", playbackInfo{ItemId: "t2", CanSeek: true}},
+			},
+		},
+		{
+			// the scrobble joins the session already started
+			do:   func() error { return j.TrackBeganPlayback("t2") },
+			want: nil,
+		},
+		{
+			// and is left to TrackEndedPlayback to end
+			do:   func() error { return j.ReportPlayback("t2", 3000, "stopped") },
+			want: nil,
+		},
+		{
+			do:   func() error { return j.TrackEndedPlayback("t2", 3, true) },
+			want: []sessionReport{{"/Sessions/Playing/Stopped", playbackInfo{ItemId: "t2", PositionTicks: 30_000_000, IsPaused: true}}},
+		},
+	}
+	for i, step := range steps {
+		mu.Lock()
+		reports = nil
+		mu.Unlock()
+		if err := step.do(); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		mu.Lock()
+		got := reports
+		mu.Unlock()
+		if len(got) != len(step.want) {
+			t.Fatalf("step %d: got reports %+v, want %+v", i, got, step.want)
+		}
+		for k := range got {
+			// positions are extrapolated from the last report while playing
+			if d := got[k].info.PositionTicks - step.want[k].info.PositionTicks; d >= 0 && d < 10_000_000 {
+				got[k].info.PositionTicks = step.want[k].info.PositionTicks
+			}
+			if got[k] != step.want[k] {
+				t.Errorf("step %d: got report %+v, want %+v", i, got[k], step.want[k])
+			}
+		}
+	}
+}
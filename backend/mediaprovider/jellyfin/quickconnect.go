@@ -0,0 +1,142 @@
+package jellyfin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dweymouth/go-jellyfin"
+)
+
+const quickConnectPollInterval = 2 * time.Second
+
+var ErrQuickConnectDisabled = errors.New("Quick Connect is not enabled on the server")
+
+// QuickConnect performs the Jellyfin Quick Connect login flow, in which
+// the user authorizes the app by entering a code in another, already
+// logged in, Jellyfin client. This allows logging in to servers
+// which authenticate with SSO, without a password.
+type QuickConnect struct {
+	// Code is the code the user must enter to authorize the login.
+	Code string
+
+	client *jellyfin.Client
+	secret string
+}
+
+type quickConnectResult struct {
+	Authenticated bool
+	Secret        string
+	Code          string
+}
+
+// StartQuickConnect initiates a Quick Connect login on the server of the given client.
+// The access token is issued to the client's device, which a client that has not
+// logged in always identifies the same way, so it can be used by another client
+// logging in with it (see JellyfinServer.TokenAuth).
+func StartQuickConnect(cli *jellyfin.Client) (*QuickConnect, error) {
+	q := &QuickConnect{client: cli}
+	var res quickConnectResult
+	err := q.request(http.MethodPost, "/QuickConnect/Initiate", nil, nil, &res)
+	if errors.Is(err, errMethodNotAllowed) {
+		// servers before Jellyfin 10.9 initiate with GET
+		err = q.request(http.MethodGet, "/QuickConnect/Initiate", nil, nil, &res)
+	}
+	if errors.Is(err, errUnauthorized) {
+		return nil, ErrQuickConnectDisabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	q.Code = res.Code
+	q.secret = res.Secret
+	return q, nil
+}
+
+// Wait polls the server until the user has authorized the login, then
+// authenticates and returns the access token and name of the user.
+// It returns early with the context's error if ctx is done.
+func (q *QuickConnect) Wait(ctx context.Context) (token, username string, err error) {
+	t := time.NewTicker(quickConnectPollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return "", "", ctx.Err()
+		case <-t.C:
+		}
+		var res quickConnectResult
+		query := map[string]string{"secret": q.secret}
+		if err := q.request(http.MethodGet, "/QuickConnect/Connect", query, nil, &res); err != nil {
+			return "", "", err
+		}
+		if res.Authenticated {
+			break
+		}
+	}
+
+	var auth struct {
+		AccessToken string
+		User        struct {
+			Name string
+		}
+	}
+	body := map[string]string{"Secret": q.secret}
+	if err := q.request(http.MethodPost, "/Users/AuthenticateWithQuickConnect", nil, body, &auth); err != nil {
+		return "", "", err
+	}
+	return auth.AccessToken, auth.User.Name, nil
+}
+
+var (
+	errUnauthorized     = errors.New("unauthorized")
+	errMethodNotAllowed = errors.New("method not allowed")
+)
+
+func (q *QuickConnect) request(method, path string, query map[string]string, body, result any) error {
+	u := q.client.BaseURL().JoinPath(path)
+	if query != nil {
+		v := u.Query()
+		for k, val := range query {
+			v.Set(k, val)
+		}
+		u.RawQuery = v.Encode()
+	}
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, u.String(), reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", q.client.AuthHeader())
+
+	resp, err := q.client.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(resp.Body).Decode(result)
+	case http.StatusUnauthorized:
+		return errUnauthorized
+	case http.StatusMethodNotAllowed:
+		return errMethodNotAllowed
+	default:
+		return fmt.Errorf("jellyfin: %s %s: %s", method, path, resp.Status)
+	}
+}
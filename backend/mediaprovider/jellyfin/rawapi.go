@@ -0,0 +1,67 @@
+package jellyfin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// apiRequest performs an authenticated request against the Jellyfin API
+// and decodes the JSON response into result, if non-nil.
+func (j *JellyfinMediaProvider) apiRequest(method, path string, params url.Values, body, result any) error {
+	cli := j.client()
+	token := cli.Token()
+	if token == "" {
+		return fmt.Errorf("jellyfin: not logged in")
+	}
+
+	u := cli.BaseURL().JoinPath(path)
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request body: %v", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, u.String(), reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("X-Emby-Token", token)
+	req.Header.Set("Authorization", fmt.Sprintf("%s, Token=\"%s\"", cli.AuthHeader(), token))
+
+	resp, err := cli.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("jellyfin: %s %s: %s %s", method, path, resp.Status, msg)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("decode json: %v", err)
+	}
+	return nil
+}
+
+func (j *JellyfinMediaProvider) serverID() string {
+	return j.client().ServerID()
+}
+
+func (j *JellyfinMediaProvider) userID() string {
+	return j.client().UserID()
+}
@@ -0,0 +1,141 @@
+package jellyfin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+var _ mediaprovider.RemoteSessionProvider = (*JellyfinMediaProvider)(nil)
+
+// sessions which have not been active for longer than this are not listed
+const remoteSessionActiveWithinSeconds = 960
+
+var errRemoteSessionNotFound = errors.New("jellyfin: remote session not found")
+
+type sessionInfo struct {
+	Id                    string
+	Client                string
+	DeviceId              string
+	DeviceName            string
+	SupportsRemoteControl bool
+	PlayableMediaTypes    []string
+	NowPlayingItem        *struct {
+		Id string
+	}
+	PlayState struct {
+		PositionTicks int64
+		IsPaused      bool
+		VolumeLevel   int
+		IsMuted       bool
+	}
+}
+
+func (j *JellyfinMediaProvider) GetRemoteSessions() ([]*mediaprovider.RemoteSession, error) {
+	sessions, err := j.getSessions()
+	if err != nil {
+		return nil, err
+	}
+	var remote []*mediaprovider.RemoteSession
+	for _, s := range sessions {
+		if j.isOwnSession(s) || !s.SupportsRemoteControl ||
+			!slices.Contains(s.PlayableMediaTypes, "Audio") {
+			continue
+		}
+		remote = append(remote, &mediaprovider.RemoteSession{
+			ID:   s.Id,
+			Name: fmt.Sprintf("%s (%s)", s.DeviceName, s.Client),
+		})
+	}
+	return remote, nil
+}
+
+func (j *JellyfinMediaProvider) RemoteSessionPlay(sessionID, trackID string, startSeconds float64) error {
+	params := url.Values{}
+	params.Set("playCommand", "PlayNow")
+	params.Set("itemIds", trackID)
+	if startSeconds > 0 {
+		params.Set("startPositionTicks", strconv.FormatInt(secondsToTicks(startSeconds), 10))
+	}
+	return j.apiRequest(http.MethodPost, fmt.Sprintf("/Sessions/%s/Playing", sessionID), params, nil, nil)
+}
+
+func (j *JellyfinMediaProvider) RemoteSessionPause(sessionID string) error {
+	return j.sendPlaystateCommand(sessionID, "Pause", nil)
+}
+
+func (j *JellyfinMediaProvider) RemoteSessionUnpause(sessionID string) error {
+	return j.sendPlaystateCommand(sessionID, "Unpause", nil)
+}
+
+func (j *JellyfinMediaProvider) RemoteSessionStop(sessionID string) error {
+	return j.sendPlaystateCommand(sessionID, "Stop", nil)
+}
+
+func (j *JellyfinMediaProvider) RemoteSessionSeek(sessionID string, seconds float64) error {
+	params := url.Values{}
+	params.Set("seekPositionTicks", strconv.FormatInt(secondsToTicks(seconds), 10))
+	return j.sendPlaystateCommand(sessionID, "Seek", params)
+}
+
+func (j *JellyfinMediaProvider) RemoteSessionSetVolume(sessionID string, vol int) error {
+	body := map[string]any{
+		"Name":      "SetVolume",
+		"Arguments": map[string]string{"Volume": strconv.Itoa(vol)},
+	}
+	return j.apiRequest(http.MethodPost, fmt.Sprintf("/Sessions/%s/Command", sessionID), nil, body, nil)
+}
+
+func (j *JellyfinMediaProvider) RemoteSessionGetStatus(sessionID string) (*mediaprovider.RemoteSessionStatus, error) {
+	sessions, err := j.getSessions()
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(sessions, func(s *sessionInfo) bool { return s.Id == sessionID })
+	if idx < 0 {
+		return nil, errRemoteSessionNotFound
+	}
+	s := sessions[idx]
+	status := &mediaprovider.RemoteSessionStatus{
+		Paused:          s.PlayState.IsPaused,
+		PositionSeconds: float64(s.PlayState.PositionTicks) / (runTimeTicksPerMicrosecond * 1_000_000),
+		Volume:          s.PlayState.VolumeLevel,
+	}
+	if s.PlayState.IsMuted {
+		status.Volume = 0
+	}
+	if s.NowPlayingItem != nil {
+		status.NowPlayingID = s.NowPlayingItem.Id
+	}
+	return status, nil
+}
+
+func (j *JellyfinMediaProvider) getSessions() ([]*sessionInfo, error) {
+	params := url.Values{}
+	params.Set("controllableByUserId", j.userID())
+	params.Set("activeWithinSeconds", strconv.Itoa(remoteSessionActiveWithinSeconds))
+	var sessions []*sessionInfo
+	if err := j.apiRequest(http.MethodGet, "/Sessions", params, nil, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (j *JellyfinMediaProvider) sendPlaystateCommand(sessionID, command string, params url.Values) error {
+	return j.apiRequest(http.MethodPost, fmt.Sprintf("/Sessions/%s/Playing/%s", sessionID, command), params, nil, nil)
+}
+
+// isOwnSession returns true if the session is that of this client,
+// identified by the device ID it sends to the server.
+func (j *JellyfinMediaProvider) isOwnSession(s *sessionInfo) bool {
+	return s.DeviceId == j.client().DeviceID()
+}
+
+func secondsToTicks(secs float64) int64 {
+	return int64(secs * runTimeTicksPerMicrosecond * 1_000_000)
+}
@@ -25,19 +25,19 @@ func (j *JellyfinMediaProvider) SearchAll(searchQuery string, maxResults int) ([
 	opts.Filter.ParentID = j.currentLibraryID
 	wg.Add(1)
 	go func() {
-		albumResult, _ := j.client.Search(searchQuery, jellyfin.TypeAlbum, opts)
+		albumResult, _ := j.client().Search(searchQuery, jellyfin.TypeAlbum, opts)
 		albums = albumResult.Albums
 		wg.Done()
 	}()
 	wg.Add(1)
 	go func() {
-		artistResult, _ := j.client.Search(searchQuery, jellyfin.TypeArtist, opts)
+		artistResult, _ := j.client().Search(searchQuery, jellyfin.TypeArtist, opts)
 		artists = artistResult.Artists
 		wg.Done()
 	}()
 	wg.Add(1)
 	go func() {
-		songResult, _ := j.client.Search(searchQuery, jellyfin.TypeSong, opts)
+		songResult, _ := j.client().Search(searchQuery, jellyfin.TypeSong, opts)
 		songs = songResult.Songs
 		wg.Done()
 	}()
@@ -47,7 +47,7 @@ func (j *JellyfinMediaProvider) SearchAll(searchQuery string, maxResults int) ([
 
 	wg.Add(1)
 	go func() {
-		p, e := j.client.GetPlaylists()
+		p, e := j.client().GetPlaylists()
 		if e == nil {
 			playlists = sharedutil.FilterSlice(p, func(p *jellyfin.Playlist) bool {
 				return helpers.AllTermsMatch(strings.ToLower(sanitize.Accents(p.Name)), queryLowerWords)
@@ -58,7 +58,7 @@ func (j *JellyfinMediaProvider) SearchAll(searchQuery string, maxResults int) ([
 
 	wg.Add(1)
 	go func() {
-		g, e := j.client.GetGenres(jellyfin.Paging{}, "")
+		g, e := j.client().GetGenres(jellyfin.Paging{}, "")
 		if e == nil {
 			genres = sharedutil.FilterSlice(g, func(g jellyfin.NameID) bool {
 				return helpers.AllTermsMatch(strings.ToLower(sanitize.Accents(g.Name)), queryLowerWords)
@@ -1,6 +1,7 @@
 package mediaprovider
 
 import (
+	"errors"
 	"image"
 	"io"
 	"net/url"
@@ -9,6 +10,9 @@ import (
 	"github.com/deluan/sanitize"
 )
 
+// ErrNotFound is returned, possibly wrapped, when a requested item does not exist on the server.
+var ErrNotFound = errors.New("not found")
+
 const (
 	// set of all supported album sorts across all media providers
 	// these strings may be translated
@@ -36,6 +40,7 @@ type (
 	ArtistIterator = MediaIterator[Artist]
 	AlbumIterator  = MediaIterator[Album]
 	TrackIterator  = MediaIterator[Track]
+	LabelIterator  = MediaIterator[Label]
 )
 
 type MediaFilter[M, F any] interface {
@@ -55,6 +60,9 @@ type AlbumFilterOptions struct {
 
 	ExcludeFavorited   bool // mut. exc. with ExcludeUnfavorited
 	ExcludeUnfavorited bool // mut. exc. with ExcludeFavorited
+
+	IncludeReleaseTypes ReleaseTypes // 0 == unset/match any
+	ExcludeReleaseTypes ReleaseTypes // 0 == unset/exclude none
 }
 
 // Clone returns a deep copy of the filter options
@@ -67,6 +75,9 @@ func (o AlbumFilterOptions) Clone() AlbumFilterOptions {
 		Genres:             genres,
 		ExcludeFavorited:   o.ExcludeFavorited,
 		ExcludeUnfavorited: o.ExcludeUnfavorited,
+
+		IncludeReleaseTypes: o.IncludeReleaseTypes,
+		ExcludeReleaseTypes: o.ExcludeReleaseTypes,
 	}
 }
 
@@ -95,7 +106,8 @@ func (a albumFilter) Clone() AlbumFilter {
 func (a albumFilter) IsNil() bool {
 	return a.options.MinYear == 0 && a.options.MaxYear == 0 &&
 		len(a.options.Genres) == 0 &&
-		!a.options.ExcludeFavorited && !a.options.ExcludeUnfavorited
+		!a.options.ExcludeFavorited && !a.options.ExcludeUnfavorited &&
+		a.options.IncludeReleaseTypes == 0 && a.options.ExcludeReleaseTypes == 0
 }
 
 func (f albumFilter) Matches(album *Album) bool {
@@ -111,6 +123,9 @@ func (f albumFilter) Matches(album *Album) bool {
 	if y := album.YearOrZero(); y < f.options.MinYear || (f.options.MaxYear > 0 && y > f.options.MaxYear) {
 		return false
 	}
+	if !ReleaseTypesMatch(f.options.IncludeReleaseTypes, f.options.ExcludeReleaseTypes, album.ReleaseTypes) {
+		return false
+	}
 	if len(f.options.Genres) == 0 {
 		return true
 	}
@@ -220,6 +235,8 @@ type MediaProvider interface {
 
 	GetArtistInfo(artistID string) (*ArtistInfo, error)
 
+	// GetPlaylist returns the playlist with its tracks,
+	// or ErrNotFound if the playlist does not exist.
 	GetPlaylist(playlistID string) (*PlaylistWithTracks, error)
 
 	GetCoverArt(coverArtID string, size int) (image.Image, error)
@@ -292,6 +309,13 @@ type SupportsRating interface {
 	SetRating(params RatingFavoriteParameters, rating int) error
 }
 
+// InstantMixProvider is implemented by servers which can create
+// a mix of similar tracks seeded from an album or a genre.
+type InstantMixProvider interface {
+	GetAlbumMix(albumID string, count int) ([]*Track, error)
+	GetGenreMix(genreName string, count int) ([]*Track, error)
+}
+
 type SupportsSharing interface {
 	CreateShareURL(id string) (*url.URL, error)
 	CanShareArtists() bool
@@ -306,6 +330,28 @@ type CanReportPlayback interface {
 	ReportPlayback(trackID string, positionMs int64, state string) error
 }
 
+// SupportsRebind is implemented by MediaProviders which can switch
+// to a different connection to the same server, such as when failing
+// over between a server's primary and alternate hostnames.
+type SupportsRebind interface {
+	// Rebind switches the provider to the base URL and session of the
+	// given Server, which must be a logged in Server of the same type.
+	Rebind(server Server) error
+
+	// Session returns the current session, so that it can be continued on
+	// another connection to the server, or nil if the server has no sessions.
+	Session() *Session
+}
+
+// Session is a logged in session with a server.
+type Session struct {
+	// Token is the access token of the session.
+	Token string
+
+	// DeviceID is the ID of the device to which the server issued the token.
+	DeviceID string
+}
+
 type LyricsProvider interface {
 	GetLyrics(track *Track) (*Lyrics, error)
 }
@@ -315,6 +361,23 @@ type RadioProvider interface {
 	GetRadioStations() ([]*RadioStation, error)
 }
 
+type FolderBrowsingProvider interface {
+	// GetFolder returns the immediate subfolders and tracks of the given folder.
+	// Use empty string to get the top-level folders of the current library.
+	GetFolder(id string) (*FolderWithChildren, error)
+}
+
+type ComposerProvider interface {
+	// GetComposers returns all artists credited as a composer on at least one track.
+	GetComposers() ([]*Artist, error)
+	GetComposerTracks(composerID string) ([]*Track, error)
+}
+
+type LabelProvider interface {
+	GetLabels() ([]*Label, error)
+	GetLabelAlbums(labelID string) ([]*Album, error)
+}
+
 type JukeboxProvider interface {
 	JukeboxStart() error
 	JukeboxStop() error
@@ -339,6 +402,48 @@ type JukeboxStatus struct {
 	PositionSeconds float64
 }
 
+// RemoteSessionProvider is implemented by MediaProviders which can
+// remotely control playback in the user's other client sessions on the server.
+type RemoteSessionProvider interface {
+	// Returns the other active sessions which can be remotely controlled
+	GetRemoteSessions() ([]*RemoteSession, error)
+	RemoteSessionPlay(sessionID, trackID string, startSeconds float64) error
+	RemoteSessionPause(sessionID string) error
+	RemoteSessionUnpause(sessionID string) error
+	RemoteSessionStop(sessionID string) error
+	RemoteSessionSeek(sessionID string, seconds float64) error
+	RemoteSessionGetStatus(sessionID string) (*RemoteSessionStatus, error)
+
+	// Sets the volume of the remote session (0-100)
+	RemoteSessionSetVolume(sessionID string, vol int) error
+}
+
+type RemoteSession struct {
+	ID   string
+	Name string
+}
+
+type RemoteSessionStatus struct {
+	// ID of the item being played, or empty if the session is not playing
+	NowPlayingID    string
+	Paused          bool
+	PositionSeconds float64
+	Volume          int
+}
+
+// ReleaseTypesMatch returns true if the given release types contain at least one of
+// the include types (if any are set) and none of the exclude types.
+// Albums with no release type information are considered to be of type ReleaseTypeAlbum.
+func ReleaseTypesMatch(include, exclude, releaseTypes ReleaseTypes) bool {
+	if releaseTypes == 0 {
+		releaseTypes = ReleaseTypeAlbum
+	}
+	if releaseTypes&exclude != 0 {
+		return false
+	}
+	return include == 0 || releaseTypes&include != 0
+}
+
 func genresMatch(filterGenres, albumGenres []string) bool {
 	for _, g1 := range filterGenres {
 		for _, g2 := range albumGenres {
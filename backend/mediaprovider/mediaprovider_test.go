@@ -0,0 +1,33 @@
+package mediaprovider
+
+import "testing"
+
+func TestAlbumFilterReleaseTypes(t *testing.T) {
+	tests := []struct {
+		include      ReleaseTypes
+		exclude      ReleaseTypes
+		releaseTypes ReleaseTypes
+		want         bool
+	}{
+		{0, 0, ReleaseTypeSingle, true},
+		{0, ReleaseTypeSingle, ReleaseTypeSingle, false},
+		{0, ReleaseTypeSingle, ReleaseTypeAlbum, true},
+		{0, ReleaseTypeLive | ReleaseTypeCompilation, ReleaseTypeAlbum | ReleaseTypeLive, false},
+		{ReleaseTypeEP, 0, ReleaseTypeAlbum, false},
+		{ReleaseTypeEP | ReleaseTypeAlbum, 0, ReleaseTypeAlbum, true},
+		{ReleaseTypeAlbum, ReleaseTypeLive, ReleaseTypeAlbum | ReleaseTypeLive, false},
+		// unknown release type is treated as an album
+		{ReleaseTypeAlbum, 0, 0, true},
+		{0, ReleaseTypeAlbum, 0, false},
+	}
+	for _, tt := range tests {
+		f := NewAlbumFilter(AlbumFilterOptions{
+			IncludeReleaseTypes: tt.include,
+			ExcludeReleaseTypes: tt.exclude,
+		})
+		if got := f.Matches(&Album{ReleaseTypes: tt.releaseTypes}); got != tt.want {
+			t.Errorf("Matches(include=%#x, exclude=%#x, releaseTypes=%#x) = %v, want %v",
+				tt.include, tt.exclude, tt.releaseTypes, got, tt.want)
+		}
+	}
+}
@@ -42,6 +42,23 @@ type Library struct {
 	Name string
 }
 
+type Folder struct {
+	ID         string
+	Name       string
+	CoverArtID string
+}
+
+type FolderWithChildren struct {
+	Folder
+
+	// Parent folders of this folder, ordered from the top-level
+	// folder down to the immediate parent
+	Ancestors []*Folder
+
+	Subfolders []*Folder
+	Tracks     []*Track
+}
+
 type ItemDate struct {
 	Year  *int
 	Month *int
@@ -79,6 +96,7 @@ type Album struct {
 	Date         ItemDate
 	ReissueDate  ItemDate
 	Genres       []string
+	RecordLabels []string
 	TrackCount   int
 	Favorite     bool
 	ReleaseTypes ReleaseTypes
@@ -128,6 +146,12 @@ type Genre struct {
 	TrackCount int
 }
 
+type Label struct {
+	ID         string // currently the same as Name for all servers
+	Name       string
+	AlbumCount int
+}
+
 type Track struct {
 	ID               string
 	CoverArtID       string
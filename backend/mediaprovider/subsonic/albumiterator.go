@@ -38,6 +38,15 @@ func filterAlbumMatches(f mediaprovider.AlbumFilter, album *subsonic.AlbumID3, i
 	if y := album.Year; y < filterOptions.MinYear || (filterOptions.MaxYear > 0 && y > filterOptions.MaxYear) {
 		return false
 	}
+	if filterOptions.IncludeReleaseTypes != 0 || filterOptions.ExcludeReleaseTypes != 0 {
+		releaseTypes := normalizeReleaseTypes(album.ReleaseTypes)
+		if album.IsCompilation {
+			releaseTypes |= mediaprovider.ReleaseTypeCompilation
+		}
+		if !mediaprovider.ReleaseTypesMatch(filterOptions.IncludeReleaseTypes, filterOptions.ExcludeReleaseTypes, releaseTypes) {
+			return false
+		}
+	}
 	if ignoreGenre || len(filterOptions.Genres) == 0 {
 		return true
 	}
@@ -66,7 +75,7 @@ func (s *subsonicMediaProvider) IterateAlbums(sortOrder string, filter mediaprov
 			if s.currentLibraryID != "" {
 				params["musicFolderId"] = s.currentLibraryID
 			}
-			return s.client.GetAlbumList2("byGenre", params)
+			return s.client().GetAlbumList2("byGenre", params)
 		}
 		return helpers.NewAlbumIterator(makeFetchFn(fetchFn), modifiedFilter, s.prefetchCoverCB)
 	}
@@ -99,7 +108,7 @@ func (s *subsonicMediaProvider) IterateAlbums(sortOrder string, filter mediaprov
 			if s.currentLibraryID != "" {
 				params["musicFolderId"] = s.currentLibraryID
 			}
-			return s.client.GetAlbumList2("byYear", params)
+			return s.client().GetAlbumList2("byYear", params)
 		}
 		return helpers.NewAlbumIterator(makeFetchFn(fetchFn), filter, s.prefetchCoverCB)
 	case mediaprovider.AlbumSortYearDescending:
@@ -108,7 +117,7 @@ func (s *subsonicMediaProvider) IterateAlbums(sortOrder string, filter mediaprov
 			if s.currentLibraryID != "" {
 				params["musicFolderId"] = s.currentLibraryID
 			}
-			return s.client.GetAlbumList2("byYear", params)
+			return s.client().GetAlbumList2("byYear", params)
 		}
 		return helpers.NewAlbumIterator(makeFetchFn(fetchFn), filter, s.prefetchCoverCB)
 	default:
@@ -136,7 +145,7 @@ func (s *subsonicMediaProvider) newSearchAlbumIter(query string, filter mediapro
 	return &searchAlbumIter{
 		searchIterBase: searchIterBase{
 			query:         query,
-			s:             s.client,
+			client:        s.client,
 			musicFolderId: s.currentLibraryID,
 		},
 		prefetchCB: cb,
@@ -165,7 +174,7 @@ func (s *searchAlbumIter) Next() *mediaprovider.Album {
 
 		// add results from artists search
 		for _, artist := range results.Artist {
-			artist, err := s.s.GetArtist(artist.ID)
+			artist, err := s.client().GetArtist(artist.ID)
 			if err != nil || artist == nil {
 				log.Printf("error fetching artist: %s", err.Error())
 			} else {
@@ -179,7 +188,7 @@ func (s *searchAlbumIter) Next() *mediaprovider.Album {
 			if song.AlbumID == "" {
 				continue
 			}
-			album, err := s.s.GetAlbum(song.AlbumID)
+			album, err := s.client().GetAlbum(song.AlbumID)
 			if err != nil || album == nil {
 				log.Printf("error fetching album: %s", err.Error())
 			} else {
@@ -231,7 +240,7 @@ func (s *subsonicMediaProvider) newRandomIter(filter mediaprovider.AlbumFilter,
 			if s.currentLibraryID != "" {
 				args["musicFolderId"] = s.currentLibraryID
 			}
-			return s.client.GetAlbumList2("random", args)
+			return s.client().GetAlbumList2("random", args)
 		}),
 		filter, s.prefetchCoverCB)
 }
@@ -246,7 +255,7 @@ func (s *subsonicMediaProvider) fetchFnFromStandardSort(sort string) helpers.Alb
 		if s.currentLibraryID != "" {
 			params["musicFolderId"] = s.currentLibraryID
 		}
-		return s.client.GetAlbumList2(sort, params)
+		return s.client().GetAlbumList2(sort, params)
 	})
 }
 
@@ -93,7 +93,7 @@ func (s *subsonicMediaProvider) newSearchArtistIter(query string, filter mediapr
 	return &searchArtistIter{
 		searchIterBase: searchIterBase{
 			query:         query,
-			s:             s.client,
+			client:        s.client,
 			musicFolderId: s.currentLibraryID,
 		},
 		prefetchCB:  cb,
@@ -167,7 +167,7 @@ func (s *subsonicMediaProvider) artistFetchFnFromStandardSort(sortFn func([]*sub
 		if s.currentLibraryID != "" {
 			params = map[string]string{"musicFolderId": s.currentLibraryID}
 		}
-		idxs, err := s.client.GetArtists(params)
+		idxs, err := s.client().GetArtists(params)
 		if err != nil {
 			return nil, err
 		}
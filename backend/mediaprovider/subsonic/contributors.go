@@ -0,0 +1,207 @@
+package subsonic
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/supersonic-app/go-subsonic/subsonic"
+)
+
+var (
+	_ mediaprovider.ComposerProvider = (*subsonicMediaProvider)(nil)
+	_ mediaprovider.LabelProvider    = (*subsonicMediaProvider)(nil)
+)
+
+const labelsAlbumPageSize = 500
+
+// The Subsonic API has no endpoint to list composers or query tracks by
+// composer, so we build both by scanning every track of the current library
+// (IterateTracks loads each album in turn) and collecting the artists credited
+// as composer in the track's OpenSubsonic contributors. Since the scan makes
+// a request per album, the result is cached.
+func (s *subsonicMediaProvider) GetComposers() ([]*mediaprovider.Artist, error) {
+	if err := s.fetchComposersIfNeeded(); err != nil {
+		return nil, err
+	}
+	return s.composersCached, nil
+}
+
+func (s *subsonicMediaProvider) GetComposerTracks(composerID string) ([]*mediaprovider.Track, error) {
+	if err := s.fetchComposersIfNeeded(); err != nil {
+		return nil, err
+	}
+	return s.composerTracksCached[composerID], nil
+}
+
+func (s *subsonicMediaProvider) fetchComposersIfNeeded() error {
+	if s.composersCached != nil && time.Now().Unix()-s.composersCachedAt < cacheValidDurationSeconds {
+		return nil
+	}
+	composers := make(map[string]*mediaprovider.Artist)
+	composerAlbums := make(map[string]map[string]struct{})
+	composerTracks := make(map[string][]*mediaprovider.Track)
+	iter := s.IterateTracks("")
+	for tr := iter.Next(); tr != nil; tr = iter.Next() {
+		for i, id := range tr.ComposerIDs {
+			if _, ok := composers[id]; !ok {
+				// contributor IDs are regular artist IDs
+				composers[id] = &mediaprovider.Artist{ID: id, Name: tr.ComposerNames[i], CoverArtID: id}
+				composerAlbums[id] = make(map[string]struct{})
+			}
+			composerAlbums[id][tr.AlbumID] = struct{}{}
+			composerTracks[id] = append(composerTracks[id], tr)
+		}
+	}
+
+	result := make([]*mediaprovider.Artist, 0, len(composers))
+	for id, c := range composers {
+		c.AlbumCount = len(composerAlbums[id])
+		result = append(result, c)
+	}
+	slices.SortFunc(result, func(a, b *mediaprovider.Artist) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	s.composersCached = result
+	s.composerTracksCached = composerTracks
+	s.composersCachedAt = time.Now().Unix()
+	return nil
+}
+
+// Labels are only returned by OpenSubsonic servers, as the recordLabels
+// property of an album. Since there is no endpoint to list labels, we fetch
+// the full album list and group it by label. The result is cached.
+func (s *subsonicMediaProvider) GetLabels() ([]*mediaprovider.Label, error) {
+	albums, err := s.getAlbumsWithLabels()
+	if err != nil {
+		return nil, err
+	}
+	labels := make(map[string]*mediaprovider.Label)
+	for _, al := range albums {
+		for _, name := range al.RecordLabels {
+			l, ok := labels[name]
+			if !ok {
+				l = &mediaprovider.Label{ID: name, Name: name}
+				labels[name] = l
+			}
+			l.AlbumCount++
+		}
+	}
+	result := make([]*mediaprovider.Label, 0, len(labels))
+	for _, l := range labels {
+		result = append(result, l)
+	}
+	slices.SortFunc(result, func(a, b *mediaprovider.Label) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return result, nil
+}
+
+func (s *subsonicMediaProvider) GetLabelAlbums(labelID string) ([]*mediaprovider.Album, error) {
+	albums, err := s.getAlbumsWithLabels()
+	if err != nil {
+		return nil, err
+	}
+	var result []*mediaprovider.Album
+	for _, al := range albums {
+		if slices.Contains(al.RecordLabels, labelID) {
+			result = append(result, al)
+		}
+	}
+	return result, nil
+}
+
+func (s *subsonicMediaProvider) getAlbumsWithLabels() ([]*mediaprovider.Album, error) {
+	if s.labelAlbumsCached != nil && time.Now().Unix()-s.labelAlbumsCachedAt < cacheValidDurationSeconds {
+		return s.labelAlbumsCached, nil
+	}
+	if !s.client().UseJSON {
+		// recordLabels is only parsed from JSON responses;
+		// the XML API is only used for servers which are not OpenSubsonic
+		return []*mediaprovider.Album{}, nil
+	}
+
+	albums := []*mediaprovider.Album{}
+	for offset := 0; ; offset += labelsAlbumPageSize {
+		page, err := s.getAlbumList2WithLabels(offset, labelsAlbumPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, al := range page {
+			if len(al.RecordLabels) > 0 {
+				albums = append(albums, al)
+			}
+		}
+		if len(page) < labelsAlbumPageSize {
+			break
+		}
+	}
+	s.labelAlbumsCached = albums
+	s.labelAlbumsCachedAt = time.Now().Unix()
+	return albums, nil
+}
+
+// getAlbumList2WithLabels performs a raw getAlbumList2 request so that
+// the OpenSubsonic recordLabels property, which go-subsonic does not
+// model, can be read alongside the regular album fields.
+func (s *subsonicMediaProvider) getAlbumList2WithLabels(offset, size int) ([]*mediaprovider.Album, error) {
+	params := url.Values{}
+	params.Set("type", "alphabeticalByName")
+	params.Set("offset", strconv.Itoa(offset))
+	params.Set("size", strconv.Itoa(size))
+	if s.currentLibraryID != "" {
+		params.Set("musicFolderId", s.currentLibraryID)
+	}
+	resp, err := s.client().Request(http.MethodGet, "getAlbumList2", params)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var wrapper struct {
+		Response struct {
+			Error *subsonic.Error `json:"error"`
+			List  struct {
+				Album []json.RawMessage `json:"album"`
+			} `json:"albumList2"`
+		} `json:"subsonic-response"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&wrapper); err != nil {
+		return nil, err
+	}
+	if e := wrapper.Response.Error; e != nil {
+		return nil, fmt.Errorf("Error #%d: %s", e.Code, e.Message)
+	}
+
+	albums := make([]*mediaprovider.Album, 0, len(wrapper.Response.List.Album))
+	for _, raw := range wrapper.Response.List.Album {
+		// AlbumID3 has a custom JSON unmarshaler, so it can't be embedded
+		// in a struct along with the extra field; decode twice instead
+		var al subsonic.AlbumID3
+		if err := json.Unmarshal(raw, &al); err != nil {
+			return nil, err
+		}
+		var labels struct {
+			RecordLabels []struct {
+				Name string `json:"name"`
+			} `json:"recordLabels"`
+		}
+		if err := json.Unmarshal(raw, &labels); err != nil {
+			return nil, err
+		}
+		album := toAlbum(&al)
+		for _, l := range labels.RecordLabels {
+			if l.Name != "" {
+				album.RecordLabels = append(album.RecordLabels, l.Name)
+			}
+		}
+		albums = append(albums, album)
+	}
+	return albums, nil
+}
@@ -0,0 +1,112 @@
+package subsonic
+
+import "github.com/dweymouth/supersonic/backend/mediaprovider"
+
+var _ mediaprovider.FolderBrowsingProvider = (*subsonicMediaProvider)(nil)
+
+// guard against malformed server responses with cyclic parent references
+const maxFolderDepth = 64
+
+type folderInfo struct {
+	name     string
+	parent   string
+	notFound bool
+}
+
+func (s *subsonicMediaProvider) GetFolder(id string) (*mediaprovider.FolderWithChildren, error) {
+	if id == "" {
+		return s.getRootFolder()
+	}
+
+	dir, err := s.client().GetMusicDirectory(id)
+	if err != nil {
+		return nil, err
+	}
+	s.folderCache.Store(dir.ID, folderInfo{name: dir.Name, parent: dir.Parent})
+
+	folder := &mediaprovider.FolderWithChildren{
+		Folder: mediaprovider.Folder{ID: dir.ID, Name: dir.Name},
+	}
+	for _, ch := range dir.Child {
+		if ch.IsDir {
+			s.folderCache.Store(ch.ID, folderInfo{name: ch.Title, parent: dir.ID})
+			folder.Subfolders = append(folder.Subfolders, &mediaprovider.Folder{
+				ID:         ch.ID,
+				Name:       ch.Title,
+				CoverArtID: ch.CoverArt,
+			})
+		} else if !ch.IsVideo {
+			folder.Tracks = append(folder.Tracks, toTrack(ch))
+		}
+	}
+	if len(folder.Tracks) > 0 {
+		folder.CoverArtID = folder.Tracks[0].CoverArtID
+	}
+	folder.Ancestors = s.folderAncestors(dir.Parent)
+	return folder, nil
+}
+
+func (s *subsonicMediaProvider) getRootFolder() (*mediaprovider.FolderWithChildren, error) {
+	var params map[string]string
+	if s.currentLibraryID != "" {
+		params = map[string]string{"musicFolderId": s.currentLibraryID}
+	}
+	idx, err := s.client().GetIndexes(params)
+	if err != nil {
+		return nil, err
+	}
+
+	folder := &mediaprovider.FolderWithChildren{}
+	for _, i := range idx.Index {
+		for _, a := range i.Artist {
+			s.folderCache.Store(a.ID, folderInfo{name: a.Name})
+			folder.Subfolders = append(folder.Subfolders, &mediaprovider.Folder{
+				ID:   a.ID,
+				Name: a.Name,
+			})
+		}
+	}
+	for _, ch := range idx.Child {
+		if !ch.IsDir && !ch.IsVideo {
+			folder.Tracks = append(folder.Tracks, toTrack(ch))
+		}
+	}
+	return folder, nil
+}
+
+// folderAncestors walks up the directory tree from parentID,
+// returning the ancestors ordered from the top-level folder down.
+// Top-level folders (children of the index) have no known ancestors,
+// so the walk stops at the first directory that cannot be loaded.
+func (s *subsonicMediaProvider) folderAncestors(parentID string) []*mediaprovider.Folder {
+	var ancestors []*mediaprovider.Folder
+	for id := parentID; id != "" && len(ancestors) < maxFolderDepth; {
+		info, ok := s.lookupFolder(id)
+		if !ok {
+			break
+		}
+		ancestors = append(ancestors, &mediaprovider.Folder{ID: id, Name: info.name})
+		id = info.parent
+	}
+	for i, j := 0, len(ancestors)-1; i < j; i, j = i+1, j-1 {
+		ancestors[i], ancestors[j] = ancestors[j], ancestors[i]
+	}
+	return ancestors
+}
+
+func (s *subsonicMediaProvider) lookupFolder(id string) (folderInfo, bool) {
+	if info, ok := s.folderCache.Load(id); ok {
+		i := info.(folderInfo)
+		return i, !i.notFound
+	}
+	dir, err := s.client().GetMusicDirectory(id)
+	if err != nil || dir == nil {
+		// remember the failure so we don't repeat the request
+		// every time a breadcrumb path is built for a top-level folder
+		s.folderCache.Store(id, folderInfo{notFound: true})
+		return folderInfo{}, false
+	}
+	info := folderInfo{name: dir.Name, parent: dir.Parent}
+	s.folderCache.Store(id, info)
+	return info, true
+}
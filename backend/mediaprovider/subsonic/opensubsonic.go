@@ -0,0 +1,126 @@
+package subsonic
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+
+	subsonicCli "github.com/supersonic-app/go-subsonic/subsonic"
+)
+
+// OpenSubsonic extension names not (yet) defined by go-subsonic
+const apiKeyAuthenticationExtension = "apiKeyAuthentication"
+
+// extensionSet records the OpenSubsonic extensions supported by a server
+// and their versions. A nil set means the server is not OpenSubsonic.
+type extensionSet map[string][]int
+
+func fetchExtensions(cli *subsonicCli.Client) (extensionSet, error) {
+	ext, err := cli.GetOpenSubsonicExtensions()
+	if err != nil {
+		return nil, err
+	}
+	set := make(extensionSet, len(ext))
+	for _, e := range ext {
+		set[e.Name] = e.Versions
+	}
+	return set, nil
+}
+
+// Has returns true if the server supports the given extension.
+func (e extensionSet) Has(name string) bool {
+	_, ok := e[name]
+	return ok
+}
+
+// authParams are the query parameters of the Subsonic password and token
+// authentication, which must not be sent along with an API key.
+var authParams = []string{"u", "p", "t", "s"}
+
+// apiKeyTransport is an http.RoundTripper which replaces the password or token
+// authentication that go-subsonic adds to every request with an OpenSubsonic API key.
+type apiKeyTransport struct {
+	base   http.RoundTripper
+	apiKey string
+}
+
+func installAPIKeyAuth(cli *subsonicCli.Client, apiKey string) {
+	base := cli.Client.Transport
+	if t, ok := base.(*apiKeyTransport); ok {
+		t.apiKey = apiKey
+		return
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	cli.Client.Transport = &apiKeyTransport{base: base, apiKey: apiKey}
+}
+
+func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the given request
+	req = req.Clone(req.Context())
+	if !isFormPost(req) {
+		req.URL.RawQuery = withAPIKey(req.URL.Query(), t.apiKey).Encode()
+		return t.base.RoundTrip(req)
+	}
+	// with the formPost extension, the auth params are sent in the body
+	q := req.URL.Query()
+	for _, p := range authParams {
+		q.Del(p)
+	}
+	req.URL.RawQuery = q.Encode()
+	if err := rewriteFormBody(req, t.apiKey); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+func isFormPost(req *http.Request) bool {
+	if req.Method != http.MethodPost || req.Body == nil || req.Body == http.NoBody {
+		return false
+	}
+	mt, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	return mt == "application/x-www-form-urlencoded"
+}
+
+// rewriteFormBody replaces the auth params in the url-encoded form body of req.
+func rewriteFormBody(req *http.Request, apiKey string) error {
+	b, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+	form, err := url.ParseQuery(string(b))
+	if err != nil {
+		return err
+	}
+	body := []byte(withAPIKey(form, apiKey).Encode())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.ContentLength = int64(len(body))
+	return nil
+}
+
+func withAPIKey(query url.Values, apiKey string) url.Values {
+	for _, p := range authParams {
+		query.Del(p)
+	}
+	query.Set("apiKey", apiKey)
+	return query
+}
+
+// apiKeyFromClient returns the API key of a client set up with
+// installAPIKeyAuth, or "" if the client does not use an API key.
+func apiKeyFromClient(cli *subsonicCli.Client) string {
+	if cli.Client == nil {
+		return ""
+	}
+	if t, ok := cli.Client.Transport.(*apiKeyTransport); ok {
+		return t.apiKey
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+package subsonic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestWithAPIKey(t *testing.T) {
+	q, _ := url.ParseQuery("id=1&u=user&t=token&s=salt&p=pass&f=json")
+	q = withAPIKey(q, "key")
+	for _, p := range authParams {
+		if q.Has(p) {
+			t.Errorf("auth param %q not removed", p)
+		}
+	}
+	if q.Get("apiKey") != "key" || q.Get("id") != "1" || q.Get("f") != "json" {
+		t.Errorf("unexpected query: %s", q.Encode())
+	}
+}
+
+func TestExtensionSet(t *testing.T) {
+	var none extensionSet
+	if none.Has("songLyrics") {
+		t.Error("nil extensionSet has extension")
+	}
+	ext := extensionSet{"songLyrics": {1}, "formPost": {1}}
+	if !ext.Has("songLyrics") || ext.Has("transcodeOffset") {
+		t.Error("Has returned wrong result")
+	}
+}
+
+func TestAPIKeyTransportFormPost(t *testing.T) {
+	var gotQuery, gotForm url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+		r.ParseForm()
+		gotForm = r.PostForm
+	}))
+	defer srv.Close()
+
+	cli := &http.Client{Transport: &apiKeyTransport{base: http.DefaultTransport, apiKey: "key"}}
+	body := "u=user&t=token&s=salt&playlistId=1&songIdToAdd=2"
+	resp, err := cli.Post(srv.URL+"/rest/updatePlaylist?f=json", "application/x-www-form-urlencoded", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	for _, p := range authParams {
+		if gotForm.Has(p) || gotQuery.Has(p) {
+			t.Errorf("auth param %q not removed", p)
+		}
+	}
+	if gotQuery.Has("apiKey") || gotForm.Get("apiKey") != "key" || gotForm.Get("playlistId") != "1" || gotForm.Get("songIdToAdd") != "2" {
+		t.Errorf("unexpected form: %s", gotForm.Encode())
+	}
+}
@@ -0,0 +1,84 @@
+package subsonic
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	subsonicCli "github.com/supersonic-app/go-subsonic/subsonic"
+)
+
+func newGenreServer(genre string, hits *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		fmt.Fprintf(w, `<subsonic-response xmlns="http://subsonic.org/restapi" status="ok" version="1.16.1">`+
+			`<genres><genre songCount="1" albumCount="1">%s</genre></genres></subsonic-response>`, genre)
+	}))
+}
+
+// Run with -race: requests in flight must not race with failover.
+func TestRebindDuringRequests(t *testing.T) {
+	var primaryHits, altHits atomic.Int32
+	primary := newGenreServer("primary", &primaryHits)
+	defer primary.Close()
+	alt := newGenreServer("alt", &altHits)
+	defer alt.Close()
+
+	newServer := func(url string) *SubsonicServer {
+		return &SubsonicServer{Client: subsonicCli.Client{
+			Client:     http.DefaultClient,
+			BaseUrl:    url,
+			User:       "user",
+			ClientName: "test",
+		}}
+	}
+	srv := newServer(primary.URL)
+	mp := srv.MediaProvider().(*subsonicMediaProvider)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if _, err := mp.client().GetGenres(); err != nil {
+					t.Errorf("request failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := range 20 {
+		url := primary.URL
+		if i%2 == 0 {
+			url = alt.URL
+		}
+		if err := mp.Rebind(newServer(url)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mp.Rebind(newServer(alt.URL)); err != nil {
+		t.Fatal(err)
+	}
+	close(stop)
+	wg.Wait()
+
+	before := altHits.Load()
+	g, err := mp.client().GetGenres()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g) != 1 || g[0].Name != "alt" || altHits.Load() != before+1 {
+		t.Errorf("request after rebind not sent to the new server: %v", g)
+	}
+}
@@ -31,7 +31,7 @@ func (s *subsonicMediaProvider) SearchAll(searchQuery string, maxResults int) ([
 		if s.currentLibraryID != "" {
 			params["musicFolderId"] = s.currentLibraryID
 		}
-		res, e := s.client.Search3(searchQuery, params)
+		res, e := s.client().Search3(searchQuery, params)
 		if e != nil {
 			err = e
 		} else {
@@ -45,7 +45,7 @@ func (s *subsonicMediaProvider) SearchAll(searchQuery string, maxResults int) ([
 
 	wg.Add(1)
 	go func() {
-		p, e := s.client.GetPlaylists(nil)
+		p, e := s.client().GetPlaylists(nil)
 		if e == nil {
 			playlists = sharedutil.FilterSlice(p, func(p *subsonic.Playlist) bool {
 				return helpers.AllTermsMatch(strings.ToLower(sanitize.Accents(p.Name)), queryLowerWords)
@@ -56,7 +56,7 @@ func (s *subsonicMediaProvider) SearchAll(searchQuery string, maxResults int) ([
 
 	wg.Add(1)
 	go func() {
-		g, e := s.client.GetGenres()
+		g, e := s.client().GetGenres()
 		if e == nil {
 			genres = sharedutil.FilterSlice(g, func(g *subsonic.Genre) bool {
 				return helpers.AllTermsMatch(strings.ToLower(sanitize.Accents(g.Name)), queryLowerWords)
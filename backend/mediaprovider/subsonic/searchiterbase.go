@@ -13,7 +13,7 @@ type searchIterBase struct {
 	artistOffset  int
 	albumOffset   int
 	songOffset    int
-	s             *subsonic.Client
+	client        func() *subsonic.Client
 }
 
 func (s *searchIterBase) fetchResults() *subsonic.SearchResult3 {
@@ -25,7 +25,7 @@ func (s *searchIterBase) fetchResults() *subsonic.SearchResult3 {
 	if s.musicFolderId != "" {
 		searchOpts["musicFolderId"] = s.musicFolderId
 	}
-	results, err := s.s.Search3(s.query, searchOpts)
+	results, err := s.client().Search3(s.query, searchOpts)
 	if err != nil {
 		log.Println(err)
 		results = nil
@@ -2,6 +2,7 @@ package subsonic
 
 import (
 	"errors"
+	"fmt"
 	"image"
 	"io"
 	"math"
@@ -10,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
@@ -23,10 +25,29 @@ const (
 	cacheValidDurationSeconds         = 120 // genres and radios aren't expected to change as much
 )
 
+// Subsonic API error codes
+const (
+	errCodeWrongCredentials = 40
+	errCodeInvalidAPIKey    = 44
+	errCodeNotFound         = 70
+)
+
+// errorCode returns the code of a Subsonic API error response.
+// go-subsonic reports these only in the error message.
+func errorCode(err error) (int, bool) {
+	if err == nil {
+		return 0, false
+	}
+	var code int
+	_, scanErr := fmt.Sscanf(err.Error(), "Error #%d:", &code)
+	return code, scanErr == nil
+}
+
 type subsonicMediaProvider struct {
 	currentLibraryID string
 
-	client          *subsonic.Client
+	// swapped by Rebind while requests may be in flight
+	conn            atomic.Pointer[subsonicConn]
 	prefetchCoverCB func(coverArtID string)
 
 	genresCached   []*mediaprovider.Genre
@@ -38,20 +59,62 @@ type subsonicMediaProvider struct {
 	radiosCached   []*mediaprovider.RadioStation
 	radiosCachedAt int64 // unix
 
-	playbackReportOnce      sync.Once
-	playbackReportSupported bool
+	composersCached      []*mediaprovider.Artist
+	composerTracksCached map[string][]*mediaprovider.Track
+	composersCachedAt    int64 // unix
+
+	labelAlbumsCached   []*mediaprovider.Album
+	labelAlbumsCachedAt int64 // unix
+
+	folderCache sync.Map // folder ID -> folderInfo
+}
+
+// subsonicConn is the client of a connection to the server
+// and the OpenSubsonic extensions the server supports.
+type subsonicConn struct {
+	client     *subsonic.Client
+	extensions extensionSet
+}
+
+func newSubsonicMediaProvider(subsonicClient *subsonic.Client, extensions extensionSet) mediaprovider.MediaProvider {
+	s := &subsonicMediaProvider{}
+	s.conn.Store(&subsonicConn{client: subsonicClient, extensions: extensions})
+	return s
+}
+
+func (s *subsonicMediaProvider) client() *subsonic.Client {
+	return s.conn.Load().client
 }
 
-func SubsonicMediaProvider(subsonicClient *subsonic.Client) mediaprovider.MediaProvider {
-	return &subsonicMediaProvider{client: subsonicClient}
+func (s *subsonicMediaProvider) extensions() extensionSet {
+	return s.conn.Load().extensions
 }
 
 func (s *subsonicMediaProvider) SetPrefetchCoverCallback(cb func(coverArtID string)) {
 	s.prefetchCoverCB = cb
 }
 
+// Rebind switches the provider to the base URL and credentials of
+// the given server. Iterators created from this provider get its
+// client for each request, so they also use the new connection.
+func (s *subsonicMediaProvider) Rebind(server mediaprovider.Server) error {
+	srv, ok := server.(*SubsonicServer)
+	if !ok {
+		return errors.New("cannot rebind to a non-Subsonic server")
+	}
+	cli := srv.Client
+	s.conn.Store(&subsonicConn{client: &cli, extensions: srv.extensions})
+	return nil
+}
+
+// Session returns nil, since Subsonic authenticates every request
+// rather than logging in to a session.
+func (s *subsonicMediaProvider) Session() *mediaprovider.Session {
+	return nil
+}
+
 func (s *subsonicMediaProvider) GetLibraries() ([]mediaprovider.Library, error) {
-	folders, err := s.client.GetMusicFolders()
+	folders, err := s.client().GetMusicFolders()
 	if err != nil {
 		return nil, err
 	}
@@ -62,16 +125,21 @@ func (s *subsonicMediaProvider) GetLibraries() ([]mediaprovider.Library, error)
 
 func (s *subsonicMediaProvider) SetLibrary(id string) error {
 	s.currentLibraryID = id
+	// discard the results cached from scans of the previous library
+	s.composersCached = nil
+	s.composerTracksCached = nil
+	s.labelAlbumsCached = nil
+	s.folderCache.Clear()
 	return nil
 }
 
 func (s *subsonicMediaProvider) CreatePlaylistWithTracks(name string, trackIDs []string) error {
 	s.playlistsCached = nil
-	return s.client.CreatePlaylistWithTracks(trackIDs, map[string]string{"name": name})
+	return s.client().CreatePlaylistWithTracks(trackIDs, map[string]string{"name": name})
 }
 
 func (s *subsonicMediaProvider) CreatePlaylist(name, description string, public bool) error {
-	pl, err := s.client.CreatePlaylist(map[string]string{"name": name})
+	pl, err := s.client().CreatePlaylist(map[string]string{"name": name})
 	if err != nil {
 		return err
 	}
@@ -88,12 +156,12 @@ func (s *subsonicMediaProvider) CreatePlaylist(name, description string, public
 	if public {
 		params["public"] = "true"
 	}
-	return s.client.UpdatePlaylist(pl.ID, params)
+	return s.client().UpdatePlaylist(pl.ID, params)
 }
 
 func (s *subsonicMediaProvider) DeletePlaylist(id string) error {
 	s.playlistsCached = nil
-	return s.client.DeletePlaylist(id)
+	return s.client().DeletePlaylist(id)
 }
 
 func (s *subsonicMediaProvider) CanMakePublicPlaylist() bool {
@@ -102,7 +170,7 @@ func (s *subsonicMediaProvider) CanMakePublicPlaylist() bool {
 
 func (s *subsonicMediaProvider) EditPlaylist(id, name, description string, public bool) error {
 	s.playlistsCached = nil
-	return s.client.UpdatePlaylist(id, map[string]string{
+	return s.client().UpdatePlaylist(id, map[string]string{
 		"name":    name,
 		"comment": description,
 		"public":  strconv.FormatBool(public),
@@ -111,16 +179,16 @@ func (s *subsonicMediaProvider) EditPlaylist(id, name, description string, publi
 
 func (s *subsonicMediaProvider) AddPlaylistTracks(id string, trackIDsToAdd []string) error {
 	s.playlistsCached = nil
-	return s.client.UpdatePlaylistTracks(id, trackIDsToAdd, nil)
+	return s.client().UpdatePlaylistTracks(id, trackIDsToAdd, nil)
 }
 
 func (s *subsonicMediaProvider) RemovePlaylistTracks(id string, removeIdxs []int) error {
 	s.playlistsCached = nil
-	return s.client.UpdatePlaylistTracks(id, nil, removeIdxs)
+	return s.client().UpdatePlaylistTracks(id, nil, removeIdxs)
 }
 
 func (s *subsonicMediaProvider) GetTrack(trackID string) (*mediaprovider.Track, error) {
-	tr, err := s.client.GetSong(trackID)
+	tr, err := s.client().GetSong(trackID)
 	if err != nil {
 		return nil, err
 	}
@@ -128,7 +196,7 @@ func (s *subsonicMediaProvider) GetTrack(trackID string) (*mediaprovider.Track,
 }
 
 func (s *subsonicMediaProvider) GetAlbum(albumID string) (*mediaprovider.AlbumWithTracks, error) {
-	al, err := s.client.GetAlbum(albumID)
+	al, err := s.client().GetAlbum(albumID)
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +208,7 @@ func (s *subsonicMediaProvider) GetAlbum(albumID string) (*mediaprovider.AlbumWi
 }
 
 func (s *subsonicMediaProvider) GetAlbumInfo(albumID string) (*mediaprovider.AlbumInfo, error) {
-	al, err := s.client.GetAlbumInfo(albumID)
+	al, err := s.client().GetAlbumInfo(albumID)
 	if err != nil {
 		return nil, err
 	}
@@ -153,7 +221,7 @@ func (s *subsonicMediaProvider) GetAlbumInfo(albumID string) (*mediaprovider.Alb
 }
 
 func (s *subsonicMediaProvider) GetArtist(artistID string) (*mediaprovider.ArtistWithAlbums, error) {
-	ar, err := s.client.GetArtist(artistID)
+	ar, err := s.client().GetArtist(artistID)
 	if err != nil {
 		return nil, err
 	}
@@ -174,7 +242,7 @@ func (s *subsonicMediaProvider) GetArtistTracks(artistID string) ([]*mediaprovid
 }
 
 func (s *subsonicMediaProvider) GetArtistInfo(artistID string) (*mediaprovider.ArtistInfo, error) {
-	info, err := s.client.GetArtistInfo2(artistID, map[string]string{})
+	info, err := s.client().GetArtistInfo2(artistID, map[string]string{})
 	if err != nil {
 		return nil, err
 	}
@@ -194,7 +262,7 @@ func (s *subsonicMediaProvider) GetCoverArt(id string, size int) (image.Image, e
 	if size > 0 {
 		params["size"] = strconv.Itoa(size)
 	}
-	return s.client.GetCoverArt(id, params)
+	return s.client().GetCoverArt(id, params)
 }
 
 func (s *subsonicMediaProvider) GetFavorites() (mediaprovider.Favorites, error) {
@@ -202,7 +270,7 @@ func (s *subsonicMediaProvider) GetFavorites() (mediaprovider.Favorites, error)
 	if s.currentLibraryID != "" {
 		params = map[string]string{"musicFolderId": s.currentLibraryID}
 	}
-	fav, err := s.client.GetStarred2(params)
+	fav, err := s.client().GetStarred2(params)
 	if err != nil {
 		return mediaprovider.Favorites{}, err
 	}
@@ -218,7 +286,7 @@ func (s *subsonicMediaProvider) GetGenres() ([]*mediaprovider.Genre, error) {
 		return s.genresCached, nil
 	}
 
-	g, err := s.client.GetGenres()
+	g, err := s.client().GetGenres()
 	if err != nil {
 		return nil, err
 	}
@@ -234,8 +302,10 @@ func (s *subsonicMediaProvider) GetGenres() ([]*mediaprovider.Genre, error) {
 }
 
 func (s *subsonicMediaProvider) GetPlaylist(playlistID string) (*mediaprovider.PlaylistWithTracks, error) {
-	pl, err := s.client.GetPlaylist(playlistID)
-	if err != nil {
+	pl, err := s.client().GetPlaylist(playlistID)
+	if code, ok := errorCode(err); ok && code == errCodeNotFound {
+		return nil, mediaprovider.ErrNotFound
+	} else if err != nil {
 		return nil, err
 	}
 	playlist := &mediaprovider.PlaylistWithTracks{
@@ -250,7 +320,7 @@ func (s *subsonicMediaProvider) GetPlaylists() ([]*mediaprovider.Playlist, error
 		return s.playlistsCached, nil
 	}
 
-	pl, err := s.client.GetPlaylists(map[string]string{})
+	pl, err := s.client().GetPlaylists(map[string]string{})
 	if err != nil {
 		return nil, err
 	}
@@ -267,7 +337,7 @@ func (s *subsonicMediaProvider) GetRandomTracks(genreName string, count int) ([]
 	if s.currentLibraryID != "" {
 		opts["musicFolderId"] = s.currentLibraryID
 	}
-	tr, err := s.client.GetRandomSongs(opts)
+	tr, err := s.client().GetRandomSongs(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -275,7 +345,7 @@ func (s *subsonicMediaProvider) GetRandomTracks(genreName string, count int) ([]
 }
 
 func (s *subsonicMediaProvider) GetSimilarTracks(artistID string, count int) ([]*mediaprovider.Track, error) {
-	tr, err := s.client.GetSimilarSongs2(artistID, map[string]string{"count": strconv.Itoa(count)})
+	tr, err := s.client().GetSimilarSongs2(artistID, map[string]string{"count": strconv.Itoa(count)})
 	if err != nil {
 		return nil, err
 	}
@@ -290,10 +360,14 @@ func (s *subsonicMediaProvider) GetStreamURL(trackID string, transcode *mediapro
 	} else if forceRaw {
 		m["format"] = "raw"
 	}
-	u, err := s.client.GetStreamURL(trackID, m)
+	u, err := s.client().GetStreamURL(trackID, m)
 	if err != nil {
 		return "", err
 	}
+	if apiKey := apiKeyFromClient(s.client()); apiKey != "" {
+		// stream URLs are fetched by the player, not through the client
+		u.RawQuery = withAPIKey(u.Query(), apiKey).Encode()
+	}
 	return u.String(), nil
 }
 
@@ -302,7 +376,7 @@ func (s *subsonicMediaProvider) GetTopTracks(artist mediaprovider.Artist, count
 	if count > 0 {
 		params["count"] = strconv.Itoa(count)
 	}
-	tr, err := s.client.GetTopSongs(artist.Name, params)
+	tr, err := s.client().GetTopSongs(artist.Name, params)
 	if err != nil {
 		return nil, err
 	}
@@ -314,13 +388,13 @@ func (s *subsonicMediaProvider) GetTopTracks(artist mediaprovider.Artist, count
 
 func (s *subsonicMediaProvider) ReplacePlaylistTracks(playlistID string, trackIDs []string) error {
 	s.playlistsCached = nil
-	return s.client.CreatePlaylistWithTracks(trackIDs, map[string]string{"playlistId": playlistID})
+	return s.client().CreatePlaylistWithTracks(trackIDs, map[string]string{"playlistId": playlistID})
 }
 
 func (s *subsonicMediaProvider) ClientDecidesScrobble() bool { return true }
 
 func (s *subsonicMediaProvider) TrackBeganPlayback(trackID string) error {
-	return s.client.Scrobble(trackID, map[string]string{
+	return s.client().Scrobble(trackID, map[string]string{
 		"time":       strconv.FormatInt(time.Now().UnixMilli(), 10),
 		"submission": "false",
 	})
@@ -330,7 +404,7 @@ func (s *subsonicMediaProvider) TrackEndedPlayback(trackID string, _ int, submis
 	if !submission {
 		return nil
 	}
-	return s.client.Scrobble(trackID, map[string]string{
+	return s.client().Scrobble(trackID, map[string]string{
 		"time":       strconv.FormatInt(time.Now().UnixMilli(), 10),
 		"submission": "true",
 	})
@@ -343,9 +417,9 @@ func (s *subsonicMediaProvider) SetFavorite(params mediaprovider.RatingFavoriteP
 		SongIDs:   params.TrackIDs,
 	}
 	if favorite {
-		return s.client.Star(subParams)
+		return s.client().Star(subParams)
 	}
-	return s.client.Unstar(subParams)
+	return s.client().Unstar(subParams)
 }
 
 func (s *subsonicMediaProvider) SetRating(params mediaprovider.RatingFavoriteParameters, rating int) error {
@@ -358,7 +432,7 @@ func (s *subsonicMediaProvider) SetRating(params mediaprovider.RatingFavoritePar
 		for i := 0; i < batchSize && offs+i < len(params.TrackIDs); i++ {
 			wg.Add(1)
 			go func(idx int) {
-				newErr := s.client.SetRating(params.TrackIDs[idx], rating)
+				newErr := s.client().SetRating(params.TrackIDs[idx], rating)
 				if err == nil && newErr != nil {
 					err = newErr
 				}
@@ -378,7 +452,7 @@ func (s *subsonicMediaProvider) SetRating(params mediaprovider.RatingFavoritePar
 }
 
 func (s *subsonicMediaProvider) CreateShareURL(id string) (*url.URL, error) {
-	share, err := s.client.CreateShare(id, nil)
+	share, err := s.client().CreateShare(id, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -398,11 +472,11 @@ func (s *subsonicMediaProvider) CanShareArtists() bool {
 }
 
 func (s *subsonicMediaProvider) DownloadTrack(trackID string) (io.Reader, error) {
-	return s.client.Download(trackID)
+	return s.client().Download(trackID)
 }
 
 func (s *subsonicMediaProvider) RescanLibrary() error {
-	_, err := s.client.StartScan()
+	_, err := s.client().StartScan()
 	return err
 }
 
@@ -410,13 +484,8 @@ func (s *subsonicMediaProvider) RescanLibrary() error {
 var _ mediaprovider.LyricsProvider = (*subsonicMediaProvider)(nil)
 
 func (s *subsonicMediaProvider) GetLyrics(track *mediaprovider.Track) (*mediaprovider.Lyrics, error) {
-	ext, err := s.client.GetOpenSubsonicExtensions()
-	supportsSynced := err == nil &&
-		slices.ContainsFunc(ext, func(ext *subsonic.OpenSubsonicExtension) bool {
-			return ext.Name == subsonic.SongLyricsExtension
-		})
-	if supportsSynced {
-		lyrics, err := s.client.GetLyricsBySongId(track.ID)
+	if s.extensions().Has(subsonic.SongLyricsExtension) {
+		lyrics, err := s.client().GetLyricsBySongId(track.ID)
 		if err != nil || len(lyrics.StructuredLyrics) == 0 {
 			return nil, err
 		}
@@ -441,7 +510,7 @@ func (s *subsonicMediaProvider) GetLyrics(track *mediaprovider.Track) (*mediapro
 		return mpLyrics, nil
 	}
 	// fallback to legacy getLyrics endpoint
-	lyrics, err := s.client.GetLyrics(track.Title, track.ArtistNames[0])
+	lyrics, err := s.client().GetLyrics(track.Title, track.ArtistNames[0])
 	if err != nil || lyrics == nil || lyrics.Text == "" {
 		return nil, err
 	}
@@ -466,18 +535,11 @@ var _ mediaprovider.CanSavePlayQueue = (*subsonicMediaProvider)(nil)
 var _ mediaprovider.CanReportPlayback = (*subsonicMediaProvider)(nil)
 
 func (s *subsonicMediaProvider) ReportPlayback(trackID string, positionMs int64, state string) error {
-	s.playbackReportOnce.Do(func() {
-		ext, err := s.client.GetOpenSubsonicExtensions()
-		s.playbackReportSupported = err == nil && slices.ContainsFunc(ext,
-			func(e *subsonic.OpenSubsonicExtension) bool {
-				return e.Name == subsonic.PlaybackReport
-			})
-	})
-	if !s.playbackReportSupported {
+	if !s.extensions().Has(subsonic.PlaybackReport) {
 		return nil
 	}
 	ignoreScrobble := true
-	return s.client.ReportPlayback(subsonic.ReportPlaybackParameters{
+	return s.client().ReportPlayback(subsonic.ReportPlaybackParameters{
 		MediaID:        trackID,
 		MediaType:      subsonic.PlaybackMediaTypeSong,
 		PositionMs:     positionMs,
@@ -491,15 +553,27 @@ func (s *subsonicMediaProvider) SavePlayQueue(trackIDs []string, currentTrackIdx
 		return nil // don't save an empty queue
 	}
 	params := make(map[string]string)
+	if s.extensions().Has(subsonic.IndexBasedQueue) {
+		// the current track is saved by index, so it is restored
+		// correctly even if the track is in the queue more than once
+		if currentTrackIdx >= 0 {
+			params["position"] = strconv.Itoa(timeSeconds * 1000)
+			params["currentIndex"] = strconv.Itoa(currentTrackIdx)
+		}
+		return s.client().SavePlayQueueByIndex(trackIDs, params)
+	}
 	if currentTrackIdx >= 0 {
 		params["position"] = strconv.Itoa(timeSeconds * 1000)
 		params["current"] = trackIDs[currentTrackIdx]
 	}
-	return s.client.SavePlayQueue(trackIDs, params)
+	return s.client().SavePlayQueue(trackIDs, params)
 }
 
 func (s *subsonicMediaProvider) GetPlayQueue() (*mediaprovider.SavedPlayQueue, error) {
-	pq, err := s.client.GetPlayQueue()
+	if s.extensions().Has(subsonic.IndexBasedQueue) {
+		return s.getPlayQueueByIndex()
+	}
+	pq, err := s.client().GetPlayQueue()
 	if err != nil {
 		return nil, err
 	}
@@ -516,6 +590,25 @@ func (s *subsonicMediaProvider) GetPlayQueue() (*mediaprovider.SavedPlayQueue, e
 	return savedQueue, nil
 }
 
+func (s *subsonicMediaProvider) getPlayQueueByIndex() (*mediaprovider.SavedPlayQueue, error) {
+	pq, err := s.client().GetPlayQueueByIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	savedQueue := &mediaprovider.SavedPlayQueue{}
+	if pq == nil {
+		return savedQueue, nil
+	}
+	savedQueue.Tracks = sharedutil.MapSlice(pq.Entries, toTrack)
+	savedQueue.TrackPos = -1
+	if len(pq.Entries) > 0 {
+		savedQueue.TrackPos = int(pq.CurrentIndex)
+	}
+	savedQueue.TimePos = int(pq.Position / 1000)
+	return savedQueue, nil
+}
+
 // RadioProvider interface
 var _ mediaprovider.RadioProvider = (*subsonicMediaProvider)(nil)
 
@@ -524,7 +617,7 @@ func (s *subsonicMediaProvider) GetRadioStations() ([]*mediaprovider.RadioStatio
 		return s.radiosCached, nil
 	}
 
-	rs, err := s.client.GetInternetRadioStations()
+	rs, err := s.client().GetInternetRadioStations()
 	if err != nil {
 		return nil, err
 	}
@@ -786,7 +879,7 @@ func fillPlaylist(pl *subsonic.Playlist, playlist *mediaprovider.Playlist) {
 }
 
 func (s *subsonicMediaProvider) GetSongRadio(trackID string, count int) ([]*mediaprovider.Track, error) {
-	tr, err := s.client.GetSimilarSongs(trackID, map[string]string{"count": strconv.Itoa(count)})
+	tr, err := s.client().GetSimilarSongs(trackID, map[string]string{"count": strconv.Itoa(count)})
 	if err != nil {
 		return nil, err
 	}
@@ -1,12 +1,22 @@
 package subsonic
 
 import (
+	"errors"
+	"log"
+
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
 	subsonicCli "github.com/supersonic-app/go-subsonic/subsonic"
 )
 
+var ErrAPIKeyAuthNotSupported = errors.New("server does not support API key authentication")
+
 type SubsonicServer struct {
 	subsonicCli.Client
+
+	// If true, the password given to Login is an OpenSubsonic API key
+	APIKeyAuth bool
+
+	extensions extensionSet
 }
 
 func (s *SubsonicServer) Login(username, password string) mediaprovider.LoginResponse {
@@ -24,13 +34,48 @@ func (s *SubsonicServer) Login(username, password string) mediaprovider.LoginRes
 		// and shows no progress in fixing this, so use the XML API
 		s.Client.UseJSON = false
 	}
+	if s.APIKeyAuth {
+		return s.loginWithAPIKey(pr, password)
+	}
 	err = s.Client.Authenticate(password)
+	if err == nil && pr.OpenSubsonic {
+		s.fetchExtensions()
+	}
 	return mediaprovider.LoginResponse{
 		Error:       err,
 		IsAuthError: err == subsonicCli.ErrAuthenticationFailure,
 	}
 }
 
+func (s *SubsonicServer) loginWithAPIKey(pr *subsonicCli.PingResponse, apiKey string) mediaprovider.LoginResponse {
+	if !pr.OpenSubsonic {
+		return mediaprovider.LoginResponse{Error: ErrAPIKeyAuthNotSupported, IsAuthError: true}
+	}
+	installAPIKeyAuth(&s.Client, apiKey)
+	// getOpenSubsonicExtensions is accessible without authentication
+	s.fetchExtensions()
+	if !s.extensions.Has(apiKeyAuthenticationExtension) {
+		return mediaprovider.LoginResponse{Error: ErrAPIKeyAuthNotSupported, IsAuthError: true}
+	}
+	// test the key - ping returns an error response if it is invalid
+	if _, err := s.Client.Get("ping", nil); err != nil {
+		if code, ok := errorCode(err); ok && (code == errCodeWrongCredentials || code == errCodeInvalidAPIKey) {
+			return mediaprovider.LoginResponse{Error: subsonicCli.ErrAuthenticationFailure, IsAuthError: true}
+		}
+		return mediaprovider.LoginResponse{Error: err}
+	}
+	return mediaprovider.LoginResponse{}
+}
+
+func (s *SubsonicServer) fetchExtensions() {
+	ext, err := fetchExtensions(&s.Client)
+	if err != nil {
+		log.Printf("error fetching OpenSubsonic extensions: %s", err.Error())
+		return
+	}
+	s.extensions = ext
+}
+
 func (s *SubsonicServer) MediaProvider() mediaprovider.MediaProvider {
-	return SubsonicMediaProvider(&s.Client)
+	return newSubsonicMediaProvider(&s.Client, s.extensions)
 }
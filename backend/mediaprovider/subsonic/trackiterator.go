@@ -19,7 +19,7 @@ func (s *subsonicMediaProvider) IterateTracks(searchQuery string) mediaprovider.
 	}
 	return &searchTracksIterator{
 		searchIterBase: searchIterBase{
-			s:             s.client,
+			client:        s.client,
 			query:         searchQuery,
 			musicFolderId: s.currentLibraryID,
 		},
@@ -95,7 +95,7 @@ func (s *searchTracksIterator) Next() *mediaprovider.Track {
 
 			// add results from artists search
 			for _, artist := range results.Artist {
-				artist, err := s.s.GetArtist(artist.ID)
+				artist, err := s.client().GetArtist(artist.ID)
 				if err != nil {
 					log.Printf("error fetching artist: %s", err.Error())
 				} else {
@@ -140,7 +140,7 @@ func (s *searchTracksIterator) addNewTracks(tracks []*subsonic.Child) {
 
 func (s *searchTracksIterator) addNewTracksFromAlbums(albums []*subsonic.AlbumID3) {
 	for _, al := range albums {
-		if album, err := s.s.GetAlbum(al.ID); err != nil {
+		if album, err := s.client().GetAlbum(al.ID); err != nil {
 			log.Printf("error fetching album: %s", err.Error())
 		} else {
 			s.addNewTracks(album.Song)
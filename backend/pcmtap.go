@@ -0,0 +1,181 @@
+package backend
+
+import (
+	"context"
+	"encoding/binary"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// sample rate of the audio decoded by the PCMTap
+const PCMTapSampleRate = 44100
+
+// how long to wait for the now playing track to be downloaded
+// to the AudioCache before giving up on decoding it
+const pcmTapCacheWait = 20 * time.Second
+
+// PCMTapState is the state of the PCMTap for the now playing track.
+type PCMTapState int
+
+const (
+	// disabled, or no track is playing
+	PCMTapIdle PCMTapState = iota
+	PCMTapDecoding
+	// there is no AudioCache to decode from, since the waveform seekbar is disabled
+	PCMTapNoAudioCache
+	// the track was not downloaded to the AudioCache in time
+	PCMTapNotCached
+	PCMTapFailed
+)
+
+// PCMTap decodes the now playing track to mono PCM in the background,
+// so that visualizations can analyze the audio at the current playback position.
+// The track is decoded from the AudioCache by a separate mpv instance,
+// which keeps the samples in sync with seeks in the player. libmpv offers
+// no access to the samples of the playing instance, so the tap only works
+// for tracks which are downloaded to the AudioCache.
+type PCMTap struct {
+	pm    *PlaybackManager
+	cache *AudioCache
+
+	mutex   sync.Mutex
+	enabled bool
+	trackID string
+	state   PCMTapState
+	path    string // path of the decoded PCM file; empty until decoding begins
+	file    *os.File
+	cancel  context.CancelFunc
+	rawBuf  []byte
+}
+
+func NewPCMTap(pm *PlaybackManager, cache *AudioCache) *PCMTap {
+	t := &PCMTap{pm: pm, cache: cache}
+	pm.OnSongChange(func(item mediaprovider.MediaItem, _ *mediaprovider.Track) {
+		t.update(item)
+	})
+	return t
+}
+
+// SetEnabled sets whether the PCMTap should decode the now playing track.
+func (t *PCMTap) SetEnabled(enabled bool) {
+	t.mutex.Lock()
+	t.enabled = enabled
+	t.mutex.Unlock()
+	t.update(t.pm.NowPlaying())
+}
+
+// Samples fills buf with the mono samples, normalized to [-1, 1],
+// which end at the current playback position.
+// Returns false if the samples are not available (yet).
+func (t *PCMTap) Samples(buf []float64) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.path == "" {
+		return false
+	}
+	if t.file == nil {
+		f, err := os.Open(t.path)
+		if err != nil {
+			return false
+		}
+		t.file = f
+	}
+
+	end := int64(t.pm.PlaybackStatus().TimePos * PCMTapSampleRate)
+	start := end - int64(len(buf))
+	if start < 0 {
+		return false
+	}
+	if cap(t.rawBuf) < 2*len(buf) {
+		t.rawBuf = make([]byte, 2*len(buf))
+	}
+	raw := t.rawBuf[:2*len(buf)]
+	if _, err := t.file.ReadAt(raw, start*2); err != nil {
+		return false // not decoded this far yet
+	}
+	for i := range buf {
+		buf[i] = float64(int16(binary.LittleEndian.Uint16(raw[2*i:]))) / (1 << 15)
+	}
+	return true
+}
+
+// State returns the state of the PCMTap for the now playing track.
+func (t *PCMTap) State() PCMTapState {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.state
+}
+
+func (t *PCMTap) setState(ctx context.Context, state PCMTapState) {
+	t.mutex.Lock()
+	if ctx.Err() == nil {
+		t.state = state
+	}
+	t.mutex.Unlock()
+}
+
+func (t *PCMTap) update(item mediaprovider.MediaItem) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	id := ""
+	state := PCMTapIdle
+	if tr, ok := item.(*mediaprovider.Track); ok && t.enabled {
+		if t.cache == nil {
+			state = PCMTapNoAudioCache
+		} else {
+			id, state = tr.ID, PCMTapDecoding
+		}
+	}
+	if id != "" && id == t.trackID {
+		return
+	}
+	t.stop()
+	t.trackID = id
+	t.state = state
+	if id == "" {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(t.cache.rootCtx)
+	t.cancel = cancel
+	go func() {
+		waitCtx, cancelWait := context.WithTimeout(ctx, pcmTapCacheWait)
+		inPath, outPath, err := prepareCachedAudioDecode(waitCtx, t.cache, id, "pcmtap", ".pcm")
+		cancelWait()
+		if err != nil {
+			t.setState(ctx, PCMTapNotCached)
+			return
+		}
+		t.mutex.Lock()
+		if ctx.Err() == nil {
+			t.path = outPath
+		}
+		t.mutex.Unlock()
+
+		if err := convertToPCM(ctx, t.cache, id, inPath, outPath, PCMTapSampleRate, false); err != nil && ctx.Err() == nil {
+			log.Printf("failed to decode track for visualization: %s", err.Error())
+			t.setState(ctx, PCMTapFailed)
+		}
+		// keep the decoded file until the tap moves on to a different track
+		<-ctx.Done()
+		_ = os.Remove(outPath)
+	}()
+}
+
+// must be called with the lock held
+func (t *PCMTap) stop() {
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+	t.path = ""
+}
@@ -32,6 +32,8 @@ const (
 	cmdForceRestartPlayback
 
 	cmdLoadTrackPaused // arg: int (idx), arg2: float64 (startTime)
+
+	cmdABLoop // arg: float64 (a), arg2: float64 (b)
 )
 
 type playbackCommand struct {
@@ -113,6 +115,11 @@ func (c *playbackCommandQueue) SetLoopMode(mode LoopMode) {
 		playbackCommand{Type: cmdLoopMode, Arg: mode})
 }
 
+func (c *playbackCommandQueue) SetABLoop(a, b float64) {
+	c.filterCommandsAndAdd([]playbackCommandType{cmdABLoop},
+		playbackCommand{Type: cmdABLoop, Arg: a, Arg2: b})
+}
+
 func (c *playbackCommandQueue) SeekSeconds(s float64) {
 	c.filterCommandsAndAdd([]playbackCommandType{cmdSeekSeconds},
 		playbackCommand{Type: cmdSeekSeconds, Arg: s})
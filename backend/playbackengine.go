@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"math/rand"
 	"slices"
 	"strings"
 	"time"
@@ -67,6 +66,7 @@ type playbackEngine struct {
 	player        player.BasePlayer
 
 	playTimeStopwatch   util.Stopwatch
+	curTrackStartTime   time.Time
 	curTrackDuration    float64
 	latestTrackPosition float64 // cleared by checkScrobble
 	callbacksDisabled   bool
@@ -79,6 +79,10 @@ type playbackEngine struct {
 	loopMode      LoopMode
 	shuffle       bool
 
+	// A-B loop points of the current track in seconds; negative if unset
+	abLoopA float64
+	abLoopB float64
+
 	pauseAfterCurrent bool // flag to pause playback after current track ends
 
 	// flags for handleOnTrackChange / handleOnStopped callbacks - reset to false in the callbacks
@@ -107,7 +111,6 @@ type playbackEngine struct {
 	lastScrobbled *mediaprovider.Track
 	playbackCfg   *PlaybackConfig
 	scrobbleCfg   *ScrobbleConfig
-	transcodeCfg  *TranscodingConfig
 	replayGainCfg ReplayGainConfig
 
 	// registered callbacks
@@ -115,6 +118,7 @@ type playbackEngine struct {
 	onSongChange       []func(nowPlaying mediaprovider.MediaItem, justScrobbledIfAny *mediaprovider.Track)
 	onPlayTimeUpdate   []func(float64, float64, bool)
 	onLoopModeChange   []func(LoopMode)
+	onABLoopChange     []func(a, b float64)
 	onShuffleChange    []func(bool)
 	onVolumeChange     []func(int)
 	onSeek             []func()
@@ -122,6 +126,13 @@ type playbackEngine struct {
 	onStopped          []func()
 	onPlaying          []func()
 	onQueueChange      []func()
+	onTrackPlayed      []func(track *mediaprovider.Track, start time.Time, played time.Duration, completed bool)
+
+	// returns the plays recorded since the given time, if listening history is available
+	playHistory func(since time.Time) []*PlayRecord
+
+	// the play history of tracks for the shuffle strategy, loaded off the engine goroutine
+	shuffleHistory shuffleHistoryCache
 
 	onRadioMetadataChange []func(radioName, title, artist string)
 }
@@ -133,7 +144,6 @@ func NewPlaybackEngine(
 	p player.BasePlayer,
 	playbackCfg *PlaybackConfig,
 	scrobbleCfg *ScrobbleConfig,
-	transcodeCfg *TranscodingConfig,
 ) *playbackEngine {
 	// clamp to 99% to avoid any possible rounding issues
 	scrobbleCfg.ThresholdPercent = clamp(scrobbleCfg.ThresholdPercent, 0, 99)
@@ -144,9 +154,10 @@ func NewPlaybackEngine(
 		player:        p,
 		playbackCfg:   playbackCfg,
 		scrobbleCfg:   scrobbleCfg,
-		transcodeCfg:  transcodeCfg,
 		nowPlayingIdx: -1,
 		wasStopped:    true,
+		abLoopA:       -1,
+		abLoopB:       -1,
 	}
 	switch playbackCfg.RepeatMode {
 	case "All":
@@ -222,6 +233,7 @@ func (p *playbackEngine) SetPlayer(pl player.BasePlayer) error {
 		p.pendingPlayerChangeStatus = stat
 		p.pendingPlayerChange = true
 	}
+	p.clearABLoop()
 	p.unregisterPlayerCallbacks(p.player)
 	if err := p.player.Stop(true); err != nil {
 		log.Printf("failed to stop player: %v", err)
@@ -392,6 +404,48 @@ func (p *playbackEngine) GetLoopMode() LoopMode {
 	return p.loopMode
 }
 
+// SetABLoop sets the A-B loop points of the current track.
+// A negative value clears the corresponding point. Playback only
+// loops once both points are set.
+func (p *playbackEngine) SetABLoop(a, b float64) error {
+	if p.isRadio || p.nowPlayingIdx < 0 {
+		a, b = -1, -1
+	}
+	if a >= 0 && b >= 0 && b < a {
+		a, b = b, a
+	}
+	p.abLoopA, p.abLoopB = a, b
+	var err error
+	if abPlayer, ok := p.player.(player.ABLoopPlayer); ok {
+		if p.abLoopActive() {
+			err = abPlayer.SetABLoop(a, b)
+		} else {
+			err = abPlayer.SetABLoop(-1, -1)
+		}
+	}
+	for _, cb := range p.onABLoopChange {
+		cb(a, b)
+	}
+	return err
+}
+
+func (p *playbackEngine) ABLoop() (a, b float64) {
+	return p.abLoopA, p.abLoopB
+}
+
+func (p *playbackEngine) abLoopActive() bool {
+	return p.abLoopA >= 0 && p.abLoopB > p.abLoopA
+}
+
+func (p *playbackEngine) clearABLoop() {
+	if p.abLoopA < 0 && p.abLoopB < 0 {
+		return
+	}
+	if err := p.SetABLoop(-1, -1); err != nil {
+		log.Printf("failed to clear A-B loop: %v", err)
+	}
+}
+
 func (p *playbackEngine) GetTrackIdxByIdFrom(items []mediaprovider.MediaItem, id string) int {
 	foundIdx := -1
 	for i, tr := range items {
@@ -421,9 +475,7 @@ func (p *playbackEngine) SetShuffle(shuffle bool) {
 	newNowPlayingIdx := 0
 	if shuffle {
 		shuffledQueue := deepCopyMediaItemSlice(p.playQueue)
-		rand.Shuffle(len(shuffledQueue), func(i, j int) {
-			shuffledQueue[i], shuffledQueue[j] = shuffledQueue[j], shuffledQueue[i]
-		})
+		p.shuffleItems(shuffledQueue)
 		if p.nowPlayingIdx >= 0 && len(p.getPlayQueue()) > p.nowPlayingIdx {
 			nowPlayingID := p.getPlayQueue()[p.nowPlayingIdx].Metadata().ID
 			p.setShuffledPlayQueue(sharedutil.ReorderItems(shuffledQueue, []int{p.GetTrackIdxByIdFrom(shuffledQueue, nowPlayingID)}, 0))
@@ -591,9 +643,7 @@ func (p *playbackEngine) LoadItemsAndPlayAtIdx(items []mediaprovider.MediaItem,
 
 	if p.shuffle || shuffle {
 		p.clearPlayQueue()
-		rand.Shuffle(len(newItems), func(i, j int) {
-			newItems[i], newItems[j] = newItems[j], newItems[i]
-		})
+		p.shuffleItems(newItems)
 		if idx < len(items) {
 			nowPlayingID := items[idx].Metadata().ID
 			p.setPlayQueue(deepCopyMediaItemSlice(items))
@@ -629,7 +679,7 @@ func (p *playbackEngine) doLoaditems(items []mediaprovider.MediaItem, insertQueu
 	}
 
 	if shuffle {
-		rand.Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
+		p.shuffleItems(items)
 	}
 
 	insertIdx := p.getPlayQueueLength()
@@ -637,7 +687,7 @@ func (p *playbackEngine) doLoaditems(items []mediaprovider.MediaItem, insertQueu
 	if insertQueueMode == Replace {
 		if p.shuffle {
 			shuffledItems := deepCopyMediaItemSlice(items)
-			rand.Shuffle(len(shuffledItems), func(i, j int) { shuffledItems[i], shuffledItems[j] = shuffledItems[j], shuffledItems[i] })
+			p.shuffleItems(shuffledItems)
 
 			p.insertItemsIntoPlayQueueAt(items, insertIdx, PlayQueue)
 			p.insertItemsIntoPlayQueueAt(shuffledItems, insertIdx, ShuffledPlayQueue)
@@ -911,6 +961,10 @@ func (p *playbackEngine) handleOnTrackChange() {
 	p.wasStopped = false
 	p.alreadyScrobbled = false
 
+	// loop points belong to the previous track (and mpv keeps them across files)
+	p.clearABLoop()
+
+	p.curTrackStartTime = time.Now()
 	p.curTrackDuration = nowPlaying.Metadata().Duration.Seconds()
 	p.sendNowPlayingScrobble() // Must come before invokeOnChangeCallbacks b/c track may immediately be scrobbled
 	p.invokeOnSongChangeCallbacks()
@@ -1032,20 +1086,29 @@ func (p *playbackEngine) setTrack(idx int, next bool, startTime float64) error {
 }
 
 func (p *playbackEngine) getMediaURLForIdx(idx int) string {
-	var url string
 	item := p.getPlayQueueItemAt(idx)
 	if tr, ok := item.(*mediaprovider.Track); ok {
-		var ts *mediaprovider.TranscodeSettings
-		if p.transcodeCfg.RequestTranscode {
-			ts = &mediaprovider.TranscodeSettings{
-				Codec:       p.transcodeCfg.Codec,
-				BitRateKBPS: p.transcodeCfg.MaxBitRateKBPS,
-			}
+		return p.streamURLForTrack(tr)
+	}
+	return item.(*mediaprovider.RadioStation).StreamURL
+}
+
+// streamURLForTrack returns the URL to stream the track from the server,
+// or "" if not connected.
+func (p *playbackEngine) streamURLForTrack(tr *mediaprovider.Track) string {
+	if p.sm.Server == nil {
+		return ""
+	}
+	// the active connection's transcoding profile, or else the global settings
+	transcodeCfg := p.sm.TranscodingConfig()
+	var ts *mediaprovider.TranscodeSettings
+	if transcodeCfg.RequestTranscode {
+		ts = &mediaprovider.TranscodeSettings{
+			Codec:       transcodeCfg.Codec,
+			BitRateKBPS: transcodeCfg.MaxBitRateKBPS,
 		}
-		url, _ = p.sm.Server.GetStreamURL(tr.ID, ts, p.transcodeCfg.ForceRawFile)
-	} else {
-		url = item.(*mediaprovider.RadioStation).StreamURL
 	}
+	url, _ := p.sm.Server.GetStreamURL(tr.ID, ts, transcodeCfg.ForceRawFile)
 	return url
 }
 
@@ -1055,7 +1118,7 @@ func (p *playbackEngine) setNextTrack(idx int) error {
 
 // call BEFORE updating p.nowPlayingIdx
 func (p *playbackEngine) checkScrobble() {
-	if !p.scrobbleCfg.Enabled || p.getPlayQueueLength() == 0 || p.nowPlayingIdx < 0 {
+	if p.getPlayQueueLength() == 0 || p.nowPlayingIdx < 0 {
 		return
 	}
 	track, ok := p.getPlayQueueItemAt(p.nowPlayingIdx).(*mediaprovider.Track)
@@ -1067,6 +1130,17 @@ func (p *playbackEngine) checkScrobble() {
 	if playDur.Seconds() < 0.1 || p.curTrackDuration < 0.1 {
 		return
 	}
+	// played tracks are reported regardless of whether scrobbling is enabled
+	completed := p.latestTrackPosition >= p.curTrackDuration*0.9
+	for _, cb := range p.onTrackPlayed {
+		cb(track, p.curTrackStartTime, playDur, completed)
+	}
+	if !p.scrobbleCfg.Enabled {
+		p.latestTrackPosition = 0
+		p.playTimeStopwatch.Reset()
+		return
+	}
+
 	pcnt := playDur.Seconds() / p.curTrackDuration * 100
 	timeThresholdMet := p.scrobbleCfg.ThresholdTimeSeconds >= 0 &&
 		playDur.Seconds() >= float64(p.scrobbleCfg.ThresholdTimeSeconds)
@@ -1102,6 +1176,12 @@ func (p *playbackEngine) sendNowPlayingScrobble() {
 	p.reportPlayback("starting")
 }
 
+// shuffles items in place according to the configured shuffle strategy
+func (p *playbackEngine) shuffleItems(items []mediaprovider.MediaItem) {
+	strategy := p.playbackCfg.ShuffleStrategy
+	shuffleMediaItems(items, strategy, p.shuffleHistory.get(strategy))
+}
+
 // creates a deep copy of the track info so that we can maintain our own state
 // (play count increases, favorite, and rating) without messing up other views' track models
 func deepCopyMediaItemSlice(tracks []mediaprovider.MediaItem) []mediaprovider.MediaItem {
@@ -1192,6 +1272,13 @@ func (p *playbackEngine) handleTimePosUpdate(seeked bool) {
 			p.setNextTrack(-1)
 		}
 	}
+	if _, native := p.player.(player.ABLoopPlayer); !native && p.abLoopActive() &&
+		s.State == player.Playing && s.TimePos >= p.abLoopB && !p.player.IsSeeking() {
+		// players without native A-B loop support are looped by polling
+		if err := p.player.SeekSeconds(p.abLoopA); err != nil {
+			log.Printf("failed to seek to A-B loop start: %v", err)
+		}
+	}
 	if p.callbacksDisabled {
 		return
 	}
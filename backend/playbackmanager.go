@@ -13,9 +13,11 @@ import (
 
 	"github.com/charlievieth/strcase"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
 	"github.com/dweymouth/supersonic/backend/player"
 	"github.com/dweymouth/supersonic/backend/player/dlna"
 	"github.com/dweymouth/supersonic/backend/player/mpv"
+	"github.com/dweymouth/supersonic/backend/player/remotesession"
 	"github.com/dweymouth/supersonic/sharedutil"
 	"github.com/supersonic-app/go-upnpcast/device"
 	"github.com/supersonic-app/go-upnpcast/services"
@@ -50,6 +52,9 @@ type PlaybackManager struct {
 	wfmUpdateImageCancel context.CancelFunc
 	wfmImageJobs         [3]*WaveformImageJob
 
+	// registered autoplay strategies, see RegisterAutoplayStrategy
+	autoplayStrategies []AutoplayStrategy
+
 	// whether autoplay tracks are currently being fetched/enqueued
 	pendingAutoplay    bool
 	wasLoadTrackPaused bool
@@ -74,10 +79,9 @@ func NewPlaybackManager(
 	p player.BasePlayer,
 	playbackCfg *PlaybackConfig,
 	scrobbleCfg *ScrobbleConfig,
-	transcodeCfg *TranscodingConfig,
 	appCfg *AppConfig,
 ) *PlaybackManager {
-	e := NewPlaybackEngine(ctx, s, c, p, playbackCfg, scrobbleCfg, transcodeCfg)
+	e := NewPlaybackEngine(ctx, s, c, p, playbackCfg, scrobbleCfg)
 	q := NewCommandQueue()
 	pm := &PlaybackManager{
 		engine:      e,
@@ -86,10 +90,14 @@ func NewPlaybackManager(
 		cfg:         playbackCfg,
 		localPlayer: p,
 		cache:       c,
+
+		autoplayStrategies: builtinAutoplayStrategies(),
 	}
 	if c != nil {
-		pm.wfmGen = NewWaveformImageGenerator(c)
+		pm.wfmGen = NewWaveformImageGenerator(c, e.streamURLForTrack)
+		s.OnServerConnected(func(*ServerConfig) { go pm.pregeneratePinnedWaveforms() })
 	}
+	s.OnServerConnected(func(*ServerConfig) { e.shuffleHistory.reload(pm.cfg.ShuffleStrategy) })
 	pm.addOnTrackChangeHook()
 	go pm.runCmdQueue(ctx)
 	return pm
@@ -136,6 +144,7 @@ func (p *PlaybackManager) addOnTrackChangeHook() {
 				p.addWfmImageJob(p.wfmGen.StartWaveformGeneration(item.(*mediaprovider.Track)))
 			}
 		}
+		p.pregenerateWaveforms()
 		if p.isLoadTrackPaused() {
 			// we need to call handleWaveformImageSongChange to ensure the waveform image is updated
 			// for the track that is loaded paused when starting the app
@@ -175,6 +184,97 @@ func (p *PlaybackManager) addOnTrackChangeHook() {
 	})
 }
 
+// SetWaveformCache sets the on-disk cache for generated waveform images.
+func (p *PlaybackManager) SetWaveformCache(cache *WaveformCache) {
+	if p.wfmGen != nil {
+		p.wfmGen.SetDiskCache(cache)
+	}
+}
+
+// CanPinAlbumWaveforms returns true if waveforms are being generated,
+// so that the waveforms of albums can be pinned.
+func (p *PlaybackManager) CanPinAlbumWaveforms() bool {
+	return p.wfmGen != nil && p.wfmGen.diskCache != nil
+}
+
+// IsAlbumWaveformsPinned returns true if the waveforms of the album are pinned in the cache.
+func (p *PlaybackManager) IsAlbumWaveformsPinned(albumID string) bool {
+	if !p.CanPinAlbumWaveforms() {
+		return false
+	}
+	return p.wfmGen.diskCache.IsAlbumPinned(p.engine.sm.ServerID.String(), albumID)
+}
+
+// PinAlbumWaveforms keeps the waveforms of the album's tracks in the cache,
+// generating them in the background if not already cached.
+func (p *PlaybackManager) PinAlbumWaveforms(albumID string) error {
+	if !p.CanPinAlbumWaveforms() {
+		return errors.New("waveforms are not enabled")
+	}
+	album, err := p.engine.sm.Server.GetAlbum(albumID)
+	if err != nil {
+		return err
+	}
+	trackIDs := sharedutil.MapSlice(album.Tracks, func(tr *mediaprovider.Track) string { return tr.ID })
+	if err := p.wfmGen.diskCache.PinAlbum(p.engine.sm.ServerID.String(), albumID, trackIDs); err != nil {
+		return err
+	}
+	p.wfmGen.PregeneratePinned(album.Tracks)
+	return nil
+}
+
+// UnpinAlbumWaveforms lets the waveforms of the album be pruned from the cache.
+func (p *PlaybackManager) UnpinAlbumWaveforms(albumID string) error {
+	if !p.CanPinAlbumWaveforms() {
+		return nil
+	}
+	return p.wfmGen.diskCache.UnpinAlbum(p.engine.sm.ServerID.String(), albumID)
+}
+
+// generate any missing waveforms of the pinned albums on the current server,
+// e.g. for tracks added to the album since it was pinned
+func (p *PlaybackManager) pregeneratePinnedWaveforms() {
+	if !p.CanPinAlbumWaveforms() {
+		return
+	}
+	serverID := p.engine.sm.ServerID.String()
+	for _, id := range p.wfmGen.diskCache.PinnedAlbums(serverID) {
+		album, err := p.engine.sm.Server.GetAlbum(id)
+		if err != nil {
+			log.Printf("error loading pinned album: %s", err.Error())
+			continue
+		}
+		if !p.wfmGen.diskCache.IsAlbumPinned(serverID, id) {
+			continue // unpinned meanwhile
+		}
+		trackIDs := sharedutil.MapSlice(album.Tracks, func(tr *mediaprovider.Track) string { return tr.ID })
+		if err := p.wfmGen.diskCache.PinAlbum(serverID, id, trackIDs); err != nil {
+			log.Printf("error updating pinned album: %s", err.Error())
+		}
+		p.wfmGen.PregeneratePinned(album.Tracks)
+	}
+}
+
+// pre-generate waveforms in the background for the upcoming tracks
+// that the audio cache is prefetching, beyond the next-up track
+func (p *PlaybackManager) pregenerateWaveforms() {
+	if p.wfmGen == nil {
+		return
+	}
+	var tracks []*mediaprovider.Track
+	npI := max(p.engine.nowPlayingIdx, 0)
+	for idx := npI + 1; idx <= npI+2 && idx < p.engine.getPlayQueueLength(); idx++ {
+		tr, ok := p.engine.getPlayQueueItemAt(idx).(*mediaprovider.Track)
+		if !ok {
+			continue
+		}
+		if _, ok := p.findWfmImageJob(tr.ID, true); !ok {
+			tracks = append(tracks, tr)
+		}
+	}
+	p.wfmGen.Pregenerate(tracks)
+}
+
 func (p *PlaybackManager) handleWaveformImageSongChange(item mediaprovider.MediaItem) {
 	if p.wfmUpdateImageCancel != nil {
 		p.wfmUpdateImageCancel()
@@ -261,17 +361,48 @@ func (p *PlaybackManager) scanRemotePlayers(ctx context.Context, waitSec int) {
 			URL:      d.URL,
 			Protocol: "DLNA",
 			new: func() (player.BasePlayer, error) {
-				return dlna.NewDLNAPlayer(d, coverArtPathFn)
+				return dlna.NewDLNAPlayer(d, coverArtPathFn, p.engine.sm.HTTPClient())
 			},
 		}
 		discovered = append(discovered, rp)
 	}
+	discovered = append(discovered, p.remoteSessionPlayers()...)
 
 	p.remotePlayersLock.Lock()
 	p.remotePlayers = discovered
 	p.remotePlayersLock.Unlock()
 }
 
+// remoteSessionPlayers returns the other client sessions of the user
+// on the server that can be remotely controlled, if supported.
+func (p *PlaybackManager) remoteSessionPlayers() []RemotePlaybackDevice {
+	rsp, ok := p.engine.sm.Server.(mediaprovider.RemoteSessionProvider)
+	if !ok {
+		return nil
+	}
+	sessions, err := rsp.GetRemoteSessions()
+	if err != nil {
+		log.Printf("error getting remote sessions: %s", err.Error())
+		return nil
+	}
+	protocol := "Remote"
+	if conf := p.engine.sm.CurrentServerConfig(); conf != nil {
+		protocol = string(conf.ServerType)
+	}
+	devices := make([]RemotePlaybackDevice, 0, len(sessions))
+	for _, s := range sessions {
+		devices = append(devices, RemotePlaybackDevice{
+			Name:     s.Name,
+			URL:      "session:" + s.ID,
+			Protocol: protocol,
+			new: func() (player.BasePlayer, error) {
+				return remotesession.NewRemoteSessionPlayer(rsp, s.ID)
+			},
+		})
+	}
+	return devices
+}
+
 func (p *PlaybackManager) RemotePlayers() []RemotePlaybackDevice {
 	p.remotePlayersLock.Lock()
 	players := p.remotePlayers
@@ -372,6 +503,12 @@ func (p *PlaybackManager) OnLoopModeChange(cb func(LoopMode)) {
 	p.engine.onLoopModeChange = append(p.engine.onLoopModeChange, cb)
 }
 
+// Registers a callback that is notified whenever the A-B loop points change.
+// Unset points are negative.
+func (p *PlaybackManager) OnABLoopChange(cb func(a, b float64)) {
+	p.engine.onABLoopChange = append(p.engine.onABLoopChange, cb)
+}
+
 // Registers a callback that is notified whenever the shuffle state changes.
 func (p *PlaybackManager) OnShuffleChange(cb func(bool)) {
 	p.engine.onShuffleChange = append(p.engine.onShuffleChange, cb)
@@ -387,6 +524,19 @@ func (p *PlaybackManager) OnQueueChange(cb func()) {
 	p.engine.onQueueChange = append(p.engine.onQueueChange, cb)
 }
 
+// SetPlayHistorySource sets the function used to look up
+// the listening history for the shuffle strategies.
+func (p *PlaybackManager) SetPlayHistorySource(history func(since time.Time) []*PlayRecord) {
+	p.engine.playHistory = history
+	p.engine.shuffleHistory.setSource(history)
+}
+
+// Registers a callback that is notified whenever a track finishes playing,
+// either by completing or by being skipped. Not invoked for radio stations.
+func (p *PlaybackManager) OnTrackPlayed(cb func(track *mediaprovider.Track, start time.Time, played time.Duration, completed bool)) {
+	p.engine.onTrackPlayed = append(p.engine.onTrackPlayed, cb)
+}
+
 // Registers a callback that is notified whenever the player has been seeked.
 func (p *PlaybackManager) OnSeek(cb func()) {
 	p.engine.onSeek = append(p.engine.onSeek, cb)
@@ -431,6 +581,22 @@ func (p *PlaybackManager) LoadPlaylist(playlistID string, insertQueueMode Insert
 	return nil
 }
 
+// Loads all tracks in the specified folder and its subfolders into the play queue.
+// If the folder is too large to load entirely, the tracks that were loaded
+// are queued and helpers.ErrFolderTruncated is returned.
+func (p *PlaybackManager) LoadFolder(folderID string, insertQueueMode InsertQueueMode, shuffle bool) error {
+	fp, ok := p.engine.sm.Server.(mediaprovider.FolderBrowsingProvider)
+	if !ok {
+		return errors.New("server does not support folder browsing")
+	}
+	tracks, err := helpers.GetFolderTracksRecursive(fp, folderID)
+	if err != nil && !errors.Is(err, helpers.ErrFolderTruncated) {
+		return err
+	}
+	p.LoadTracks(tracks, insertQueueMode, shuffle)
+	return err
+}
+
 // Load tracks into the play queue.
 // If replacing the current queue (!appendToQueue), playback will be stopped.
 func (p *PlaybackManager) LoadTracks(tracks []*mediaprovider.Track, insertQueueMode InsertQueueMode, shuffle bool) {
@@ -487,6 +653,18 @@ func (p *PlaybackManager) PlayPlaylist(playlistID string, firstTrack int, shuffl
 	return nil
 }
 
+func (p *PlaybackManager) PlayFolder(folderID string, shuffle bool) error {
+	err := p.LoadFolder(folderID, Replace, shuffle)
+	if err != nil && !errors.Is(err, helpers.ErrFolderTruncated) {
+		return err
+	}
+	if p.engine.replayGainCfg.Mode == ReplayGainAuto {
+		p.SetReplayGainMode(player.ReplayGainTrack)
+	}
+	p.PlayFromBeginning()
+	return err
+}
+
 func (p *PlaybackManager) PlayTrack(trackID string) error {
 	tr, err := p.engine.sm.Server.GetTrack(trackID)
 	if err != nil {
@@ -582,6 +760,30 @@ func (p *PlaybackManager) PlaySimilarSongs(id string) error {
 	})
 }
 
+// PlayAlbumMix plays a mix of tracks similar to the given album.
+func (p *PlaybackManager) PlayAlbumMix(albumID string) error {
+	return p.playInstantMix(func(mp mediaprovider.InstantMixProvider, count int) ([]*mediaprovider.Track, error) {
+		return mp.GetAlbumMix(albumID, count)
+	})
+}
+
+// PlayGenreMix plays a mix of tracks from the given genre.
+func (p *PlaybackManager) PlayGenreMix(genreName string) error {
+	return p.playInstantMix(func(mp mediaprovider.InstantMixProvider, count int) ([]*mediaprovider.Track, error) {
+		return mp.GetGenreMix(genreName, count)
+	})
+}
+
+func (p *PlaybackManager) playInstantMix(fetch func(mediaprovider.InstantMixProvider, int) ([]*mediaprovider.Track, error)) error {
+	mp, ok := p.engine.sm.Server.(mediaprovider.InstantMixProvider)
+	if !ok {
+		return errors.New("server does not support instant mixes")
+	}
+	return p.fetchAndPlayTracks(func() ([]*mediaprovider.Track, error) {
+		return fetch(mp, p.appCfg.EnqueueBatchSize)
+	})
+}
+
 func (p *PlaybackManager) PlayRandomAlbums(genreName string) error {
 	mp := p.engine.sm.GetServer()
 	if mp == nil {
@@ -599,12 +801,19 @@ func (p *PlaybackManager) PlayRandomAlbums(genreName string) error {
 		}
 	}
 	iter := mp.IterateAlbums(mediaprovider.AlbumSortRandom, mediaprovider.NewAlbumFilter(options))
-	insertMode := Replace
-	for i := range 20 {
+	var albums []*mediaprovider.Album
+	for range 20 {
 		al := iter.Next()
 		if al == nil {
 			break
 		}
+		albums = append(albums, al)
+	}
+	strategy := p.cfg.ShuffleStrategy
+	shuffleAlbums(albums, strategy, loadShuffleHistory(strategy, p.engine.playHistory, true))
+
+	insertMode := Replace
+	for i, al := range albums {
 		if al, err := mp.GetAlbum(al.ID); err == nil {
 			p.LoadTracks(al.Tracks, insertMode, false)
 			if i == 0 {
@@ -701,6 +910,50 @@ func (p *PlaybackManager) GetLoopMode() LoopMode {
 	return p.engine.loopMode
 }
 
+// SetABLoop sets the A-B repeat points of the current track in seconds.
+// A negative value clears the corresponding point. Once both points are set,
+// playback seeks back to A whenever it reaches B.
+// The loop is cleared when the track changes.
+func (p *PlaybackManager) SetABLoop(a, b float64) {
+	p.cmdQueue.SetABLoop(a, b)
+}
+
+// SetABLoopPoint sets the A (isStart) or B point of the A-B loop,
+// keeping the other point unchanged.
+func (p *PlaybackManager) SetABLoopPoint(secs float64, isStart bool) {
+	a, b := p.engine.ABLoop()
+	if isStart {
+		a = secs
+	} else {
+		b = secs
+	}
+	p.cmdQueue.SetABLoop(a, b)
+}
+
+// CycleABLoop sets the A point of the A-B loop at the current playback position
+// if it is unset, then the B point, and clears the loop if both are set.
+func (p *PlaybackManager) CycleABLoop() {
+	pos := p.engine.PlaybackStatus().TimePos
+	a, b := p.engine.ABLoop()
+	switch {
+	case a < 0:
+		p.cmdQueue.SetABLoop(pos, -1)
+	case b < 0:
+		p.cmdQueue.SetABLoop(a, pos)
+	default:
+		p.cmdQueue.SetABLoop(-1, -1)
+	}
+}
+
+func (p *PlaybackManager) ClearABLoop() {
+	p.cmdQueue.SetABLoop(-1, -1)
+}
+
+// ABLoop returns the current A-B loop points. Unset points are negative.
+func (p *PlaybackManager) ABLoop() (a, b float64) {
+	return p.engine.ABLoop()
+}
+
 func (p *PlaybackManager) IsAutoplay() bool {
 	return p.cfg.Autoplay
 }
@@ -823,7 +1076,9 @@ func (p *PlaybackManager) enqueueAutoplayTracks() {
 	// tracks we will enqueue
 	var tracks []*mediaprovider.Track
 
+	// exclusion rules, applied to the results of all strategies
 	filterAutoplayTracks := func(tracks []*mediaprovider.Track) []*mediaprovider.Track {
+		seen := make(map[string]bool, len(tracks))
 		return sharedutil.FilterSlice(tracks, func(t *mediaprovider.Track) bool {
 			shouldSkip :=
 				(p.cfg.SkipOneStarWhenShuffling && t.Rating == 1) ||
@@ -831,55 +1086,32 @@ func (p *PlaybackManager) enqueueAutoplayTracks() {
 			recentlyPlayed := slices.ContainsFunc(queue, func(i mediaprovider.MediaItem) bool {
 				return i.Metadata().Type == mediaprovider.MediaItemTypeTrack && i.Metadata().ID == t.ID
 			})
-			return !shouldSkip && !recentlyPlayed
+			duplicate := seen[t.ID]
+			seen[t.ID] = true
+			return !shouldSkip && !recentlyPlayed && !duplicate
 		})
 	}
 
+	var tr *mediaprovider.Track
+	if t, ok := nowPlaying.(*mediaprovider.Track); ok {
+		tr = t
+	}
+	strategies := p.enabledAutoplayStrategies()
+
 	// since this func is invoked in a callback from the playback engine,
 	// need to do the rest async as it may take time and block other callbacks
 	p.pendingAutoplay = true
 	go func() {
 		defer func() { p.pendingAutoplay = false }()
 
-		// first 2 strategies - similar by artist, and similar by genres - only work for tracks
-		if nowPlaying.Metadata().Type == mediaprovider.MediaItemTypeTrack {
-			tr := nowPlaying.(*mediaprovider.Track)
-
-			// similar tracks by artist
-			if len(tr.ArtistIDs) > 0 {
-				similar, err := s.GetSimilarTracks(tr.ArtistIDs[0], p.appCfg.EnqueueBatchSize)
-				if err != nil {
-					log.Printf("autoplay error: failed to get similar tracks: %v", err)
-				}
-				tracks = filterAutoplayTracks(similar)
-			}
-
-			// fallback to random tracks from genre
-			if len(tracks) == 0 {
-				for _, g := range tr.Genres {
-					if g == "" {
-						continue
-					}
-					byGenre, err := s.GetRandomTracks(g, p.appCfg.EnqueueBatchSize)
-					if err != nil {
-						log.Printf("autoplay error: failed to get tracks by genre: %v", err)
-					}
-					tracks = filterAutoplayTracks(byGenre)
-					if len(tracks) > 0 {
-						break
-					}
-				}
-			}
-		}
-
-		// random tracks works regardless of the type of the last playing media
-		if len(tracks) == 0 {
-			// fallback to random tracks
-			random, err := s.GetRandomTracks("", p.appCfg.EnqueueBatchSize)
+		for _, strategy := range strategies {
+			found, err := strategy.Tracks(s, tr, p.appCfg.EnqueueBatchSize)
 			if err != nil {
-				log.Printf("autoplay error: failed to get random tracks: %v", err)
+				log.Printf("autoplay error: strategy %q failed: %v", strategy.Name, err)
+			}
+			if tracks = filterAutoplayTracks(found); len(tracks) > 0 {
+				break
 			}
-			tracks = filterAutoplayTracks(random)
 		}
 
 		if len(tracks) > 0 {
@@ -948,6 +1180,8 @@ func (p *PlaybackManager) runCmdQueue(ctx context.Context) {
 					c.Arg.(*mediaprovider.RadioStation),
 					c.Arg2.(InsertQueueMode),
 				)
+			case cmdABLoop:
+				logIfErr("SetABLoop", p.engine.SetABLoop(c.Arg.(float64), c.Arg2.(float64)))
 			case cmdLoadTrackPaused:
 				logIfErr("LoadTrackPaused", p.engine.loadTrackPaused(c.Arg.(int), c.Arg2.(float64)))
 			case cmdForceRestartPlayback:
@@ -18,6 +18,7 @@ import (
 
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
 	"github.com/dweymouth/supersonic/backend/player"
+	"github.com/dweymouth/supersonic/backend/upnp"
 	"github.com/dweymouth/supersonic/backend/util"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/supersonic-app/go-upnpcast/device"
@@ -39,11 +40,17 @@ type proxyMapEntry struct {
 type DLNAPlayer struct {
 	player.BasePlayerCallbackImpl
 
-	destroyed     bool
+	destroyed atomic.Bool
+	done      chan struct{} // closed by Destroy
+
+	// cancels the last request sent to the renderer
+	requestLock   sync.Mutex
 	cancelRequest context.CancelFunc
 
 	avTransport   *avtransport.Client
 	renderControl *renderingcontrol.Client
+	// URL of the renderer's device description
+	descURL string
 
 	// coverArtPathFn returns a local filesystem path to the cached cover
 	// art image for the given CoverArtID, or an error if no path is
@@ -52,17 +59,31 @@ type DLNAPlayer struct {
 	// so the renderer can fetch it.
 	coverArtPathFn func(coverArtID string) (string, error)
 
-	state   int // stopped, playing, paused
-	seeking bool
+	// client used by the local proxy to fetch media from the music server
+	proxyClient *http.Client
+
+	seeking atomic.Bool
 
+	// guards the playback state and track metadata, which are accessed
+	// from the renderer's event callbacks and the track change timer
 	metaLock      sync.Mutex
+	state         int // stopped, playing, paused
 	curTrackMeta  mediaprovider.MediaItemMetadata
 	nextTrackMeta mediaprovider.MediaItemMetadata
+	// proxy URLs of the current and next tracks, by which the
+	// renderer's events identify the track it is playing
+	curURL  string
+	nextURL string
+
+	// incremented on every track change, so that a track change
+	// detected by more than one means is handled only once
+	trackGen atomic.Uint64
 
 	// if true, report playback time 00:00
 	// pending time sync with player after beginning playback
-	pendingPlayStart bool
-	// start playback position in seconds of the last seek/time sync
+	pendingPlayStart atomic.Bool
+	// start playback position in seconds of the last seek/time sync,
+	// guarded by metaLock
 	lastStartTime int
 	// how long the track has been playing since last time sync
 	stopwatch util.Stopwatch
@@ -72,6 +93,7 @@ type DLNAPlayer struct {
 	localIP     string
 	proxyPort   int
 
+	// seek to be sent when playback continues, guarded by metaLock
 	pendingSeek     bool
 	pendingSeekSecs float64
 
@@ -84,18 +106,27 @@ type DLNAPlayer struct {
 
 	// If SetNextAVTransport fails (e.g. because the device
 	// does not support the API/gapless), this flag is set
-	// true, and the next firing of the track change timer
-	// should clear it to false and use SetAVTransport
-	// to begin playing the item in nextTrackMeta.
-	failedToSetNext    bool
-	unsetNextMediaItem *avtransport.MediaItem
-
-	timerActive atomic.Bool
-	timer       *time.Timer
-	resetChan   chan (time.Duration)
-}
-
-func NewDLNAPlayer(device *device.MediaRenderer, coverArtPathFn func(coverArtID string) (string, error)) (*DLNAPlayer, error) {
+	// true, and the track change should clear it to false
+	// and use SetAVTransport to begin playing nextMedia.
+	failedToSetNext bool
+	nextMedia       *avtransport.MediaItem
+
+	// subscription to the renderer's AVTransport events, if supported
+	events       atomic.Pointer[upnp.Subscriber]
+	eventsActive atomic.Bool
+	// the transport state the renderer is expected to report after a
+	// command sent by us, until which its events are not acted on
+	expectedState string
+	expectedUntil time.Time
+
+	timerLock sync.Mutex
+	timer     *time.Timer
+}
+
+// NewDLNAPlayer creates a player for the given renderer. proxyClient is used
+// to fetch media from the music server for the local proxy, and should
+// carry any TLS settings and headers the server requires.
+func NewDLNAPlayer(device *device.MediaRenderer, coverArtPathFn func(coverArtID string) (string, error), proxyClient *http.Client) (*DLNAPlayer, error) {
 	retry := retryablehttp.NewClient()
 	retry.RetryMax = 3
 	retry.RetryWaitMin = 100 * time.Millisecond
@@ -120,12 +151,16 @@ func NewDLNAPlayer(device *device.MediaRenderer, coverArtPathFn func(coverArtID
 		return nil, fmt.Errorf("failed to connect to %s", device.FriendlyName)
 	}
 
-	return &DLNAPlayer{
+	d := &DLNAPlayer{
 		avTransport:    avt,
 		renderControl:  rc,
-		resetChan:      make(chan time.Duration),
+		descURL:        device.URL,
 		coverArtPathFn: coverArtPathFn,
-	}, nil
+		proxyClient:    proxyClient,
+		done:           make(chan struct{}),
+	}
+	go d.pollPosition()
+	return d, nil
 }
 
 // buildMediaItem assembles the avtransport.MediaItem for a track. It
@@ -162,67 +197,96 @@ func (d *DLNAPlayer) buildMediaItem(playbackURL string, meta mediaprovider.Media
 	return item
 }
 
+// requestContext returns the context for a request to the renderer,
+// with the given timeout if non-zero, which is canceled by a forced
+// stop or Destroy until the next request is made.
+func (d *DLNAPlayer) requestContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	d.requestLock.Lock()
+	d.cancelRequest = cancel
+	d.requestLock.Unlock()
+	return ctx, cancel
+}
+
+func (d *DLNAPlayer) cancelLastRequest() {
+	d.requestLock.Lock()
+	cancel := d.cancelRequest
+	d.requestLock.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
 func (d *DLNAPlayer) SetVolume(vol int) error {
-	if d.destroyed {
+	if d.destroyed.Load() {
 		return nil
 	}
-	ctx, cancel := context.WithCancel(context.Background())
-	d.cancelRequest = cancel
+	ctx, cancel := d.requestContext(0)
 	defer cancel()
 	return d.renderControl.SetVolume(ctx, vol)
 }
 
 func (d *DLNAPlayer) GetVolume() int {
-	if d.destroyed {
+	if d.destroyed.Load() {
 		return 0
 	}
-	ctx, cancel := context.WithCancel(context.Background())
-	d.cancelRequest = cancel
+	ctx, cancel := d.requestContext(0)
 	defer cancel()
 	vol, _ := d.renderControl.GetVolume(ctx)
 	return vol
 }
 
 func (d *DLNAPlayer) PlayFile(urlstr string, meta mediaprovider.MediaItemMetadata, startTime float64) error {
-	if d.destroyed {
+	if d.destroyed.Load() {
 		return nil
 	}
 
 	d.ensureSetupProxy()
 
+	key := d.addURLToProxy(urlstr)
+	media := d.buildMediaItem(d.urlForItem(key), meta)
+
 	d.metaLock.Lock()
+	d.trackGen.Add(1)
 	d.curTrackMeta = meta
+	d.curURL = media.URL
+	d.nextTrackMeta = mediaprovider.MediaItemMetadata{}
+	d.nextURL, d.nextMedia, d.failedToSetNext = "", nil, false
 	d.metaLock.Unlock()
-	key := d.addURLToProxy(urlstr)
-
-	media := d.buildMediaItem(d.urlForItem(key), meta)
 
 	if err := d.playAVTransportMedia(&media); err != nil {
 		return err
 	}
-	d.pendingPlayStart = true
+	d.pendingPlayStart.Store(true)
 	if startTime > 0 {
-		// TODO: do something better than this!!
-		time.Sleep(2 * time.Second)
-		if !d.destroyed {
+		d.waitForPlaybackStart()
+		if !d.destroyed.Load() {
 			d.sendSeekCmd(startTime)
 		}
-		d.pendingPlayStart = false
+		d.pendingPlayStart.Store(false)
 	} else {
 		go func() {
 			time.Sleep(2 * time.Second)
-			if !d.destroyed {
+			if !d.destroyed.Load() {
 				d.syncPlaybackTime()
 			}
-			d.pendingPlayStart = false
+			d.pendingPlayStart.Store(false)
 		}()
 	}
-	d.state = playing
 	remainingDur := meta.Duration - time.Duration(startTime)*time.Second
 	d.setTrackChangeTimer(remainingDur)
+	d.metaLock.Lock()
+	d.state = playing
 	d.stopwatch.Reset()
 	d.stopwatch.Start()
 	d.lastStartTime = int(startTime)
+	d.metaLock.Unlock()
 	d.InvokeOnPlaying()
 	d.InvokeOnTrackChange()
 	if startTime > 0 {
@@ -233,29 +297,26 @@ func (d *DLNAPlayer) PlayFile(urlstr string, meta mediaprovider.MediaItemMetadat
 }
 
 func (d *DLNAPlayer) playAVTransportMedia(media *avtransport.MediaItem) error {
-	ctx, cancel := context.WithCancel(context.Background())
-	d.cancelRequest = cancel
+	ctx, cancel := d.requestContext(0)
 	defer cancel()
 
+	d.expectState("PLAYING")
 	err := d.avTransport.SetAVTransportMedia(ctx, media)
-	if err != nil {
-		return err
+	if err == nil {
+		err = d.avTransport.Play(ctx)
 	}
-	if err := d.avTransport.Play(ctx); err != nil {
-		return err
+	if err != nil {
+		d.expectState("")
 	}
-	return nil
+	return err
 }
 
 func (d *DLNAPlayer) SetNextFile(url string, meta mediaprovider.MediaItemMetadata) error {
-	if d.destroyed {
+	if d.destroyed.Load() {
 		return nil
 	}
 
 	var media *avtransport.MediaItem
-	d.metaLock.Lock()
-	d.nextTrackMeta = meta
-	d.metaLock.Unlock()
 	if url != "" {
 		d.ensureSetupProxy()
 
@@ -266,100 +327,106 @@ func (d *DLNAPlayer) SetNextFile(url string, meta mediaprovider.MediaItemMetadat
 		// empty media item to signify erasing next track in device queue
 		media = &avtransport.MediaItem{}
 	}
+	d.metaLock.Lock()
+	d.nextTrackMeta = meta
+	d.nextURL = media.URL
+	d.nextMedia = media
+	d.failedToSetNext = false
+	d.metaLock.Unlock()
 
-	ctx, cancel := context.WithCancel(context.Background())
-	d.cancelRequest = cancel
+	ctx, cancel := d.requestContext(0)
 	defer cancel()
-	err := d.avTransport.SetNextAVTransportMedia(ctx, media)
-	if err != nil {
+	if err := d.avTransport.SetNextAVTransportMedia(ctx, media); err != nil {
+		// fall back to starting the next track ourselves when the current one ends
+		log.Printf("DLNA renderer did not accept next track, falling back to non-gapless playback: %v", err)
 		d.metaLock.Lock()
-		d.failedToSetNext = true
-		d.unsetNextMediaItem = media
+		d.failedToSetNext = url != ""
 		d.metaLock.Unlock()
 	}
-	return err
+	return nil
 }
 
 func (d *DLNAPlayer) Continue() error {
-	if d.destroyed || d.state == playing {
+	if d.destroyed.Load() || d.playState() == playing {
 		return nil
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	d.cancelRequest = cancel
+	ctx, cancel := d.requestContext(0)
 	defer cancel()
 
-	if d.pendingSeek {
-		d.pendingSeek = false
-		err := d.avTransport.Seek(ctx, int(d.pendingSeekSecs))
-		if err != nil {
+	d.metaLock.Lock()
+	pendingSeek, seekSecs := d.pendingSeek, d.pendingSeekSecs
+	d.pendingSeek = false
+	d.metaLock.Unlock()
+	if pendingSeek {
+		if err := d.avTransport.Seek(ctx, int(seekSecs)); err != nil {
 			return err
 		}
 	}
 
+	d.expectState("PLAYING")
 	if err := d.avTransport.Play(ctx); err != nil {
+		d.expectState("")
 		return err
 	}
 	d.metaLock.Lock()
 	nextTrackChange := d.curTrackMeta.Duration - d.curPlayPos()
-	d.metaLock.Unlock()
 	d.state = playing
-	d.setTrackChangeTimer(nextTrackChange)
 	d.stopwatch.Start()
+	d.metaLock.Unlock()
+	d.setTrackChangeTimer(nextTrackChange)
 	d.InvokeOnPlaying()
 	return nil
 }
 
 func (d *DLNAPlayer) Pause() error {
-	if d.destroyed || d.state != playing {
+	if d.destroyed.Load() || d.playState() != playing {
 		return nil
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	d.cancelRequest = cancel
+	ctx, cancel := d.requestContext(0)
 	defer cancel()
+	d.expectState("PAUSED_PLAYBACK")
 	if err := d.avTransport.Pause(ctx); err != nil {
+		d.expectState("")
 		return err
 	}
 	d.setTrackChangeTimer(0)
+	d.metaLock.Lock()
 	d.stopwatch.Stop()
 	d.state = paused
+	d.metaLock.Unlock()
 	d.InvokeOnPaused()
 	return nil
 }
 
 func (d *DLNAPlayer) Stop(force bool) error {
-	if d.destroyed {
+	if d.destroyed.Load() {
 		return nil
 	}
-	if force && d.cancelRequest != nil {
-		d.cancelRequest()
+	if force {
+		d.cancelLastRequest()
 	}
 
-	switch d.state {
+	switch d.playState() {
 	case stopped:
 		return nil
 	case playing:
-		var ctx context.Context
-		var cancel context.CancelFunc
+		var timeout time.Duration
 		if force {
-			ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
-		} else {
-			ctx, cancel = context.WithCancel(context.Background())
+			timeout = 2 * time.Second
 		}
-		d.cancelRequest = cancel
+		ctx, cancel := d.requestContext(timeout)
 		defer cancel()
 
+		d.expectState("PAUSED_PLAYBACK")
 		if err := d.avTransport.Pause(ctx); err != nil {
+			d.expectState("")
 			return err
 		}
 		fallthrough
 	case paused:
-		d.setTrackChangeTimer(0)
-		d.stopwatch.Reset()
-		d.lastStartTime = 0
-		d.state = stopped
-		d.InvokeOnStopped()
+		d.setStopped()
 		return nil
 	default:
 		return errors.New("invalid player state")
@@ -367,35 +434,39 @@ func (d *DLNAPlayer) Stop(force bool) error {
 }
 
 func (d *DLNAPlayer) SeekSeconds(secs float64) error {
-	if d.destroyed {
+	if d.destroyed.Load() {
 		return nil
 	}
 
-	if d.state == paused {
+	if d.playState() == paused {
+		d.metaLock.Lock()
 		d.pendingSeek = true
 		d.pendingSeekSecs = secs
+		d.metaLock.Unlock()
 	} else {
 		if err := d.sendSeekCmd(secs); err != nil {
 			return err
 		}
 	}
 
+	d.metaLock.Lock()
 	d.lastStartTime = int(secs)
 	d.stopwatch.Reset()
-
-	if d.state == playing {
-		d.metaLock.Lock()
-		nextTrackChange := d.curTrackMeta.Duration - time.Duration(secs)*time.Second
-		d.metaLock.Unlock()
-		d.setTrackChangeTimer(nextTrackChange)
+	isPlaying := d.state == playing
+	if isPlaying {
 		d.stopwatch.Start()
 	}
+	nextTrackChange := d.curTrackMeta.Duration - time.Duration(secs)*time.Second
+	d.metaLock.Unlock()
+	if isPlaying {
+		d.setTrackChangeTimer(nextTrackChange)
+	}
 
 	d.InvokeOnSeek()
 
 	go func() {
 		time.Sleep(4 * time.Second)
-		if !d.destroyed {
+		if !d.destroyed.Load() {
 			d.syncPlaybackTime()
 		}
 	}()
@@ -403,22 +474,20 @@ func (d *DLNAPlayer) SeekSeconds(secs float64) error {
 }
 
 func (d *DLNAPlayer) sendSeekCmd(secs float64) error {
-	d.seeking = true
+	d.seeking.Store(true)
+	defer d.seeking.Store(false)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	if err := d.avTransport.Seek(ctx, int(secs)); err != nil {
-		d.seeking = false
-		return err
-	}
-	d.seeking = false
-	return nil
+	return d.avTransport.Seek(ctx, int(secs))
 }
 
 func (d *DLNAPlayer) IsSeeking() bool {
-	return d.seeking
+	return d.seeking.Load()
 }
 
 func (d *DLNAPlayer) GetStatus() player.Status {
+	d.metaLock.Lock()
+	defer d.metaLock.Unlock()
 	state := player.Stopped
 	if d.state == playing {
 		state = player.Playing
@@ -427,7 +496,7 @@ func (d *DLNAPlayer) GetStatus() player.Status {
 	}
 
 	var timePos float64
-	if !d.pendingPlayStart {
+	if !d.pendingPlayStart.Load() {
 		timePos = d.curPlayPos().Seconds()
 	}
 	return player.Status{
@@ -437,15 +506,37 @@ func (d *DLNAPlayer) GetStatus() player.Status {
 	}
 }
 
+func (d *DLNAPlayer) playState() int {
+	d.metaLock.Lock()
+	defer d.metaLock.Unlock()
+	return d.state
+}
+
+// setStopped cancels the track change and sets the state to stopped.
+func (d *DLNAPlayer) setStopped() {
+	d.setTrackChangeTimer(0)
+	d.metaLock.Lock()
+	d.stopwatch.Reset()
+	d.lastStartTime = 0
+	d.state = stopped
+	d.metaLock.Unlock()
+	d.InvokeOnStopped()
+}
+
+// curPlayPos returns the playback position. The caller must hold metaLock.
 func (d *DLNAPlayer) curPlayPos() time.Duration {
 	return time.Duration(d.lastStartTime)*time.Second + d.stopwatch.Elapsed()
 }
 
 func (d *DLNAPlayer) Destroy() {
-	d.destroyed = true
+	if d.destroyed.Swap(true) {
+		return
+	}
+	close(d.done)
 	d.setTrackChangeTimer(0)
-	if d.cancelRequest != nil {
-		d.cancelRequest()
+	d.cancelLastRequest()
+	if sub := d.events.Load(); sub != nil {
+		go sub.Close()
 	}
 
 	if d.proxyServer != nil {
@@ -456,15 +547,36 @@ func (d *DLNAPlayer) Destroy() {
 	}
 }
 
+// syncPlaybackTime reconciles the playback position with the renderer's,
+// rescheduling the track change and notifying a seek if they had drifted apart.
 func (d *DLNAPlayer) syncPlaybackTime() {
+	gen := d.trackGen.Load()
 	start := time.Now()
-	if pos, err := d.avTransport.GetPositionInfo(context.Background()); err == nil {
-		d.lastStartTime = int(pos.RelTime.Seconds() + (time.Since(start) / 2).Seconds())
-		d.stopwatch.Reset()
-		if d.state == playing {
-			d.stopwatch.Start()
-		}
-		d.setTrackChangeTimer(d.curTrackMeta.Duration - time.Duration(d.lastStartTime)*time.Second)
+	pos, err := d.avTransport.GetPositionInfo(context.Background())
+	if err != nil || gen != d.trackGen.Load() {
+		// failed, or the track changed during the request
+		return
+	}
+	d.metaLock.Lock()
+	if pos.RelTime == 0 && d.curPlayPos() > maxPositionDrift {
+		// some renderers do not report the position
+		d.metaLock.Unlock()
+		return
+	}
+	relTime := pos.RelTime + time.Since(start)/2
+	drift := relTime - d.curPlayPos()
+	d.lastStartTime = int(relTime.Seconds())
+	d.stopwatch.Reset()
+	isPlaying := d.state == playing
+	if isPlaying {
+		d.stopwatch.Start()
+	}
+	remaining := d.curTrackMeta.Duration - time.Duration(d.lastStartTime)*time.Second
+	d.metaLock.Unlock()
+	if isPlaying {
+		d.setTrackChangeTimer(max(remaining, time.Millisecond))
+	}
+	if drift.Abs() > maxPositionDrift {
 		d.InvokeOnSeek()
 	}
 }
@@ -486,99 +598,91 @@ func (d *DLNAPlayer) ensureSetupProxy() error {
 	}
 	d.proxyPort = listener.Addr().(*net.TCPAddr).Port
 
-	d.proxyServer = &http.Server{
-		Handler: http.HandlerFunc(d.handleRequest),
-	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", d.handleRequest)
+	mux.HandleFunc(eventCallbackPath, d.handleEvent)
+	d.proxyServer = &http.Server{Handler: mux}
 
 	go d.proxyServer.Serve(listener)
+	go d.subscribeEvents()
 	return nil
 }
 
+// setTrackChangeTimer schedules the change to the next track after dur,
+// or cancels the scheduled change if dur is 0.
 func (d *DLNAPlayer) setTrackChangeTimer(dur time.Duration) {
-	if d.timerActive.Swap(true) {
-		// was active
-		d.resetChan <- dur
-		return
+	d.timerLock.Lock()
+	defer d.timerLock.Unlock()
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
 	}
 	if dur == 0 {
-		d.timerActive.Store(false)
 		return
 	}
-
-	d.timer = time.NewTimer(dur)
-	go func() {
-		for {
-			select {
-			case dur := <-d.resetChan:
-				if dur == 0 {
-					d.timerActive.Store(false)
-					if !d.timer.Stop() {
-						select {
-						case <-d.timer.C:
-						default:
-						}
-					}
-					d.timer = nil
-					return
-				}
-				// reset the timer
-				if !d.timer.Stop() {
-					select {
-					case <-d.timer.C:
-					default:
-					}
-				}
-				d.timer.Reset(dur)
-			case <-d.timer.C:
-				d.timerActive.Store(false)
-				d.timer = nil
-				d.handleOnTrackChange()
-				return
-			}
+	if d.eventsActive.Load() {
+		// the renderer's events signal the track change;
+		// the timer is only a fallback in case they are missed
+		dur += eventGracePeriod
+	}
+	gen := d.trackGen.Load()
+	d.timer = time.AfterFunc(dur, func() {
+		if !d.destroyed.Load() {
+			d.handleOnTrackChange(gen, false)
 		}
-	}()
+	})
 }
 
-func (d *DLNAPlayer) handleOnTrackChange() {
-	stopping := false
-	d.metaLock.Lock()
-	if d.nextTrackMeta.ID == "" {
-		stopping = true
+// handleOnTrackChange changes from the track of generation gen to the next.
+// advanced is true if the renderer is known to be playing the next track.
+func (d *DLNAPlayer) handleOnTrackChange(gen uint64, advanced bool) {
+	if !d.trackGen.CompareAndSwap(gen, gen+1) {
+		return // already handled
 	}
+	d.metaLock.Lock()
+	stopping := d.nextTrackMeta.ID == ""
 	d.curTrackMeta = d.nextTrackMeta
 	d.nextTrackMeta = mediaprovider.MediaItemMetadata{}
+	d.curURL, d.nextURL = d.nextURL, ""
 	nextTrackChange := d.curTrackMeta.Duration
+	media := d.nextMedia
+	needToPlay := d.failedToSetNext
+	d.nextMedia, d.failedToSetNext = nil, false
 	d.metaLock.Unlock()
 
 	if stopping {
-		d.lastStartTime = 0
-		d.stopwatch.Reset()
-		d.InvokeOnStopped()
-	} else {
-		d.metaLock.Lock()
-		if d.failedToSetNext {
-			d.failedToSetNext = false
-			media := d.unsetNextMediaItem
-			d.unsetNextMediaItem = nil
-			d.metaLock.Unlock()
-			d.playAVTransportMedia(media)
-		} else {
-			d.metaLock.Unlock()
+		d.setStopped()
+		return
+	}
+
+	if !needToPlay && !advanced {
+		// verify that the renderer did go on to the next track,
+		// as some accept SetNextAVTransportURI but ignore it
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		info, err := d.avTransport.GetTransportInfo(ctx)
+		cancel()
+		needToPlay = err == nil && (info.State == "STOPPED" || info.State == "NO_MEDIA_PRESENT")
+	}
+	if needToPlay && media != nil {
+		if err := d.playAVTransportMedia(media); err != nil {
+			log.Printf("failed to play next track on DLNA renderer: %v", err)
 		}
+	}
 
-		d.lastStartTime = 0
-		d.stopwatch.Reset()
-		d.stopwatch.Start()
-		d.setTrackChangeTimer(nextTrackChange)
-		d.InvokeOnTrackChange()
+	d.metaLock.Lock()
+	d.lastStartTime = 0
+	d.stopwatch.Reset()
+	d.stopwatch.Start()
+	d.metaLock.Unlock()
+	d.setTrackChangeTimer(nextTrackChange)
+	d.InvokeOnTrackChange()
 
-		go func() {
-			time.Sleep(5 * time.Second)
-			if !d.destroyed {
-				d.syncPlaybackTime()
-			}
-		}()
-	}
+	go func() {
+		time.Sleep(5 * time.Second)
+		if !d.destroyed.Load() {
+			d.syncPlaybackTime()
+		}
+	}()
 }
 
 func (d *DLNAPlayer) urlForItem(key string) string {
@@ -611,8 +715,11 @@ func (d *DLNAPlayer) handleRequest(w http.ResponseWriter, r *http.Request) {
 	// Copy headers from the original request to the new request
 	proxyReq.Header = r.Header
 
-	// Create an HTTP client and send the request
-	client := &http.Client{}
+	// Send the request with the server's HTTP client
+	client := d.proxyClient
+	if client == nil {
+		client = http.DefaultClient
+	}
 	resp, err := client.Do(proxyReq)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadGateway)
@@ -0,0 +1,277 @@
+package dlna
+
+import (
+	"cmp"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/upnp"
+)
+
+const (
+	// path on the proxy server to which the renderer sends its events
+	eventCallbackPath = "/event"
+
+	// how long past the expected end of a track the track change
+	// timer waits for the renderer's event before changing tracks
+	eventGracePeriod = 3 * time.Second
+
+	// how long the renderer's events are not acted on after a command
+	// sent by us, unless it reports the expected state sooner
+	expectStateTimeout = 10 * time.Second
+
+	// how often the playback position is reconciled with the renderer's
+	positionPollInterval = 10 * time.Second
+
+	// larger differences from the renderer's position are notified as seeks
+	maxPositionDrift = 2 * time.Second
+
+	// a stop reported by the renderer this close to the end of
+	// the track is taken as the end of the track
+	endOfTrackTolerance = 10 * time.Second
+)
+
+// subscribeEvents subscribes to the renderer's AVTransport events, by which
+// state and track changes are detected. If the renderer does not support
+// events, the player falls back to timing the track changes itself.
+func (d *DLNAPlayer) subscribeEvents() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	eventURL, err := avTransportEventURL(ctx, d.descURL)
+	if err != nil {
+		log.Printf("DLNA renderer does not support events: %v", err)
+		return
+	}
+	sub := &upnp.Subscriber{
+		EventURL:    eventURL,
+		CallbackURL: fmt.Sprintf("http://%s:%d%s", d.localIP, d.proxyPort, eventCallbackPath),
+		OnEvent:     d.handleAVTransportEvent,
+	}
+	d.events.Store(sub)
+	if err := sub.Subscribe(ctx); err != nil {
+		log.Printf("failed to subscribe to DLNA renderer events: %v", err)
+		d.events.Store(nil)
+		return
+	}
+	if d.destroyed.Load() {
+		sub.Close()
+		return
+	}
+	d.eventsActive.Store(true)
+}
+
+func (d *DLNAPlayer) handleEvent(w http.ResponseWriter, r *http.Request) {
+	if sub := d.events.Load(); sub != nil {
+		sub.ServeHTTP(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusPreconditionFailed)
+}
+
+func (d *DLNAPlayer) handleAVTransportEvent(vars map[string]string) {
+	if d.destroyed.Load() || vars["LastChange"] == "" {
+		return
+	}
+	lc, err := upnp.ParseLastChange(vars["LastChange"])
+	if err != nil {
+		log.Printf("invalid LastChange event from DLNA renderer: %v", err)
+		return
+	}
+	state := lc["TransportState"]
+	trackURI := cmp.Or(lc["CurrentTrackURI"], lc["AVTransportURI"])
+
+	d.metaLock.Lock()
+	gen := d.trackGen.Load()
+	advanced := trackURI != "" && d.nextURL != "" && !d.failedToSetNext &&
+		sameURL(trackURI, d.nextURL) && !sameURL(trackURI, d.curURL)
+	expected := d.expectedState
+	if expected != "" && time.Now().After(d.expectedUntil) {
+		expected, d.expectedState = "", ""
+	}
+	confirmed := state != "" && state == expected
+	if confirmed {
+		d.expectedState = ""
+	}
+	d.metaLock.Unlock()
+
+	if advanced {
+		// the renderer went on to the next track by itself (gapless)
+		d.handleOnTrackChange(gen, true)
+		return
+	}
+	if state == "" || (expected != "" && !confirmed) {
+		// no state change, or a transitional state while
+		// the renderer is carrying out our command
+		return
+	}
+	if confirmed {
+		if state == "PLAYING" {
+			d.syncPlaybackTime()
+		}
+		return
+	}
+
+	// state changes made on the renderer itself
+	d.metaLock.Lock()
+	prevState := d.state
+	atEnd := d.curPlayPos() >= d.curTrackMeta.Duration-endOfTrackTolerance
+	switch {
+	case state == "PLAYING" && prevState == paused:
+		d.state = playing
+		d.stopwatch.Start()
+	case state == "PAUSED_PLAYBACK" && prevState == playing:
+		d.stopwatch.Stop()
+		d.state = paused
+	}
+	d.metaLock.Unlock()
+
+	switch state {
+	case "PLAYING":
+		if prevState == paused {
+			d.InvokeOnPlaying()
+			// also reschedules the track change
+			d.syncPlaybackTime()
+		}
+	case "PAUSED_PLAYBACK":
+		if prevState == playing {
+			d.setTrackChangeTimer(0)
+			d.InvokeOnPaused()
+		}
+	case "STOPPED", "NO_MEDIA_PRESENT":
+		if prevState != playing {
+			return
+		}
+		if atEnd {
+			// the renderer finished the track without going on to the next
+			d.handleOnTrackChange(gen, false)
+		} else {
+			d.setStopped()
+		}
+	}
+}
+
+// expectState marks that the renderer is about to report the given
+// transport state because of a command sent by us. An empty state
+// clears the expectation, e.g. if the command failed.
+func (d *DLNAPlayer) expectState(state string) {
+	d.metaLock.Lock()
+	d.expectedState = state
+	d.expectedUntil = time.Now().Add(expectStateTimeout)
+	d.metaLock.Unlock()
+}
+
+// waitForPlaybackStart waits for the renderer to begin
+// playing media which was just sent to it.
+func (d *DLNAPlayer) waitForPlaybackStart() {
+	if !d.eventsActive.Load() {
+		time.Sleep(2 * time.Second)
+		return
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		d.metaLock.Lock()
+		started := d.expectedState != "PLAYING"
+		d.metaLock.Unlock()
+		if started || d.destroyed.Load() {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// pollPosition periodically reconciles the playback position with the
+// renderer's, so that time-based track changes and scrobbles stay accurate.
+func (d *DLNAPlayer) pollPosition() {
+	t := time.NewTicker(positionPollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-d.done:
+			return
+		case <-t.C:
+			if d.playState() == playing && !d.seeking.Load() && !d.pendingPlayStart.Load() {
+				d.syncPlaybackTime()
+			}
+		}
+	}
+}
+
+// avTransportEventURL reads the event subscription URL of the renderer's
+// AVTransport service from its device description.
+func avTransportEventURL(ctx context.Context, descURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, descURL, nil)
+	if err != nil {
+		return "", err
+	}
+	// the renderer is on the local network, so don't use the app's proxy
+	resp, err := upnp.LocalHTTPClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var desc struct {
+		URLBase string             `xml:"URLBase"`
+		Device  deviceDescServices `xml:"device"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&desc); err != nil {
+		return "", err
+	}
+	eventSubURL := desc.Device.findEventSubURL("urn:schemas-upnp-org:service:AVTransport:")
+	if eventSubURL == "" {
+		return "", errors.New("no AVTransport event URL")
+	}
+
+	base, err := url.Parse(cmp.Or(desc.URLBase, descURL))
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(eventSubURL, "/") && !strings.Contains(eventSubURL, "://") {
+		// like the control URLs, relative URLs are taken from the root
+		eventSubURL = "/" + eventSubURL
+	}
+	ref, err := url.Parse(eventSubURL)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+type deviceDescServices struct {
+	Services []struct {
+		Type        string `xml:"serviceType"`
+		EventSubURL string `xml:"eventSubURL"`
+	} `xml:"serviceList>service"`
+	Devices []deviceDescServices `xml:"deviceList>device"`
+}
+
+func (d *deviceDescServices) findEventSubURL(serviceTypePrefix string) string {
+	for _, s := range d.Services {
+		if strings.HasPrefix(s.Type, serviceTypePrefix) && s.EventSubURL != "" {
+			return s.EventSubURL
+		}
+	}
+	for i := range d.Devices {
+		if u := d.Devices[i].findEventSubURL(serviceTypePrefix); u != "" {
+			return u
+		}
+	}
+	return ""
+}
+
+// sameURL compares URLs as echoed back by renderers,
+// some of which change their escaping.
+func sameURL(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ua, err1 := url.PathUnescape(a)
+	ub, err2 := url.PathUnescape(b)
+	return err1 == nil && err2 == nil && ua == ub
+}
@@ -0,0 +1,327 @@
+package dlna
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/upnp"
+	"github.com/supersonic-app/go-upnpcast/services/avtransport"
+)
+
+const (
+	testCurURL  = "http://192.168.1.2:8080/a%20b"
+	testNextURL = "http://192.168.1.2:8080/c%20d"
+)
+
+// playerCallbacks counts the player's callbacks.
+type playerCallbacks struct {
+	playing, paused, stopped, trackChange int
+}
+
+// newTestPlayer returns a player playing the first of two tracks, 30s into it,
+// on a renderer which fails all requests.
+func newTestPlayer(t *testing.T) (*DLNAPlayer, *playerCallbacks) {
+	renderer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(renderer.Close)
+
+	d := &DLNAPlayer{
+		avTransport:   avtransport.NewClient(renderer.URL, ""),
+		done:          make(chan struct{}),
+		state:         playing,
+		curTrackMeta:  mediaprovider.MediaItemMetadata{ID: "1", Duration: 3 * time.Minute},
+		nextTrackMeta: mediaprovider.MediaItemMetadata{ID: "2", Duration: 4 * time.Minute},
+		curURL:        testCurURL,
+		nextURL:       testNextURL,
+		lastStartTime: 30,
+	}
+	d.eventsActive.Store(true)
+	t.Cleanup(d.Destroy)
+
+	cb := &playerCallbacks{}
+	d.OnPlaying(func() { cb.playing++ })
+	d.OnPaused(func() { cb.paused++ })
+	d.OnStopped(func() { cb.stopped++ })
+	d.OnTrackChange(func() { cb.trackChange++ })
+	return d, cb
+}
+
+func lastChangeEvent(vars ...upnp.Arg) map[string]string {
+	return map[string]string{
+		"LastChange": upnp.LastChange("urn:schemas-upnp-org:metadata-1-0/AVT/", vars, ""),
+	}
+}
+
+func TestHandleAVTransportEvent(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		setup func(d *DLNAPlayer)
+		event map[string]string
+
+		wantState  int
+		wantCurURL string
+		wantExpect string
+		wantGen    uint64
+		wantCalls  playerCallbacks
+	}{
+		{
+			name:       "renderer advanced to next track",
+			event:      lastChangeEvent(upnp.Arg{Name: "CurrentTrackURI", Value: testNextURL}),
+			wantState:  playing,
+			wantCurURL: testNextURL,
+			wantGen:    1,
+			wantCalls:  playerCallbacks{trackChange: 1},
+		},
+		{
+			name:       "next track URL echoed with different escaping",
+			event:      lastChangeEvent(upnp.Arg{Name: "AVTransportURI", Value: "http://192.168.1.2:8080/c d"}),
+			wantState:  playing,
+			wantCurURL: testNextURL,
+			wantGen:    1,
+			wantCalls:  playerCallbacks{trackChange: 1},
+		},
+		{
+			name:       "current track reported",
+			event:      lastChangeEvent(upnp.Arg{Name: "CurrentTrackURI", Value: testCurURL}),
+			wantState:  playing,
+			wantCurURL: testCurURL,
+		},
+		{
+			name: "next track not set on renderer",
+			setup: func(d *DLNAPlayer) {
+				d.failedToSetNext = true
+			},
+			event:      lastChangeEvent(upnp.Arg{Name: "CurrentTrackURI", Value: testNextURL}),
+			wantState:  playing,
+			wantCurURL: testCurURL,
+		},
+		{
+			name:       "no LastChange",
+			event:      map[string]string{"Other": "1"},
+			wantState:  playing,
+			wantCurURL: testCurURL,
+		},
+		{
+			name: "transitional state while expecting",
+			setup: func(d *DLNAPlayer) {
+				d.expectState("PAUSED_PLAYBACK")
+			},
+			event:      lastChangeEvent(upnp.Arg{Name: "TransportState", Value: "TRANSITIONING"}),
+			wantState:  playing,
+			wantCurURL: testCurURL,
+			wantExpect: "PAUSED_PLAYBACK",
+		},
+		{
+			name: "expected state confirmed",
+			setup: func(d *DLNAPlayer) {
+				d.state = paused
+				d.expectState("PAUSED_PLAYBACK")
+			},
+			event:      lastChangeEvent(upnp.Arg{Name: "TransportState", Value: "PAUSED_PLAYBACK"}),
+			wantState:  paused,
+			wantCurURL: testCurURL,
+		},
+		{
+			name: "expected state timed out",
+			setup: func(d *DLNAPlayer) {
+				d.expectState("PLAYING")
+				d.expectedUntil = time.Now().Add(-time.Second)
+			},
+			event:      lastChangeEvent(upnp.Arg{Name: "TransportState", Value: "PAUSED_PLAYBACK"}),
+			wantState:  paused,
+			wantCurURL: testCurURL,
+			wantCalls:  playerCallbacks{paused: 1},
+		},
+		{
+			name:       "paused on renderer",
+			event:      lastChangeEvent(upnp.Arg{Name: "TransportState", Value: "PAUSED_PLAYBACK"}),
+			wantState:  paused,
+			wantCurURL: testCurURL,
+			wantCalls:  playerCallbacks{paused: 1},
+		},
+		{
+			name: "resumed on renderer",
+			setup: func(d *DLNAPlayer) {
+				d.state = paused
+			},
+			event:      lastChangeEvent(upnp.Arg{Name: "TransportState", Value: "PLAYING"}),
+			wantState:  playing,
+			wantCurURL: testCurURL,
+			wantCalls:  playerCallbacks{playing: 1},
+		},
+		{
+			name:       "stopped on renderer mid-track",
+			event:      lastChangeEvent(upnp.Arg{Name: "TransportState", Value: "STOPPED"}),
+			wantState:  stopped,
+			wantCurURL: testCurURL,
+			wantCalls:  playerCallbacks{stopped: 1},
+		},
+		{
+			name: "stopped while paused",
+			setup: func(d *DLNAPlayer) {
+				d.state = paused
+			},
+			event:      lastChangeEvent(upnp.Arg{Name: "TransportState", Value: "STOPPED"}),
+			wantState:  paused,
+			wantCurURL: testCurURL,
+		},
+		{
+			name: "end of track",
+			setup: func(d *DLNAPlayer) {
+				d.lastStartTime = 175
+			},
+			event:      lastChangeEvent(upnp.Arg{Name: "TransportState", Value: "STOPPED"}),
+			wantState:  playing,
+			wantCurURL: testNextURL,
+			wantGen:    1,
+			wantCalls:  playerCallbacks{trackChange: 1},
+		},
+		{
+			name: "end of last track",
+			setup: func(d *DLNAPlayer) {
+				d.lastStartTime = 175
+				d.nextTrackMeta = mediaprovider.MediaItemMetadata{}
+				d.nextURL = ""
+			},
+			event:     lastChangeEvent(upnp.Arg{Name: "TransportState", Value: "NO_MEDIA_PRESENT"}),
+			wantState: stopped,
+			wantGen:   1,
+			wantCalls: playerCallbacks{stopped: 1},
+		},
+		{
+			name: "destroyed",
+			setup: func(d *DLNAPlayer) {
+				d.destroyed.Store(true)
+			},
+			event:      lastChangeEvent(upnp.Arg{Name: "TransportState", Value: "STOPPED"}),
+			wantState:  playing,
+			wantCurURL: testCurURL,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			d, cb := newTestPlayer(t)
+			if tt.setup != nil {
+				tt.setup(d)
+			}
+			d.handleAVTransportEvent(tt.event)
+
+			d.metaLock.Lock()
+			defer d.metaLock.Unlock()
+			if d.state != tt.wantState {
+				t.Errorf("state = %d, want %d", d.state, tt.wantState)
+			}
+			if d.curURL != tt.wantCurURL {
+				t.Errorf("curURL = %q, want %q", d.curURL, tt.wantCurURL)
+			}
+			if d.expectedState != tt.wantExpect {
+				t.Errorf("expectedState = %q, want %q", d.expectedState, tt.wantExpect)
+			}
+			if gen := d.trackGen.Load(); gen != tt.wantGen {
+				t.Errorf("trackGen = %d, want %d", gen, tt.wantGen)
+			}
+			if *cb != tt.wantCalls {
+				t.Errorf("callbacks = %+v, want %+v", *cb, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestHandleOnTrackChangeGenerations(t *testing.T) {
+	d, cb := newTestPlayer(t)
+
+	// a stale generation is ignored
+	d.trackGen.Store(3)
+	d.handleOnTrackChange(2, true)
+	if cb.trackChange != 0 || d.curURL != testCurURL {
+		t.Fatalf("track changed for stale generation")
+	}
+
+	// the same change detected twice, e.g. by an event and the timer
+	d.handleOnTrackChange(3, true)
+	d.handleOnTrackChange(3, false)
+	if cb.trackChange != 1 {
+		t.Errorf("track change callbacks = %d, want 1", cb.trackChange)
+	}
+	if gen := d.trackGen.Load(); gen != 4 {
+		t.Errorf("trackGen = %d, want 4", gen)
+	}
+	d.metaLock.Lock()
+	defer d.metaLock.Unlock()
+	if d.curURL != testNextURL || d.curTrackMeta.ID != "2" {
+		t.Errorf("current track = %q (%s), want %q (2)", d.curURL, d.curTrackMeta.ID, testNextURL)
+	}
+	if d.nextURL != "" || d.nextTrackMeta.ID != "" {
+		t.Errorf("next track not cleared: %q (%s)", d.nextURL, d.nextTrackMeta.ID)
+	}
+}
+
+func TestSameURL(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want bool
+	}{
+		{"http://h/a", "http://h/a", true},
+		{"http://h/a%20b", "http://h/a b", true},
+		{"http://h/a%2Fb", "http://h/a/b", true},
+		{"http://h/a", "http://h/b", false},
+		{"http://h/a%zz", "http://h/a", false},
+		{"", "http://h/a", false},
+	} {
+		if got := sameURL(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameURL(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAVTransportEventURL(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		urlBase string
+		subURL  string
+		want    string // path, or absolute URL if it has a scheme
+	}{
+		{name: "absolute path", subURL: "/AVTransport/event", want: "/AVTransport/event"},
+		{name: "relative path", subURL: "AVTransport/event", want: "/AVTransport/event"},
+		{name: "URLBase", urlBase: "http://10.0.0.5:1400/", subURL: "evt", want: "http://10.0.0.5:1400/evt"},
+		{name: "absolute URL", subURL: "http://10.0.0.6/evt", want: "http://10.0.0.6/evt"},
+		{name: "missing"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">`))
+				if tt.urlBase != "" {
+					w.Write([]byte("<URLBase>" + tt.urlBase + "</URLBase>"))
+				}
+				w.Write([]byte(`<device><serviceList><service>
+<serviceType>urn:schemas-upnp-org:service:RenderingControl:1</serviceType>
+<eventSubURL>/RenderingControl/event</eventSubURL>
+</service></serviceList><deviceList><device><serviceList><service>
+<serviceType>urn:schemas-upnp-org:service:AVTransport:1</serviceType>
+<eventSubURL>` + tt.subURL + `</eventSubURL>
+</service></serviceList></device></deviceList></device></root>`))
+			}))
+			defer srv.Close()
+
+			got, err := avTransportEventURL(context.Background(), srv.URL+"/desc/device.xml")
+			if tt.subURL == "" {
+				if err == nil {
+					t.Errorf("expected error, got %q", got)
+				}
+				return
+			}
+			want := tt.want
+			if want[0] == '/' {
+				want = srv.URL + want
+			}
+			if err != nil || got != want {
+				t.Errorf("avTransportEventURL = %q, %v; want %q", got, err, want)
+			}
+		})
+	}
+}
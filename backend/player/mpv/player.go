@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -51,6 +53,23 @@ type MediaInfo struct {
 
 var _ player.URLPlayer = (*Player)(nil)
 
+// StreamOptions are the HTTP and TLS settings for streaming from a server.
+// They apply only to URLs on the server's hosts, so that e.g. credentials
+// in the headers are not sent to radio stations.
+type StreamOptions struct {
+	// lowercase host[:port] of each of the server's hostnames
+	Hosts []string
+
+	Headers map[string]string
+
+	// PEM files of the CA bundle, client certificate and key
+	CAFile   string
+	CertFile string
+	KeyFile  string
+
+	SkipTLSVerify bool
+}
+
 // Player encapsulates the mpv instance and provides functions
 // to control it and to check its status.
 type Player struct {
@@ -68,6 +87,9 @@ type Player struct {
 	lenPlaylist    int64
 	prePausedState player.State
 	clientName     string
+	httpProxy      string
+	streamOpts     StreamOptions
+	streamOptsLock sync.Mutex
 	equalizer      Equalizer
 	peaksEnabled   bool
 	pauseFade      bool
@@ -124,6 +146,9 @@ func (p *Player) Init(maxCacheMB int) error {
 		m.SetOption("volume", mpv.FORMAT_INT64, p.vol)
 
 		p.SetAudioExclusive(p.audioExclusive)
+		if p.httpProxy != "" {
+			m.SetOptionString("http-proxy", p.httpProxy)
+		}
 		if p.haveRGainOpts {
 			p.SetReplayGainOptions(p.replayGainOpts)
 		}
@@ -152,7 +177,7 @@ func (p *Player) PlayFile(url string, _ mediaprovider.MediaItemMetadata, startTi
 	if !p.initialized {
 		return ErrUnitialized
 	}
-	err := p.mpv.Command([]string{"loadfile", url, "replace"})
+	err := p.loadFile(url, "replace")
 	if err != nil {
 		return err
 	}
@@ -204,7 +229,7 @@ func (p *Player) SetNextFile(url string, _ mediaprovider.MediaItemMetadata) erro
 		return nil
 	}
 
-	err := p.mpv.Command([]string{"loadfile", url, "append"})
+	err := p.loadFile(url, "append")
 	if err == nil {
 		p.lenPlaylist++
 	}
@@ -223,6 +248,27 @@ func (p *Player) SeekSeconds(secs float64) error {
 	return err
 }
 
+// Sets the A-B loop points of the currently playing track.
+// Negative values clear the loop.
+func (p *Player) SetABLoop(a, b float64) error {
+	if !p.initialized {
+		return ErrUnitialized
+	}
+	for _, prop := range []struct {
+		name string
+		val  float64
+	}{{"ab-loop-a", a}, {"ab-loop-b", b}} {
+		val := "no"
+		if prop.val >= 0 {
+			val = fmt.Sprintf("%0.3f", prop.val)
+		}
+		if err := p.mpv.SetPropertyString(prop.name, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Sets the volume of the player (0-100).
 // Unlike most Player functions, SetVolume can be called before Init,
 // to set the initial volume of the player on startup.
@@ -289,6 +335,82 @@ func (p *Player) SetAudioExclusive(tf bool) {
 	}
 }
 
+// Sets the HTTP proxy used for streaming, or "" to use the http_proxy
+// environment variable. Like SetAudioExclusive, it can be called before Init.
+func (p *Player) SetHTTPProxy(proxyURL string) {
+	p.httpProxy = proxyURL
+	if p.initialized {
+		p.mpv.SetOptionString("http-proxy", proxyURL)
+	}
+}
+
+// Sets the HTTP headers and TLS settings used for streaming from the server.
+// Like SetAudioExclusive, it can be called before Init.
+func (p *Player) SetStreamOptions(opts StreamOptions) {
+	p.streamOptsLock.Lock()
+	defer p.streamOptsLock.Unlock()
+	p.streamOpts = opts
+}
+
+// loadFile runs mpv's loadfile command for the given URL, passing the
+// stream options as per-file options if it is on one of the server's hosts
+// so that the file already playing is unaffected.
+func (p *Player) loadFile(rawURL, flags string) error {
+	args := map[string]*mpv.Node{
+		"name":  {Data: "loadfile", Format: mpv.FORMAT_STRING},
+		"url":   {Data: rawURL, Format: mpv.FORMAT_STRING},
+		"flags": {Data: flags, Format: mpv.FORMAT_STRING},
+	}
+	if opts := p.fileStreamOptions(rawURL); len(opts) > 0 {
+		args["options"] = &mpv.Node{Data: opts, Format: mpv.FORMAT_NODE_MAP}
+	}
+	return p.mpv.CommandNode(mpv.Node{Data: args, Format: mpv.FORMAT_NODE_MAP}, &mpv.Node{})
+}
+
+// fileStreamOptions returns the mpv per-file options for loading the given URL,
+// or nil if it is not on one of the server's hosts.
+func (p *Player) fileStreamOptions(rawURL string) map[string]*mpv.Node {
+	p.streamOptsLock.Lock()
+	opts := p.streamOpts
+	p.streamOptsLock.Unlock()
+
+	u, err := url.Parse(rawURL)
+	if err != nil || !slices.Contains(opts.Hosts, strings.ToLower(u.Host)) {
+		return nil
+	}
+	fileOpts := make(map[string]*mpv.Node)
+	set := func(name, value string) {
+		fileOpts[name] = &mpv.Node{Data: value, Format: mpv.FORMAT_STRING}
+	}
+	if len(opts.Headers) > 0 {
+		// string list option: commas separate the entries and must be escaped
+		escaper := strings.NewReplacer(`\`, `\\`, ",", `\,`)
+		headers := make([]string, 0, len(opts.Headers))
+		for name, value := range opts.Headers {
+			headers = append(headers, escaper.Replace(name+": "+value))
+		}
+		slices.Sort(headers)
+		set("http-header-fields", strings.Join(headers, ","))
+	}
+	if opts.CAFile != "" {
+		set("tls-ca-file", opts.CAFile)
+		// mpv does not verify certificates by default
+		if !opts.SkipTLSVerify {
+			set("tls-verify", "yes")
+		}
+	}
+	if opts.CertFile != "" {
+		set("tls-cert-file", opts.CertFile)
+		keyFile := opts.KeyFile
+		if keyFile == "" {
+			// key may be bundled in the same PEM file as the certificate
+			keyFile = opts.CertFile
+		}
+		set("tls-key-file", keyFile)
+	}
+	return fileOpts
+}
+
 func (p *Player) SetPauseFade(pauseFade bool) {
 	p.pauseFade = pauseFade
 }
@@ -43,6 +43,14 @@ type ReplayGainPlayer interface {
 	SetReplayGainOptions(ReplayGainOptions) error
 }
 
+// ABLoopPlayer is implemented by players which can natively
+// loop a section of the current track.
+type ABLoopPlayer interface {
+	// SetABLoop loops playback between a and b (in seconds).
+	// Negative values clear the loop.
+	SetABLoop(a, b float64) error
+}
+
 // The playback state (Stopped, Paused, or Playing).
 type State int
 
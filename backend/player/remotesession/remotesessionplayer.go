@@ -0,0 +1,316 @@
+package remotesession
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/player"
+	"github.com/dweymouth/supersonic/backend/util"
+)
+
+const (
+	stopped = 0
+	playing = 1
+	paused  = 2
+)
+
+const (
+	// interval at which the status of the remote session is polled
+	pollInterval = time.Second
+
+	// how long to wait for the remote session to begin playing a
+	// track before assuming it failed to, and treating it as stopped
+	playStartTimeout = 15 * time.Second
+)
+
+// RemoteSessionPlayer is a player which remotely controls another
+// client session of the user on the server, such as the Jellyfin
+// app on a TV. The session plays one track at a time, so the next
+// track is started by the player once the current one has ended.
+type RemoteSessionPlayer struct {
+	player.BasePlayerCallbackImpl
+
+	provider  mediaprovider.RemoteSessionProvider
+	sessionID string
+
+	mu      sync.Mutex
+	state   int // stopped, playing, paused
+	volume  int
+	seeking bool
+
+	curTrack  *mediaprovider.Track
+	nextTrack *mediaprovider.Track
+	// true once the session has been seen playing curTrack
+	confirmed bool
+	playedAt  time.Time
+
+	// start playback position in seconds of the last status sync
+	lastStartTime float64
+	// how long the track has been playing since the last status sync
+	stopwatch util.Stopwatch
+
+	cancelPoll context.CancelFunc
+}
+
+// NewRemoteSessionPlayer creates a player controlling the given session.
+func NewRemoteSessionPlayer(provider mediaprovider.RemoteSessionProvider, sessionID string) (*RemoteSessionPlayer, error) {
+	status, err := provider.RemoteSessionGetStatus(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &RemoteSessionPlayer{
+		provider:   provider,
+		sessionID:  sessionID,
+		volume:     status.Volume,
+		cancelPoll: cancel,
+	}
+	go r.poll(ctx)
+	return r, nil
+}
+
+func (r *RemoteSessionPlayer) SetVolume(vol int) error {
+	if err := r.provider.RemoteSessionSetVolume(r.sessionID, vol); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.volume = vol
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *RemoteSessionPlayer) GetVolume() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.volume
+}
+
+func (r *RemoteSessionPlayer) PlayTrack(track *mediaprovider.Track, startTime float64) error {
+	if track == nil {
+		return errors.New("no track to play")
+	}
+	if err := r.provider.RemoteSessionPlay(r.sessionID, track.ID, startTime); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.setCurrentTrack(track, startTime)
+	r.mu.Unlock()
+
+	r.InvokeOnPlaying()
+	r.InvokeOnTrackChange()
+	if startTime > 0 {
+		r.InvokeOnSeek()
+	}
+	return nil
+}
+
+// must be called with mu held
+func (r *RemoteSessionPlayer) setCurrentTrack(track *mediaprovider.Track, startTime float64) {
+	r.curTrack = track
+	r.confirmed = false
+	r.playedAt = time.Now()
+	r.state = playing
+	r.lastStartTime = startTime
+	r.stopwatch.Reset()
+	r.stopwatch.Start()
+}
+
+func (r *RemoteSessionPlayer) SetNextTrack(track *mediaprovider.Track) error {
+	r.mu.Lock()
+	r.nextTrack = track
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *RemoteSessionPlayer) Continue() error {
+	r.mu.Lock()
+	state := r.state
+	r.mu.Unlock()
+	if state != paused {
+		return nil
+	}
+	if err := r.provider.RemoteSessionUnpause(r.sessionID); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.state = playing
+	r.stopwatch.Start()
+	r.mu.Unlock()
+	r.InvokeOnPlaying()
+	return nil
+}
+
+func (r *RemoteSessionPlayer) Pause() error {
+	r.mu.Lock()
+	state := r.state
+	r.mu.Unlock()
+	if state != playing {
+		return nil
+	}
+	if err := r.provider.RemoteSessionPause(r.sessionID); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.state = paused
+	r.stopwatch.Stop()
+	r.mu.Unlock()
+	r.InvokeOnPaused()
+	return nil
+}
+
+func (r *RemoteSessionPlayer) Stop(_ bool) error {
+	r.mu.Lock()
+	state := r.state
+	r.mu.Unlock()
+	if state == stopped {
+		return nil
+	}
+	if err := r.provider.RemoteSessionStop(r.sessionID); err != nil {
+		return err
+	}
+	r.setStopped()
+	r.InvokeOnStopped()
+	return nil
+}
+
+func (r *RemoteSessionPlayer) setStopped() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state = stopped
+	r.curTrack = nil
+	r.lastStartTime = 0
+	r.stopwatch.Reset()
+}
+
+func (r *RemoteSessionPlayer) SeekSeconds(secs float64) error {
+	r.mu.Lock()
+	r.seeking = true
+	r.mu.Unlock()
+	err := r.provider.RemoteSessionSeek(r.sessionID, secs)
+	r.mu.Lock()
+	r.seeking = false
+	if err == nil {
+		r.lastStartTime = secs
+		r.stopwatch.Reset()
+		if r.state == playing {
+			r.stopwatch.Start()
+		}
+	}
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	r.InvokeOnSeek()
+	return nil
+}
+
+func (r *RemoteSessionPlayer) IsSeeking() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.seeking
+}
+
+func (r *RemoteSessionPlayer) GetStatus() player.Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state := player.Stopped
+	if r.state == playing {
+		state = player.Playing
+	} else if r.state == paused {
+		state = player.Paused
+	}
+	var duration float64
+	if r.curTrack != nil {
+		duration = r.curTrack.Duration.Seconds()
+	}
+	return player.Status{
+		State:    state,
+		TimePos:  r.lastStartTime + r.stopwatch.Elapsed().Seconds(),
+		Duration: duration,
+	}
+}
+
+func (r *RemoteSessionPlayer) Destroy() {
+	r.cancelPoll()
+}
+
+func (r *RemoteSessionPlayer) poll(ctx context.Context) {
+	t := time.NewTicker(pollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		status, err := r.provider.RemoteSessionGetStatus(r.sessionID)
+		if err != nil {
+			log.Printf("error getting remote session status: %s", err.Error())
+			continue
+		}
+		if ctx.Err() == nil {
+			r.syncStatus(status)
+		}
+	}
+}
+
+// syncStatus updates the player from the status of the remote session,
+// which may also have been changed by the user on the remote device.
+func (r *RemoteSessionPlayer) syncStatus(status *mediaprovider.RemoteSessionStatus) {
+	r.mu.Lock()
+	r.volume = status.Volume
+	if r.state == stopped || r.curTrack == nil || r.seeking {
+		r.mu.Unlock()
+		return
+	}
+
+	if status.NowPlayingID == r.curTrack.ID {
+		r.confirmed = true
+		r.lastStartTime = status.PositionSeconds
+		r.stopwatch.Reset()
+		var invoke func()
+		if status.Paused && r.state == playing {
+			r.state = paused
+			invoke = r.InvokeOnPaused
+		} else if !status.Paused {
+			r.stopwatch.Start()
+			if r.state == paused {
+				r.state = playing
+				invoke = r.InvokeOnPlaying
+			}
+		}
+		r.mu.Unlock()
+		if invoke != nil {
+			invoke()
+		}
+		return
+	}
+
+	if !r.confirmed && time.Since(r.playedAt) < playStartTimeout {
+		// the session has not started playing the track yet
+		r.mu.Unlock()
+		return
+	}
+
+	// the track has ended, or something else was played on the remote device
+	next := r.nextTrack
+	r.nextTrack = nil
+	r.mu.Unlock()
+	if next != nil && status.NowPlayingID == "" {
+		if err := r.provider.RemoteSessionPlay(r.sessionID, next.ID, 0); err == nil {
+			r.mu.Lock()
+			r.setCurrentTrack(next, 0)
+			r.mu.Unlock()
+			r.InvokeOnTrackChange()
+			return
+		} else {
+			log.Printf("error playing next track on remote session: %s", err.Error())
+		}
+	}
+	r.setStopped()
+	r.InvokeOnStopped()
+}
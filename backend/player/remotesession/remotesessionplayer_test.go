@@ -0,0 +1,155 @@
+package remotesession
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/player"
+)
+
+// fakeSessionAPI records the commands sent to a remote session.
+type fakeSessionAPI struct {
+	mu       sync.Mutex
+	commands []string
+	status   mediaprovider.RemoteSessionStatus
+}
+
+func (f *fakeSessionAPI) record(format string, args ...any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, fmt.Sprintf(format, args...))
+	return nil
+}
+
+func (f *fakeSessionAPI) takeCommands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := f.commands
+	f.commands = nil
+	return c
+}
+
+func (f *fakeSessionAPI) GetRemoteSessions() ([]*mediaprovider.RemoteSession, error) {
+	return nil, nil
+}
+
+func (f *fakeSessionAPI) RemoteSessionPlay(sessionID, trackID string, startSeconds float64) error {
+	return f.record("play %s %s %g", sessionID, trackID, startSeconds)
+}
+
+func (f *fakeSessionAPI) RemoteSessionPause(sessionID string) error {
+	return f.record("pause %s", sessionID)
+}
+
+func (f *fakeSessionAPI) RemoteSessionUnpause(sessionID string) error {
+	return f.record("unpause %s", sessionID)
+}
+
+func (f *fakeSessionAPI) RemoteSessionStop(sessionID string) error {
+	return f.record("stop %s", sessionID)
+}
+
+func (f *fakeSessionAPI) RemoteSessionSeek(sessionID string, seconds float64) error {
+	return f.record("seek %s %g", sessionID, seconds)
+}
+
+func (f *fakeSessionAPI) RemoteSessionGetStatus(sessionID string) (*mediaprovider.RemoteSessionStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status := f.status
+	return &status, nil
+}
+
+func (f *fakeSessionAPI) RemoteSessionSetVolume(sessionID string, vol int) error {
+	return f.record("volume %s %d", sessionID, vol)
+}
+
+func TestRemoteSessionPlayer(t *testing.T) {
+	api := &fakeSessionAPI{status: mediaprovider.RemoteSessionStatus{Volume: 40}}
+	r, err := NewRemoteSessionPlayer(api, "tv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// stop polling, so that the status is synced only by the test
+	r.Destroy()
+	if r.GetVolume() != 40 {
+		t.Errorf("got volume %d, want the session's volume 40", r.GetVolume())
+	}
+
+	var events []string
+	r.OnPlaying(func() { events = append(events, "playing") })
+	r.OnPaused(func() { events = append(events, "paused") })
+	r.OnStopped(func() { events = append(events, "stopped") })
+	r.OnTrackChange(func() { events = append(events, "trackchange") })
+	expect := func(step string, wantCommands, wantEvents []string, wantState player.State) {
+		t.Helper()
+		if got := api.takeCommands(); !slices.Equal(got, wantCommands) {
+			t.Errorf("%s: got commands %q, want %q", step, got, wantCommands)
+		}
+		if !slices.Equal(events, wantEvents) {
+			t.Errorf("%s: got events %q, want %q", step, events, wantEvents)
+		}
+		events = nil
+		if s := r.GetStatus().State; s != wantState {
+			t.Errorf("%s: got state %v, want %v", step, s, wantState)
+		}
+	}
+
+	t1 := &mediaprovider.Track{ID: "t1", Duration: 3 * time.Minute}
+	t2 := &mediaprovider.Track{ID: "t2", Duration: 4 * time.Minute}
+	if err := r.PlayTrack(t1, 0); err != nil {
+		t.Fatal(err)
+	}
+	r.SetNextTrack(t2)
+	expect("play", []string{"play tv t1 0"}, []string{"playing", "trackchange"}, player.Playing)
+
+	// the session has not begun playing the track yet
+	r.syncStatus(&mediaprovider.RemoteSessionStatus{})
+	expect("not started", nil, nil, player.Playing)
+
+	// the user pauses on the remote device
+	r.syncStatus(&mediaprovider.RemoteSessionStatus{NowPlayingID: "t1", Paused: true, PositionSeconds: 30})
+	expect("paused remotely", nil, []string{"paused"}, player.Paused)
+	if pos := r.GetStatus().TimePos; pos != 30 {
+		t.Errorf("got position %g, want 30", pos)
+	}
+
+	if err := r.Continue(); err != nil {
+		t.Fatal(err)
+	}
+	expect("continue", []string{"unpause tv"}, []string{"playing"}, player.Playing)
+
+	if err := r.SeekSeconds(90); err != nil {
+		t.Fatal(err)
+	}
+	expect("seek", []string{"seek tv 90"}, nil, player.Playing)
+
+	// the track ends, so the next track is started
+	r.syncStatus(&mediaprovider.RemoteSessionStatus{})
+	expect("track ended", []string{"play tv t2 0"}, []string{"trackchange"}, player.Playing)
+	if d := r.GetStatus().Duration; d != t2.Duration.Seconds() {
+		t.Errorf("got duration %g, want that of the next track", d)
+	}
+
+	// something else is played on the remote device once confirmed playing
+	r.syncStatus(&mediaprovider.RemoteSessionStatus{NowPlayingID: "t2"})
+	r.syncStatus(&mediaprovider.RemoteSessionStatus{NowPlayingID: "other"})
+	expect("replaced remotely", nil, []string{"stopped"}, player.Stopped)
+
+	if err := r.Stop(false); err != nil {
+		t.Fatal(err)
+	}
+	expect("stop when stopped", nil, nil, player.Stopped)
+
+	if err := r.SetVolume(75); err != nil {
+		t.Fatal(err)
+	}
+	expect("volume", []string{"volume tv 75"}, nil, player.Stopped)
+	if r.GetVolume() != 75 {
+		t.Errorf("got volume %d, want 75", r.GetVolume())
+	}
+}
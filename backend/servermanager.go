@@ -2,12 +2,12 @@ package backend
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/dweymouth/go-jellyfin"
@@ -25,6 +25,10 @@ type ServerManager struct {
 	ServerID     uuid.UUID
 	Server       mediaprovider.MediaProvider
 
+	// true if connected to the current server through its AltHostname.
+	// Written by the connection monitor on failover.
+	connectedToAltHostname atomic.Bool
+
 	useKeyring        bool
 	prefetchCoverCB   func(string)
 	appName           string
@@ -32,6 +36,15 @@ type ServerManager struct {
 	config            *Config
 	onServerConnected []func(*ServerConfig)
 	onLogout          []func()
+
+	// connection health monitoring and failover
+	httpClient          *http.Client
+	health              *connectionHealth
+	reconnecting        atomic.Bool
+	lastReconnect       time.Time
+	lastReauthenticate  time.Time
+	onConnectionChanged []func(bool)
+	onAuthExpired       []func(*ServerConfig)
 }
 
 var ErrUnreachable = errors.New("server is unreachable")
@@ -53,12 +66,22 @@ func (s *ServerManager) SetPrefetchAlbumCoverCallback(cb func(string)) {
 }
 
 func (s *ServerManager) ConnectToServer(conf *ServerConfig, password string) error {
-	cli, err := s.connect(conf.ServerConnection, password)
+	health := s.newConnectionHealth(conf)
+	cli, isAlt, err := s.connect(conf.ServerConnection, password, nil, health)
 	if err != nil {
+		s.stopHealthMonitor()
 		return err
 	}
+	s.httpClient, _ = newHTTPClient(conf.ServerConnection, 0 /*no timeout*/)
+	s.connectedToAltHostname.Store(isAlt)
 	s.Server = cli.MediaProvider()
 	s.Server.SetPrefetchCoverCallback(s.prefetchCoverCB)
+	s.startHealthMonitor(health, &monitoredSession{
+		server:     s.Server,
+		conf:       conf,
+		connection: conf.ServerConnection,
+		password:   password,
+	})
 	s.LoggedInUser = conf.Username
 	s.ServerID = conf.ID
 	s.SetDefaultServer(s.ServerID)
@@ -74,7 +97,7 @@ func (s *ServerManager) TestConnectionAndAuth(
 	err := ErrUnreachable
 	done := make(chan bool)
 	go func() {
-		_, err = s.connect(connection, password)
+		_, _, err = s.connect(connection, password, nil, nil)
 		close(done)
 	}()
 	select {
@@ -85,6 +108,68 @@ func (s *ServerManager) TestConnectionAndAuth(
 	}
 }
 
+// QuickConnect logs in to a Jellyfin server with Quick Connect. onCode is called
+// with the code the user must enter in another Jellyfin client to authorize the login.
+// It blocks until the login is authorized, or ctx is done, and returns the access
+// token, which is used in place of the password when connecting to the server
+// with QuickConnect set, and the name of the user who authorized the login.
+func (s *ServerManager) QuickConnect(
+	ctx context.Context, connection ServerConnection, onCode func(code string),
+) (token, username string, err error) {
+	timeout := time.Second * time.Duration(s.config.Application.RequestTimeoutSeconds)
+	httpCli, err := newHTTPClient(connection, timeout)
+	if err != nil {
+		return "", "", err
+	}
+	var qc *jellyfinMP.QuickConnect
+	for _, hostname := range []string{connection.Hostname, connection.AltHostname} {
+		if hostname == "" {
+			continue
+		}
+		var client *jellyfin.Client
+		client, err = jellyfin.NewClient(NormalizeJellyfinURL(hostname), res.AppName, res.AppVersion, jellyfin.WithHTTPClient(httpCli))
+		if err != nil {
+			continue
+		}
+		if qc, err = jellyfinMP.StartQuickConnect(client); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return "", "", err
+	}
+	onCode(qc.Code)
+	return qc.Wait(ctx)
+}
+
+// CurrentServerConfig returns the config of the connected server, or nil if not connected.
+func (s *ServerManager) CurrentServerConfig() *ServerConfig {
+	if s.Server == nil {
+		return nil
+	}
+	for _, conf := range s.config.Servers {
+		if conf.ID == s.ServerID {
+			return conf
+		}
+	}
+	return nil
+}
+
+// TranscodingConfig returns the transcoding settings for the active connection:
+// the current server's override for the connected hostname if set,
+// or else the global transcoding settings.
+func (s *ServerManager) TranscodingConfig() *TranscodingConfig {
+	if conf := s.CurrentServerConfig(); conf != nil {
+		if s.connectedToAltHostname.Load() && conf.AltTranscoding != nil {
+			return conf.AltTranscoding
+		}
+		if !s.connectedToAltHostname.Load() && conf.Transcoding != nil {
+			return conf.Transcoding
+		}
+	}
+	return &s.config.Transcoding
+}
+
 func (s *ServerManager) GetDefaultServer() *ServerConfig {
 	for _, s := range s.config.Servers {
 		if s.Default {
@@ -140,12 +225,21 @@ func (s *ServerManager) Logout(deletePassword bool) {
 		for _, cb := range s.onLogout {
 			cb()
 		}
+		s.stopHealthMonitor()
 		s.Server = nil
+		s.httpClient = nil
+		s.connectedToAltHostname.Store(false)
 		s.LoggedInUser = ""
 		s.ServerID = uuid.UUID{}
 	}
 }
 
+// ConnectedToAltHostname returns true if connected to the
+// current server through its AltHostname.
+func (s *ServerManager) ConnectedToAltHostname() bool {
+	return s.connectedToAltHostname.Load()
+}
+
 func (s *ServerManager) deleteServerPassword(serverID uuid.UUID) {
 	if s.useKeyring {
 		keyring.Delete(s.appName, s.ServerID.String())
@@ -176,7 +270,13 @@ func (s *ServerManager) SetServerPassword(server *ServerConfig, password string)
 	return errors.New("keyring not available")
 }
 
-func (s *ServerManager) connect(connection ServerConnection, password string) (mediaprovider.Server, error) {
+// connect logs in to the server through whichever of the primary
+// and alternate hostnames responds first. isAlt is true if the
+// returned server is connected through the alternate hostname.
+// If session is non-nil, the server continues that session instead of
+// logging in with the password. If health is non-nil, all requests
+// made by the server are recorded to it.
+func (s *ServerManager) connect(connection ServerConnection, password string, session *mediaprovider.Session, health *connectionHealth) (_ mediaprovider.Server, isAlt bool, err error) {
 	var cli, altCli mediaprovider.Server
 	timeout := time.Second * time.Duration(s.config.Application.RequestTimeoutSeconds)
 
@@ -188,54 +288,93 @@ func (s *ServerManager) connect(connection ServerConnection, password string) (m
 		connection.AltHostname = NormalizeServerURL(connection.AltHostname)
 	}
 
+	// each client needs its own http.Client, since the
+	// Jellyfin provider installs its own transport
+	newHTTPClient := func() (*http.Client, error) {
+		httpCli, err := newHTTPClient(connection, timeout)
+		if err != nil {
+			log.Printf("error creating HTTP client: %s", err.Error())
+			return nil, err
+		}
+		monitorRequests(httpCli, health)
+		return httpCli, nil
+	}
+
 	if connection.ServerType == ServerTypeJellyfin {
-		client, err := jellyfin.NewClient(connection.Hostname, res.AppName, res.AppVersion, jellyfin.WithTimeout(timeout))
+		// a session is continued by logging in with its token from its device
+		tokenAuth := connection.QuickConnect
+		var deviceOpts []jellyfin.ClientOptionFunc
+		if session != nil {
+			tokenAuth = true
+			password = session.Token
+			deviceOpts = append(deviceOpts, jellyfin.WithDeviceID(session.DeviceID))
+		}
+
+		httpCli, err := newHTTPClient()
+		if err != nil {
+			return nil, false, err
+		}
+		client, err := jellyfin.NewClient(connection.Hostname, res.AppName, res.AppVersion,
+			append(deviceOpts, jellyfin.WithHTTPClient(httpCli))...)
 		if err != nil {
 			log.Printf("error creating Jellyfin client: %s", err.Error())
-			return nil, err
+			return nil, false, err
 		}
-		s.checkSetInsecureSkipVerify(connection.SkipSSLVerify, client.HTTPClient)
 		cli = &jellyfinMP.JellyfinServer{
-			Client: *client,
+			Client:    *client,
+			TokenAuth: tokenAuth,
 		}
 
 		if connection.AltHostname != "" {
-			altClient, err := jellyfin.NewClient(connection.AltHostname, res.AppName, res.AppVersion, jellyfin.WithTimeout(timeout))
+			altHTTPCli, err := newHTTPClient()
+			if err != nil {
+				return nil, false, err
+			}
+			altClient, err := jellyfin.NewClient(connection.AltHostname, res.AppName, res.AppVersion,
+				append(deviceOpts, jellyfin.WithHTTPClient(altHTTPCli))...)
 			if err != nil {
 				log.Printf("error creating Jellyfin alternative client: %s", err.Error())
-				return nil, err
+				return nil, false, err
 			}
-			s.checkSetInsecureSkipVerify(connection.SkipSSLVerify, altClient.HTTPClient)
 			altCli = &jellyfinMP.JellyfinServer{
-				Client: *altClient,
+				Client:    *altClient,
+				TokenAuth: tokenAuth,
 			}
 		}
 	} else {
+		httpCli, err := newHTTPClient()
+		if err != nil {
+			return nil, false, err
+		}
+		altHTTPCli, err := newHTTPClient()
+		if err != nil {
+			return nil, false, err
+		}
 		ua := fmt.Sprintf("%s/%s", s.appName, s.appVersion)
 		cli = &subsonicMP.SubsonicServer{
 			Client: subsonic.Client{
 				UserAgent:    ua,
-				Client:       &http.Client{Timeout: timeout},
+				Client:       httpCli,
 				BaseUrl:      connection.Hostname,
 				User:         connection.Username,
 				PasswordAuth: connection.LegacyAuth,
 				ClientName:   res.AppName,
 				UseJSON:      true,
 			},
+			APIKeyAuth: connection.APIKeyAuth,
 		}
-		s.checkSetInsecureSkipVerify(connection.SkipSSLVerify, cli.(*subsonicMP.SubsonicServer).Client.Client)
 		altCli = &subsonicMP.SubsonicServer{
 			Client: subsonic.Client{
 				UserAgent:    ua,
-				Client:       &http.Client{Timeout: timeout},
+				Client:       altHTTPCli,
 				BaseUrl:      connection.AltHostname,
 				User:         connection.Username,
 				PasswordAuth: connection.LegacyAuth,
 				ClientName:   res.AppName,
 				UseJSON:      true,
 			},
+			APIKeyAuth: connection.APIKeyAuth,
 		}
-		s.checkSetInsecureSkipVerify(connection.SkipSSLVerify, altCli.(*subsonicMP.SubsonicServer).Client.Client)
 	}
 
 	// struct to return hostname type in isAlt and connection success on err
@@ -273,20 +412,12 @@ func (s *ServerManager) connect(connection ServerConnection, password string) (m
 
 	select {
 	case <-ctx.Done():
-		return nil, ErrUnreachable
+		return nil, false, ErrUnreachable
 	case res := <-pingChan:
 		if res.isAlt {
-			return altCli, res.err
-		}
-		return cli, res.err
-	}
-}
-
-func (s *ServerManager) checkSetInsecureSkipVerify(skip bool, cli *http.Client) {
-	if skip {
-		cli.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			return altCli, true, res.err
 		}
+		return cli, false, res.err
 	}
 }
 
@@ -0,0 +1,332 @@
+package backend
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// Shuffle strategies, selectable via PlaybackConfig.ShuffleStrategy
+const (
+	ShuffleRandom       = "Random"
+	ShuffleArtistSpread = "Artist spread"
+	ShuffleAlbumSpread  = "Album spread"
+	ShuffleWeighted     = "Weighted by rating"
+	ShuffleAvoidRecent  = "Avoid recently played"
+)
+
+const (
+	// tracks played within this window are considered recently played by ShuffleAvoidRecent
+	recentlyPlayedWindow = 7 * 24 * time.Hour
+
+	// plays within this window count towards the skip penalty of ShuffleWeighted
+	skipHistoryWindow = 90 * 24 * time.Hour
+
+	// weight reduction of ShuffleWeighted for an item which is always skipped
+	maxSkipPenalty = 0.8
+
+	// the cached listening history is reloaded once it is older than this
+	shuffleHistoryMaxAge = 10 * time.Minute
+)
+
+var ShuffleStrategies = []string{ShuffleRandom, ShuffleArtistSpread, ShuffleAlbumSpread, ShuffleWeighted, ShuffleAvoidRecent}
+
+// the properties of an item relevant to the shuffle strategies
+type shuffleItem struct {
+	artist string
+	album  string
+	weight float64
+	recent bool
+}
+
+// the listening history relevant to the shuffle strategies,
+// keyed by track (or album) ID
+type shuffleHistory struct {
+	recent    map[string]bool
+	skipRatio map[string]float64 // fraction of plays which were skipped
+}
+
+// shuffledOrder returns a permutation of the indexes of items according to the strategy.
+func shuffledOrder(items []shuffleItem, strategy string) []int {
+	order := rand.Perm(len(items))
+	switch strategy {
+	case ShuffleArtistSpread:
+		spreadOrder(order, func(i int) string { return items[i].artist })
+	case ShuffleAlbumSpread:
+		spreadOrder(order, func(i int) string { return items[i].album })
+	case ShuffleWeighted:
+		// weighted random sampling without replacement (Efraimidis-Spirakis)
+		keys := make([]float64, len(items))
+		for i, it := range items {
+			keys[i] = math.Pow(rand.Float64(), 1/max(it.weight, 0.01))
+		}
+		sort.SliceStable(order, func(a, b int) bool { return keys[order[a]] > keys[order[b]] })
+	case ShuffleAvoidRecent:
+		sort.SliceStable(order, func(a, b int) bool { return !items[order[a]].recent && items[order[b]].recent })
+	}
+	return order
+}
+
+// spreadOrder reorders the (already shuffled) order so that items with the
+// same key are spread as evenly as possible throughout the whole list.
+// Each group of n items is assigned evenly spaced positions with a random
+// offset, and the items are then sorted by position.
+func spreadOrder(order []int, key func(int) string) {
+	groups := make(map[string][]int)
+	var keys []string
+	for _, i := range order {
+		k := key(i)
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], i)
+	}
+	pos := make(map[int]float64, len(order))
+	for _, k := range keys {
+		g := groups[k]
+		spacing := 1 / float64(len(g))
+		offset := rand.Float64() * spacing
+		for j, i := range g {
+			// small jitter avoids different groups interleaving in lockstep
+			jitter := (rand.Float64() - 0.5) * spacing * 0.2
+			pos[i] = offset + float64(j)*spacing + jitter
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return pos[order[a]] < pos[order[b]] })
+	separateAdjacent(order, key)
+}
+
+// separateAdjacent reorders the order as little as possible so that no two
+// adjacent items have the same key, where that is possible. Items are taken in
+// order, but one with the same key as the previous item is passed over for
+// the next, unless its key has so many items left that one must be placed now
+// to keep them separated. The jitter of spreadOrder may otherwise place items
+// of two groups with similar offsets next to each other.
+func separateAdjacent(order []int, key func(int) string) {
+	n := len(order)
+	remaining := make(map[string]int)
+	maxCount := 0
+	for _, i := range order {
+		k := key(i)
+		remaining[k]++
+		maxCount = max(maxCount, remaining[k])
+	}
+	src := slices.Clone(order)
+	used := make([]bool, n)
+	first := 0 // first unused index of src
+	var last string
+	for out := range n {
+		left := n - out
+		forced, isForced := "", false
+		if maxCount*2 > left {
+			for k, c := range remaining {
+				if c*2 > left {
+					forced, isForced = k, true
+					break
+				}
+			}
+		}
+		pick := first // if only items with the previous key are left
+		for j := first; j < n; j++ {
+			if used[j] {
+				continue
+			}
+			k := key(src[j])
+			if isForced && k == forced || !isForced && (out == 0 || k != last) {
+				pick = j
+				break
+			}
+		}
+		used[pick] = true
+		for first < n && used[first] {
+			first++
+		}
+		last = key(src[pick])
+		remaining[last]--
+		order[out] = src[pick]
+	}
+}
+
+func shuffleItemForMediaItem(item mediaprovider.MediaItem, history shuffleHistory) shuffleItem {
+	tr, ok := item.(*mediaprovider.Track)
+	if !ok {
+		return shuffleItem{weight: 1}
+	}
+	artist := ""
+	if len(tr.ArtistIDs) > 0 {
+		artist = tr.ArtistIDs[0]
+	} else if len(tr.ArtistNames) > 0 {
+		artist = tr.ArtistNames[0]
+	}
+	return shuffleItem{
+		artist: artist,
+		album:  tr.AlbumID,
+		weight: ratingWeight(tr.Rating, tr.Favorite) * skipWeight(history.skipRatio[tr.ID]),
+		recent: history.recent[tr.ID],
+	}
+}
+
+// unrated items are weighted between 2 and 3 stars
+func ratingWeight(rating int, favorite bool) float64 {
+	w := 2.5
+	if rating > 0 {
+		w = float64(rating)
+	}
+	if favorite {
+		w += 2.5
+	}
+	return w
+}
+
+// frequently skipped items are weighted down
+func skipWeight(skipRatio float64) float64 {
+	return 1 - maxSkipPenalty*skipRatio
+}
+
+// shuffleMediaItems shuffles items in place according to the strategy,
+// using the listening history of the tracks.
+func shuffleMediaItems(items []mediaprovider.MediaItem, strategy string, history shuffleHistory) {
+	si := make([]shuffleItem, len(items))
+	for i, it := range items {
+		si[i] = shuffleItemForMediaItem(it, history)
+	}
+	applyOrder(items, shuffledOrder(si, strategy))
+}
+
+// shuffleAlbums shuffles albums in place according to the strategy,
+// using the listening history of the albums.
+func shuffleAlbums(albums []*mediaprovider.Album, strategy string, history shuffleHistory) {
+	si := make([]shuffleItem, len(albums))
+	for i, al := range albums {
+		artist := ""
+		if len(al.ArtistIDs) > 0 {
+			artist = al.ArtistIDs[0]
+		}
+		si[i] = shuffleItem{
+			artist: artist,
+			album:  al.ID,
+			weight: ratingWeight(0, al.Favorite) * skipWeight(history.skipRatio[al.ID]),
+			recent: history.recent[al.ID],
+		}
+	}
+	applyOrder(albums, shuffledOrder(si, strategy))
+}
+
+// shuffleHistoryCache is the track listening history summarized for a shuffle
+// strategy. Reading the history from the database must not block the playback
+// engine, so it is loaded in the background, and until it has loaded for the
+// configured strategy, items are shuffled without it.
+type shuffleHistoryCache struct {
+	mu       sync.Mutex
+	history  func(since time.Time) []*PlayRecord
+	strategy string
+	cached   shuffleHistory
+	loadedAt time.Time
+	loading  bool
+	gen      int // incremented to discard loads in progress
+}
+
+func (c *shuffleHistoryCache) setSource(history func(since time.Time) []*PlayRecord) {
+	c.mu.Lock()
+	c.history = history
+	c.mu.Unlock()
+}
+
+// reload discards the cached history, e.g. since the server has changed,
+// and begins loading it for the strategy.
+func (c *shuffleHistoryCache) reload(strategy string) {
+	c.mu.Lock()
+	c.gen++
+	c.strategy = ""
+	c.cached = shuffleHistory{}
+	c.loading = false
+	c.mu.Unlock()
+	c.get(strategy)
+}
+
+// get returns the cached history for the strategy, and begins
+// reloading it in the background if it is stale.
+func (c *shuffleHistoryCache) get(strategy string) shuffleHistory {
+	if strategy != ShuffleAvoidRecent && strategy != ShuffleWeighted {
+		return shuffleHistory{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.history == nil {
+		return shuffleHistory{}
+	}
+	if !c.loading && (c.strategy != strategy || time.Since(c.loadedAt) > shuffleHistoryMaxAge) {
+		c.loading = true
+		go c.load(strategy, c.history, c.gen)
+	}
+	if c.strategy != strategy {
+		return shuffleHistory{}
+	}
+	return c.cached
+}
+
+func (c *shuffleHistoryCache) load(strategy string, history func(since time.Time) []*PlayRecord, gen int) {
+	h := loadShuffleHistory(strategy, history, false)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	c.strategy = strategy
+	c.cached = h
+	c.loadedAt = time.Now()
+	c.loading = false
+}
+
+// loadShuffleHistory summarizes the listening history of tracks (or albums)
+// if the strategy needs it and listening history is available.
+func loadShuffleHistory(strategy string, history func(since time.Time) []*PlayRecord, albums bool) shuffleHistory {
+	if history == nil {
+		return shuffleHistory{}
+	}
+	id := func(p *PlayRecord) string { return p.TrackID }
+	if albums {
+		id = func(p *PlayRecord) string { return p.AlbumID }
+	}
+	switch strategy {
+	case ShuffleAvoidRecent:
+		recent := make(map[string]bool)
+		for _, p := range history(time.Now().Add(-recentlyPlayedWindow)) {
+			recent[id(p)] = true
+		}
+		return shuffleHistory{recent: recent}
+	case ShuffleWeighted:
+		return shuffleHistory{skipRatio: skipRatios(history(time.Now().Add(-skipHistoryWindow)), id)}
+	}
+	return shuffleHistory{}
+}
+
+// skipRatios returns the fraction of the plays of each ID which were skipped.
+func skipRatios(plays []*PlayRecord, id func(*PlayRecord) string) map[string]float64 {
+	total := make(map[string]int)
+	skipped := make(map[string]int)
+	for _, p := range plays {
+		total[id(p)]++
+		if !p.Completed {
+			skipped[id(p)]++
+		}
+	}
+	ratios := make(map[string]float64, len(skipped))
+	for k, n := range skipped {
+		ratios[k] = float64(n) / float64(total[k])
+	}
+	return ratios
+}
+
+func applyOrder[T any](items []T, order []int) {
+	orig := make([]T, len(items))
+	copy(orig, items)
+	for i, idx := range order {
+		items[i] = orig[idx]
+	}
+}
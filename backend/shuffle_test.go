@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+func TestShuffledOrder(t *testing.T) {
+	items := make([]shuffleItem, 30)
+	for i := range items {
+		items[i] = shuffleItem{
+			artist: []string{"a", "b", "c"}[i%3],
+			album:  []string{"x", "y"}[i%2],
+			weight: float64(i%5 + 1),
+			recent: i < 10,
+		}
+	}
+	for _, strategy := range ShuffleStrategies {
+		order := shuffledOrder(items, strategy)
+		sorted := slices.Clone(order)
+		slices.Sort(sorted)
+		for i, idx := range sorted {
+			if i != idx {
+				t.Fatalf("%s: order is not a permutation: %v", strategy, order)
+			}
+		}
+	}
+
+	order := shuffledOrder(items, ShuffleAvoidRecent)
+	for i, idx := range order {
+		if recent := items[idx].recent; recent != (i >= 20) {
+			t.Fatalf("expected recently played items last: %v", order)
+		}
+	}
+}
+
+func TestShuffledOrderSpreads(t *testing.T) {
+	items := make([]shuffleItem, 12)
+	for i := range items {
+		items[i] = shuffleItem{
+			artist: []string{"a", "b", "c"}[i%3],
+			album:  []string{"x", "y"}[i%2],
+		}
+	}
+	for range 500 {
+		order := shuffledOrder(items, ShuffleArtistSpread)
+		for i := 1; i < len(order); i++ {
+			if items[order[i]].artist == items[order[i-1]].artist {
+				t.Fatalf("artist spread: adjacent items by the same artist: %v", order)
+			}
+		}
+		order = shuffledOrder(items, ShuffleAlbumSpread)
+		for i := 1; i < len(order); i++ {
+			if items[order[i]].album == items[order[i-1]].album {
+				t.Fatalf("album spread: adjacent items from the same album: %v", order)
+			}
+		}
+	}
+}
+
+func TestShuffleWeightedPenalizesSkips(t *testing.T) {
+	plays := []*PlayRecord{
+		{TrackID: "skipped", Completed: false},
+		{TrackID: "skipped", Completed: false},
+		{TrackID: "played", Completed: true},
+	}
+	history := shuffleHistory{skipRatio: skipRatios(plays, func(p *PlayRecord) string { return p.TrackID })}
+	skipped := shuffleItemForMediaItem(&mediaprovider.Track{ID: "skipped"}, history)
+	played := shuffleItemForMediaItem(&mediaprovider.Track{ID: "played"}, history)
+	if skipped.weight >= played.weight {
+		t.Fatalf("expected skipped track to be weighted down: %v >= %v", skipped.weight, played.weight)
+	}
+
+	// otherwise identical tracks: the skipped one should usually come later
+	items := []shuffleItem{skipped, played}
+	var skippedFirst int
+	for range 1000 {
+		if shuffledOrder(items, ShuffleWeighted)[0] == 0 {
+			skippedFirst++
+		}
+	}
+	if skippedFirst > 400 {
+		t.Errorf("skipped track shuffled first %d of 1000 times", skippedFirst)
+	}
+}
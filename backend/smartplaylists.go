@@ -0,0 +1,518 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/google/uuid"
+)
+
+const smartPlaylistsFile = "smart_playlists.json"
+
+// Prefix of the description of a server playlist into which a smart playlist
+// is materialized. It is followed by the rules in JSON, which other devices
+// pick up so that the smart playlist is not tied to one installation.
+const smartPlaylistDescriptionPrefix = "Supersonic smart playlist: "
+
+// Smart playlist rule fields and operators.
+// The names match those of Navidrome smart playlists (.nsp files)
+// so that rules can be exported to the server format unchanged.
+const (
+	SmartFieldTitle      = "title"
+	SmartFieldArtist     = "artist"
+	SmartFieldAlbum      = "album"
+	SmartFieldGenre      = "genre"
+	SmartFieldYear       = "year"
+	SmartFieldRating     = "rating"
+	SmartFieldPlayCount  = "playcount"
+	SmartFieldLastPlayed = "lastplayed"
+	SmartFieldDateAdded  = "dateadded"
+	SmartFieldFavorite   = "loved"
+
+	SmartOpIs           = "is"
+	SmartOpIsNot        = "isNot"
+	SmartOpContains     = "contains"
+	SmartOpNotContains  = "notContains"
+	SmartOpGreaterThan  = "gt"
+	SmartOpLessThan     = "lt"
+	SmartOpInTheLast    = "inTheLast"
+	SmartOpNotInTheLast = "notInTheLast"
+
+	SmartSortRandom     = "random"
+	SmartSortTitle      = "title"
+	SmartSortArtist     = "artist"
+	SmartSortAlbum      = "album"
+	SmartSortYear       = "year"
+	SmartSortRating     = "rating"
+	SmartSortPlayCount  = "playcount"
+	SmartSortLastPlayed = "lastplayed"
+	SmartSortDateAdded  = "dateadded"
+)
+
+var (
+	SmartPlaylistFields = []string{SmartFieldTitle, SmartFieldArtist, SmartFieldAlbum, SmartFieldGenre,
+		SmartFieldYear, SmartFieldRating, SmartFieldPlayCount, SmartFieldLastPlayed, SmartFieldDateAdded, SmartFieldFavorite}
+	SmartPlaylistSorts = []string{SmartSortRandom, SmartSortTitle, SmartSortArtist, SmartSortAlbum,
+		SmartSortYear, SmartSortRating, SmartSortPlayCount, SmartSortLastPlayed, SmartSortDateAdded}
+)
+
+// SmartPlaylistOperators returns the operators which are valid for the given field.
+func SmartPlaylistOperators(field string) []string {
+	switch field {
+	case SmartFieldTitle, SmartFieldArtist, SmartFieldAlbum, SmartFieldGenre:
+		return []string{SmartOpIs, SmartOpIsNot, SmartOpContains, SmartOpNotContains}
+	case SmartFieldYear, SmartFieldRating, SmartFieldPlayCount:
+		return []string{SmartOpIs, SmartOpIsNot, SmartOpGreaterThan, SmartOpLessThan}
+	case SmartFieldLastPlayed, SmartFieldDateAdded:
+		return []string{SmartOpInTheLast, SmartOpNotInTheLast}
+	case SmartFieldFavorite:
+		return []string{SmartOpIs}
+	}
+	return nil
+}
+
+type SmartPlaylistRule struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	// Numeric fields store the number in string form, date fields
+	// a number of days, and the favorite field "true" or "false"
+	Value string `json:"value"`
+}
+
+type SmartPlaylist struct {
+	ID       string              `json:"id"`
+	ServerID string              `json:"serverId"`
+	Name     string              `json:"name"`
+	MatchAny bool                `json:"matchAny"` // match any rule instead of all rules
+	Rules    []SmartPlaylistRule `json:"rules"`
+	Sort     string              `json:"sort"`
+	Limit    int                 `json:"limit"` // 0 == unlimited
+
+	// ID of the server playlist the rules were last materialized into
+	PlaylistID string `json:"playlistId,omitempty"`
+}
+
+// smartPlaylistDefinition is the part of a SmartPlaylist stored on the server.
+type smartPlaylistDefinition struct {
+	MatchAny bool                `json:"matchAny,omitempty"`
+	Rules    []SmartPlaylistRule `json:"rules"`
+	Sort     string              `json:"sort,omitempty"`
+	Limit    int                 `json:"limit,omitempty"`
+}
+
+func (s *SmartPlaylist) definition() smartPlaylistDefinition {
+	return smartPlaylistDefinition{MatchAny: s.MatchAny, Rules: s.Rules, Sort: s.Sort, Limit: s.Limit}
+}
+
+func (s *SmartPlaylist) setDefinition(d smartPlaylistDefinition) {
+	s.MatchAny, s.Rules, s.Sort, s.Limit = d.MatchAny, d.Rules, d.Sort, d.Limit
+}
+
+// serverDescription returns the description of the server
+// playlist the smart playlist is materialized into.
+func (s *SmartPlaylist) serverDescription() (string, error) {
+	b, err := json.Marshal(s.definition())
+	if err != nil {
+		return "", err
+	}
+	return smartPlaylistDescriptionPrefix + string(b), nil
+}
+
+// parseSmartPlaylistDescription returns the smart playlist definition
+// stored in the description of a server playlist, if any.
+func parseSmartPlaylistDescription(description string) (smartPlaylistDefinition, bool) {
+	var d smartPlaylistDefinition
+	js, ok := strings.CutPrefix(description, smartPlaylistDescriptionPrefix)
+	if !ok || json.Unmarshal([]byte(js), &d) != nil {
+		return d, false
+	}
+	return d, true
+}
+
+// Matches returns true if the track satisfies the playlist's rules.
+func (s *SmartPlaylist) Matches(tr *mediaprovider.Track) bool {
+	return s.matchesAt(tr, time.Now())
+}
+
+func (s *SmartPlaylist) matchesAt(tr *mediaprovider.Track, now time.Time) bool {
+	if len(s.Rules) == 0 {
+		return true
+	}
+	for _, r := range s.Rules {
+		m := r.matches(tr, now)
+		if s.MatchAny && m {
+			return true
+		}
+		if !s.MatchAny && !m {
+			return false
+		}
+	}
+	return !s.MatchAny
+}
+
+func (r SmartPlaylistRule) matches(tr *mediaprovider.Track, now time.Time) bool {
+	switch r.Field {
+	case SmartFieldTitle:
+		return r.matchStrings([]string{tr.Title})
+	case SmartFieldArtist:
+		return r.matchStrings(tr.ArtistNames)
+	case SmartFieldAlbum:
+		return r.matchStrings([]string{tr.Album})
+	case SmartFieldGenre:
+		return r.matchStrings(tr.Genres)
+	case SmartFieldYear:
+		return r.matchInt(tr.Year)
+	case SmartFieldRating:
+		return r.matchInt(tr.Rating)
+	case SmartFieldPlayCount:
+		return r.matchInt(tr.PlayCount)
+	case SmartFieldLastPlayed:
+		return r.matchDate(tr.LastPlayed, now)
+	case SmartFieldDateAdded:
+		return r.matchDate(tr.DateAdded, now)
+	case SmartFieldFavorite:
+		want, _ := strconv.ParseBool(r.Value)
+		return tr.Favorite == want
+	}
+	return false
+}
+
+func (r SmartPlaylistRule) matchStrings(values []string) bool {
+	val := strings.ToLower(r.Value)
+	has := slices.ContainsFunc(values, func(v string) bool {
+		v = strings.ToLower(v)
+		if r.Operator == SmartOpIs || r.Operator == SmartOpIsNot {
+			return v == val
+		}
+		return strings.Contains(v, val)
+	})
+	if r.Operator == SmartOpIsNot || r.Operator == SmartOpNotContains {
+		return !has
+	}
+	return has
+}
+
+func (r SmartPlaylistRule) matchInt(value int) bool {
+	want, err := strconv.Atoi(strings.TrimSpace(r.Value))
+	if err != nil {
+		return false
+	}
+	switch r.Operator {
+	case SmartOpIs:
+		return value == want
+	case SmartOpIsNot:
+		return value != want
+	case SmartOpGreaterThan:
+		return value > want
+	case SmartOpLessThan:
+		return value < want
+	}
+	return false
+}
+
+func (r SmartPlaylistRule) matchDate(value time.Time, now time.Time) bool {
+	days, err := strconv.Atoi(strings.TrimSpace(r.Value))
+	if err != nil {
+		return false
+	}
+	inRange := !value.IsZero() && value.After(now.AddDate(0, 0, -days))
+	if r.Operator == SmartOpNotInTheLast {
+		return !inRange
+	}
+	return inRange
+}
+
+// Evaluate returns the tracks in the library matching the playlist's rules,
+// sorted and limited according to the playlist's settings.
+func (s *SmartPlaylist) Evaluate(mp mediaprovider.MediaProvider) ([]*mediaprovider.Track, error) {
+	var tracks []*mediaprovider.Track
+	iter := mp.IterateTracks("")
+	for tr := iter.Next(); tr != nil; tr = iter.Next() {
+		if s.Matches(tr) {
+			tracks = append(tracks, tr)
+		}
+	}
+	s.sortTracks(tracks)
+	if s.Limit > 0 && len(tracks) > s.Limit {
+		tracks = tracks[:s.Limit]
+	}
+	return tracks, nil
+}
+
+func (s *SmartPlaylist) sortTracks(tracks []*mediaprovider.Track) {
+	str := func(f func(*mediaprovider.Track) string) func(a, b *mediaprovider.Track) bool {
+		return func(a, b *mediaprovider.Track) bool {
+			return strings.ToLower(f(a)) < strings.ToLower(f(b))
+		}
+	}
+	var less func(a, b *mediaprovider.Track) bool
+	switch s.Sort {
+	case SmartSortTitle:
+		less = str(func(t *mediaprovider.Track) string { return t.Title })
+	case SmartSortArtist:
+		less = str(func(t *mediaprovider.Track) string { return strings.Join(t.ArtistNames, ", ") })
+	case SmartSortAlbum:
+		less = str(func(t *mediaprovider.Track) string { return t.Album })
+	case SmartSortYear:
+		less = func(a, b *mediaprovider.Track) bool { return a.Year < b.Year }
+	// the following sorts are most useful in descending order
+	case SmartSortRating:
+		less = func(a, b *mediaprovider.Track) bool { return a.Rating > b.Rating }
+	case SmartSortPlayCount:
+		less = func(a, b *mediaprovider.Track) bool { return a.PlayCount > b.PlayCount }
+	case SmartSortLastPlayed:
+		less = func(a, b *mediaprovider.Track) bool { return a.LastPlayed.After(b.LastPlayed) }
+	case SmartSortDateAdded:
+		less = func(a, b *mediaprovider.Track) bool { return a.DateAdded.After(b.DateAdded) }
+	default:
+		rand.Shuffle(len(tracks), func(i, j int) { tracks[i], tracks[j] = tracks[j], tracks[i] })
+		return
+	}
+	sort.SliceStable(tracks, func(i, j int) bool { return less(tracks[i], tracks[j]) })
+}
+
+// MarshalNSP encodes the smart playlist in the Navidrome smart playlist (.nsp) format.
+func (s *SmartPlaylist) MarshalNSP() ([]byte, error) {
+	rules := make([]map[string]map[string]any, 0, len(s.Rules))
+	for _, r := range s.Rules {
+		var value any = r.Value
+		switch r.Field {
+		case SmartFieldYear, SmartFieldRating, SmartFieldPlayCount, SmartFieldLastPlayed, SmartFieldDateAdded:
+			n, err := strconv.Atoi(strings.TrimSpace(r.Value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid number for %s: %q", r.Field, r.Value)
+			}
+			value = n
+		case SmartFieldFavorite:
+			b, _ := strconv.ParseBool(r.Value)
+			value = b
+		}
+		rules = append(rules, map[string]map[string]any{r.Operator: {r.Field: value}})
+	}
+	nsp := map[string]any{
+		"name": s.Name,
+	}
+	if s.MatchAny {
+		nsp["any"] = rules
+	} else {
+		nsp["all"] = rules
+	}
+	if s.Sort != "" {
+		nsp["sort"] = s.Sort
+		switch s.Sort {
+		case SmartSortRating, SmartSortPlayCount, SmartSortLastPlayed, SmartSortDateAdded:
+			nsp["order"] = "desc"
+		}
+	}
+	if s.Limit > 0 {
+		nsp["limit"] = s.Limit
+	}
+	return json.MarshalIndent(nsp, "", "  ")
+}
+
+// SmartPlaylistManager stores the smart playlist definitions for all servers.
+type SmartPlaylistManager struct {
+	filePath string
+
+	mu        sync.Mutex
+	playlists []*SmartPlaylist
+}
+
+func NewSmartPlaylistManager(configDir string) *SmartPlaylistManager {
+	s := &SmartPlaylistManager{filePath: filepath.Join(configDir, smartPlaylistsFile)}
+	if b, err := os.ReadFile(s.filePath); err == nil {
+		_ = json.Unmarshal(b, &s.playlists)
+	}
+	return s
+}
+
+// Playlists returns the smart playlists defined for the given server, sorted by name.
+func (s *SmartPlaylistManager) Playlists(serverID string) []*SmartPlaylist {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pls := sharedutil.FilterSlice(s.playlists, func(p *SmartPlaylist) bool {
+		return p.ServerID == serverID
+	})
+	sort.Slice(pls, func(i, j int) bool {
+		return strings.ToLower(pls[i].Name) < strings.ToLower(pls[j].Name)
+	})
+	return pls
+}
+
+// Playlist returns the smart playlist with the given ID, or nil if not found.
+func (s *SmartPlaylistManager) Playlist(id string) *SmartPlaylist {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if idx := slices.IndexFunc(s.playlists, func(p *SmartPlaylist) bool { return p.ID == id }); idx >= 0 {
+		return s.playlists[idx]
+	}
+	return nil
+}
+
+// Save adds or updates the smart playlist and writes all definitions to disk.
+func (s *SmartPlaylistManager) Save(pl *SmartPlaylist) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pl.ID == "" {
+		pl.ID = uuid.NewString()
+	}
+	if idx := slices.IndexFunc(s.playlists, func(p *SmartPlaylist) bool { return p.ID == pl.ID }); idx >= 0 {
+		s.playlists[idx] = pl
+	} else {
+		s.playlists = append(s.playlists, pl)
+	}
+	return s.writeFile()
+}
+
+func (s *SmartPlaylistManager) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.playlists = slices.DeleteFunc(s.playlists, func(p *SmartPlaylist) bool { return p.ID == id })
+	return s.writeFile()
+}
+
+// Refresh evaluates the smart playlist and replaces the tracks of its
+// server playlist with the result, creating the server playlist if needed.
+func (s *SmartPlaylistManager) Refresh(mp mediaprovider.MediaProvider, pl *SmartPlaylist) error {
+	// the UI shares pl, so a new server playlist is recorded on a copy
+	updated := *pl
+	tracks, err := updated.Evaluate(mp)
+	if err != nil {
+		return err
+	}
+	desc, err := updated.serverDescription()
+	if err != nil {
+		return err
+	}
+	var serverPl *mediaprovider.PlaylistWithTracks
+	if updated.PlaylistID != "" {
+		serverPl, err = mp.GetPlaylist(updated.PlaylistID)
+		if errors.Is(err, mediaprovider.ErrNotFound) {
+			updated.PlaylistID = "" // deleted on the server
+		} else if err != nil {
+			return err
+		}
+	}
+	if updated.PlaylistID == "" {
+		id, err := createEmptyPlaylist(mp, updated.Name, desc)
+		if err != nil {
+			return err
+		}
+		updated.PlaylistID = id
+		if err := s.Save(&updated); err != nil {
+			return err
+		}
+	} else if serverPl.Name != updated.Name || serverPl.Description != desc {
+		if err := mp.EditPlaylist(updated.PlaylistID, updated.Name, desc, serverPl.Public); err != nil {
+			return err
+		}
+	}
+	return mp.ReplacePlaylistTracks(updated.PlaylistID, sharedutil.TracksToIDs(tracks))
+}
+
+// StoreOnServer writes the name and rules of the smart playlist
+// to its server playlist, if it has been materialized into one.
+func (s *SmartPlaylistManager) StoreOnServer(mp mediaprovider.MediaProvider, pl *SmartPlaylist) error {
+	if pl.PlaylistID == "" {
+		return nil
+	}
+	desc, err := pl.serverDescription()
+	if err != nil {
+		return err
+	}
+	serverPl, err := mp.GetPlaylist(pl.PlaylistID)
+	if err != nil {
+		return err
+	}
+	return mp.EditPlaylist(pl.PlaylistID, pl.Name, desc, serverPl.Public)
+}
+
+// SyncFromServer adds or updates the smart playlists of the given server
+// from the rules stored in the descriptions of its playlists, e.g. by
+// another device.
+func (s *SmartPlaylistManager) SyncFromServer(mp mediaprovider.MediaProvider, serverID string) error {
+	serverPls, err := mp.GetPlaylists()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	for _, p := range serverPls {
+		def, ok := parseSmartPlaylistDescription(p.Description)
+		if !ok {
+			continue
+		}
+		idx := slices.IndexFunc(s.playlists, func(pl *SmartPlaylist) bool {
+			return pl.ServerID == serverID && pl.PlaylistID == p.ID
+		})
+		if idx < 0 {
+			pl := &SmartPlaylist{ID: uuid.NewString(), ServerID: serverID, Name: p.Name, PlaylistID: p.ID}
+			pl.setDefinition(def)
+			s.playlists = append(s.playlists, pl)
+			changed = true
+			continue
+		}
+		pl := s.playlists[idx]
+		if pl.Name != p.Name || !reflect.DeepEqual(pl.definition(), def) {
+			updated := *pl
+			updated.Name = p.Name
+			updated.setDefinition(def)
+			s.playlists[idx] = &updated
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return s.writeFile()
+}
+
+// the MediaProvider API does not return the ID of a created playlist,
+// so we find it afterwards as the playlist with the given name which was
+// not present before
+func createEmptyPlaylist(mp mediaprovider.MediaProvider, name, description string) (string, error) {
+	before, err := mp.GetPlaylists()
+	if err != nil {
+		return "", err
+	}
+	existing := make(map[string]bool, len(before))
+	for _, p := range before {
+		existing[p.ID] = true
+	}
+	if err := mp.CreatePlaylist(name, description, false); err != nil {
+		return "", err
+	}
+	after, err := mp.GetPlaylists()
+	if err != nil {
+		return "", err
+	}
+	for _, p := range after {
+		if p.Name == name && !existing[p.ID] {
+			return p.ID, nil
+		}
+	}
+	return "", errors.New("created playlist not found")
+}
+
+func (s *SmartPlaylistManager) writeFile() error {
+	b, err := json.MarshalIndent(s.playlists, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.filePath, b, 0o644)
+}
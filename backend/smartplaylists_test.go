@@ -0,0 +1,70 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+func TestSmartPlaylistMatches(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	jazz := &mediaprovider.Track{
+		Title:      "So What",
+		Genres:     []string{"Jazz"},
+		Rating:     5,
+		LastPlayed: now.AddDate(0, 0, -120),
+	}
+	rock := &mediaprovider.Track{
+		Title:      "Paranoid",
+		Genres:     []string{"Rock"},
+		Rating:     4,
+		LastPlayed: now.AddDate(0, 0, -3),
+	}
+
+	sp := &SmartPlaylist{Rules: []SmartPlaylistRule{
+		{Field: SmartFieldRating, Operator: SmartOpGreaterThan, Value: "3"},
+		{Field: SmartFieldLastPlayed, Operator: SmartOpNotInTheLast, Value: "90"},
+		{Field: SmartFieldGenre, Operator: SmartOpIs, Value: "jazz"},
+	}}
+	if !sp.matchesAt(jazz, now) {
+		t.Error("expected jazz track to match all rules")
+	}
+	if sp.matchesAt(rock, now) {
+		t.Error("expected rock track not to match all rules")
+	}
+
+	sp.MatchAny = true
+	if !sp.matchesAt(rock, now) {
+		t.Error("expected rock track to match any rule")
+	}
+	sp.Rules = []SmartPlaylistRule{{Field: SmartFieldTitle, Operator: SmartOpContains, Value: "what"}}
+	if sp.matchesAt(rock, now) {
+		t.Error("expected rock track not to match title rule")
+	}
+}
+
+func TestSmartPlaylistServerDescription(t *testing.T) {
+	sp := &SmartPlaylist{
+		Name:     "Jazz",
+		MatchAny: true,
+		Rules:    []SmartPlaylistRule{{Field: SmartFieldGenre, Operator: SmartOpIs, Value: "jazz"}},
+		Sort:     "random",
+		Limit:    50,
+	}
+	desc, err := sp.serverDescription()
+	if err != nil {
+		t.Fatal(err)
+	}
+	def, ok := parseSmartPlaylistDescription(desc)
+	if !ok {
+		t.Fatalf("failed to parse description %q", desc)
+	}
+	if !reflect.DeepEqual(def, sp.definition()) {
+		t.Errorf("got definition %+v, want %+v", def, sp.definition())
+	}
+	if _, ok := parseSmartPlaylistDescription("My favorite songs"); ok {
+		t.Error("expected a plain description not to parse")
+	}
+}
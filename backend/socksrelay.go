@@ -0,0 +1,168 @@
+package backend
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// socksRelay is an HTTP proxy on the loopback interface which forwards
+// requests through a SOCKS5 proxy. mpv supports only HTTP proxies, so it
+// streams through the relay when a SOCKS proxy is configured. Since other
+// local processes can reach the relay, it requires a random password.
+type socksRelay struct {
+	upstream  *url.URL
+	dialer    proxy.ContextDialer
+	transport *http.Transport
+	listener  net.Listener
+	server    *http.Server
+	password  string
+}
+
+const socksRelayUser = "supersonic"
+
+func startSOCKSRelay(upstream *url.URL) (*socksRelay, error) {
+	d, err := proxy.FromURL(upstream, proxy.Direct)
+	if err != nil {
+		return nil, err
+	}
+	dialer, ok := d.(proxy.ContextDialer)
+	if !ok {
+		dialer = contextDialer{d}
+	}
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	r := &socksRelay{
+		upstream:  upstream,
+		dialer:    dialer,
+		transport: newProxyTransport(http.ProxyURL(upstream)),
+		listener:  l,
+		password:  hex.EncodeToString(secret),
+	}
+	r.server = &http.Server{Handler: r}
+	go r.server.Serve(l)
+	return r, nil
+}
+
+// URL returns the URL, including credentials, by which to use the relay as an HTTP proxy.
+func (r *socksRelay) URL() string {
+	return (&url.URL{
+		Scheme: "http",
+		User:   url.UserPassword(socksRelayUser, r.password),
+		Host:   r.listener.Addr().String(),
+	}).String()
+}
+
+func (r *socksRelay) Close() error {
+	r.transport.CloseIdleConnections()
+	return r.server.Close()
+}
+
+func (r *socksRelay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !r.authorized(req) {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="supersonic"`)
+		http.Error(w, "proxy authentication required", http.StatusProxyAuthRequired)
+		return
+	}
+	if req.Method == http.MethodConnect {
+		r.tunnel(w, req)
+		return
+	}
+	if !req.URL.IsAbs() {
+		http.Error(w, "not a proxy request", http.StatusBadRequest)
+		return
+	}
+
+	out := req.Clone(req.Context())
+	out.RequestURI = ""
+	out.Header.Del("Proxy-Authorization")
+	out.Header.Del("Proxy-Connection")
+	resp, err := r.transport.RoundTrip(out)
+	if err != nil {
+		log.Printf("SOCKS relay: %s", err.Error())
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// tunnel connects the client to the requested host through the SOCKS proxy.
+func (r *socksRelay) tunnel(w http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithTimeout(req.Context(), 30*time.Second)
+	upstream, err := r.dialer.DialContext(ctx, "tcp", req.Host)
+	cancel()
+	if err != nil {
+		log.Printf("SOCKS relay: %s", err.Error())
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "tunneling not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, buf, err := hj.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+
+	done := make(chan struct{}, 2)
+	go func() {
+		// data the client sent along with the CONNECT request is buffered
+		io.Copy(upstream, buf)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, upstream)
+		done <- struct{}{}
+	}()
+	<-done
+	conn.Close()
+	upstream.Close()
+}
+
+func (r *socksRelay) authorized(req *http.Request) bool {
+	auth, ok := strings.CutPrefix(req.Header.Get("Proxy-Authorization"), "Basic ")
+	if !ok {
+		return false
+	}
+	b, err := base64.StdEncoding.DecodeString(auth)
+	if err != nil {
+		return false
+	}
+	want := socksRelayUser + ":" + r.password
+	return subtle.ConstantTimeCompare(b, []byte(want)) == 1
+}
+
+type contextDialer struct {
+	proxy.Dialer
+}
+
+func (d contextDialer) DialContext(_ context.Context, network, addr string) (net.Conn, error) {
+	return d.Dial(network, addr)
+}
@@ -0,0 +1,121 @@
+package backend
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// startTestSOCKS5 starts a minimal SOCKS5 proxy (no auth, CONNECT only)
+// and returns its URL and the number of connections it has relayed.
+func startTestSOCKS5(t *testing.T) (*url.URL, *atomic.Int32) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	var relayed atomic.Int32
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				hdr := make([]byte, 2)
+				if _, err := io.ReadFull(c, hdr); err != nil {
+					return
+				}
+				io.ReadFull(c, make([]byte, hdr[1])) // auth methods
+				c.Write([]byte{5, 0})
+				req := make([]byte, 4)
+				if _, err := io.ReadFull(c, req); err != nil {
+					return
+				}
+				var host string
+				switch req[3] {
+				case 1:
+					ip := make([]byte, 4)
+					io.ReadFull(c, ip)
+					host = net.IP(ip).String()
+				case 3:
+					n := make([]byte, 1)
+					io.ReadFull(c, n)
+					name := make([]byte, n[0])
+					io.ReadFull(c, name)
+					host = string(name)
+				default:
+					return
+				}
+				port := make([]byte, 2)
+				io.ReadFull(c, port)
+				target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+				if err != nil {
+					c.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer target.Close()
+				relayed.Add(1)
+				c.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(target, c)
+				io.Copy(c, target)
+			}()
+		}
+	}()
+	return &url.URL{Scheme: "socks5", Host: l.Addr().String()}, &relayed
+}
+
+func TestSOCKSRelay(t *testing.T) {
+	socksURL, relayed := startTestSOCKS5(t)
+	r, err := startSOCKSRelay(socksURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	relayURL, _ := url.Parse(r.URL())
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "stream")
+	})
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	tls := httptest.NewTLSServer(handler)
+	defer tls.Close()
+
+	// plain HTTP is proxied with absolute-form requests, HTTPS with CONNECT
+	for _, srv := range []*httptest.Server{plain, tls} {
+		transport := srv.Client().Transport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(relayURL)
+		before := relayed.Load()
+		resp, err := (&http.Client{Transport: transport}).Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "stream" {
+			t.Errorf("%s: got body %q", srv.URL, body)
+		}
+		if relayed.Load() == before {
+			t.Errorf("%s: request not sent through the SOCKS proxy", srv.URL)
+		}
+	}
+
+	noAuth := *relayURL
+	noAuth.User = url.UserPassword(socksRelayUser, "wrong")
+	resp, err := (&http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(&noAuth)}}).Get(plain.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusProxyAuthRequired {
+		t.Errorf("wrong password: got status %d, want %d", resp.StatusCode, http.StatusProxyAuthRequired)
+	}
+}
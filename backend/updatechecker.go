@@ -3,7 +3,6 @@ package backend
 import (
 	"context"
 	"log"
-	"net/http"
 	"net/url"
 	"strings"
 	"time"
@@ -66,7 +65,7 @@ func (u *UpdateChecker) checkForUpdate() {
 }
 
 func (u *UpdateChecker) CheckLatestVersionTag() string {
-	resp, err := http.Head(u.latestReleaseURL)
+	resp, err := proxyHTTPClient.Head(u.latestReleaseURL)
 	if err != nil {
 		log.Printf("failed to check for newest version: %s", err.Error())
 		return ""
@@ -0,0 +1,52 @@
+package upnp
+
+import "strings"
+
+// NewConnectionManager creates the ConnectionManager service of a device
+// which sources and/or sinks media of the given protocols, and supports
+// only a single, implicit connection.
+func NewConnectionManager(source, sink []string) *Service {
+	sourceInfo := strings.Join(source, ",")
+	sinkInfo := strings.Join(sink, ",")
+	direction := "Output"
+	if len(sink) > 0 {
+		direction = "Input"
+	}
+	return &Service{
+		Type: ConnectionManagerType,
+		ID:   ConnectionManagerID,
+		SCPD: ConnectionManagerSCPD,
+		Handler: func(a *Action) ([]Arg, error) {
+			switch a.Name {
+			case "GetProtocolInfo":
+				return []Arg{
+					{Name: "Source", Value: sourceInfo},
+					{Name: "Sink", Value: sinkInfo},
+				}, nil
+			case "GetCurrentConnectionIDs":
+				return []Arg{{Name: "ConnectionIDs", Value: "0"}}, nil
+			case "GetCurrentConnectionInfo":
+				if a.Args["ConnectionID"] != "0" {
+					return nil, &Error{Code: 706, Description: "Invalid connection reference"}
+				}
+				return []Arg{
+					{Name: "RcsID", Value: "0"},
+					{Name: "AVTransportID", Value: "0"},
+					{Name: "ProtocolInfo", Value: ""},
+					{Name: "PeerConnectionManager", Value: ""},
+					{Name: "PeerConnectionID", Value: "-1"},
+					{Name: "Direction", Value: direction},
+					{Name: "Status", Value: "OK"},
+				}, nil
+			}
+			return nil, ErrInvalidAction
+		},
+		EventState: func() []Arg {
+			return []Arg{
+				{Name: "SourceProtocolInfo", Value: sourceInfo},
+				{Name: "SinkProtocolInfo", Value: sinkInfo},
+				{Name: "CurrentConnectionIDs", Value: "0"},
+			}
+		},
+	}
+}
@@ -0,0 +1,338 @@
+// Package upnp implements the device side of UPnP: SSDP advertisement,
+// the device description, and the control (SOAP) and eventing (GENA)
+// endpoints of the device's services.
+package upnp
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/util"
+	"github.com/google/uuid"
+	"github.com/koron/go-ssdp"
+)
+
+const (
+	// how long SSDP advertisements are valid
+	ssdpMaxAge = 1800
+
+	descriptionPath = "/description.xml"
+)
+
+// Device is a UPnP root device served over HTTP and advertised over SSDP.
+type Device struct {
+	// e.g. "urn:schemas-upnp-org:device:MediaRenderer:1"
+	Type         string
+	FriendlyName string
+	ModelName    string
+	ModelNumber  string
+	// DLNA device class and version, e.g. "DMR-1.50". Optional.
+	DLNADoc string
+	// Unique device name. If empty, a stable one
+	// is derived from the device type and hostname.
+	UUID string
+
+	Services []*Service
+
+	mux         *http.ServeMux
+	port        int
+	server      *http.Server
+	advertisers []*ssdp.Advertiser
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// Handle registers an additional HTTP handler on the device's server,
+// e.g. for media served by the device. Must be called before Start.
+func (d *Device) Handle(pattern string, handler http.Handler) {
+	if d.mux == nil {
+		d.mux = http.NewServeMux()
+	}
+	d.mux.Handle(pattern, handler)
+}
+
+// Start starts serving the device on an ephemeral port and advertising it.
+func (d *Device) Start() error {
+	if d.UUID == "" {
+		hostname, _ := os.Hostname()
+		d.UUID = uuid.NewSHA1(uuid.NameSpaceURL, []byte("supersonic:"+hostname+":"+d.Type)).String()
+	}
+	if d.mux == nil {
+		d.mux = http.NewServeMux()
+	}
+	desc, err := d.description()
+	if err != nil {
+		return err
+	}
+	d.mux.HandleFunc(descriptionPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+		w.Write(desc)
+	})
+	for _, s := range d.Services {
+		s.register(d.mux)
+	}
+
+	listeners, err := listenOnAdvertisedInterfaces()
+	if err != nil {
+		return err
+	}
+	d.port = listeners[0].Addr().(*net.TCPAddr).Port
+	d.server = &http.Server{Handler: d.serverHeader(d.mux)}
+	for _, l := range listeners {
+		go d.server.Serve(l)
+	}
+
+	if err := d.advertise(); err != nil {
+		d.server.Close()
+		return err
+	}
+	return nil
+}
+
+// Close says goodbye over SSDP and stops serving the device.
+func (d *Device) Close() error {
+	if d.cancel != nil {
+		d.cancel()
+		d.wg.Wait()
+	}
+	for _, a := range d.advertisers {
+		a.Bye()
+		a.Close()
+	}
+	d.advertisers = nil
+	for _, s := range d.Services {
+		s.closeEvents()
+	}
+	if d.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return d.server.Shutdown(ctx)
+}
+
+// Port returns the port the device is served on.
+func (d *Device) Port() int {
+	return d.port
+}
+
+// listenOnAdvertisedInterfaces listens on an ephemeral port on the IPv4
+// addresses of the interfaces the device is advertised on over SSDP, rather
+// than on all interfaces. The same port is used on every address if possible.
+func listenOnAdvertisedInterfaces() ([]net.Listener, error) {
+	ips := advertisedIPv4s()
+	if localIP, err := util.GetLocalIP(); err == nil && !slices.Contains(ips, localIP) {
+		// the address used in URLs which are not tied to an interface
+		ips = append(ips, localIP)
+	}
+	if len(ips) == 0 {
+		return nil, errors.New("no network interface to serve the device on")
+	}
+
+	var listeners []net.Listener
+	port := 0
+	for _, ip := range ips {
+		l, err := net.Listen("tcp4", net.JoinHostPort(ip, strconv.Itoa(port)))
+		if err != nil {
+			log.Printf("error listening on %s: %s", ip, err.Error())
+			continue
+		}
+		if port == 0 {
+			port = l.Addr().(*net.TCPAddr).Port
+		}
+		listeners = append(listeners, l)
+	}
+	if len(listeners) == 0 {
+		return nil, errors.New("failed to listen on any network interface")
+	}
+	return listeners, nil
+}
+
+// advertisedIPv4s returns the IPv4 addresses of the interfaces
+// go-ssdp multicasts advertisements on.
+func advertisedIPv4s() []string {
+	ifaces := ssdp.Interfaces
+	if len(ifaces) == 0 {
+		all, err := net.Interfaces()
+		if err != nil {
+			return nil
+		}
+		for _, ifi := range all {
+			if ifi.Flags&net.FlagUp != 0 && ifi.Flags&net.FlagMulticast != 0 {
+				ifaces = append(ifaces, ifi)
+			}
+		}
+	}
+	var ips []string
+	for i := range ifaces {
+		if ip := interfaceIPv4(&ifaces[i]); ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+func (d *Device) advertise() error {
+	udn := "uuid:" + d.UUID
+	location := ssdp.LocationProviderFunc(d.location)
+	server := d.serverString()
+
+	// notification types (and their USNs) of the device and its services
+	nts := [][2]string{
+		{"upnp:rootdevice", udn + "::upnp:rootdevice"},
+		{udn, udn},
+		{d.Type, udn + "::" + d.Type},
+	}
+	for _, s := range d.Services {
+		nts = append(nts, [2]string{s.Type, udn + "::" + s.Type})
+	}
+	for _, nt := range nts {
+		a, err := ssdp.Advertise(nt[0], nt[1], location, server, ssdpMaxAge)
+		if err != nil {
+			for _, a := range d.advertisers {
+				a.Close()
+			}
+			d.advertisers = nil
+			return err
+		}
+		d.advertisers = append(d.advertisers, a)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		// re-announce well before the advertisements expire
+		t := time.NewTicker(ssdpMaxAge / 3 * time.Second)
+		defer t.Stop()
+		for {
+			for _, a := range d.advertisers {
+				if err := a.Alive(); err != nil {
+					log.Printf("error sending SSDP announcement: %s", err.Error())
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// location returns the URL of the device description,
+// using the address of the interface the message is sent from.
+func (d *Device) location(_ net.Addr, ifi *net.Interface) string {
+	ip := interfaceIPv4(ifi)
+	if ip == "" {
+		ip, _ = util.GetLocalIP()
+	}
+	return fmt.Sprintf("http://%s:%d%s", ip, d.Port(), descriptionPath)
+}
+
+func (d *Device) serverHeader(h http.Handler) http.Handler {
+	server := d.serverString()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", server)
+		h.ServeHTTP(w, r)
+	})
+}
+
+type descRoot struct {
+	XMLName     xml.Name   `xml:"urn:schemas-upnp-org:device-1-0 root"`
+	DLNANS      string     `xml:"xmlns:dlna,attr,omitempty"`
+	SpecVersion descSpec   `xml:"specVersion"`
+	Device      descDevice `xml:"device"`
+}
+
+type descSpec struct {
+	Major int `xml:"major"`
+	Minor int `xml:"minor"`
+}
+
+type descDevice struct {
+	DeviceType      string        `xml:"deviceType"`
+	FriendlyName    string        `xml:"friendlyName"`
+	Manufacturer    string        `xml:"manufacturer"`
+	ManufacturerURL string        `xml:"manufacturerURL"`
+	ModelName       string        `xml:"modelName"`
+	ModelNumber     string        `xml:"modelNumber"`
+	UDN             string        `xml:"UDN"`
+	DLNADoc         string        `xml:"dlna:X_DLNADOC,omitempty"`
+	Services        []descService `xml:"serviceList>service"`
+}
+
+type descService struct {
+	ServiceType string `xml:"serviceType"`
+	ServiceID   string `xml:"serviceId"`
+	SCPDURL     string `xml:"SCPDURL"`
+	ControlURL  string `xml:"controlURL"`
+	EventSubURL string `xml:"eventSubURL"`
+}
+
+func (d *Device) description() ([]byte, error) {
+	root := descRoot{
+		SpecVersion: descSpec{Major: 1, Minor: 0},
+		Device: descDevice{
+			DeviceType:      d.Type,
+			FriendlyName:    d.FriendlyName,
+			Manufacturer:    "Supersonic",
+			ManufacturerURL: "https://github.com/dweymouth/supersonic",
+			ModelName:       d.ModelName,
+			ModelNumber:     d.ModelNumber,
+			UDN:             "uuid:" + d.UUID,
+			DLNADoc:         d.DLNADoc,
+		},
+	}
+	if d.DLNADoc != "" {
+		root.DLNANS = "urn:schemas-dlna-org:device-1-0"
+	}
+	for _, s := range d.Services {
+		root.Device.Services = append(root.Device.Services, descService{
+			ServiceType: s.Type,
+			ServiceID:   s.ID,
+			SCPDURL:     s.path() + "/scpd.xml",
+			ControlURL:  s.path() + "/control",
+			EventSubURL: s.path() + "/event",
+		})
+	}
+	b, err := xml.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+func interfaceIPv4(ifi *net.Interface) string {
+	if ifi == nil {
+		return ""
+	}
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return ""
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil {
+			return ipnet.IP.String()
+		}
+	}
+	return ""
+}
+
+// serverString returns the SERVER header value of the device's messages.
+func (d *Device) serverString() string {
+	return fmt.Sprintf("%s/1.0 UPnP/1.0 %s/%s", runtime.GOOS, d.ModelName, d.ModelNumber)
+}
@@ -0,0 +1,181 @@
+package upnp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const didlLiteHeader = `<DIDL-Lite xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/" ` +
+	`xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/">`
+
+// Classes of DIDL-Lite containers.
+const (
+	ClassContainer     = "object.container"
+	ClassStorageFolder = "object.container.storageFolder"
+	ClassMusicArtist   = "object.container.person.musicArtist"
+	ClassMusicAlbum    = "object.container.album.musicAlbum"
+	ClassMusicGenre    = "object.container.genre.musicGenre"
+	ClassPlaylist      = "object.container.playlistContainer"
+)
+
+// DIDLObject is a DIDLItem or DIDLContainer.
+type DIDLObject interface {
+	writeXML(buf *bytes.Buffer)
+}
+
+// MarshalDIDL returns a DIDL-Lite document describing the objects.
+func MarshalDIDL(objects []DIDLObject) string {
+	var buf bytes.Buffer
+	buf.WriteString(didlLiteHeader)
+	for _, o := range objects {
+		o.writeXML(&buf)
+	}
+	buf.WriteString("</DIDL-Lite>")
+	return buf.String()
+}
+
+// DIDLContainer is the metadata of a container of media items.
+type DIDLContainer struct {
+	ID       string
+	ParentID string
+	Title    string
+	// one of the Class constants
+	Class       string
+	ChildCount  int
+	Artist      string
+	AlbumArtURI string
+}
+
+func (c *DIDLContainer) writeXML(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, `<container id="%s" parentID="%s" childCount="%d" restricted="1" searchable="0">`,
+		escape(c.ID), escape(c.ParentID), c.ChildCount)
+	writeElement(buf, "dc:title", c.Title)
+	if c.Artist != "" {
+		writeElement(buf, "dc:creator", c.Artist)
+		writeElement(buf, "upnp:artist", c.Artist)
+	}
+	if c.AlbumArtURI != "" {
+		writeElement(buf, "upnp:albumArtURI", c.AlbumArtURI)
+	}
+	writeElement(buf, "upnp:class", c.Class)
+	buf.WriteString("</container>")
+}
+
+// DIDLItem is the metadata of a media item, as described in DIDL-Lite.
+type DIDLItem struct {
+	ID          string
+	ParentID    string // "-1" if empty
+	Title       string
+	Artist      string
+	Album       string
+	Genre       string
+	AlbumArtURI string
+	TrackNumber int
+	// duration in seconds, if known
+	Duration float64
+
+	// the URL and protocol info of the item's resource, if any
+	URL          string
+	ProtocolInfo string
+}
+
+// Marshal returns a DIDL-Lite document describing the item.
+func (d *DIDLItem) Marshal() string {
+	return MarshalDIDL([]DIDLObject{d})
+}
+
+func (d *DIDLItem) writeXML(buf *bytes.Buffer) {
+	parentID := d.ParentID
+	if parentID == "" {
+		parentID = "-1"
+	}
+	fmt.Fprintf(buf, `<item id="%s" parentID="%s" restricted="1">`, escape(d.ID), escape(parentID))
+	writeElement(buf, "dc:title", d.Title)
+	if d.Artist != "" {
+		writeElement(buf, "dc:creator", d.Artist)
+		writeElement(buf, "upnp:artist", d.Artist)
+	}
+	if d.Album != "" {
+		writeElement(buf, "upnp:album", d.Album)
+	}
+	if d.Genre != "" {
+		writeElement(buf, "upnp:genre", d.Genre)
+	}
+	if d.TrackNumber > 0 {
+		writeElement(buf, "upnp:originalTrackNumber", strconv.Itoa(d.TrackNumber))
+	}
+	if d.AlbumArtURI != "" {
+		writeElement(buf, "upnp:albumArtURI", d.AlbumArtURI)
+	}
+	writeElement(buf, "upnp:class", "object.item.audioItem.musicTrack")
+	if d.URL != "" || d.Duration > 0 {
+		buf.WriteString("<res")
+		if d.Duration > 0 {
+			fmt.Fprintf(buf, ` duration="%s"`, FormatDuration(d.Duration))
+		}
+		if d.ProtocolInfo != "" {
+			fmt.Fprintf(buf, ` protocolInfo="%s"`, escape(d.ProtocolInfo))
+		}
+		buf.WriteString(">")
+		xml.EscapeText(buf, []byte(d.URL))
+		buf.WriteString("</res>")
+	}
+	buf.WriteString("</item>")
+}
+
+type didlLite struct {
+	Items []struct {
+		Title       string   `xml:"http://purl.org/dc/elements/1.1/ title"`
+		Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+		Artists     []string `xml:"urn:schemas-upnp-org:metadata-1-0/upnp/ artist"`
+		Album       string   `xml:"urn:schemas-upnp-org:metadata-1-0/upnp/ album"`
+		AlbumArtURI string   `xml:"urn:schemas-upnp-org:metadata-1-0/upnp/ albumArtURI"`
+		Res         []struct {
+			Duration string `xml:"duration,attr"`
+		} `xml:"res"`
+	} `xml:"item"`
+}
+
+// ParseDIDLItem parses the metadata of the first item of a DIDL-Lite document.
+// It returns nil if the document is empty or invalid.
+func ParseDIDLItem(didl string) *DIDLItem {
+	if strings.TrimSpace(didl) == "" {
+		return nil
+	}
+	var d didlLite
+	if err := xml.Unmarshal([]byte(didl), &d); err != nil || len(d.Items) == 0 {
+		return nil
+	}
+	it := d.Items[0]
+	item := &DIDLItem{
+		Title:       it.Title,
+		Artist:      it.Creator,
+		Album:       it.Album,
+		AlbumArtURI: it.AlbumArtURI,
+	}
+	if len(it.Artists) > 0 {
+		item.Artist = it.Artists[0]
+	}
+	for _, res := range it.Res {
+		if dur, err := ParseDuration(res.Duration); err == nil {
+			item.Duration = dur
+			break
+		}
+	}
+	return item
+}
+
+func writeElement(buf *bytes.Buffer, name, value string) {
+	fmt.Fprintf(buf, "<%s>", name)
+	xml.EscapeText(buf, []byte(value))
+	fmt.Fprintf(buf, "</%s>", name)
+}
+
+func escape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
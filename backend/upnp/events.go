@@ -0,0 +1,272 @@
+package upnp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// subscription duration granted if the subscriber does not request one
+	defaultSubscriptionTimeout = 1800 * time.Second
+	maxSubscriptionTimeout     = 24 * time.Hour
+
+	// limit of active subscriptions to a service
+	maxSubscriptions = 32
+)
+
+// the client to send event notifications with, which
+// must not go through any proxy configured for the app
+var notifyClient = &http.Client{
+	Timeout:   5 * time.Second,
+	Transport: &http.Transport{Proxy: nil},
+}
+
+// LocalHTTPClient returns a client for requests to devices on the
+// local network, which bypasses any proxy configured for the app.
+func LocalHTTPClient() *http.Client {
+	return notifyClient
+}
+
+type subscription struct {
+	sid       string
+	callbacks []string
+	seq       uint32
+	expires   time.Time
+}
+
+// eventPublisher handles GENA subscriptions to a service and
+// sends event notifications of its state to the subscribers.
+type eventPublisher struct {
+	state func() []Arg
+
+	mu     sync.Mutex
+	subs   map[string]*subscription
+	closed bool
+
+	// serializes notifications so that subscribers receive them in order
+	notifyMu sync.Mutex
+}
+
+func newEventPublisher(state func() []Arg) *eventPublisher {
+	return &eventPublisher{state: state, subs: make(map[string]*subscription)}
+}
+
+func (e *eventPublisher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "SUBSCRIBE":
+		e.handleSubscribe(w, r)
+	case "UNSUBSCRIBE":
+		e.mu.Lock()
+		_, ok := e.subs[r.Header.Get("SID")]
+		delete(e.subs, r.Header.Get("SID"))
+		e.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusPreconditionFailed)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (e *eventPublisher) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	timeout := parseTimeout(r.Header.Get("TIMEOUT"))
+	sid := r.Header.Get("SID")
+
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var sub *subscription
+	if sid != "" {
+		// renewal
+		if r.Header.Get("CALLBACK") != "" || r.Header.Get("NT") != "" {
+			e.mu.Unlock()
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if sub = e.subs[sid]; sub == nil {
+			e.mu.Unlock()
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		sub.expires = time.Now().Add(timeout)
+		e.mu.Unlock()
+	} else {
+		// events are only sent back to the subscriber itself, so that the
+		// device can't be used to send requests to other hosts (CallStranger)
+		callbacks := slices.DeleteFunc(parseCallbacks(r.Header.Get("CALLBACK")), func(cb string) bool {
+			return !isCallbackToHost(cb, r.RemoteAddr)
+		})
+		if r.Header.Get("NT") != "upnp:event" || len(callbacks) == 0 {
+			e.mu.Unlock()
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		e.removeExpired()
+		if len(e.subs) >= maxSubscriptions {
+			e.mu.Unlock()
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		sub = &subscription{
+			sid:       "uuid:" + uuid.NewString(),
+			callbacks: callbacks,
+			expires:   time.Now().Add(timeout),
+		}
+		e.subs[sub.sid] = sub
+		e.mu.Unlock()
+		// the initial event must follow the response to the subscription
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			e.notify([]*subscription{sub})
+		}()
+	}
+
+	w.Header().Set("SID", sub.sid)
+	w.Header().Set("TIMEOUT", fmt.Sprintf("Second-%d", int(timeout.Seconds())))
+	w.Header().Set("Content-Length", "0")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (e *eventPublisher) notifyAll() {
+	e.mu.Lock()
+	e.removeExpired()
+	subs := make([]*subscription, 0, len(e.subs))
+	for _, sub := range e.subs {
+		subs = append(subs, sub)
+	}
+	e.mu.Unlock()
+	if len(subs) == 0 {
+		return
+	}
+	go e.notify(subs)
+}
+
+func (e *eventPublisher) notify(subs []*subscription) {
+	e.notifyMu.Lock()
+	defer e.notifyMu.Unlock()
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<e:propertyset xmlns:e="urn:schemas-upnp-org:event-1-0">`)
+	for _, v := range e.state() {
+		fmt.Fprintf(&buf, "<e:property><%s>", v.Name)
+		xml.EscapeText(&buf, []byte(v.Value))
+		fmt.Fprintf(&buf, "</%s></e:property>", v.Name)
+	}
+	buf.WriteString(`</e:propertyset>`)
+	body := buf.Bytes()
+
+	for _, sub := range subs {
+		e.mu.Lock()
+		seq := sub.seq
+		// the sequence number wraps around to 1, as 0 is the initial event
+		if sub.seq++; sub.seq == 0 {
+			sub.seq = 1
+		}
+		e.mu.Unlock()
+		for _, cb := range sub.callbacks {
+			if err := sendNotify(cb, sub.sid, seq, body); err == nil {
+				break
+			} else {
+				log.Printf("error sending UPnP event to %s: %s", cb, err.Error())
+			}
+		}
+	}
+}
+
+// removeExpired removes the expired subscriptions. Must be called with e.mu held.
+func (e *eventPublisher) removeExpired() {
+	now := time.Now()
+	for sid, sub := range e.subs {
+		if now.After(sub.expires) {
+			delete(e.subs, sid)
+		}
+	}
+}
+
+func (e *eventPublisher) close() {
+	e.mu.Lock()
+	e.closed = true
+	clear(e.subs)
+	e.mu.Unlock()
+}
+
+func sendNotify(callback, sid string, seq uint32, body []byte) error {
+	req, err := http.NewRequest("NOTIFY", callback, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("NT", "upnp:event")
+	req.Header.Set("NTS", "upnp:propchange")
+	req.Header.Set("SID", sid)
+	req.Header.Set("SEQ", strconv.FormatUint(uint64(seq), 10))
+	resp, err := notifyClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
+
+// parseCallbacks parses the CALLBACK header of a subscription,
+// a list of one or more URLs enclosed in angle brackets.
+func parseCallbacks(hdr string) []string {
+	var callbacks []string
+	for _, part := range strings.Split(hdr, "<") {
+		cb, _, ok := strings.Cut(part, ">")
+		if ok && strings.HasPrefix(cb, "http://") {
+			callbacks = append(callbacks, cb)
+		}
+	}
+	return callbacks
+}
+
+// isCallbackToHost returns whether the callback URL
+// is on the host of the given remote address.
+func isCallbackToHost(callback, remoteAddr string) bool {
+	u, err := url.Parse(callback)
+	if err != nil {
+		return false
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	cbIP, remoteIP := net.ParseIP(u.Hostname()), net.ParseIP(host)
+	return cbIP != nil && remoteIP != nil && cbIP.Equal(remoteIP)
+}
+
+// parseTimeout parses a TIMEOUT header of the form "Second-1800" or "Second-infinite".
+func parseTimeout(hdr string) time.Duration {
+	secs, ok := strings.CutPrefix(hdr, "Second-")
+	if !ok {
+		return defaultSubscriptionTimeout
+	}
+	if secs == "infinite" {
+		return maxSubscriptionTimeout
+	}
+	n, err := strconv.Atoi(secs)
+	if err != nil || n <= 0 {
+		return defaultSubscriptionTimeout
+	}
+	return min(time.Duration(n)*time.Second, maxSubscriptionTimeout)
+}
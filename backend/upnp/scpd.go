@@ -0,0 +1,34 @@
+package upnp
+
+import (
+	_ "embed"
+)
+
+// Types and IDs of the standard services implemented by Supersonic.
+const (
+	AVTransportType       = "urn:schemas-upnp-org:service:AVTransport:1"
+	AVTransportID         = "urn:upnp-org:serviceId:AVTransport"
+	RenderingControlType  = "urn:schemas-upnp-org:service:RenderingControl:1"
+	RenderingControlID    = "urn:upnp-org:serviceId:RenderingControl"
+	ConnectionManagerType = "urn:schemas-upnp-org:service:ConnectionManager:1"
+	ConnectionManagerID   = "urn:upnp-org:serviceId:ConnectionManager"
+	ContentDirectoryType  = "urn:schemas-upnp-org:service:ContentDirectory:1"
+	ContentDirectoryID    = "urn:upnp-org:serviceId:ContentDirectory"
+
+	MediaRendererType = "urn:schemas-upnp-org:device:MediaRenderer:1"
+	MediaServerType   = "urn:schemas-upnp-org:device:MediaServer:1"
+)
+
+// Service descriptions, listing the actions implemented by Supersonic.
+
+//go:embed scpd/AVTransport.xml
+var AVTransportSCPD []byte
+
+//go:embed scpd/RenderingControl.xml
+var RenderingControlSCPD []byte
+
+//go:embed scpd/ConnectionManager.xml
+var ConnectionManagerSCPD []byte
+
+//go:embed scpd/ContentDirectory.xml
+var ContentDirectorySCPD []byte
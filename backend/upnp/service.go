@@ -0,0 +1,178 @@
+package upnp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// maximum size of a SOAP request body
+const maxRequestSize = 1 << 20
+
+// Error is a UPnP error returned from an action.
+type Error struct {
+	Code        int
+	Description string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("UPnP error %d: %s", e.Code, e.Description)
+}
+
+var (
+	ErrInvalidAction = &Error{Code: 401, Description: "Invalid Action"}
+	ErrInvalidArgs   = &Error{Code: 402, Description: "Invalid Args"}
+	ErrActionFailed  = &Error{Code: 501, Description: "Action Failed"}
+)
+
+// Arg is an argument of an action, or the value of an evented state variable.
+type Arg struct {
+	Name  string
+	Value string
+}
+
+// Action is an invocation of an action of a service.
+type Action struct {
+	Name string
+	Args map[string]string
+	// the host (and port) the request was sent to,
+	// by which the device can be reached by the caller
+	Host string
+}
+
+// Service is a service of a Device.
+type Service struct {
+	// e.g. "urn:schemas-upnp-org:service:AVTransport:1"
+	Type string
+	// e.g. "urn:upnp-org:serviceId:AVTransport"
+	ID string
+	// the service description
+	SCPD []byte
+
+	// Handler invokes an action, returning its out arguments in order.
+	// Returning an error which is not an *Error fails with ErrActionFailed.
+	Handler func(a *Action) ([]Arg, error)
+
+	// EventState returns the current values of the evented state variables,
+	// which are sent to subscribers initially and by NotifyChanged.
+	// If nil, the service does not support subscriptions.
+	EventState func() []Arg
+
+	events *eventPublisher
+}
+
+// path returns the base path of the service's URLs, which is
+// the last component of the service ID, e.g. "/AVTransport".
+func (s *Service) path() string {
+	return "/" + s.ID[strings.LastIndex(s.ID, ":")+1:]
+}
+
+func (s *Service) register(mux *http.ServeMux) {
+	mux.HandleFunc(s.path()+"/scpd.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+		w.Write(s.SCPD)
+	})
+	mux.HandleFunc(s.path()+"/control", s.handleControl)
+	if s.EventState != nil {
+		s.events = newEventPublisher(s.EventState)
+		mux.Handle(s.path()+"/event", s.events)
+	} else {
+		mux.HandleFunc(s.path()+"/event", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		})
+	}
+}
+
+// NotifyChanged sends the current state to the service's subscribers.
+func (s *Service) NotifyChanged() {
+	if s.events != nil {
+		s.events.notifyAll()
+	}
+}
+
+func (s *Service) closeEvents() {
+	if s.events != nil {
+		s.events.close()
+	}
+}
+
+type soapEnvelope struct {
+	Body struct {
+		Action struct {
+			XMLName xml.Name
+			Args    []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:",any"`
+	} `xml:"Body"`
+}
+
+func (s *Service) handleControl(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var env soapEnvelope
+	if err := xml.NewDecoder(io.LimitReader(r.Body, maxRequestSize)).Decode(&env); err != nil {
+		writeSOAPFault(w, ErrInvalidAction)
+		return
+	}
+	action := &Action{
+		Name: env.Body.Action.XMLName.Local,
+		Args: make(map[string]string, len(env.Body.Action.Args)),
+		Host: r.Host,
+	}
+	for _, arg := range env.Body.Action.Args {
+		action.Args[arg.XMLName.Local] = arg.Value
+	}
+	// the SOAPACTION header is "serviceType#actionName"
+	if hdr := strings.Trim(r.Header.Get("SOAPACTION"), `"`); hdr != "" &&
+		!strings.HasSuffix(hdr, "#"+action.Name) {
+		writeSOAPFault(w, ErrInvalidAction)
+		return
+	}
+
+	out, err := s.Handler(action)
+	if err != nil {
+		var upnpErr *Error
+		if !errors.As(err, &upnpErr) {
+			log.Printf("UPnP action %s failed: %s", action.Name, err.Error())
+			upnpErr = ErrActionFailed
+		}
+		writeSOAPFault(w, upnpErr)
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`)
+	fmt.Fprintf(&buf, `<u:%sResponse xmlns:u="%s">`, action.Name, s.Type)
+	for _, arg := range out {
+		fmt.Fprintf(&buf, "<%s>", arg.Name)
+		xml.EscapeText(&buf, []byte(arg.Value))
+		fmt.Fprintf(&buf, "</%s>", arg.Name)
+	}
+	fmt.Fprintf(&buf, `</u:%sResponse></s:Body></s:Envelope>`, action.Name)
+	w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+	w.Header().Set("EXT", "")
+	w.Write(buf.Bytes())
+}
+
+func writeSOAPFault(w http.ResponseWriter, e *Error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body><s:Fault>`)
+	buf.WriteString(`<faultcode>s:Client</faultcode><faultstring>UPnPError</faultstring>`)
+	fmt.Fprintf(&buf, `<detail><UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>%d</errorCode><errorDescription>`, e.Code)
+	xml.EscapeText(&buf, []byte(e.Description))
+	buf.WriteString(`</errorDescription></UPnPError></detail></s:Fault></s:Body></s:Envelope>`)
+	w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,193 @@
+package upnp
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// subscription duration requested from remote services
+const requestedSubscriptionTimeout = 300 * time.Second
+
+// Subscriber subscribes to the events of a remote service and renews the
+// subscription until closed. The event notifications must be routed to
+// its ServeHTTP method, which is reachable by the service at CallbackURL.
+type Subscriber struct {
+	// the eventSubURL of the remote service
+	EventURL    string
+	CallbackURL string
+
+	// OnEvent is called, in order, with the evented
+	// state variables of each event notification.
+	OnEvent func(vars map[string]string)
+
+	mu         sync.Mutex
+	sid        string
+	renewTimer *time.Timer
+	closed     bool
+	events     chan map[string]string
+}
+
+// Subscribe subscribes to the events of the service.
+func (s *Subscriber) Subscribe(ctx context.Context) error {
+	s.mu.Lock()
+	if s.events == nil {
+		s.events = make(chan map[string]string, 16)
+		go s.dispatchEvents(s.events)
+	}
+	s.mu.Unlock()
+	return s.subscribe(ctx)
+}
+
+// Close unsubscribes from the events of the service.
+func (s *Subscriber) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	sid := s.sid
+	if s.renewTimer != nil {
+		s.renewTimer.Stop()
+	}
+	if s.events != nil {
+		close(s.events)
+	}
+	s.mu.Unlock()
+
+	if sid != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if req, err := http.NewRequestWithContext(ctx, "UNSUBSCRIBE", s.EventURL, nil); err == nil {
+			req.Header.Set("SID", sid)
+			if resp, err := notifyClient.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+	}
+}
+
+// subscribe makes a new subscription, or renews the current one.
+func (s *Subscriber) subscribe(ctx context.Context) error {
+	s.mu.Lock()
+	sid := s.sid
+	s.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, "SUBSCRIBE", s.EventURL, nil)
+	if err != nil {
+		return err
+	}
+	if sid != "" {
+		req.Header.Set("SID", sid)
+	} else {
+		req.Header.Set("CALLBACK", "<"+s.CallbackURL+">")
+		req.Header.Set("NT", "upnp:event")
+	}
+	req.Header.Set("TIMEOUT", fmt.Sprintf("Second-%d", int(requestedSubscriptionTimeout.Seconds())))
+	resp, err := notifyClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusPreconditionFailed && sid != "" {
+		// the subscription has expired; subscribe anew
+		s.mu.Lock()
+		s.sid = ""
+		s.mu.Unlock()
+		return s.subscribe(ctx)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("subscribe: status %s", resp.Status)
+	}
+	if sid = resp.Header.Get("SID"); sid == "" {
+		return errors.New("subscribe: no SID in response")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.sid = sid
+	// renew well before the subscription expires
+	s.renewTimer = time.AfterFunc(parseTimeout(resp.Header.Get("TIMEOUT"))/2, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.subscribe(ctx); err != nil {
+			log.Printf("error renewing UPnP event subscription: %s", err.Error())
+		}
+	})
+	return nil
+}
+
+func (s *Subscriber) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "NOTIFY" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.Lock()
+	// the initial event may arrive before the SID is known
+	known := s.sid == "" || r.Header.Get("SID") == s.sid
+	s.mu.Unlock()
+	if !known || r.Header.Get("NT") != "upnp:event" {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	vars, err := ParsePropertySet(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed && s.events != nil {
+		select {
+		case s.events <- vars:
+		default:
+			log.Println("dropping UPnP event: too many pending events")
+		}
+	}
+}
+
+// dispatchEvents calls OnEvent outside of the HTTP handler,
+// so that it can make requests back to the service.
+func (s *Subscriber) dispatchEvents(events <-chan map[string]string) {
+	for vars := range events {
+		if s.OnEvent != nil {
+			s.OnEvent(vars)
+		}
+	}
+}
+
+// ParsePropertySet parses the body of an event notification,
+// returning the evented state variables.
+func ParsePropertySet(r io.Reader) (map[string]string, error) {
+	var set struct {
+		Properties []struct {
+			Vars []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:"property"`
+	}
+	if err := xml.NewDecoder(r).Decode(&set); err != nil {
+		return nil, err
+	}
+	vars := make(map[string]string)
+	for _, p := range set.Properties {
+		for _, v := range p.Vars {
+			vars[v.XMLName.Local] = strings.TrimSpace(v.Value)
+		}
+	}
+	return vars, nil
+}
@@ -0,0 +1,87 @@
+package upnp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Namespaces of the LastChange events of AVTransport and RenderingControl.
+const (
+	AVTransportEventNS      = "urn:schemas-upnp-org:metadata-1-0/AVT/"
+	RenderingControlEventNS = "urn:schemas-upnp-org:metadata-1-0/RCS/"
+)
+
+// FormatDuration formats seconds as a UPnP duration, H+:MM:SS.
+func FormatDuration(secs float64) string {
+	s := max(int(secs), 0)
+	return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// ParseDuration parses a UPnP duration, H+:MM:SS[.F+], to seconds.
+func ParseDuration(str string) (float64, error) {
+	parts := strings.Split(str, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid duration: %q", str)
+	}
+	var secs float64
+	for _, p := range parts {
+		// fractions of seconds may also be given as F0/F1
+		p, _, _ = strings.Cut(p, "/")
+		f, err := strconv.ParseFloat(p, 64)
+		if err != nil || f < 0 {
+			return 0, fmt.Errorf("invalid duration: %q", str)
+		}
+		secs = secs*60 + f
+	}
+	return secs, nil
+}
+
+// LastChange builds the value of the LastChange state variable, an event
+// of the changed state variables of instance 0 of the service. If channel is
+// not empty, the variables are given for that channel (for RenderingControl).
+func LastChange(namespace string, vars []Arg, channel string) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<Event xmlns="%s"><InstanceID val="0">`, namespace)
+	for _, v := range vars {
+		fmt.Fprintf(&buf, "<%s", v.Name)
+		if channel != "" {
+			fmt.Fprintf(&buf, ` channel="%s"`, channel)
+		}
+		buf.WriteString(` val="`)
+		xml.EscapeText(&buf, []byte(v.Value))
+		buf.WriteString(`"/>`)
+	}
+	buf.WriteString("</InstanceID></Event>")
+	return buf.String()
+}
+
+// ParseLastChange parses the value of a LastChange state variable, returning
+// the changed state variables of instance 0 of the service. Variables given
+// per channel (by RenderingControl) are not distinguished.
+func ParseLastChange(lastChange string) (map[string]string, error) {
+	var event struct {
+		Instances []struct {
+			ID   string `xml:"val,attr"`
+			Vars []struct {
+				XMLName xml.Name
+				Val     string `xml:"val,attr"`
+			} `xml:",any"`
+		} `xml:"InstanceID"`
+	}
+	if err := xml.Unmarshal([]byte(lastChange), &event); err != nil {
+		return nil, err
+	}
+	vars := make(map[string]string)
+	for _, inst := range event.Instances {
+		if inst.ID != "0" {
+			continue
+		}
+		for _, v := range inst.Vars {
+			vars[v.XMLName.Local] = v.Val
+		}
+	}
+	return vars, nil
+}
@@ -0,0 +1,122 @@
+package upnp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want float64
+	}{
+		{"0:00:00", 0},
+		{"0:03:25", 205},
+		{"1:02:03.500", 3723.5},
+		{"10:00:00", 36000},
+		{"0:00:01.1/2", 1.1},
+	} {
+		got, err := ParseDuration(tt.in)
+		if err != nil {
+			t.Errorf("ParseDuration(%q) returned error: %v", tt.in, err)
+		} else if got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"", "3:25", "a:b:c", "0:-1:00"} {
+		if _, err := ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%q) expected error", in)
+		}
+	}
+
+	if got := FormatDuration(3723.9); got != "1:02:03" {
+		t.Errorf("FormatDuration = %q, want 1:02:03", got)
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want time.Duration
+	}{
+		{"Second-300", 300 * time.Second},
+		{"Second-infinite", maxSubscriptionTimeout},
+		{"Second-99999999", maxSubscriptionTimeout},
+		{"", defaultSubscriptionTimeout},
+		{"Second-x", defaultSubscriptionTimeout},
+	} {
+		if got := parseTimeout(tt.in); got != tt.want {
+			t.Errorf("parseTimeout(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseDIDLItem(t *testing.T) {
+	item := &DIDLItem{ID: "1", Title: `Song "A" & B`, Artist: "Artist", Album: "Album", Duration: 185}
+	got := ParseDIDLItem(item.Marshal())
+	if got == nil {
+		t.Fatal("ParseDIDLItem returned nil")
+	}
+	if got.Title != item.Title || got.Artist != item.Artist || got.Album != item.Album || got.Duration != item.Duration {
+		t.Errorf("ParseDIDLItem = %+v, want %+v", got, item)
+	}
+	if ParseDIDLItem("") != nil || ParseDIDLItem("<not xml") != nil {
+		t.Error("ParseDIDLItem of invalid metadata should return nil")
+	}
+}
+
+func TestParseLastChange(t *testing.T) {
+	lc := LastChange(AVTransportEventNS, []Arg{
+		{Name: "TransportState", Value: "PLAYING"},
+		{Name: "CurrentTrackURI", Value: "http://host/a?b=1&c=2"},
+	}, "")
+	got, err := ParseLastChange(lc)
+	if err != nil {
+		t.Fatalf("ParseLastChange returned error: %v", err)
+	}
+	if got["TransportState"] != "PLAYING" || got["CurrentTrackURI"] != "http://host/a?b=1&c=2" {
+		t.Errorf("ParseLastChange = %v", got)
+	}
+}
+
+func TestParsePropertySet(t *testing.T) {
+	body := `<?xml version="1.0"?><e:propertyset xmlns:e="urn:schemas-upnp-org:event-1-0">` +
+		`<e:property><LastChange>&lt;Event/&gt;</LastChange></e:property>` +
+		`<e:property><SystemUpdateID>3</SystemUpdateID></e:property></e:propertyset>`
+	got, err := ParsePropertySet(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("ParsePropertySet returned error: %v", err)
+	}
+	if got["LastChange"] != "<Event/>" || got["SystemUpdateID"] != "3" {
+		t.Errorf("ParsePropertySet = %v", got)
+	}
+}
+
+func TestSubscribeCallbackHost(t *testing.T) {
+	e := newEventPublisher(func() []Arg { return nil })
+	subscribe := func(callback string) int {
+		r := httptest.NewRequest("SUBSCRIBE", "/event", nil)
+		r.RemoteAddr = "127.0.0.1:40000"
+		r.Header.Set("NT", "upnp:event")
+		r.Header.Set("CALLBACK", callback)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := subscribe("<http://192.0.2.1:8080/cb>"); code != http.StatusPreconditionFailed {
+		t.Errorf("subscribe with callback to other host = %d, want 412", code)
+	}
+	for i := range maxSubscriptions {
+		if code := subscribe("<http://127.0.0.1:1/cb>"); code != http.StatusOK {
+			t.Fatalf("subscription %d = %d, want 200", i, code)
+		}
+	}
+	if code := subscribe("<http://127.0.0.1:1/cb>"); code != http.StatusServiceUnavailable {
+		t.Errorf("subscribe past limit = %d, want 503", code)
+	}
+	e.close()
+}
@@ -0,0 +1,639 @@
+package backend
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/upnp"
+)
+
+const (
+	// how long the children of a container are cached
+	// before being fetched from the server again
+	upnpBrowseCacheTTL = 5 * time.Minute
+
+	// number of children fetched to report the child count of a large
+	// container, which is only known once all its children have been fetched
+	upnpChildCountPeek = 50
+)
+
+// object IDs of the top-level containers of the content directory
+const (
+	upnpRootID      = "0"
+	upnpArtistsID   = "artists"
+	upnpAlbumsID    = "albums"
+	upnpGenresID    = "genres"
+	upnpPlaylistsID = "playlists"
+	upnpFoldersID   = "folders"
+)
+
+// headers of the server's stream response forwarded to clients
+var upnpStreamHeaders = []string{
+	"Content-Type", "Content-Length", "Content-Range", "Accept-Ranges",
+	"Last-Modified", "ETag", "Cache-Control",
+}
+
+var errUPnPNoSuchObject = &upnp.Error{Code: 701, Description: "No such object"}
+
+// UPnPMediaServer exposes the library of the connected server
+// as a UPnP/DLNA MediaServer on the local network, so that it
+// can be browsed and played by other devices.
+type UPnPMediaServer struct {
+	// CoverArtPathFn returns a local filesystem path to the cover art
+	// of the given ID, which is served to clients that browse the library.
+	CoverArtPathFn func(coverArtID string) (string, error)
+
+	conn     atomic.Pointer[upnpServerConnection]
+	device   *upnp.Device
+	cd       *upnp.Service
+	updateID atomic.Uint32
+
+	cacheLock sync.Mutex
+	cache     map[string]*upnpCacheEntry
+}
+
+// upnpServerConnection is the connection to the server captured when it
+// is connected, so that the HTTP handlers need not read the ServerManager.
+type upnpServerConnection struct {
+	mp         mediaprovider.MediaProvider
+	httpClient *http.Client
+}
+
+// upnpCacheEntry holds the children of a container fetched so far.
+// The children of large containers, such as all albums, are fetched
+// from the server page by page, only as far as clients browse.
+type upnpCacheEntry struct {
+	lock     sync.Mutex
+	children []*upnpObject
+	next     func() *upnpObject // returns the next child, or nil when all have been fetched
+	fetched  time.Time
+}
+
+// upnpObject is a node of the content directory;
+// either a container or, if track is non-nil, a track.
+type upnpObject struct {
+	id         string
+	title      string
+	class      string
+	childCount int
+	artist     string
+	coverArtID string
+	track      *mediaprovider.Track
+}
+
+func NewUPnPMediaServer(friendlyName, appVersion string, sm *ServerManager) *UPnPMediaServer {
+	m := &UPnPMediaServer{cache: make(map[string]*upnpCacheEntry)}
+	m.updateID.Store(1)
+	m.cd = &upnp.Service{
+		Type:    upnp.ContentDirectoryType,
+		ID:      upnp.ContentDirectoryID,
+		SCPD:    upnp.ContentDirectorySCPD,
+		Handler: m.handleContentDirectory,
+		EventState: func() []upnp.Arg {
+			return []upnp.Arg{{Name: "SystemUpdateID", Value: strconv.Itoa(int(m.updateID.Load()))}}
+		},
+	}
+	cm := upnp.NewConnectionManager(upnpSinkProtocols, nil)
+	m.device = &upnp.Device{
+		Type:         upnp.MediaServerType,
+		FriendlyName: friendlyName,
+		ModelName:    "Supersonic",
+		ModelNumber:  appVersion,
+		DLNADoc:      "DMS-1.50",
+		Services:     []*upnp.Service{m.cd, cm},
+	}
+	m.device.Handle("/stream/", http.HandlerFunc(m.serveStream))
+	m.device.Handle("/art/", serveUPnPCoverArt(func(id string) (string, error) {
+		if m.CoverArtPathFn == nil {
+			return "", fmt.Errorf("cover art not available")
+		}
+		return m.CoverArtPathFn(id)
+	}))
+
+	sm.OnServerConnected(func(*ServerConfig) {
+		m.conn.Store(&upnpServerConnection{mp: sm.Server, httpClient: sm.HTTPClient()})
+		m.libraryChanged()
+	})
+	sm.OnLogout(func() {
+		m.conn.Store(nil)
+		m.libraryChanged()
+	})
+
+	return m
+}
+
+// Start begins advertising the media server on the local network.
+func (m *UPnPMediaServer) Start() error {
+	return m.device.Start()
+}
+
+// Shutdown stops advertising the media server and closes its HTTP server.
+func (m *UPnPMediaServer) Shutdown() {
+	m.device.Close()
+}
+
+// server returns the media provider of the connected server, or nil.
+func (m *UPnPMediaServer) server() mediaprovider.MediaProvider {
+	if c := m.conn.Load(); c != nil {
+		return c.mp
+	}
+	return nil
+}
+
+func (m *UPnPMediaServer) libraryChanged() {
+	m.cacheLock.Lock()
+	m.cache = make(map[string]*upnpCacheEntry)
+	m.cacheLock.Unlock()
+	m.updateID.Add(1)
+	m.cd.NotifyChanged()
+}
+
+func (m *UPnPMediaServer) handleContentDirectory(a *upnp.Action) ([]upnp.Arg, error) {
+	switch a.Name {
+	case "GetSearchCapabilities":
+		return []upnp.Arg{{Name: "SearchCaps", Value: ""}}, nil
+	case "GetSortCapabilities":
+		return []upnp.Arg{{Name: "SortCaps", Value: ""}}, nil
+	case "GetSystemUpdateID":
+		return []upnp.Arg{{Name: "Id", Value: strconv.Itoa(int(m.updateID.Load()))}}, nil
+	case "Browse":
+		return m.browse(a)
+	}
+	return nil, upnp.ErrInvalidAction
+}
+
+func (m *UPnPMediaServer) browse(a *upnp.Action) ([]upnp.Arg, error) {
+	objectID := a.Args["ObjectID"]
+	start, err1 := strconv.Atoi(a.Args["StartingIndex"])
+	count, err2 := strconv.Atoi(a.Args["RequestedCount"])
+	if err1 != nil || err2 != nil || start < 0 || count < 0 {
+		return nil, upnp.ErrInvalidArgs
+	}
+
+	var objects []upnp.DIDLObject
+	var total int
+	switch a.Args["BrowseFlag"] {
+	case "BrowseMetadata":
+		obj, parentID, err := m.metadata(objectID)
+		if err != nil {
+			return nil, err
+		}
+		objects = []upnp.DIDLObject{m.didlObject(obj, parentID, a.Host)}
+		total = 1
+	case "BrowseDirectChildren":
+		n := -1
+		if count > 0 {
+			n = start + count
+		}
+		children, complete, err := m.children(objectID, n)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range children[min(start, len(children)):] {
+			objects = append(objects, m.didlObject(c, objectID, a.Host))
+		}
+		// if not all children have been fetched, the total is unknown,
+		// which is indicated by 0 and clients browse until fewer are returned
+		if complete {
+			total = len(children)
+		}
+	default:
+		return nil, upnp.ErrInvalidArgs
+	}
+
+	return []upnp.Arg{
+		{Name: "Result", Value: upnp.MarshalDIDL(objects)},
+		{Name: "NumberReturned", Value: strconv.Itoa(len(objects))},
+		{Name: "TotalMatches", Value: strconv.Itoa(total)},
+		{Name: "UpdateID", Value: strconv.Itoa(int(m.updateID.Load()))},
+	}, nil
+}
+
+// metadata returns the object of the given ID and the ID of its parent.
+func (m *UPnPMediaServer) metadata(objectID string) (*upnpObject, string, error) {
+	if objectID == upnpRootID {
+		children, _, _ := m.children(upnpRootID, -1)
+		return &upnpObject{id: upnpRootID, title: "Supersonic", class: upnp.ClassContainer, childCount: len(children)}, "-1", nil
+	}
+	mp := m.server()
+	if mp == nil {
+		return nil, "", errUPnPNoSuchObject
+	}
+
+	kind, key, ok := splitUPnPObjectID(objectID)
+	if !ok {
+		// one of the top-level containers
+		for _, c := range m.rootContainers() {
+			if c.id == objectID {
+				// a lower bound if the container is large
+				children, _, err := m.children(objectID, upnpChildCountPeek)
+				if err != nil {
+					return nil, "", err
+				}
+				c.childCount = len(children)
+				return c, upnpRootID, nil
+			}
+		}
+		return nil, "", errUPnPNoSuchObject
+	}
+
+	switch kind {
+	case "artist":
+		ar, err := mp.GetArtist(key)
+		if err != nil {
+			return nil, "", err
+		}
+		return upnpArtistObject(&ar.Artist), upnpArtistsID, nil
+	case "album":
+		al, err := mp.GetAlbum(key)
+		if err != nil {
+			return nil, "", err
+		}
+		return upnpAlbumObject(&al.Album), upnpAlbumsID, nil
+	case "genre":
+		genres, _, err := m.children(upnpGenresID, -1)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, g := range genres {
+			if g.id == objectID {
+				return g, upnpGenresID, nil
+			}
+		}
+		return nil, "", errUPnPNoSuchObject
+	case "playlist":
+		pl, err := mp.GetPlaylist(key)
+		if err != nil {
+			return nil, "", err
+		}
+		return upnpPlaylistObject(&pl.Playlist), upnpPlaylistsID, nil
+	case "folder":
+		fp, ok := mp.(mediaprovider.FolderBrowsingProvider)
+		if !ok {
+			return nil, "", errUPnPNoSuchObject
+		}
+		f, err := fp.GetFolder(key)
+		if err != nil {
+			return nil, "", err
+		}
+		parentID := upnpFoldersID
+		if n := len(f.Ancestors); n > 0 && f.Ancestors[n-1].ID != "" {
+			parentID = "folder/" + url.PathEscape(f.Ancestors[n-1].ID)
+		}
+		obj := upnpFolderObject(&f.Folder)
+		obj.childCount = len(f.Subfolders) + len(f.Tracks)
+		return obj, parentID, nil
+	case "track":
+		tr, err := mp.GetTrack(key)
+		if err != nil {
+			return nil, "", err
+		}
+		return &upnpObject{id: objectID, track: tr}, "album/" + url.PathEscape(tr.AlbumID), nil
+	}
+	return nil, "", errUPnPNoSuchObject
+}
+
+// children returns the first n children (or all, if n < 0) of the given
+// container, and whether they are all of its children. Children are
+// served from the cache if they were fetched recently.
+func (m *UPnPMediaServer) children(objectID string, n int) ([]*upnpObject, bool, error) {
+	m.cacheLock.Lock()
+	entry, ok := m.cache[objectID]
+	m.cacheLock.Unlock()
+	if !ok || time.Since(entry.fetched) >= upnpBrowseCacheTTL {
+		children, next, err := m.fetchChildren(objectID)
+		if err != nil {
+			return nil, false, err
+		}
+		entry = &upnpCacheEntry{children: children, next: next, fetched: time.Now()}
+		m.cacheLock.Lock()
+		for id, e := range m.cache {
+			if time.Since(e.fetched) >= upnpBrowseCacheTTL {
+				delete(m.cache, id)
+			}
+		}
+		m.cache[objectID] = entry
+		m.cacheLock.Unlock()
+	}
+
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+	for entry.next != nil && (n < 0 || len(entry.children) < n) {
+		c := entry.next()
+		if c == nil {
+			entry.next = nil
+			break
+		}
+		entry.children = append(entry.children, c)
+	}
+	children := entry.children
+	if n >= 0 && n < len(children) {
+		children = children[:n]
+	}
+	return children, entry.next == nil && len(children) == len(entry.children), nil
+}
+
+// fetchChildren returns the children of the given container, or for large
+// containers, a function to fetch them one by one as the server is paged through.
+func (m *UPnPMediaServer) fetchChildren(objectID string) ([]*upnpObject, func() *upnpObject, error) {
+	mp := m.server()
+	if objectID == upnpRootID {
+		if mp == nil {
+			return nil, nil, nil
+		}
+		return m.rootContainers(), nil, nil
+	}
+	if mp == nil {
+		return nil, nil, errUPnPNoSuchObject
+	}
+
+	var children []*upnpObject
+	switch objectID {
+	case upnpArtistsID:
+		iter := mp.IterateArtists(mediaprovider.ArtistSortNameAZ, mediaprovider.NewArtistFilter(mediaprovider.ArtistFilterOptions{}))
+		return nil, func() *upnpObject {
+			if ar := iter.Next(); ar != nil {
+				return upnpArtistObject(ar)
+			}
+			return nil
+		}, nil
+	case upnpAlbumsID:
+		iter := mp.IterateAlbums(mediaprovider.AlbumSortTitleAZ, mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{}))
+		return nil, upnpAlbumIterator(iter), nil
+	case upnpGenresID:
+		genres, err := mp.GetGenres()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, g := range genres {
+			children = append(children, &upnpObject{
+				id:         "genre/" + url.PathEscape(g.Name),
+				title:      g.Name,
+				class:      upnp.ClassMusicGenre,
+				childCount: g.AlbumCount,
+			})
+		}
+		return children, nil, nil
+	case upnpPlaylistsID:
+		playlists, err := mp.GetPlaylists()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, p := range playlists {
+			children = append(children, upnpPlaylistObject(p))
+		}
+		return children, nil, nil
+	case upnpFoldersID:
+		children, err := m.folderChildren("")
+		return children, nil, err
+	}
+
+	kind, key, ok := splitUPnPObjectID(objectID)
+	if !ok {
+		return nil, nil, errUPnPNoSuchObject
+	}
+	switch kind {
+	case "artist":
+		ar, err := mp.GetArtist(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, al := range ar.Albums {
+			children = append(children, upnpAlbumObject(al))
+		}
+		return children, nil, nil
+	case "album":
+		al, err := mp.GetAlbum(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		return upnpTrackObjects(al.Tracks), nil, nil
+	case "genre":
+		filter := mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{Genres: []string{key}})
+		return nil, upnpAlbumIterator(mp.IterateAlbums(mediaprovider.AlbumSortTitleAZ, filter)), nil
+	case "playlist":
+		pl, err := mp.GetPlaylist(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		return upnpTrackObjects(pl.Tracks), nil, nil
+	case "folder":
+		children, err := m.folderChildren(key)
+		return children, nil, err
+	}
+	return nil, nil, errUPnPNoSuchObject
+}
+
+func upnpAlbumIterator(iter mediaprovider.AlbumIterator) func() *upnpObject {
+	return func() *upnpObject {
+		if al := iter.Next(); al != nil {
+			return upnpAlbumObject(al)
+		}
+		return nil
+	}
+}
+
+func (m *UPnPMediaServer) folderChildren(folderID string) ([]*upnpObject, error) {
+	fp, ok := m.server().(mediaprovider.FolderBrowsingProvider)
+	if !ok {
+		return nil, errUPnPNoSuchObject
+	}
+	f, err := fp.GetFolder(folderID)
+	if err != nil {
+		return nil, err
+	}
+	children := make([]*upnpObject, 0, len(f.Subfolders)+len(f.Tracks))
+	for _, sub := range f.Subfolders {
+		children = append(children, upnpFolderObject(sub))
+	}
+	return append(children, upnpTrackObjects(f.Tracks)...), nil
+}
+
+func (m *UPnPMediaServer) rootContainers() []*upnpObject {
+	roots := []*upnpObject{
+		{id: upnpArtistsID, title: "Artists", class: upnp.ClassContainer},
+		{id: upnpAlbumsID, title: "Albums", class: upnp.ClassContainer},
+		{id: upnpGenresID, title: "Genres", class: upnp.ClassContainer},
+		{id: upnpPlaylistsID, title: "Playlists", class: upnp.ClassContainer},
+	}
+	if _, ok := m.server().(mediaprovider.FolderBrowsingProvider); ok {
+		roots = append(roots, &upnpObject{id: upnpFoldersID, title: "Folders", class: upnp.ClassStorageFolder})
+	}
+	return roots
+}
+
+func (m *UPnPMediaServer) didlObject(o *upnpObject, parentID, host string) upnp.DIDLObject {
+	if tr := o.track; tr != nil {
+		contentType := tr.ContentType
+		if contentType == "" {
+			contentType = "audio/mpeg"
+		}
+		item := &upnp.DIDLItem{
+			ID:           o.id,
+			ParentID:     parentID,
+			Title:        tr.Title,
+			Artist:       strings.Join(tr.ArtistNames, ", "),
+			Album:        tr.Album,
+			TrackNumber:  tr.TrackNumber,
+			Duration:     tr.Duration.Seconds(),
+			AlbumArtURI:  m.coverArtURL(host, tr.CoverArtID),
+			URL:          fmt.Sprintf("http://%s/stream/%s", host, url.PathEscape(tr.ID)),
+			ProtocolInfo: "http-get:*:" + contentType + ":DLNA.ORG_OP=01;DLNA.ORG_FLAGS=01700000000000000000000000000000",
+		}
+		if len(tr.Genres) > 0 {
+			item.Genre = tr.Genres[0]
+		}
+		return item
+	}
+	return &upnp.DIDLContainer{
+		ID:          o.id,
+		ParentID:    parentID,
+		Title:       o.title,
+		Class:       o.class,
+		ChildCount:  o.childCount,
+		Artist:      o.artist,
+		AlbumArtURI: m.coverArtURL(host, o.coverArtID),
+	}
+}
+
+func (m *UPnPMediaServer) coverArtURL(host, coverArtID string) string {
+	if m.CoverArtPathFn == nil || coverArtID == "" {
+		return ""
+	}
+	return fmt.Sprintf("http://%s/art/%s", host, url.PathEscape(coverArtID))
+}
+
+// serveStream proxies the stream of a track from the server,
+// so that clients need neither the server's address nor credentials.
+func (m *UPnPMediaServer) serveStream(w http.ResponseWriter, r *http.Request) {
+	conn := m.conn.Load()
+	if conn == nil {
+		http.NotFound(w, r)
+		return
+	}
+	mp := conn.mp
+	trackID := strings.TrimPrefix(r.URL.Path, "/stream/")
+	// errors are not sent to clients, as they may contain
+	// the stream URL, with the server credentials
+	streamURL, err := mp.GetStreamURL(trackID, nil, true)
+	if err != nil {
+		log.Printf("UPnP media server: error getting stream URL: %v", err)
+		http.NotFound(w, r)
+		return
+	}
+
+	proxyReq, err := http.NewRequestWithContext(r.Context(), r.Method, streamURL, nil)
+	if err != nil {
+		log.Printf("UPnP media server: error creating stream request: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	// forward range requests so clients can seek
+	for _, h := range []string{"Range", "If-Range"} {
+		if v := r.Header.Get(h); v != "" {
+			proxyReq.Header.Set(h, v)
+		}
+	}
+
+	resp, err := conn.httpClient.Do(proxyReq)
+	if err != nil {
+		log.Printf("UPnP media server: error fetching stream: %v", err)
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	// forward only the headers describing the content; others,
+	// such as Set-Cookie, concern the server and not the client
+	for _, h := range upnpStreamHeaders {
+		for _, value := range resp.Header.Values(h) {
+			w.Header().Add(h, value)
+		}
+	}
+	w.Header().Set("transferMode.dlna.org", "Streaming")
+	w.WriteHeader(resp.StatusCode)
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		log.Printf("UPnP media server: error copying stream: %v", err)
+	}
+}
+
+// serveUPnPCoverArt returns a handler serving the cover art files
+// at /art/{coverArtID}, as located by pathFn.
+func serveUPnPCoverArt(pathFn func(coverArtID string) (string, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		coverArtID := strings.TrimPrefix(r.URL.Path, "/art/")
+		path, err := pathFn(coverArtID)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, path)
+	})
+}
+
+// splitUPnPObjectID splits an object ID of the form "{kind}/{escaped key}".
+func splitUPnPObjectID(objectID string) (kind, key string, ok bool) {
+	kind, escaped, ok := strings.Cut(objectID, "/")
+	if !ok {
+		return "", "", false
+	}
+	key, err := url.PathUnescape(escaped)
+	return kind, key, err == nil
+}
+
+func upnpArtistObject(ar *mediaprovider.Artist) *upnpObject {
+	return &upnpObject{
+		id:         "artist/" + url.PathEscape(ar.ID),
+		title:      ar.Name,
+		class:      upnp.ClassMusicArtist,
+		childCount: ar.AlbumCount,
+		coverArtID: ar.CoverArtID,
+	}
+}
+
+func upnpAlbumObject(al *mediaprovider.Album) *upnpObject {
+	return &upnpObject{
+		id:         "album/" + url.PathEscape(al.ID),
+		title:      al.Name,
+		class:      upnp.ClassMusicAlbum,
+		childCount: al.TrackCount,
+		artist:     strings.Join(al.ArtistNames, ", "),
+		coverArtID: al.CoverArtID,
+	}
+}
+
+func upnpPlaylistObject(p *mediaprovider.Playlist) *upnpObject {
+	return &upnpObject{
+		id:         "playlist/" + url.PathEscape(p.ID),
+		title:      p.Name,
+		class:      upnp.ClassPlaylist,
+		childCount: p.TrackCount,
+		coverArtID: p.CoverArtID,
+	}
+}
+
+func upnpFolderObject(f *mediaprovider.Folder) *upnpObject {
+	return &upnpObject{
+		id:         "folder/" + url.PathEscape(f.ID),
+		title:      f.Name,
+		class:      upnp.ClassStorageFolder,
+		coverArtID: f.CoverArtID,
+	}
+}
+
+func upnpTrackObjects(tracks []*mediaprovider.Track) []*upnpObject {
+	objs := make([]*upnpObject, len(tracks))
+	for i, tr := range tracks {
+		objs[i] = &upnpObject{id: "track/" + url.PathEscape(tr.ID), track: tr}
+	}
+	return objs
+}
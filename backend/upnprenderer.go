@@ -0,0 +1,512 @@
+package backend
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/player"
+	"github.com/dweymouth/supersonic/backend/upnp"
+	"github.com/dweymouth/supersonic/backend/util"
+)
+
+// protocols of the media the renderer accepts
+var upnpSinkProtocols = []string{
+	"http-get:*:audio/mpeg:*",
+	"http-get:*:audio/mp4:*",
+	"http-get:*:audio/x-m4a:*",
+	"http-get:*:audio/aac:*",
+	"http-get:*:audio/flac:*",
+	"http-get:*:audio/x-flac:*",
+	"http-get:*:audio/ogg:*",
+	"http-get:*:application/ogg:*",
+	"http-get:*:audio/opus:*",
+	"http-get:*:audio/webm:*",
+	"http-get:*:audio/wav:*",
+	"http-get:*:audio/x-wav:*",
+	"http-get:*:audio/L16:*",
+	"http-get:*:audio/x-ms-wma:*",
+	"http-get:*:audio/x-aiff:*",
+	"http-get:*:audio/x-ape:*",
+	"http-get:*:audio/x-wavpack:*",
+}
+
+var (
+	errUPnPInvalidInstanceID = &upnp.Error{Code: 718, Description: "Invalid InstanceID"}
+	errUPnPSeekModeNotSupp   = &upnp.Error{Code: 710, Description: "Seek mode not supported"}
+	errUPnPIllegalSeekTarget = &upnp.Error{Code: 711, Description: "Illegal seek target"}
+	errUPnPResourceNotFound  = &upnp.Error{Code: 716, Description: "Resource not found"}
+)
+
+// UPnPRenderer advertises Supersonic as a UPnP/DLNA MediaRenderer on the
+// local network, so that control points such as phone apps can cast to it.
+// Media cast to the renderer is played through the PlaybackManager
+// as ad-hoc radio station items, replacing the play queue.
+type UPnPRenderer struct {
+	// CoverArtPathFn returns a local filesystem path to the cover art
+	// image for the given CoverArtID, which is served to control points.
+	CoverArtPathFn func(coverArtID string) (string, error)
+
+	pm     *PlaybackManager
+	device *upnp.Device
+	avt    *upnp.Service
+	rc     *upnp.Service
+
+	mu sync.Mutex
+	// the current and next media set by the control point
+	uri, uriMeta         string
+	station              *mediaprovider.RadioStation
+	duration             float64 // from the metadata, if known
+	loaded               bool    // whether station has been loaded into the play queue
+	nextURI, nextURIMeta string
+	nextStation          *mediaprovider.RadioStation
+	nextDuration         float64
+	// volume before muting, or -1 if not muted
+	preMuteVolume int
+}
+
+// NewUPnPRenderer creates a renderer advertised with the given name.
+func NewUPnPRenderer(friendlyName, appVersion string, pm *PlaybackManager) *UPnPRenderer {
+	u := &UPnPRenderer{pm: pm, preMuteVolume: -1}
+	u.avt = &upnp.Service{
+		Type:       upnp.AVTransportType,
+		ID:         upnp.AVTransportID,
+		SCPD:       upnp.AVTransportSCPD,
+		Handler:    u.handleAVTransport,
+		EventState: u.avTransportEventState,
+	}
+	u.rc = &upnp.Service{
+		Type:       upnp.RenderingControlType,
+		ID:         upnp.RenderingControlID,
+		SCPD:       upnp.RenderingControlSCPD,
+		Handler:    u.handleRenderingControl,
+		EventState: u.renderingControlEventState,
+	}
+	cm := upnp.NewConnectionManager(nil, upnpSinkProtocols)
+	u.device = &upnp.Device{
+		Type:         upnp.MediaRendererType,
+		FriendlyName: friendlyName,
+		ModelName:    "Supersonic",
+		ModelNumber:  appVersion,
+		DLNADoc:      "DMR-1.50",
+		Services:     []*upnp.Service{u.avt, cm, u.rc},
+	}
+	u.device.Handle("/art/", serveUPnPCoverArt(func(id string) (string, error) {
+		return u.CoverArtPathFn(id)
+	}))
+
+	pm.OnSongChange(func(item mediaprovider.MediaItem, _ *mediaprovider.Track) {
+		u.mu.Lock()
+		if item != nil && u.nextStation != nil && item.Metadata().ID == u.nextStation.ID {
+			// the queued next media has begun playing
+			u.uri, u.uriMeta, u.station, u.duration = u.nextURI, u.nextURIMeta, u.nextStation, u.nextDuration
+			u.nextURI, u.nextURIMeta, u.nextStation = "", "", nil
+		}
+		u.mu.Unlock()
+		u.avt.NotifyChanged()
+	})
+	pm.OnPlaying(u.avt.NotifyChanged)
+	pm.OnPaused(u.avt.NotifyChanged)
+	pm.OnStopped(u.avt.NotifyChanged)
+	pm.OnVolumeChange(func(vol int) {
+		u.mu.Lock()
+		if vol > 0 {
+			u.preMuteVolume = -1
+		}
+		u.mu.Unlock()
+		u.rc.NotifyChanged()
+	})
+
+	return u
+}
+
+// Starts serving and advertising the renderer.
+func (u *UPnPRenderer) Start() error {
+	return u.device.Start()
+}
+
+// Stops advertising and serving the renderer.
+func (u *UPnPRenderer) Shutdown() {
+	u.device.Close()
+}
+
+func (u *UPnPRenderer) handleAVTransport(a *upnp.Action) ([]upnp.Arg, error) {
+	if a.Args["InstanceID"] != "0" {
+		return nil, errUPnPInvalidInstanceID
+	}
+	switch a.Name {
+	case "SetAVTransportURI":
+		return nil, u.setAVTransportURI(a.Args["CurrentURI"], a.Args["CurrentURIMetaData"])
+	case "SetNextAVTransportURI":
+		return nil, u.setNextAVTransportURI(a.Args["NextURI"], a.Args["NextURIMetaData"])
+	case "GetMediaInfo":
+		s := u.currentState()
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		nrTracks, medium := "0", "NONE"
+		if s.uri != "" {
+			nrTracks, medium = "1", "NETWORK"
+		}
+		return []upnp.Arg{
+			{Name: "NrTracks", Value: nrTracks},
+			{Name: "MediaDuration", Value: upnp.FormatDuration(s.duration)},
+			{Name: "CurrentURI", Value: s.uri},
+			{Name: "CurrentURIMetaData", Value: s.meta},
+			{Name: "NextURI", Value: u.nextURI},
+			{Name: "NextURIMetaData", Value: u.nextURIMeta},
+			{Name: "PlayMedium", Value: medium},
+			{Name: "RecordMedium", Value: "NOT_IMPLEMENTED"},
+			{Name: "WriteStatus", Value: "NOT_IMPLEMENTED"},
+		}, nil
+	case "GetTransportInfo":
+		return []upnp.Arg{
+			{Name: "CurrentTransportState", Value: u.currentState().transportState},
+			{Name: "CurrentTransportStatus", Value: "OK"},
+			{Name: "CurrentSpeed", Value: "1"},
+		}, nil
+	case "GetPositionInfo":
+		s := u.currentState()
+		track := "0"
+		if s.uri != "" || s.meta != "" {
+			track = "1"
+		}
+		return []upnp.Arg{
+			{Name: "Track", Value: track},
+			{Name: "TrackDuration", Value: upnp.FormatDuration(s.duration)},
+			{Name: "TrackMetaData", Value: s.meta},
+			{Name: "TrackURI", Value: s.uri},
+			{Name: "RelTime", Value: upnp.FormatDuration(s.position)},
+			{Name: "AbsTime", Value: upnp.FormatDuration(s.position)},
+			{Name: "RelCount", Value: "2147483647"}, // not implemented
+			{Name: "AbsCount", Value: "2147483647"},
+		}, nil
+	case "GetDeviceCapabilities":
+		return []upnp.Arg{
+			{Name: "PlayMedia", Value: "NETWORK"},
+			{Name: "RecMedia", Value: "NOT_IMPLEMENTED"},
+			{Name: "RecQualityModes", Value: "NOT_IMPLEMENTED"},
+		}, nil
+	case "GetTransportSettings":
+		return []upnp.Arg{
+			{Name: "PlayMode", Value: "NORMAL"},
+			{Name: "RecQualityMode", Value: "NOT_IMPLEMENTED"},
+		}, nil
+	case "GetCurrentTransportActions":
+		return []upnp.Arg{
+			{Name: "Actions", Value: u.currentState().transportActions()},
+		}, nil
+	case "Play":
+		u.play()
+		return nil, nil
+	case "Pause":
+		u.pm.Pause()
+		return nil, nil
+	case "Stop":
+		u.pm.Stop()
+		return nil, nil
+	case "Seek":
+		unit := a.Args["Unit"]
+		if unit != "REL_TIME" && unit != "ABS_TIME" {
+			return nil, errUPnPSeekModeNotSupp
+		}
+		secs, err := upnp.ParseDuration(a.Args["Target"])
+		if err != nil {
+			return nil, errUPnPIllegalSeekTarget
+		}
+		u.seek(secs)
+		return nil, nil
+	case "Next":
+		u.pm.SeekNext()
+		return nil, nil
+	case "Previous":
+		u.pm.SeekBackOrPrevious()
+		return nil, nil
+	}
+	return nil, upnp.ErrInvalidAction
+}
+
+func (u *UPnPRenderer) setAVTransportURI(uri, meta string) error {
+	if uri != "" {
+		if err := checkUPnPMediaURI(uri); err != nil {
+			return err
+		}
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.nextURI, u.nextURIMeta, u.nextStation = "", "", nil
+	if uri == "" {
+		u.uri, u.uriMeta, u.station, u.loaded = "", "", nil, false
+		return nil
+	}
+	u.uri, u.uriMeta = uri, meta
+	u.station, u.duration = newUPnPRadioStation(uri, meta)
+	u.loaded = false
+	// a new URI set while playing begins playing immediately
+	if u.pm.PlaybackStatus().State == player.Playing {
+		u.pm.PlayRadioStation(u.station)
+		u.loaded = true
+	}
+	go u.avt.NotifyChanged()
+	return nil
+}
+
+func (u *UPnPRenderer) setNextAVTransportURI(uri, meta string) error {
+	if uri != "" {
+		if err := checkUPnPMediaURI(uri); err != nil {
+			return err
+		}
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if uri == "" {
+		u.nextURI, u.nextURIMeta, u.nextStation = "", "", nil
+		return nil
+	}
+	u.nextURI, u.nextURIMeta = uri, meta
+	u.nextStation, u.nextDuration = newUPnPRadioStation(uri, meta)
+	if u.loaded {
+		u.pm.LoadRadioStation(u.nextStation, InsertNext)
+	}
+	go u.avt.NotifyChanged()
+	return nil
+}
+
+func (u *UPnPRenderer) play() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.station != nil && (!u.loaded || u.pm.PlaybackStatus().State == player.Stopped) {
+		u.pm.PlayRadioStation(u.station)
+		if u.nextStation != nil {
+			u.pm.LoadRadioStation(u.nextStation, Append)
+		}
+		u.loaded = true
+		return
+	}
+	u.pm.Continue()
+}
+
+func (u *UPnPRenderer) seek(secs float64) {
+	if u.isPlayingStation() {
+		// the engine does not seek radio stations, as live streams
+		// can't be seeked, so seek the cast media in the player
+		u.pm.CurrentPlayer().SeekSeconds(secs)
+		return
+	}
+	u.pm.SeekSeconds(secs)
+}
+
+func (u *UPnPRenderer) isPlayingStation() bool {
+	nowPlaying := u.pm.NowPlaying()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.loaded && u.station != nil && nowPlaying != nil && nowPlaying.Metadata().ID == u.station.ID
+}
+
+func (u *UPnPRenderer) handleRenderingControl(a *upnp.Action) ([]upnp.Arg, error) {
+	if a.Args["InstanceID"] != "0" {
+		return nil, errUPnPInvalidInstanceID
+	}
+	switch a.Name {
+	case "ListPresets":
+		return []upnp.Arg{{Name: "CurrentPresetNameList", Value: "FactoryDefaults"}}, nil
+	case "SelectPreset":
+		if a.Args["PresetName"] != "FactoryDefaults" {
+			return nil, upnp.ErrInvalidArgs
+		}
+		return nil, nil
+	case "GetVolume":
+		return []upnp.Arg{{Name: "CurrentVolume", Value: strconv.Itoa(u.pm.Volume())}}, nil
+	case "SetVolume":
+		vol, err := strconv.Atoi(a.Args["DesiredVolume"])
+		if err != nil || vol < 0 || vol > 100 {
+			return nil, upnp.ErrInvalidArgs
+		}
+		u.pm.SetVolume(vol)
+		return nil, nil
+	case "GetMute":
+		return []upnp.Arg{{Name: "CurrentMute", Value: boolToUPnP(u.isMuted())}}, nil
+	case "SetMute":
+		mute := a.Args["DesiredMute"] == "1" || strings.EqualFold(a.Args["DesiredMute"], "true")
+		u.setMute(mute)
+		return nil, nil
+	}
+	return nil, upnp.ErrInvalidAction
+}
+
+func (u *UPnPRenderer) isMuted() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.preMuteVolume >= 0
+}
+
+func (u *UPnPRenderer) setMute(mute bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if mute == (u.preMuteVolume >= 0) {
+		return
+	}
+	if mute {
+		u.preMuteVolume = u.pm.Volume()
+		u.pm.SetVolume(0)
+	} else {
+		vol := u.preMuteVolume
+		u.preMuteVolume = -1
+		u.pm.SetVolume(vol)
+	}
+}
+
+type upnpRendererState struct {
+	transportState     string
+	uri, meta          string
+	duration, position float64
+}
+
+func (s upnpRendererState) transportActions() string {
+	switch s.transportState {
+	case "PLAYING":
+		return "Pause,Stop,Seek,Next,Previous"
+	case "PAUSED_PLAYBACK":
+		return "Play,Stop,Seek,Next,Previous"
+	case "STOPPED":
+		return "Play"
+	}
+	return ""
+}
+
+// currentState returns the state of the renderer, which is the playback
+// state of the app, whether or not it is playing media cast to it.
+func (u *UPnPRenderer) currentState() upnpRendererState {
+	status := u.pm.PlaybackStatus()
+	nowPlaying := u.pm.NowPlaying()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	var s upnpRendererState
+	switch {
+	case u.station != nil && !u.loaded:
+		// set by the control point, but not yet played
+		s.transportState = "STOPPED"
+		s.uri, s.meta, s.duration = u.uri, u.uriMeta, u.duration
+		return s
+	case u.station != nil && nowPlaying != nil && nowPlaying.Metadata().ID == u.station.ID:
+		// the engine reports no duration for radio stations
+		s.uri, s.meta, s.duration = u.uri, u.uriMeta, u.duration
+	case nowPlaying != nil:
+		meta := nowPlaying.Metadata()
+		item := &upnp.DIDLItem{
+			ID:       meta.ID,
+			Title:    meta.Name,
+			Album:    meta.Album,
+			Duration: meta.Duration.Seconds(),
+		}
+		if len(meta.Artists) > 0 {
+			item.Artist = meta.Artists[0]
+		}
+		if u.CoverArtPathFn != nil && meta.CoverArtID != "" {
+			item.AlbumArtURI = u.coverArtURL(meta.CoverArtID)
+		}
+		s.meta = item.Marshal()
+		s.duration = status.Duration
+	default:
+		s.transportState = "NO_MEDIA_PRESENT"
+		return s
+	}
+	s.position = status.TimePos
+	switch status.State {
+	case player.Playing:
+		s.transportState = "PLAYING"
+	case player.Paused:
+		s.transportState = "PAUSED_PLAYBACK"
+	default:
+		s.transportState = "STOPPED"
+	}
+	return s
+}
+
+func (u *UPnPRenderer) avTransportEventState() []upnp.Arg {
+	s := u.currentState()
+	u.mu.Lock()
+	nextURI, nextURIMeta := u.nextURI, u.nextURIMeta
+	u.mu.Unlock()
+	nrTracks := "0"
+	if s.uri != "" || s.meta != "" {
+		nrTracks = "1"
+	}
+	vars := []upnp.Arg{
+		{Name: "TransportState", Value: s.transportState},
+		{Name: "TransportStatus", Value: "OK"},
+		{Name: "CurrentTransportActions", Value: s.transportActions()},
+		{Name: "NumberOfTracks", Value: nrTracks},
+		{Name: "CurrentTrack", Value: nrTracks},
+		{Name: "AVTransportURI", Value: s.uri},
+		{Name: "AVTransportURIMetaData", Value: s.meta},
+		{Name: "CurrentTrackURI", Value: s.uri},
+		{Name: "CurrentTrackMetaData", Value: s.meta},
+		{Name: "CurrentTrackDuration", Value: upnp.FormatDuration(s.duration)},
+		{Name: "CurrentMediaDuration", Value: upnp.FormatDuration(s.duration)},
+		{Name: "NextAVTransportURI", Value: nextURI},
+		{Name: "NextAVTransportURIMetaData", Value: nextURIMeta},
+	}
+	return []upnp.Arg{{Name: "LastChange", Value: upnp.LastChange(upnp.AVTransportEventNS, vars, "")}}
+}
+
+func (u *UPnPRenderer) renderingControlEventState() []upnp.Arg {
+	vars := []upnp.Arg{
+		{Name: "Volume", Value: strconv.Itoa(u.pm.Volume())},
+		{Name: "Mute", Value: boolToUPnP(u.isMuted())},
+	}
+	return []upnp.Arg{{Name: "LastChange", Value: upnp.LastChange(upnp.RenderingControlEventNS, vars, "Master")}}
+}
+
+func (u *UPnPRenderer) coverArtURL(coverArtID string) string {
+	ip, err := util.GetLocalIP()
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("http://%s:%d/art/%s", ip, u.device.Port(), url.PathEscape(coverArtID))
+}
+
+// checkUPnPMediaURI rejects media URIs other than http(s), as the URI
+// is opened by mpv, which would also open local files and devices.
+func checkUPnPMediaURI(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errUPnPResourceNotFound
+	}
+	return nil
+}
+
+// newUPnPRadioStation creates the ad-hoc queue item for media cast to the
+// renderer, also returning its duration from the metadata, if given.
+func newUPnPRadioStation(uri, meta string) (*mediaprovider.RadioStation, float64) {
+	sum := sha1.Sum([]byte(uri))
+	station := &mediaprovider.RadioStation{
+		ID:          "upnp-" + hex.EncodeToString(sum[:8]),
+		StationName: uri[strings.LastIndex(uri, "/")+1:],
+		StreamURL:   uri,
+	}
+	item := upnp.ParseDIDLItem(meta)
+	if item == nil {
+		return station, 0
+	}
+	if item.Title != "" {
+		station.StationName = item.Title
+		station.Title = item.Title
+	}
+	if item.Artist != "" {
+		station.Artists = []string{item.Artist}
+	}
+	return station, item.Duration
+}
+
+func boolToUPnP(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
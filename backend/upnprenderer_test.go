@@ -0,0 +1,19 @@
+package backend
+
+import "testing"
+
+func TestCheckUPnPMediaURI(t *testing.T) {
+	for _, uri := range []string{"http://192.168.1.2:8080/track.mp3", "https://example.com/a?b=c"} {
+		if err := checkUPnPMediaURI(uri); err != nil {
+			t.Errorf("checkUPnPMediaURI(%q) = %v, want nil", uri, err)
+		}
+	}
+	for _, uri := range []string{
+		"file:///etc/passwd", "edl://x", "av://v4l2:/dev/video0", "lavfi://sine",
+		"ytdl://abc", "http:///nohost", "/local/path", "not a url",
+	} {
+		if err := checkUPnPMediaURI(uri); err != errUPnPResourceNotFound {
+			t.Errorf("checkUPnPMediaURI(%q) = %v, want errUPnPResourceNotFound", uri, err)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/20after4/configdir"
+)
+
+const (
+	waveformFileExt = ".wfm"
+
+	// file in each server's cache dir listing the pinned albums
+	pinnedWaveformsFile = "pinned.json"
+
+	// prune the on-disk cache after this many new waveforms are written
+	waveformWritesBetweenPrunes = 50
+)
+
+// WaveformCache is a size-bounded on-disk cache of analyzed waveform data,
+// so that waveforms don't need to be regenerated by transcoding the track
+// every time it is played. Entries are keyed by server and track ID.
+// The waveforms of pinned albums are kept regardless of the size limit.
+type WaveformCache struct {
+	mutex        sync.Mutex
+	baseDir      string
+	maxSizeBytes int64
+	writes       int
+}
+
+// NewWaveformCache returns a new WaveformCache storing
+// up to maxSizeBytes of waveforms in baseDir.
+func NewWaveformCache(baseDir string, maxSizeBytes int64) *WaveformCache {
+	if err := configdir.MakePath(baseDir); err != nil {
+		log.Println("failed to create waveform cache dir")
+		baseDir = ""
+	}
+	w := &WaveformCache{baseDir: baseDir, maxSizeBytes: maxSizeBytes}
+	go w.prune()
+	return w
+}
+
+// SetMaxSizeBytes sets the maximum size of the on-disk waveform cache.
+// Least recently used waveforms are deleted to maintain the size limit.
+func (w *WaveformCache) SetMaxSizeBytes(size int64) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.maxSizeBytes = size
+}
+
+// Has returns true if the waveform for the given track is cached.
+func (w *WaveformCache) Has(serverID, trackID string) bool {
+	if w == nil || w.baseDir == "" {
+		return false
+	}
+	_, err := os.Stat(w.pathFor(serverID, trackID))
+	return err == nil
+}
+
+func (w *WaveformCache) load(serverID, trackID string) (*waveformData, bool) {
+	if w == nil || w.baseDir == "" {
+		return nil, false
+	}
+	path := w.pathFor(serverID, trackID)
+	b, err := os.ReadFile(path)
+	if err != nil || len(b) != 2*len(waveformData{}.Peak) {
+		return nil, false
+	}
+	// modTime is used as the last accessed time when pruning
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	data := &waveformData{done: true}
+	n := copy(data.Peak[:], b)
+	copy(data.RMS[:], b[n:])
+	data.progress = len(data.Peak)
+	return data, true
+}
+
+func (w *WaveformCache) save(serverID, trackID string, data *waveformData) {
+	if w == nil || w.baseDir == "" {
+		return
+	}
+	dir := filepath.Join(w.baseDir, sanitizeFileName(serverID))
+	if err := configdir.MakePath(dir); err != nil {
+		log.Printf("failed to create waveform cache dir: %s", err.Error())
+		return
+	}
+	b := make([]byte, 0, 2*len(data.Peak))
+	b = append(b, data.Peak[:]...)
+	b = append(b, data.RMS[:]...)
+	if err := os.WriteFile(w.pathFor(serverID, trackID), b, 0o644); err != nil {
+		log.Printf("failed to cache waveform: %s", err.Error())
+		return
+	}
+
+	w.mutex.Lock()
+	w.writes++
+	needPrune := w.writes >= waveformWritesBetweenPrunes
+	if needPrune {
+		w.writes = 0
+	}
+	w.mutex.Unlock()
+	if needPrune {
+		w.prune()
+	}
+}
+
+// PinAlbum keeps the waveforms of the given tracks of an album
+// in the cache regardless of the size limit, until it is unpinned.
+func (w *WaveformCache) PinAlbum(serverID, albumID string, trackIDs []string) error {
+	return w.updatePinned(serverID, func(pinned map[string][]string) {
+		pinned[albumID] = trackIDs
+	})
+}
+
+// UnpinAlbum lets the waveforms of an album be pruned from the cache again.
+func (w *WaveformCache) UnpinAlbum(serverID, albumID string) error {
+	return w.updatePinned(serverID, func(pinned map[string][]string) {
+		delete(pinned, albumID)
+	})
+}
+
+// IsAlbumPinned returns true if the album is pinned on the given server.
+func (w *WaveformCache) IsAlbumPinned(serverID, albumID string) bool {
+	if w == nil {
+		return false
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	_, ok := w.readPinned(serverID)[albumID]
+	return ok
+}
+
+// PinnedAlbums returns the IDs of the pinned albums on the given server.
+func (w *WaveformCache) PinnedAlbums(serverID string) []string {
+	if w == nil {
+		return nil
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	var ids []string
+	for id := range w.readPinned(serverID) {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (w *WaveformCache) updatePinned(serverID string, update func(map[string][]string)) error {
+	if w == nil || w.baseDir == "" {
+		return errors.New("waveform cache is not available")
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	pinned := w.readPinned(serverID)
+	update(pinned)
+	dir := filepath.Join(w.baseDir, sanitizeFileName(serverID))
+	if err := configdir.MakePath(dir); err != nil {
+		return err
+	}
+	b, err := json.Marshal(pinned)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, pinnedWaveformsFile), b, 0o644)
+}
+
+// readPinned returns the pinned albums of the server and their track IDs.
+// The caller must hold the mutex.
+func (w *WaveformCache) readPinned(serverID string) map[string][]string {
+	pinned := make(map[string][]string)
+	if w.baseDir == "" {
+		return pinned
+	}
+	b, err := os.ReadFile(filepath.Join(w.baseDir, sanitizeFileName(serverID), pinnedWaveformsFile))
+	if err == nil {
+		_ = json.Unmarshal(b, &pinned)
+	}
+	return pinned
+}
+
+// pinnedPaths returns the paths of the waveforms of all pinned tracks.
+// The caller must hold the mutex.
+func (w *WaveformCache) pinnedPaths() map[string]bool {
+	paths := make(map[string]bool)
+	serverDirs, _ := os.ReadDir(w.baseDir)
+	for _, d := range serverDirs {
+		if !d.IsDir() {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(w.baseDir, d.Name(), pinnedWaveformsFile))
+		if err != nil {
+			continue
+		}
+		var pinned map[string][]string
+		if json.Unmarshal(b, &pinned) != nil {
+			continue
+		}
+		for _, trackIDs := range pinned {
+			for _, id := range trackIDs {
+				paths[filepath.Join(w.baseDir, d.Name(), sanitizeFileName(id)+waveformFileExt)] = true
+			}
+		}
+	}
+	return paths
+}
+
+func (w *WaveformCache) pathFor(serverID, trackID string) string {
+	return filepath.Join(w.baseDir, sanitizeFileName(serverID), sanitizeFileName(trackID)+waveformFileExt)
+}
+
+func (w *WaveformCache) prune() {
+	if w.baseDir == "" {
+		return
+	}
+	w.mutex.Lock()
+	maxSize := w.maxSizeBytes
+	pinned := w.pinnedPaths()
+	w.mutex.Unlock()
+
+	type fileInfo struct {
+		path    string
+		size    int64
+		modTime int64
+	}
+	var files []fileInfo
+	var totalSize int64
+	filepath.WalkDir(w.baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, waveformFileExt) || pinned[path] {
+			// pinned waveforms neither count towards the limit nor are pruned
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			files = append(files, fileInfo{path: path, size: info.Size(), modTime: info.ModTime().UnixMilli()})
+			totalSize += info.Size()
+		}
+		return nil
+	})
+
+	if totalSize > maxSize {
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].modTime < files[j].modTime
+		})
+		for i := 0; i < len(files) && totalSize > maxSize; i++ {
+			if err := os.Remove(files[i].path); err == nil {
+				totalSize -= files[i].size
+			}
+		}
+	}
+}
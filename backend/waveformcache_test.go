@@ -0,0 +1,70 @@
+package backend
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWaveformCache(t *testing.T) {
+	c := NewWaveformCache(t.TempDir(), 20*1_048_576)
+	if _, ok := c.load("srv", "t1"); ok {
+		t.Fatal("expected cache miss")
+	}
+	data := &waveformData{}
+	for i := range data.Peak {
+		data.Peak[i] = byte(i)
+		data.RMS[i] = byte(i / 2)
+	}
+	c.save("srv", "t1", data)
+	if !c.Has("srv", "t1") || c.Has("other", "t1") {
+		t.Error("expected waveforms to be keyed by server and track")
+	}
+	loaded, ok := c.load("srv", "t1")
+	if !ok || !loaded.done || loaded.progress != len(loaded.Peak) {
+		t.Fatal("expected complete cached waveform")
+	}
+	if loaded.Peak != data.Peak || loaded.RMS != data.RMS {
+		t.Error("cached waveform data does not match")
+	}
+
+	c.SetMaxSizeBytes(int64(3 * 2 * len(data.Peak)))
+	for _, id := range []string{"t2", "t3", "t4"} {
+		c.save("srv", id, data)
+	}
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(c.pathFor("srv", "t1"), old, old)
+	c.prune()
+	if c.Has("srv", "t1") {
+		t.Error("expected least recently used waveform to be pruned")
+	}
+}
+
+func TestWaveformCachePinnedAlbums(t *testing.T) {
+	data := &waveformData{}
+	c := NewWaveformCache(t.TempDir(), int64(2*len(data.Peak)))
+	if err := c.PinAlbum("srv", "al1", []string{"t1", "t2"}); err != nil {
+		t.Fatal(err)
+	}
+	if !c.IsAlbumPinned("srv", "al1") || c.IsAlbumPinned("other", "al1") {
+		t.Error("expected album to be pinned per server")
+	}
+	for _, id := range []string{"t1", "t2", "t3", "t4"} {
+		c.save("srv", id, data)
+	}
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(c.pathFor("srv", "t1"), old, old)
+	c.prune()
+	if !c.Has("srv", "t1") || !c.Has("srv", "t2") {
+		t.Error("expected waveforms of pinned album to survive pruning")
+	}
+
+	c.UnpinAlbum("srv", "al1")
+	if len(c.PinnedAlbums("srv")) != 0 {
+		t.Error("expected album to be unpinned")
+	}
+	c.prune()
+	if c.Has("srv", "t1") {
+		t.Error("expected waveforms of unpinned album to be pruned")
+	}
+}
@@ -21,8 +21,20 @@ import (
 	"github.com/supersonic-app/go-mpv"
 )
 
+// maximum time to spend pre-generating the waveform of a single track
+const pregenerateTimeout = 2 * time.Minute
+
 type WaveformImageGenerator struct {
 	audioCache *AudioCache
+	diskCache  *WaveformCache
+
+	// returns the URL to download the audio of a track from
+	streamURL func(*mediaprovider.Track) string
+
+	pregenLock    sync.Mutex
+	pregenQueue   []*mediaprovider.Track
+	pinnedQueue   []*mediaprovider.Track
+	pregenRunning bool
 }
 
 // Buffer pool for waveform analysis to reduce allocations
@@ -102,8 +114,17 @@ func (w *WaveformImageJob) Get() *WaveformImage {
 	return result
 }
 
-func NewWaveformImageGenerator(cache *AudioCache) *WaveformImageGenerator {
-	return &WaveformImageGenerator{audioCache: cache}
+func NewWaveformImageGenerator(cache *AudioCache, streamURL func(*mediaprovider.Track) string) *WaveformImageGenerator {
+	return &WaveformImageGenerator{audioCache: cache, streamURL: streamURL}
+}
+
+// SetDiskCache sets the on-disk cache used to persist generated waveforms.
+func (w *WaveformImageGenerator) SetDiskCache(cache *WaveformCache) {
+	w.diskCache = cache
+}
+
+func (w *WaveformImageGenerator) serverID() string {
+	return w.audioCache.s.ServerID.String()
 }
 
 func (w *WaveformImageGenerator) StartWaveformGeneration(item *mediaprovider.Track) *WaveformImageJob {
@@ -114,6 +135,14 @@ func (w *WaveformImageGenerator) StartWaveformGeneration(item *mediaprovider.Tra
 		cancel: cancel,
 	}
 
+	// serve from the disk cache if possible, even if the audio is no longer cached
+	serverID := w.serverID()
+	if data, ok := w.diskCache.load(serverID, item.ID); ok {
+		generateWaveformImage(ctx, data, job)
+		job.done = true
+		return job
+	}
+
 	// Set up a pipeline of concurrent tasks that need to complete to generate
 	// a waveform image:
 	// 1. Begin downloading the file from the server
@@ -121,65 +150,16 @@ func (w *WaveformImageGenerator) StartWaveformGeneration(item *mediaprovider.Tra
 	// 3. Begin analyzing the resulting WAV file
 	// 4. Begin generating the image from the analysis data
 	go func() {
-		path := w.audioCache.ObtainReferenceToFile(job.ItemID)
-		// wait for file to begin downloading if not already
-		for path == "" {
-			time.Sleep(50 * time.Millisecond)
-			if e := ctx.Err(); e != nil {
-				job.setError(e)
-				return
-			}
-			path = w.audioCache.ObtainReferenceToFile(job.ItemID)
-		}
-		// and wait for content to begin being written
-		for {
-			if s, err := os.Stat(path); err == nil && s.Size() > 0 {
-				break
-			}
-			time.Sleep(50 * time.Millisecond)
-			if e := ctx.Err(); e != nil {
-				job.setError(e)
-				return
-			}
-		}
-
-		dir := filepath.Dir(path)
-		var transcodeFile string
-		for i := 0; true; i++ {
-			if i > 0 {
-				transcodeFile = filepath.Join(dir, fmt.Sprintf("%s_waveform_%d.wav", filepath.Base(path), i))
-			} else {
-				transcodeFile = filepath.Join(dir, filepath.Base(path)+"_waveform.wav")
-			}
-			if _, err := os.Stat(transcodeFile); os.IsNotExist(err) {
-				break // found a suitable filename that doesn't exist
-			}
-		}
-
-		fileDone := func() bool {
-			return w.audioCache.IsFullyDownloaded(job.ItemID)
-		}
-
-		// If file isn't fully downloaded from server,
-		// stream it to MPV via fifo so it doesn't possibly
-		// terminate the conversion to WAV early encountering EOF
-		if !fileDone() {
-			srv, err := util.NewFileStreamerServer(path, fileDone)
-			if err != nil {
-				job.setError(err)
-				return
-			}
-
-			path = srv.Addr()
-
-			go srv.Serve()
-			time.Sleep(10 * time.Millisecond) // make sure server has time to come up
+		path, transcodeFile, err := prepareCachedAudioDecode(ctx, w.audioCache, job.ItemID, "waveform", ".wav")
+		if err != nil {
+			job.setError(err)
+			return
 		}
 
 		// Start converting the file to WAV for analysis
 		var wavConvertDone bool
 		go func() {
-			err := w.convertToWav(ctx, job.ItemID, path, transcodeFile)
+			err := convertToPCM(ctx, w.audioCache, job.ItemID, path, transcodeFile, 22050, true)
 			wavConvertDone = true
 			if err != nil {
 				job.setError(err)
@@ -204,6 +184,8 @@ func (w *WaveformImageGenerator) StartWaveformGeneration(item *mediaprovider.Tra
 			err := analyzeWavFile(ctx, transcodeFile, data, item.Duration.Milliseconds(), func() bool { return wavConvertDone })
 			if err != nil {
 				job.setError(err)
+			} else if ctx.Err() == nil {
+				w.diskCache.save(serverID, item.ID, data)
 			}
 			data.done = true
 			// Final notification that processing is complete
@@ -223,6 +205,84 @@ func (w *WaveformImageGenerator) StartWaveformGeneration(item *mediaprovider.Tra
 	return job
 }
 
+// Pregenerate generates and caches waveforms in the background for the given tracks,
+// one at a time, replacing any tracks still pending from a previous call.
+// Tracks whose audio is not being fetched into the AudioCache are skipped.
+func (w *WaveformImageGenerator) Pregenerate(tracks []*mediaprovider.Track) {
+	w.pregenLock.Lock()
+	defer w.pregenLock.Unlock()
+	w.pregenQueue = tracks
+	if !w.pregenRunning && len(tracks) > 0 {
+		w.pregenRunning = true
+		go w.runPregenerate()
+	}
+}
+
+// PregeneratePinned generates and caches waveforms in the background for the
+// tracks of a pinned album, downloading the audio of tracks which aren't
+// in the AudioCache. These are generated after the tracks passed to Pregenerate.
+func (w *WaveformImageGenerator) PregeneratePinned(tracks []*mediaprovider.Track) {
+	w.pregenLock.Lock()
+	defer w.pregenLock.Unlock()
+	w.pinnedQueue = append(w.pinnedQueue, tracks...)
+	if !w.pregenRunning && len(tracks) > 0 {
+		w.pregenRunning = true
+		go w.runPregenerate()
+	}
+}
+
+func (w *WaveformImageGenerator) runPregenerate() {
+	for {
+		w.pregenLock.Lock()
+		if (len(w.pregenQueue) == 0 && len(w.pinnedQueue) == 0) || w.audioCache.rootCtx.Err() != nil {
+			w.pregenRunning = false
+			w.pinnedQueue = nil
+			w.pregenLock.Unlock()
+			return
+		}
+		var tr *mediaprovider.Track
+		pinned := len(w.pregenQueue) == 0
+		if pinned {
+			tr = w.pinnedQueue[0]
+			w.pinnedQueue = w.pinnedQueue[1:]
+		} else {
+			tr = w.pregenQueue[0]
+			w.pregenQueue = w.pregenQueue[1:]
+		}
+		w.pregenLock.Unlock()
+
+		if w.diskCache.Has(w.serverID(), tr.ID) {
+			continue
+		}
+		// download the audio of pinned tracks not already being fetched for playback
+		var downloaded bool
+		if w.audioCache.PathForCachedOrDownloadingFile(tr.ID) == "" {
+			if !pinned || w.streamURL == nil {
+				continue
+			}
+			url := w.streamURL(tr)
+			if url == "" {
+				continue
+			}
+			w.audioCache.CacheFileTemporarily(tr.ID, url)
+			downloaded = true
+		}
+		job := w.StartWaveformGeneration(tr)
+		// give up if the audio is evicted from the AudioCache before it's been analyzed
+		deadline := time.Now().Add(pregenerateTimeout)
+		for !job.Done() {
+			if time.Now().After(deadline) {
+				job.Cancel()
+				break
+			}
+			time.Sleep(200 * time.Millisecond)
+		}
+		if downloaded {
+			w.audioCache.EvictTemporaryFile(tr.ID)
+		}
+	}
+}
+
 type waveformData struct {
 	Peak [1024]byte
 	RMS  [1024]byte
@@ -435,7 +495,68 @@ func float64ToByte(val float64) byte {
 	return byte(val * 255)
 }
 
-func (w *WaveformImageGenerator) convertToWav(ctx context.Context, id, inPath, outPath string) error {
+// prepareCachedAudioDecode waits for the track to begin downloading into the AudioCache
+// and obtains a reference to it. It returns the path (or local streaming URL, if not yet
+// fully downloaded) to decode from, and an unused output file path for the decoded audio.
+func prepareCachedAudioDecode(ctx context.Context, cache *AudioCache, id, suffix, ext string) (inPath, outPath string, err error) {
+	path := cache.ObtainReferenceToFile(id)
+	// wait for file to begin downloading if not already
+	for path == "" {
+		time.Sleep(50 * time.Millisecond)
+		if e := ctx.Err(); e != nil {
+			return "", "", e
+		}
+		path = cache.ObtainReferenceToFile(id)
+	}
+	// and wait for content to begin being written
+	for {
+		if s, err := os.Stat(path); err == nil && s.Size() > 0 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+		if e := ctx.Err(); e != nil {
+			cache.ReleaseReferenceToFile(id)
+			return "", "", e
+		}
+	}
+
+	dir := filepath.Dir(path)
+	for i := 0; true; i++ {
+		if i > 0 {
+			outPath = filepath.Join(dir, fmt.Sprintf("%s_%s_%d%s", filepath.Base(path), suffix, i, ext))
+		} else {
+			outPath = filepath.Join(dir, fmt.Sprintf("%s_%s%s", filepath.Base(path), suffix, ext))
+		}
+		if _, err := os.Stat(outPath); os.IsNotExist(err) {
+			break // found a suitable filename that doesn't exist
+		}
+	}
+
+	fileDone := func() bool {
+		return cache.IsFullyDownloaded(id)
+	}
+
+	// If file isn't fully downloaded from server,
+	// stream it to MPV via fifo so it doesn't possibly
+	// terminate the conversion to WAV early encountering EOF
+	if !fileDone() {
+		srv, err := util.NewFileStreamerServer(path, fileDone)
+		if err != nil {
+			cache.ReleaseReferenceToFile(id)
+			return "", "", err
+		}
+
+		path = srv.Addr()
+
+		go srv.Serve()
+		time.Sleep(10 * time.Millisecond) // make sure server has time to come up
+	}
+	return path, outPath, nil
+}
+
+// convertToPCM decodes inPath to mono 16 bit PCM at the given sample rate,
+// with or without a WAV header, and releases the AudioCache reference to the file when done.
+func convertToPCM(ctx context.Context, cache *AudioCache, id, inPath, outPath string, sampleRate int, wavHeader bool) error {
 	m := mpv.Create()
 	m.SetOptionString("video", "no")
 	m.SetOptionString("audio-display", "no")
@@ -444,11 +565,14 @@ func (w *WaveformImageGenerator) convertToWav(ctx context.Context, id, inPath, o
 	m.SetOptionString("ao-pcm-file", outPath)
 	m.SetOptionString("ao", "pcm")
 	m.SetOption("volume", mpv.FORMAT_INT64, 100)
-	// no need to preserve full sample resolution just for waveform image
+	// no need to preserve full sample resolution for analysis,
 	// let's make less data to process and smaller on-disk file
-	m.SetOption("audio-samplerate", mpv.FORMAT_INT64, 22050)
+	m.SetOption("audio-samplerate", mpv.FORMAT_INT64, sampleRate)
 	m.SetOptionString("audio-channels", "mono")
 	m.SetOptionString("audio-format", "s16")
+	if !wavHeader {
+		m.SetOptionString("ao-pcm-waveheader", "no")
+	}
 	if err := m.Initialize(); err != nil {
 		return err
 	}
@@ -456,7 +580,7 @@ func (w *WaveformImageGenerator) convertToWav(ctx context.Context, id, inPath, o
 	defer m.TerminateDestroy()
 
 	m.Command([]string{"loadfile", inPath, "replace"})
-	defer w.audioCache.ReleaseReferenceToFile(id)
+	defer cache.ReleaseReferenceToFile(id)
 
 	// Wait for MPV idle or ctx expiry
 	for {
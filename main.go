@@ -113,6 +113,7 @@ func main() {
 	myApp.OnReactivate = util.FyneDoFunc(mainWindow.Show)
 	myApp.OnExit = util.FyneDoFunc(mainWindow.Quit)
 	myApp.OnReloadTheme = util.FyneDoFunc(mainWindow.ReloadTheme)
+	myApp.OnMiniPlayer = util.FyneDoFunc(mainWindow.ToggleMiniPlayer)
 
 	if runtime.GOOS == "windows" {
 		windowStartupTasks := sync.OnceFunc(func() {
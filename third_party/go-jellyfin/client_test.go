@@ -0,0 +1,110 @@
+package jellyfin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name          string
+		urlStr        string
+		clientName    string
+		clientVersion string
+		want          *Client
+		wantErr       bool
+	}{
+		{
+			name:          "POSITIVE - makes new client and parses url",
+			urlStr:        "https://jellyfin.example.com/",
+			clientName:    "supersonic",
+			clientVersion: "1",
+			want: &Client{
+				baseURL: &url.URL{
+					Scheme: "https",
+					Host:   "jellyfin.example.com",
+					Path:   "/",
+				},
+				ClientName:    "supersonic",
+				ClientVersion: "1",
+				HTTPClient: &http.Client{
+					Timeout: DefaultTimeOut,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:          "POSITIVE - makes new client and parses ip",
+			urlStr:        "http://10.0.0.10:8096",
+			clientName:    "supersonic",
+			clientVersion: "1",
+			want: &Client{
+				baseURL: &url.URL{
+					Scheme: "http",
+					Host:   "10.0.0.10:8096",
+					Path:   "/",
+				},
+				ClientName:    "supersonic",
+				ClientVersion: "1",
+				HTTPClient: &http.Client{
+					Timeout: DefaultTimeOut,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:          "NEGATIVE - empty url",
+			urlStr:        "",
+			clientName:    "supersonic",
+			clientVersion: "1",
+			want:          nil,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewClient(tt.urlStr, tt.clientName, tt.clientVersion)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewClient() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewClient() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginWithToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Users/Me" || r.Header.Get("X-Emby-Token") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"Name":"user","Id":"userID","ServerId":"serverID"}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, "supersonic", "1", WithDeviceID("deviceID"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.LoginWithToken("wrong"); err == nil {
+		t.Error("LoginWithToken() with a rejected token succeeded")
+	}
+	if c.Token() != "" {
+		t.Errorf("Token() = %q after a failed login", c.Token())
+	}
+	if err := c.LoginWithToken("token"); err != nil {
+		t.Fatalf("LoginWithToken() error = %v", err)
+	}
+	if c.Token() != "token" || c.UserID() != "userID" || c.ServerID() != "serverID" || c.LoggedInUser() != "user" {
+		t.Errorf("LoginWithToken() session = %q, %q, %q, %q", c.Token(), c.UserID(), c.ServerID(), c.LoggedInUser())
+	}
+	if c.DeviceID() != "deviceID" {
+		t.Errorf("DeviceID() = %q, want the device the token was issued to", c.DeviceID())
+	}
+}
@@ -0,0 +1,5 @@
+module github.com/dweymouth/go-jellyfin
+
+go 1.20
+
+require golang.org/x/image v0.14.0
@@ -16,12 +16,7 @@ import (
 
 type BottomPanel struct {
 	widget.BaseWidget
-
-	imageLoader util.ThumbnailLoader
-
-	NowPlaying  *widgets.NowPlayingCard
-	Controls    *widgets.PlayerControls
-	AuxControls *widgets.AuxControls
+	*playerWidgets
 
 	container *fyne.Container
 
@@ -31,8 +26,36 @@ type BottomPanel struct {
 var _ fyne.Widget = (*BottomPanel)(nil)
 
 func NewBottomPanel(pm *backend.PlaybackManager, im *backend.ImageManager, contr *controller.Controller, cfg *backend.Config) *BottomPanel {
-	bp := &BottomPanel{cfg: cfg}
+	bp := &BottomPanel{cfg: cfg, playerWidgets: newPlayerWidgets(pm, im, contr, cfg)}
+	bp.ExtendBaseWidget(bp)
+
+	bp.container = container.New(layouts.NewLeftMiddleRightLayout(300, 0.4),
+		bp.NowPlaying, bp.Controls, bp.AuxControls)
+	return bp
+}
+
+func (bp *BottomPanel) Refresh() {
+	bp.NowPlaying.ShowAlbumYear = bp.cfg.AlbumsPage.ShowYears
+	bp.BaseWidget.Refresh()
+}
+
+func (bp *BottomPanel) CreateRenderer() fyne.WidgetRenderer {
 	bp.ExtendBaseWidget(bp)
+	return widget.NewSimpleRenderer(bp.container)
+}
+
+// playerWidgets holds the now playing card and playback control widgets,
+// kept in sync with the PlaybackManager. Shared by the BottomPanel and MiniPlayer.
+type playerWidgets struct {
+	imageLoader util.ThumbnailLoader
+
+	NowPlaying  *widgets.NowPlayingCard
+	Controls    *widgets.PlayerControls
+	AuxControls *widgets.AuxControls
+}
+
+func newPlayerWidgets(pm *backend.PlaybackManager, im *backend.ImageManager, contr *controller.Controller, cfg *backend.Config) *playerWidgets {
+	bp := &playerWidgets{}
 
 	pm.OnSongChange(bp.onSongChange)
 	pm.OnRadioMetadataChange(bp.onRadioMetadataChange)
@@ -104,6 +127,25 @@ func NewBottomPanel(pm *backend.PlaybackManager, im *backend.ImageManager, contr
 	bp.Controls.OnSeek(func(f float64) {
 		pm.SeekFraction(f)
 	})
+	bp.Controls.OnSetLoopPoint(func(pos float64, isStart bool) {
+		if np := pm.NowPlaying(); np != nil {
+			pm.SetABLoopPoint(pos*np.Metadata().Duration.Seconds(), isStart)
+		}
+	})
+	bp.Controls.OnClearLoop(pm.ClearABLoop)
+	pm.OnABLoopChange(func(a, b float64) {
+		dur := 0.0
+		if np := pm.NowPlaying(); np != nil {
+			dur = np.Metadata().Duration.Seconds()
+		}
+		toRatio := func(secs float64) float64 {
+			if secs < 0 || dur <= 0 {
+				return -1
+			}
+			return secs / dur
+		}
+		fyne.Do(func() { bp.Controls.SetLoopPoints(toRatio(a), toRatio(b)) })
+	})
 	bp.Controls.OnChangeLoopMode(func() {
 		pm.SetNextLoopMode()
 	})
@@ -135,13 +177,10 @@ func NewBottomPanel(pm *backend.PlaybackManager, im *backend.ImageManager, contr
 	bp.AuxControls.OnShowCastMenu(contr.ShowCastMenu)
 
 	bp.imageLoader = util.NewThumbnailLoader(im, bp.NowPlaying.SetImage)
-
-	bp.container = container.New(layouts.NewLeftMiddleRightLayout(300, 0.4),
-		bp.NowPlaying, bp.Controls, bp.AuxControls)
 	return bp
 }
 
-func (bp *BottomPanel) onSongChange(song mediaprovider.MediaItem, _ *mediaprovider.Track) {
+func (bp *playerWidgets) onSongChange(song mediaprovider.MediaItem, _ *mediaprovider.Track) {
 	fyne.Do(func() {
 		if song == nil {
 			bp.NowPlaying.Update(nil)
@@ -153,7 +192,7 @@ func (bp *BottomPanel) onSongChange(song mediaprovider.MediaItem, _ *mediaprovid
 	})
 }
 
-func (bp *BottomPanel) onRadioMetadataChange(radioName, title, artist string) {
+func (bp *playerWidgets) onRadioMetadataChange(radioName, title, artist string) {
 	fyne.Do(func() {
 		bp.NowPlaying.Update(&mediaprovider.Track{
 			Title:       title,
@@ -163,18 +202,8 @@ func (bp *BottomPanel) onRadioMetadataChange(radioName, title, artist string) {
 	})
 }
 
-func (bp *BottomPanel) updateWaveformImg(img *backend.WaveformImage) {
+func (bp *playerWidgets) updateWaveformImg(img *backend.WaveformImage) {
 	fyne.Do(func() {
 		bp.Controls.UpdateWaveformImg(img)
 	})
 }
-
-func (bp *BottomPanel) Refresh() {
-	bp.NowPlaying.ShowAlbumYear = bp.cfg.AlbumsPage.ShowYears
-	bp.BaseWidget.Refresh()
-}
-
-func (bp *BottomPanel) CreateRenderer() fyne.WidgetRenderer {
-	bp.ExtendBaseWidget(bp)
-	return widget.NewSimpleRenderer(bp.container)
-}
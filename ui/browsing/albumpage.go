@@ -219,6 +219,8 @@ type AlbumPageHeader struct {
 	genreLabel            *widgets.MultiHyperlink
 	miscLabel             *widget.Label
 	shareMenuItem         *fyne.MenuItem
+	instantMixMenuItem    *fyne.MenuItem
+	keepWaveformsMenuItem *fyne.MenuItem
 	collapseBtn           *widgets.HeaderCollapseButton
 	artistReleaseTypeLine *fyne.Container
 
@@ -292,11 +294,43 @@ func NewAlbumPageHeader(page *AlbumPage) *AlbumPageHeader {
 				a.page.contr.ShowShareDialog(a.albumID)
 			})
 			a.shareMenuItem.Icon = myTheme.ShareIcon
-			menu := fyne.NewMenu("", playNext, queue, playlist, download, info, a.shareMenuItem)
+			a.instantMixMenuItem = fyne.NewMenuItem(lang.L("Play instant mix"), func() {
+				go func() {
+					if err := a.page.pm.PlayAlbumMix(a.albumID); err != nil {
+						log.Printf("error playing instant mix: %v", err)
+						fyne.Do(func() {
+							a.page.contr.ToastProvider.ShowErrorToast(lang.L("Unable to play instant mix"))
+						})
+					}
+				}()
+			})
+			a.instantMixMenuItem.Icon = myTheme.ShuffleIcon
+			a.keepWaveformsMenuItem = fyne.NewMenuItem(lang.L("Keep waveforms"), func() {
+				albumID, pin := a.albumID, !a.keepWaveformsMenuItem.Checked
+				go func() {
+					var err error
+					if pin {
+						err = a.page.pm.PinAlbumWaveforms(albumID)
+					} else {
+						err = a.page.pm.UnpinAlbumWaveforms(albumID)
+					}
+					if err != nil {
+						log.Printf("error pinning album waveforms: %v", err)
+						fyne.Do(func() {
+							a.page.contr.ToastProvider.ShowErrorToast(lang.L("Unable to keep waveforms"))
+						})
+					}
+				}()
+			})
+			menu := fyne.NewMenu("", playNext, queue, a.instantMixMenuItem, playlist, download, info, a.shareMenuItem, a.keepWaveformsMenuItem)
 			pop = widget.NewPopUpMenu(menu, fyne.CurrentApp().Driver().CanvasForObject(a))
 		}
 		_, canShare := page.mp.(mediaprovider.SupportsSharing)
 		a.shareMenuItem.Disabled = !canShare
+		_, canMix := a.page.mp.(mediaprovider.InstantMixProvider)
+		a.instantMixMenuItem.Disabled = !canMix
+		a.keepWaveformsMenuItem.Disabled = !a.page.pm.CanPinAlbumWaveforms()
+		a.keepWaveformsMenuItem.Checked = a.page.pm.IsAlbumWaveformsPinned(a.albumID)
 		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(menuBtn)
 		pop.ShowAtPosition(fyne.NewPos(pos.X, pos.Y+menuBtn.Size().Height))
 	}
@@ -43,7 +43,10 @@ func (a *albumsPageAdapter) Title() string { return lang.L("Albums") }
 func (a *albumsPageAdapter) Filter() mediaprovider.AlbumFilter {
 	if a.filter == nil {
 		a.filter = mediaprovider.NewAlbumFilter(
-			mediaprovider.AlbumFilterOptions{},
+			mediaprovider.AlbumFilterOptions{
+				IncludeReleaseTypes: a.cfg.IncludeReleaseTypes,
+				ExcludeReleaseTypes: a.cfg.ExcludeReleaseTypes,
+			},
 		)
 	}
 	return a.filter
@@ -146,11 +149,13 @@ func (a *albumsPageAdapter) ActionButton() fyne.CanvasObject {
 }
 
 func (a *albumsPageAdapter) Iter(sortOrderIdx int, filter mediaprovider.AlbumFilter) widgets.GridViewIterator {
+	saveReleaseTypeFilter(a.cfg, filter)
 	sortOrder := a.mp.AlbumSortOrders()[sortOrderIdx]
 	return widgets.NewGridViewAlbumIterator(a.mp.IterateAlbums(sortOrder, filter))
 }
 
 func (a *albumsPageAdapter) SearchIter(query string, filter mediaprovider.AlbumFilter) widgets.GridViewIterator {
+	saveReleaseTypeFilter(a.cfg, filter)
 	return widgets.NewGridViewAlbumIterator(a.mp.SearchAlbums(query, filter))
 }
 
@@ -163,3 +168,10 @@ func (a *albumsPageAdapter) RefreshGrid(gv *widgets.GridView) {
 	gv.ShowSuffix = a.cfg.ShowYears
 	gv.Refresh()
 }
+
+// persists the release type filter settings, which are shared between the Albums and Genre pages
+func saveReleaseTypeFilter(cfg *backend.AlbumsPageConfig, filter mediaprovider.AlbumFilter) {
+	filterOptions := filter.Options()
+	cfg.IncludeReleaseTypes = filterOptions.IncludeReleaseTypes
+	cfg.ExcludeReleaseTypes = filterOptions.ExcludeReleaseTypes
+}
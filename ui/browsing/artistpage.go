@@ -35,6 +35,7 @@ type artistPageState struct {
 	activeView   int
 	topTrackSort widgets.TracklistSort
 	allTrackSort widgets.TracklistSort
+	filter       mediaprovider.AlbumFilter
 
 	pool  *util.WidgetPool
 	cfg   *backend.ArtistPageConfig
@@ -66,6 +67,7 @@ type ArtistPage struct {
 	groupedReleases *widgets.GroupedReleases
 	tracklistCtr    *fyne.Container
 	sortButton      *widgets.SortChooserButton
+	filterButton    *widgets.AlbumFilterButton
 	nowPlayingID    string
 	header          *ArtistPageHeader
 	container       *fyne.Container
@@ -98,6 +100,10 @@ func NewArtistPage(artistID string, cfg *backend.ArtistPageConfig, pool *util.Wi
 		im:         im,
 		contr:      contr,
 		activeView: activeView,
+		filter: mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{
+			IncludeReleaseTypes: cfg.IncludeReleaseTypes,
+			ExcludeReleaseTypes: cfg.ExcludeReleaseTypes,
+		}),
 	})
 }
 
@@ -128,9 +134,18 @@ func newArtistPage(state artistPageState) *ArtistPage {
 			a.sortButton.SetSelectedIndex(i)
 		}
 	}
+	a.filterButton = widgets.NewAlbumFilterButton(a.filter, func() ([]*mediaprovider.Genre, error) { return nil, nil })
+	a.filterButton.GenreDisabled = true
+	a.filterButton.OnChanged = func() {
+		filterOptions := a.filter.Options()
+		a.cfg.IncludeReleaseTypes = filterOptions.IncludeReleaseTypes
+		a.cfg.ExcludeReleaseTypes = filterOptions.ExcludeReleaseTypes
+		a.showAlbumGrid(true /*reSort*/)
+	}
+	a.filterButton.Refresh()
 	viewToggleRow := container.NewBorder(nil, nil,
 		container.NewHBox(util.NewHSpace(5), viewToggle),
-		container.NewHBox(a.sortButton, util.NewHSpace(10)),
+		container.NewHBox(a.filterButton, a.sortButton, util.NewHSpace(10)),
 		layout.NewSpacer(),
 	)
 	a.container = container.NewBorder(
@@ -234,13 +249,25 @@ func (a *ArtistPage) playArtistRadio() {
 	}()
 }
 
+// returns the artist's albums that match the current filter
+func (a *ArtistPage) filteredAlbums() []*mediaprovider.Album {
+	if a.artistInfo == nil {
+		return nil
+	}
+	if a.filter.IsNil() {
+		return a.artistInfo.Albums
+	}
+	return sharedutil.FilterSlice(a.artistInfo.Albums, a.filter.Matches)
+}
+
 func (a *ArtistPage) getGridViewAlbumsModel() []widgets.GridViewItemModel {
 	if a.artistInfo == nil {
 		return nil
 	}
 
-	a.sortAlbumsSlices(a.artistInfo.Albums)
-	return sharedutil.MapSlice(a.artistInfo.Albums, a.albumToGridViewItemModel)
+	albums := a.filteredAlbums()
+	a.sortAlbumsSlices(albums)
+	return sharedutil.MapSlice(albums, a.albumToGridViewItemModel)
 }
 
 func (a *ArtistPage) albumToGridViewItemModel(al *mediaprovider.Album) widgets.GridViewItemModel {
@@ -263,7 +290,7 @@ func (a *ArtistPage) getGroupedReleasesModel() widgets.GroupedReleasesModel {
 	eps := []*mediaprovider.Album{}
 	singles := []*mediaprovider.Album{}
 
-	for _, album := range a.artistInfo.Albums {
+	for _, album := range a.filteredAlbums() {
 		switch rt := album.ReleaseTypes; {
 		case rt&mediaprovider.ReleaseTypeEP > 0:
 			eps = append(eps, album)
@@ -346,22 +373,21 @@ func (a *ArtistPage) showAlbumGrid(reSort bool) {
 		return // already showing album grid
 	}
 	a.activeView = 0
-
-	allAlbums := func() bool {
-		return slices.IndexFunc(a.artistInfo.Albums, func(al *mediaprovider.Album) bool {
-			return al.ReleaseTypes&mediaprovider.ReleaseTypeCompilation > 0 ||
-				al.ReleaseTypes&mediaprovider.ReleaseTypeEP > 0 ||
-				al.ReleaseTypes&mediaprovider.ReleaseTypeSingle > 0
-		}) < 0
+	if a.artistInfo == nil {
+		// page not loaded yet or invalid artist
+		return
 	}
-	useGroupedReleases := a.artistInfo != nil && len(a.artistInfo.Albums) <= 50 && !allAlbums()
 
-	if a.albumGrid == nil && a.groupedReleases == nil {
-		if a.artistInfo == nil {
-			// page not loaded yet or invalid artist
-			return
-		}
-		if useGroupedReleases {
+	albums := a.filteredAlbums()
+	allAlbums := slices.IndexFunc(albums, func(al *mediaprovider.Album) bool {
+		return al.ReleaseTypes&mediaprovider.ReleaseTypeCompilation > 0 ||
+			al.ReleaseTypes&mediaprovider.ReleaseTypeEP > 0 ||
+			al.ReleaseTypes&mediaprovider.ReleaseTypeSingle > 0
+	}) < 0
+	useGroupedReleases := len(albums) <= 50 && !allAlbums
+
+	if useGroupedReleases {
+		if a.groupedReleases == nil {
 			model := a.getGroupedReleasesModel()
 			if g := a.pool.Obtain(util.WidgetTypeGroupedReleases); g != nil {
 				a.groupedReleases = g.(*widgets.GroupedReleases)
@@ -378,7 +404,12 @@ func (a *ArtistPage) showAlbumGrid(reSort bool) {
 				a.groupedReleases.ScrollToOffset(a.gridScrollPos)
 				a.gridScrollPos = 0
 			}
-		} else {
+		} else if reSort {
+			a.groupedReleases.Model = a.getGroupedReleasesModel()
+		}
+		a.container.Objects[0].(*fyne.Container).Objects[0] = a.groupedReleases
+	} else {
+		if a.albumGrid == nil {
 			model := a.getGridViewAlbumsModel()
 			if g := a.pool.Obtain(util.WidgetTypeGridView); g != nil {
 				a.albumGrid = g.(*widgets.GridView)
@@ -392,22 +423,13 @@ func (a *ArtistPage) showAlbumGrid(reSort bool) {
 				a.albumGrid.ScrollToOffset(a.gridScrollPos)
 				a.gridScrollPos = 0
 			}
+		} else if reSort {
+			a.albumGrid.ResetFixed(a.getGridViewAlbumsModel())
 		}
-	} else if reSort {
-		if useGroupedReleases {
-			a.groupedReleases.Model = a.getGroupedReleasesModel()
-		} else {
-			model := a.getGridViewAlbumsModel()
-			a.albumGrid.ResetFixed(model)
-		}
-	}
-	a.sortButton.Show()
-	if useGroupedReleases {
-		a.container.Objects[0].(*fyne.Container).Objects[0] = a.groupedReleases
-		a.container.Objects[0].Refresh()
-	} else {
 		a.container.Objects[0].(*fyne.Container).Objects[0] = a.albumGrid
 	}
+	a.sortButton.Show()
+	a.filterButton.Show()
 	a.container.Objects[0].Refresh()
 }
 
@@ -554,14 +576,17 @@ func (a *ArtistPage) onViewChange(num int) {
 	case 0:
 		a.showAlbumGrid(false /*reSort*/)
 		a.sortButton.Show()
+		a.filterButton.Show()
 		a.cfg.InitialView = viewDiscography
 	case 1:
 		a.showTopTracks()
 		a.sortButton.Hide()
+		a.filterButton.Hide()
 		a.cfg.InitialView = viewTopTracks
 	case 2:
 		a.showAllTracks()
 		a.sortButton.Hide()
+		a.filterButton.Hide()
 		a.cfg.InitialView = viewAllTracks
 	}
 }
@@ -0,0 +1,340 @@
+package browsing
+
+import (
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/dweymouth/supersonic/ui/controller"
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+	"github.com/dweymouth/supersonic/ui/util"
+	"github.com/dweymouth/supersonic/ui/widgets"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ComposerPage shows the works (tracks) credited to a composer,
+// and the albums on which they appear.
+type ComposerPage struct {
+	widget.BaseWidget
+
+	composerPageState
+
+	disposed     bool
+	tracks       []*mediaprovider.Track
+	nowPlayingID string
+
+	titleDisp *widget.RichText
+	tracklist *widgets.Tracklist
+	albumGrid *widgets.GridView
+	content   *fyne.Container
+	container *fyne.Container
+}
+
+const (
+	composerViewWorks  = 0
+	composerViewAlbums = 1
+)
+
+type composerPageState struct {
+	composerID string
+	conf       *backend.ComposerPageConfig
+	contr      *controller.Controller
+	widgetPool *util.WidgetPool
+	pm         *backend.PlaybackManager
+	im         *backend.ImageManager
+	canRate    bool
+	canShare   bool
+	activeView int
+	scroll     float32
+	gridScroll float32
+}
+
+func NewComposerPage(
+	composerID string,
+	conf *backend.ComposerPageConfig,
+	pool *util.WidgetPool,
+	contr *controller.Controller,
+	pm *backend.PlaybackManager,
+	im *backend.ImageManager,
+	canRate, canShare bool,
+) *ComposerPage {
+	return newComposerPage(composerPageState{
+		composerID: composerID,
+		conf:       conf,
+		contr:      contr,
+		widgetPool: pool,
+		pm:         pm,
+		im:         im,
+		canRate:    canRate,
+		canShare:   canShare,
+	})
+}
+
+func newComposerPage(state composerPageState) *ComposerPage {
+	a := &ComposerPage{composerPageState: state}
+	a.ExtendBaseWidget(a)
+
+	a.titleDisp = util.NewTruncatingRichText()
+	a.titleDisp.Segments[0].(*widget.TextSegment).Style.SizeName = theme.SizeNameHeadingText
+
+	if tl := a.widgetPool.Obtain(util.WidgetTypeTracklist); tl != nil {
+		a.tracklist = tl.(*widgets.Tracklist)
+		a.tracklist.Reset()
+	} else {
+		a.tracklist = widgets.NewTracklist(nil, a.im, false)
+	}
+	a.tracklist.SetVisibleColumns(a.conf.TracklistColumns)
+	a.tracklist.OnVisibleColumnsChanged = func(cols []string) {
+		a.conf.TracklistColumns = cols
+	}
+	a.tracklist.Options = widgets.TracklistOptions{
+		DisableRating:  !a.canRate,
+		DisableSharing: !a.canShare,
+	}
+	a.contr.ConnectTracklistActions(a.tracklist)
+
+	viewToggle := widgets.NewToggleText(a.activeView, []string{lang.L("Works"), lang.L("Albums")})
+	viewToggle.OnChanged = a.setView
+	header := container.NewVBox(
+		container.NewBorder(nil, nil, nil, a.buildButtonRow(), a.titleDisp),
+		container.NewHBox(viewToggle),
+	)
+	a.content = container.NewStack(a.tracklist)
+	a.container = container.New(&layout.CustomPaddedLayout{LeftPadding: 15, RightPadding: 15, TopPadding: 5, BottomPadding: 15},
+		container.NewBorder(header, nil, nil, nil, a.content))
+	if a.activeView == composerViewAlbums {
+		a.setView(composerViewAlbums)
+	}
+
+	a.tracklist.SetLoading(true)
+	go a.load()
+	return a
+}
+
+func (a *ComposerPage) buildButtonRow() *fyne.Container {
+	playButton := widget.NewButtonWithIcon(lang.L("Play"), theme.MediaPlayIcon(), func() {
+		a.playTracks(false)
+	})
+	shuffleBtn := widget.NewButtonWithIcon(lang.L("Shuffle"), myTheme.ShuffleIcon, func() {
+		a.playTracks(true)
+	})
+	var pop *widget.PopUpMenu
+	menuBtn := widget.NewButtonWithIcon("", theme.MoreHorizontalIcon(), nil)
+	menuBtn.OnTapped = func() {
+		if pop == nil {
+			playNext := fyne.NewMenuItem(lang.L("Play next"), func() {
+				go a.pm.LoadTracks(a.tracks, backend.InsertNext, false)
+			})
+			playNext.Icon = myTheme.PlayNextIcon
+			queue := fyne.NewMenuItem(lang.L("Add to queue"), func() {
+				go a.pm.LoadTracks(a.tracks, backend.Append, false)
+			})
+			queue.Icon = theme.ContentAddIcon()
+			playlist := fyne.NewMenuItem(lang.L("Add to playlist")+"...", func() {
+				a.contr.DoAddTracksToPlaylistWorkflow(sharedutil.TracksToIDs(a.tracks))
+			})
+			playlist.Icon = myTheme.PlaylistIcon
+			download := fyne.NewMenuItem(lang.L("Download")+"...", func() {
+				a.contr.ShowDownloadDialog(a.tracks, a.titleDisp.String())
+			})
+			download.Icon = theme.DownloadIcon()
+			pop = widget.NewPopUpMenu(fyne.NewMenu("", playNext, queue, playlist, download),
+				fyne.CurrentApp().Driver().CanvasForObject(a))
+		}
+		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(menuBtn)
+		pop.ShowAtPosition(fyne.NewPos(pos.X, pos.Y+menuBtn.Size().Height))
+	}
+	return container.NewHBox(playButton, shuffleBtn, menuBtn)
+}
+
+func (a *ComposerPage) playTracks(shuffle bool) {
+	if len(a.tracks) == 0 {
+		return
+	}
+	tracks := a.tracks
+	go func() {
+		a.pm.LoadTracks(tracks, backend.Replace, shuffle)
+		a.pm.PlayFromBeginning()
+	}()
+}
+
+func (a *ComposerPage) setView(view int) {
+	a.activeView = view
+	if view == composerViewWorks {
+		a.content.Objects[0] = a.tracklist
+		a.content.Refresh()
+		return
+	}
+	if a.albumGrid == nil {
+		if g := a.widgetPool.Obtain(util.WidgetTypeGridView); g != nil {
+			a.albumGrid = g.(*widgets.GridView)
+			a.albumGrid.Placeholder = myTheme.AlbumIcon
+			a.albumGrid.ResetFixed(composerAlbumsModel(a.tracks))
+		} else {
+			a.albumGrid = widgets.NewFixedGridView(composerAlbumsModel(a.tracks), a.im, myTheme.AlbumIcon)
+		}
+		a.contr.ConnectAlbumGridActions(a.albumGrid)
+		if a.gridScroll != 0 {
+			a.albumGrid.ScrollToOffset(a.gridScroll)
+			a.gridScroll = 0
+		}
+	}
+	a.content.Objects[0] = a.albumGrid
+	a.content.Refresh()
+}
+
+// composerAlbumsModel returns the albums on which the tracks appear,
+// sorted by name. The favorite state of the albums is not known.
+func composerAlbumsModel(tracks []*mediaprovider.Track) []widgets.GridViewItemModel {
+	seen := make(map[string]bool)
+	var albums []widgets.GridViewItemModel
+	for _, tr := range tracks {
+		if tr.AlbumID == "" || seen[tr.AlbumID] {
+			continue
+		}
+		seen[tr.AlbumID] = true
+		model := widgets.GridViewItemModel{
+			Name:         tr.Album,
+			ID:           tr.AlbumID,
+			CoverArtID:   tr.CoverArtID,
+			Secondary:    tr.AlbumArtistNames,
+			SecondaryIDs: tr.AlbumArtistIDs,
+		}
+		if tr.Year > 0 {
+			model.Suffix = strconv.Itoa(tr.Year)
+		}
+		albums = append(albums, model)
+	}
+	slices.SortStableFunc(albums, func(a, b widgets.GridViewItemModel) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return albums
+}
+
+// should be called asynchronously
+func (a *ComposerPage) load() {
+	tracks, err := a.contr.GetComposerTracks(a.composerID)
+	if err != nil {
+		log.Printf("error loading composer tracks: %v", err.Error())
+		fyne.Do(func() {
+			a.tracklist.SetLoading(false)
+			a.contr.ToastProvider.ShowErrorToast(lang.L("An error occurred"))
+		})
+		return
+	}
+	name := a.composerName(tracks)
+	if a.disposed {
+		return
+	}
+	fyne.Do(func() {
+		a.tracks = tracks
+		a.titleDisp.Segments[0].(*widget.TextSegment).Text = name
+		a.titleDisp.Refresh()
+		a.tracklist.SetLoading(false)
+		a.tracklist.SetTracks(tracks)
+		a.tracklist.SetNowPlaying(a.nowPlayingID)
+		if a.scroll != 0 {
+			a.tracklist.ScrollToOffset(a.scroll)
+			a.scroll = 0
+		}
+		if a.albumGrid != nil {
+			scroll := a.albumGrid.GetScrollOffset()
+			a.albumGrid.ResetFixed(composerAlbumsModel(tracks))
+			a.albumGrid.ScrollToOffset(max(scroll, a.gridScroll))
+			a.gridScroll = 0
+		}
+	})
+}
+
+// finds the composer's name from the track credits,
+// or else from the list of all composers
+func (a *ComposerPage) composerName(tracks []*mediaprovider.Track) string {
+	for _, tr := range tracks {
+		if i := slices.Index(tr.ComposerIDs, a.composerID); i >= 0 && i < len(tr.ComposerNames) {
+			return tr.ComposerNames[i]
+		}
+		if slices.Contains(tr.ComposerNames, a.composerID) {
+			return a.composerID
+		}
+	}
+	if composers, err := a.contr.GetComposers(); err == nil {
+		for _, c := range composers {
+			if c.ID == a.composerID {
+				return c.Name
+			}
+		}
+	}
+	return lang.L("Composer")
+}
+
+func (a *ComposerPage) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(a.container)
+}
+
+func (a *ComposerPage) Save() SavedPage {
+	a.disposed = true
+	s := a.composerPageState
+	s.scroll = a.tracklist.GetScrollOffset()
+	a.tracklist.SetLoading(false)
+	a.tracklist.Clear()
+	a.widgetPool.Release(util.WidgetTypeTracklist, a.tracklist)
+	if a.albumGrid != nil {
+		s.gridScroll = a.albumGrid.GetScrollOffset()
+		a.albumGrid.Clear()
+		a.widgetPool.Release(util.WidgetTypeGridView, a.albumGrid)
+	}
+	return &s
+}
+
+func (s *composerPageState) Restore() Page {
+	return newComposerPage(*s)
+}
+
+func (a *ComposerPage) Route() controller.Route {
+	return controller.ComposerRoute(a.composerID)
+}
+
+func (a *ComposerPage) Reload() {
+	a.tracklist.SetLoading(true)
+	go a.load()
+}
+
+var _ CanShowNowPlaying = (*ComposerPage)(nil)
+
+func (a *ComposerPage) OnSongChange(item mediaprovider.MediaItem, lastScrobbledIfAny *mediaprovider.Track) {
+	a.nowPlayingID = sharedutil.MediaItemIDOrEmptyStr(item)
+	a.tracklist.SetNowPlaying(a.nowPlayingID)
+	a.tracklist.IncrementPlayCount(sharedutil.MediaItemIDOrEmptyStr(lastScrobbledIfAny))
+}
+
+var _ CanSelectAll = (*ComposerPage)(nil)
+
+func (a *ComposerPage) SelectAll() {
+	if a.activeView == composerViewWorks {
+		a.tracklist.SelectAll()
+	}
+}
+
+func (a *ComposerPage) UnselectAll() {
+	a.tracklist.UnselectAll()
+}
+
+var _ Scrollable = (*ComposerPage)(nil)
+
+func (a *ComposerPage) Scroll(amount float32) {
+	if a.activeView == composerViewAlbums && a.albumGrid != nil {
+		a.albumGrid.ScrollToOffset(a.albumGrid.GetScrollOffset() + amount)
+		return
+	}
+	a.tracklist.ScrollToOffset(a.tracklist.GetScrollOffset() + amount)
+}
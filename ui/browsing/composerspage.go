@@ -0,0 +1,90 @@
+package browsing
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/lang"
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
+	"github.com/dweymouth/supersonic/ui/controller"
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+	"github.com/dweymouth/supersonic/ui/util"
+	"github.com/dweymouth/supersonic/ui/widgets"
+)
+
+type composersPageAdapter struct {
+	contr *controller.Controller
+}
+
+func NewComposersPage(pool *util.WidgetPool, contr *controller.Controller, mp mediaprovider.MediaProvider, im *backend.ImageManager) Page {
+	adapter := &composersPageAdapter{contr: contr}
+	return NewGridViewPage(adapter, pool, mp, im)
+}
+
+func (a *composersPageAdapter) Title() string { return lang.L("Composers") }
+
+func (a *composersPageAdapter) Filter() mediaprovider.ArtistFilter { return nil }
+
+func (a *composersPageAdapter) FilterButton() widgets.FilterButton[mediaprovider.Artist, mediaprovider.ArtistFilterOptions] {
+	return nil
+}
+
+func (a *composersPageAdapter) PlaceholderResource() fyne.Resource { return myTheme.ArtistIcon }
+
+func (a *composersPageAdapter) Route() controller.Route { return controller.ComposersRoute() }
+
+func (a *composersPageAdapter) ActionButton() fyne.CanvasObject { return nil }
+
+func (a *composersPageAdapter) Iter(_ int, _ mediaprovider.ArtistFilter) widgets.GridViewIterator {
+	return a.iter(mediaprovider.NewArtistFilter(mediaprovider.ArtistFilterOptions{}))
+}
+
+func (a *composersPageAdapter) SearchIter(query string, _ mediaprovider.ArtistFilter) widgets.GridViewIterator {
+	return a.iter(mediaprovider.NewArtistFilter(mediaprovider.ArtistFilterOptions{SearchQuery: query}))
+}
+
+// composers are fetched from the server as a single list,
+// which the iterator then pages through
+func (a *composersPageAdapter) iter(filter mediaprovider.ArtistFilter) widgets.GridViewIterator {
+	var composers []*mediaprovider.Artist
+	fetch := func(offset, limit int) ([]*mediaprovider.Artist, error) {
+		if composers == nil {
+			c, err := a.contr.GetComposers()
+			if err != nil {
+				return nil, err
+			}
+			composers = c
+		}
+		return pageSlice(composers, offset, limit), nil
+	}
+	return noFavoriteIterator{widgets.NewGridViewArtistIterator(helpers.NewArtistIterator(fetch, filter, func(string) {}))}
+}
+
+// noFavoriteIterator hides the favorite button of the items, since
+// composers are not favoritable artists on all servers.
+type noFavoriteIterator struct {
+	widgets.GridViewIterator
+}
+
+func (n noFavoriteIterator) NextN(count int) []widgets.GridViewItemModel {
+	items := n.GridViewIterator.NextN(count)
+	for i := range items {
+		items[i].CanFavorite = false
+	}
+	return items
+}
+
+func (a *composersPageAdapter) InitGrid(gv *widgets.GridView) {
+	a.contr.ConnectComposerGridActions(gv)
+}
+
+func (a *composersPageAdapter) RefreshGrid(gv *widgets.GridView) {
+	gv.Refresh()
+}
+
+func pageSlice[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	return items[offset:min(offset+limit, len(items))]
+}
@@ -0,0 +1,430 @@
+package browsing
+
+import (
+	"log"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/dweymouth/supersonic/ui/controller"
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+	"github.com/dweymouth/supersonic/ui/util"
+	"github.com/dweymouth/supersonic/ui/widgets"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+type FoldersPage struct {
+	widget.BaseWidget
+
+	foldersPageState
+
+	disposed     bool
+	folder       *mediaprovider.FolderWithChildren
+	nowPlayingID string
+
+	titleDisp   *widget.RichText
+	breadcrumbs *fyne.Container
+	buttonRow   *fyne.Container
+	folderList  *FolderList
+	tracklist   *widgets.Tracklist
+	emptyMsg    fyne.CanvasObject
+	content     *fyne.Container
+	container   *fyne.Container
+}
+
+type foldersPageState struct {
+	folderID   string
+	conf       *backend.FoldersPageConfig
+	contr      *controller.Controller
+	widgetPool *util.WidgetPool
+	fp         mediaprovider.FolderBrowsingProvider
+	im         *backend.ImageManager
+	canRate    bool
+	canShare   bool
+	scroll     float32
+}
+
+func NewFoldersPage(
+	folderID string,
+	conf *backend.FoldersPageConfig,
+	pool *util.WidgetPool,
+	contr *controller.Controller,
+	fp mediaprovider.FolderBrowsingProvider,
+	im *backend.ImageManager,
+	canRate, canShare bool,
+) *FoldersPage {
+	return newFoldersPage(foldersPageState{
+		folderID:   folderID,
+		conf:       conf,
+		contr:      contr,
+		widgetPool: pool,
+		fp:         fp,
+		im:         im,
+		canRate:    canRate,
+		canShare:   canShare,
+	})
+}
+
+func newFoldersPage(state foldersPageState) *FoldersPage {
+	a := &FoldersPage{foldersPageState: state}
+	a.ExtendBaseWidget(a)
+
+	a.titleDisp = util.NewTruncatingRichText()
+	a.titleDisp.Segments[0].(*widget.TextSegment).Style.SizeName = theme.SizeNameHeadingText
+	a.breadcrumbs = container.NewHBox()
+
+	a.folderList = NewFolderList()
+	a.folderList.OnNavTo = func(id string) { a.contr.NavigateTo(controller.FoldersRoute(id)) }
+	a.folderList.OnPlay = a.contr.PlayFolder
+	a.folderList.OnQueue = a.contr.QueueFolder
+	a.folderList.OnDownload = a.contr.DownloadFolder
+
+	if tl := a.widgetPool.Obtain(util.WidgetTypeTracklist); tl != nil {
+		a.tracklist = tl.(*widgets.Tracklist)
+		a.tracklist.Reset()
+	} else {
+		a.tracklist = widgets.NewTracklist(nil, a.im, false)
+	}
+	a.tracklist.SetVisibleColumns(a.conf.TracklistColumns)
+	a.tracklist.OnVisibleColumnsChanged = func(cols []string) {
+		a.conf.TracklistColumns = cols
+	}
+	a.tracklist.Options = widgets.TracklistOptions{
+		DisableRating:  !a.canRate,
+		DisableSharing: !a.canShare,
+	}
+	a.contr.ConnectTracklistActions(a.tracklist)
+
+	a.emptyMsg = container.NewCenter(widgets.NewInfoMessage(
+		lang.L("This folder is empty"), ""))
+	a.emptyMsg.Hide()
+
+	a.buttonRow = a.buildButtonRow()
+	a.content = container.NewStack(a.tracklist) // shows loading state until loaded
+	a.buildContainer()
+
+	a.tracklist.SetLoading(true)
+	go a.load()
+	return a
+}
+
+func (a *FoldersPage) buildButtonRow() *fyne.Container {
+	playButton := widget.NewButtonWithIcon(lang.L("Play"), theme.MediaPlayIcon(), func() {
+		a.contr.PlayFolder(a.folderID, false)
+	})
+	shuffleBtn := widget.NewButtonWithIcon(lang.L("Shuffle"), myTheme.ShuffleIcon, func() {
+		a.contr.PlayFolder(a.folderID, true)
+	})
+	var pop *widget.PopUpMenu
+	menuBtn := widget.NewButtonWithIcon("", theme.MoreHorizontalIcon(), nil)
+	menuBtn.OnTapped = func() {
+		if pop == nil {
+			playNext := fyne.NewMenuItem(lang.L("Play next"), func() {
+				a.contr.QueueFolder(a.folderID, true)
+			})
+			playNext.Icon = myTheme.PlayNextIcon
+			queue := fyne.NewMenuItem(lang.L("Add to queue"), func() {
+				a.contr.QueueFolder(a.folderID, false)
+			})
+			queue.Icon = theme.ContentAddIcon()
+			download := fyne.NewMenuItem(lang.L("Download")+"...", func() {
+				a.contr.DownloadFolder(a.folderID, a.titleDisp.String())
+			})
+			download.Icon = theme.DownloadIcon()
+			pop = widget.NewPopUpMenu(fyne.NewMenu("", playNext, queue, download),
+				fyne.CurrentApp().Driver().CanvasForObject(a))
+		}
+		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(menuBtn)
+		pop.ShowAtPosition(fyne.NewPos(pos.X, pos.Y+menuBtn.Size().Height))
+	}
+	row := container.NewHBox(playButton, shuffleBtn, menuBtn)
+	// playing the entire library recursively is not offered from the top level
+	row.Hidden = a.folderID == ""
+	return row
+}
+
+func (a *FoldersPage) buildContainer() {
+	header := container.NewVBox(
+		container.NewHScroll(a.breadcrumbs),
+		container.NewBorder(nil, nil, nil, a.buttonRow, a.titleDisp),
+	)
+	a.container = container.New(&layout.CustomPaddedLayout{LeftPadding: 15, RightPadding: 15, TopPadding: 5, BottomPadding: 15},
+		container.NewBorder(header, nil, nil, nil,
+			container.NewStack(a.emptyMsg, a.content)))
+}
+
+// should be called asynchronously
+func (a *FoldersPage) load() {
+	folder, err := a.fp.GetFolder(a.folderID)
+	if err != nil {
+		log.Printf("error loading folder: %v", err.Error())
+		fyne.Do(func() {
+			a.tracklist.SetLoading(false)
+			a.contr.ToastProvider.ShowErrorToast(lang.L("An error occurred"))
+		})
+		return
+	}
+	if a.disposed {
+		return
+	}
+	fyne.Do(func() {
+		a.folder = folder
+		a.tracklist.SetLoading(false)
+		a.updateHeader()
+		a.folderList.SetFolders(folder.Subfolders)
+		a.tracklist.SetTracks(folder.Tracks)
+		a.tracklist.SetNowPlaying(a.nowPlayingID)
+		a.updateContent()
+		if a.scroll != 0 {
+			a.scrollContent(a.scroll)
+			a.scroll = 0
+		}
+	})
+}
+
+func (a *FoldersPage) updateHeader() {
+	title := a.folder.Name
+	if a.folderID == "" || title == "" {
+		title = lang.L("Folders")
+	}
+	a.titleDisp.Segments[0].(*widget.TextSegment).Text = title
+	a.titleDisp.Refresh()
+
+	a.breadcrumbs.RemoveAll()
+	if a.folderID == "" {
+		return
+	}
+	a.addBreadcrumb(lang.L("Folders"), "")
+	for _, anc := range a.folder.Ancestors {
+		a.addBreadcrumb(anc.Name, anc.ID)
+	}
+}
+
+func (a *FoldersPage) addBreadcrumb(name, id string) {
+	if len(a.breadcrumbs.Objects) > 0 {
+		a.breadcrumbs.Add(widget.NewLabel("›"))
+	}
+	link := widget.NewHyperlink(name, nil)
+	link.OnTapped = func() { a.contr.NavigateTo(controller.FoldersRoute(id)) }
+	a.breadcrumbs.Add(link)
+}
+
+func (a *FoldersPage) updateContent() {
+	hasFolders := len(a.folder.Subfolders) > 0
+	hasTracks := len(a.folder.Tracks) > 0
+	switch {
+	case hasFolders && hasTracks:
+		split := container.NewVSplit(a.folderList, a.tracklist)
+		split.SetOffset(0.4)
+		a.content.Objects = []fyne.CanvasObject{split}
+	case hasFolders:
+		a.content.Objects = []fyne.CanvasObject{a.folderList}
+	case hasTracks:
+		a.content.Objects = []fyne.CanvasObject{a.tracklist}
+	default:
+		a.content.Objects = nil
+	}
+	if hasFolders || hasTracks {
+		a.emptyMsg.Hide()
+	} else {
+		a.emptyMsg.Show()
+	}
+	a.content.Refresh()
+}
+
+func (a *FoldersPage) scrollContent(offset float32) {
+	if a.folder != nil && len(a.folder.Subfolders) == 0 {
+		a.tracklist.ScrollToOffset(offset)
+	} else {
+		a.folderList.list.ScrollToOffset(offset)
+	}
+}
+
+func (a *FoldersPage) contentScrollOffset() float32 {
+	if a.folder != nil && len(a.folder.Subfolders) == 0 {
+		return a.tracklist.GetScrollOffset()
+	}
+	return a.folderList.list.GetScrollOffset()
+}
+
+func (a *FoldersPage) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(a.container)
+}
+
+func (a *FoldersPage) Save() SavedPage {
+	a.disposed = true
+	s := a.foldersPageState
+	s.scroll = a.contentScrollOffset()
+	a.tracklist.SetLoading(false)
+	a.tracklist.Clear()
+	a.widgetPool.Release(util.WidgetTypeTracklist, a.tracklist)
+	return &s
+}
+
+func (s *foldersPageState) Restore() Page {
+	return newFoldersPage(*s)
+}
+
+func (a *FoldersPage) Route() controller.Route {
+	return controller.FoldersRoute(a.folderID)
+}
+
+func (a *FoldersPage) Reload() {
+	a.tracklist.SetLoading(true)
+	go a.load()
+}
+
+var _ CanShowNowPlaying = (*FoldersPage)(nil)
+
+func (a *FoldersPage) OnSongChange(item mediaprovider.MediaItem, lastScrobbledIfAny *mediaprovider.Track) {
+	a.nowPlayingID = sharedutil.MediaItemIDOrEmptyStr(item)
+	a.tracklist.SetNowPlaying(a.nowPlayingID)
+	a.tracklist.IncrementPlayCount(sharedutil.MediaItemIDOrEmptyStr(lastScrobbledIfAny))
+}
+
+var _ CanSelectAll = (*FoldersPage)(nil)
+
+func (a *FoldersPage) SelectAll() {
+	a.tracklist.SelectAll()
+}
+
+func (a *FoldersPage) UnselectAll() {
+	a.tracklist.UnselectAll()
+}
+
+var _ Scrollable = (*FoldersPage)(nil)
+
+func (a *FoldersPage) Scroll(amount float32) {
+	a.scrollContent(a.contentScrollOffset() + amount)
+}
+
+type FolderList struct {
+	widget.BaseWidget
+
+	OnNavTo    func(folderID string)
+	OnPlay     func(folderID string, shuffle bool)
+	OnQueue    func(folderID string, next bool)
+	OnDownload func(folderID, folderName string)
+
+	folders  []*mediaprovider.Folder
+	selected *mediaprovider.Folder
+
+	list      *widgets.FocusList
+	menu      *widget.PopUpMenu
+	container *fyne.Container
+}
+
+type FolderListRow struct {
+	widgets.FocusListRowBase
+
+	Item              *mediaprovider.Folder
+	OnTappedSecondary func(*fyne.PointEvent)
+
+	nameLabel *widget.Label
+}
+
+func NewFolderListRow() *FolderListRow {
+	a := &FolderListRow{
+		nameLabel: util.NewTruncatingLabel(),
+	}
+	a.ExtendBaseWidget(a)
+	a.Content = container.NewBorder(nil, nil,
+		container.New(layout.NewCustomPaddedLayout(0, 0, 5, 0), widget.NewIcon(theme.FolderIcon())),
+		nil, a.nameLabel)
+	return a
+}
+
+func (a *FolderListRow) TappedSecondary(e *fyne.PointEvent) {
+	if a.OnTappedSecondary != nil {
+		a.OnTappedSecondary(e)
+	}
+}
+
+func NewFolderList() *FolderList {
+	a := &FolderList{}
+	a.ExtendBaseWidget(a)
+	a.list = widgets.NewFocusList(
+		func() int { return len(a.folders) },
+		func() fyne.CanvasObject {
+			r := NewFolderListRow()
+			r.OnTapped = func() {
+				if a.OnNavTo != nil {
+					a.OnNavTo(r.Item.ID)
+				}
+			}
+			r.OnTappedSecondary = func(e *fyne.PointEvent) {
+				a.selected = r.Item
+				a.showMenu(e.AbsolutePosition)
+			}
+			r.OnFocusNeighbor = func(up bool) {
+				a.list.FocusNeighbor(r.ItemID(), up)
+			}
+			return r
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			row := item.(*FolderListRow)
+			if row.Item != a.folders[id] {
+				row.EnsureUnfocused()
+				row.ListItemID = id
+				row.Item = a.folders[id]
+				row.nameLabel.Text = row.Item.Name
+				row.Refresh()
+			}
+		},
+	)
+	a.container = container.NewStack(a.list)
+	return a
+}
+
+func (a *FolderList) SetFolders(folders []*mediaprovider.Folder) {
+	a.folders = folders
+	a.Refresh()
+}
+
+func (a *FolderList) showMenu(pos fyne.Position) {
+	if a.menu == nil {
+		play := fyne.NewMenuItem(lang.L("Play"), func() {
+			if a.OnPlay != nil {
+				a.OnPlay(a.selected.ID, false)
+			}
+		})
+		play.Icon = theme.MediaPlayIcon()
+		shuffle := fyne.NewMenuItem(lang.L("Shuffle"), func() {
+			if a.OnPlay != nil {
+				a.OnPlay(a.selected.ID, true)
+			}
+		})
+		shuffle.Icon = myTheme.ShuffleIcon
+		playNext := fyne.NewMenuItem(lang.L("Play next"), func() {
+			if a.OnQueue != nil {
+				a.OnQueue(a.selected.ID, true)
+			}
+		})
+		playNext.Icon = myTheme.PlayNextIcon
+		queue := fyne.NewMenuItem(lang.L("Add to queue"), func() {
+			if a.OnQueue != nil {
+				a.OnQueue(a.selected.ID, false)
+			}
+		})
+		queue.Icon = theme.ContentAddIcon()
+		download := fyne.NewMenuItem(lang.L("Download")+"...", func() {
+			if a.OnDownload != nil {
+				a.OnDownload(a.selected.ID, a.selected.Name)
+			}
+		})
+		download.Icon = theme.DownloadIcon()
+		a.menu = widget.NewPopUpMenu(fyne.NewMenu("", play, shuffle, playNext, queue, download),
+			fyne.CurrentApp().Driver().CanvasForObject(a))
+	}
+	a.menu.ShowAtPosition(pos)
+}
+
+func (a *FolderList) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(a.container)
+}
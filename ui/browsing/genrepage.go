@@ -35,7 +35,9 @@ func (g *genrePageAdapter) Filter() mediaprovider.AlbumFilter {
 	if g.filter == nil {
 		g.filter = mediaprovider.NewAlbumFilter(
 			mediaprovider.AlbumFilterOptions{
-				Genres: []string{g.genre},
+				Genres:              []string{g.genre},
+				IncludeReleaseTypes: g.cfg.IncludeReleaseTypes,
+				ExcludeReleaseTypes: g.cfg.ExcludeReleaseTypes,
 			},
 		)
 	}
@@ -62,9 +64,12 @@ func (g *genrePageAdapter) ActionButton() fyne.CanvasObject {
 	fn := func() {
 		go func() {
 			var err error
-			if g.cfg.ShuffleMode == "Albums" {
+			switch g.cfg.ShuffleMode {
+			case "Albums":
 				err = g.pm.PlayRandomAlbums(g.genre)
-			} else {
+			case "Mix":
+				err = g.pm.PlayGenreMix(g.genre)
+			default:
 				err = g.pm.PlayRandomSongs(g.genre)
 			}
 			if err != nil {
@@ -76,36 +81,42 @@ func (g *genrePageAdapter) ActionButton() fyne.CanvasObject {
 		}()
 	}
 
-	var tracks, albums *fyne.MenuItem
+	var tracks, albums, mix *fyne.MenuItem
 
-	setShuffleMode := func(isAlbums bool) {
-		if isAlbums {
-			g.cfg.ShuffleMode = "Albums"
-		} else {
-			g.cfg.ShuffleMode = "Tracks"
-		}
-		albums.Checked = isAlbums
-		tracks.Checked = !isAlbums
+	setShuffleMode := func(mode string) {
+		g.cfg.ShuffleMode = mode
+		albums.Checked = mode == "Albums"
+		mix.Checked = mode == "Mix"
+		tracks.Checked = !albums.Checked && !mix.Checked
 	}
 
-	tracks = fyne.NewMenuItem(lang.L("Tracks"), func() { setShuffleMode(false) })
+	tracks = fyne.NewMenuItem(lang.L("Tracks"), func() { setShuffleMode("Tracks") })
 	tracks.Icon = myTheme.TracksIcon
-	albums = fyne.NewMenuItem(lang.L("Albums"), func() { setShuffleMode(true) })
+	albums = fyne.NewMenuItem(lang.L("Albums"), func() { setShuffleMode("Albums") })
 	albums.Icon = myTheme.AlbumIcon
+	// an instant mix of similar tracks, if the server supports it
+	mix = fyne.NewMenuItem(lang.L("Instant mix"), func() { setShuffleMode("Mix") })
+	mix.Icon = myTheme.ShuffleIcon
+
+	items := []*fyne.MenuItem{tracks, albums}
+	if _, ok := g.mp.(mediaprovider.InstantMixProvider); ok {
+		items = append(items, mix)
+	} else if g.cfg.ShuffleMode == "Mix" {
+		g.cfg.ShuffleMode = "Tracks"
+	}
+	setShuffleMode(g.cfg.ShuffleMode)
 
-	isAlbums := g.cfg.ShuffleMode == "Albums"
-	albums.Checked = isAlbums
-	tracks.Checked = !isAlbums
-
-	menu := fyne.NewMenu("", tracks, albums)
+	menu := fyne.NewMenu("", items...)
 	return widgets.NewOptionButtonWithIcon(lang.L("Play random"), myTheme.ShuffleIcon, menu, fn)
 }
 
 func (a *genrePageAdapter) Iter(sortOrderIdx int, filter mediaprovider.AlbumFilter) widgets.GridViewIterator {
+	saveReleaseTypeFilter(a.cfg, filter)
 	return widgets.NewGridViewAlbumIterator(a.mp.IterateAlbums("", filter))
 }
 
 func (a *genrePageAdapter) SearchIter(query string, filter mediaprovider.AlbumFilter) widgets.GridViewIterator {
+	saveReleaseTypeFilter(a.cfg, filter)
 	return widgets.NewGridViewAlbumIterator(a.mp.SearchAlbums(query, filter))
 }
 
@@ -0,0 +1,96 @@
+package browsing
+
+import (
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/dweymouth/supersonic/ui/controller"
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+	"github.com/dweymouth/supersonic/ui/util"
+	"github.com/dweymouth/supersonic/ui/widgets"
+
+	"fyne.io/fyne/v2"
+)
+
+type labelPageAdapter struct {
+	labelID   string
+	cfg       *backend.AlbumsPageConfig
+	contr     *controller.Controller
+	filter    mediaprovider.AlbumFilter
+	filterBtn *widgets.AlbumFilterButton
+}
+
+func NewLabelPage(labelID string, cfg *backend.AlbumsPageConfig, pool *util.WidgetPool, contr *controller.Controller, mp mediaprovider.MediaProvider, im *backend.ImageManager) Page {
+	adapter := &labelPageAdapter{labelID: labelID, cfg: cfg, contr: contr}
+	return NewGridViewPage(adapter, pool, mp, im)
+}
+
+// label IDs are currently always the label name
+func (l *labelPageAdapter) Title() string { return l.labelID }
+
+func (l *labelPageAdapter) Filter() mediaprovider.AlbumFilter {
+	if l.filter == nil {
+		l.filter = mediaprovider.NewAlbumFilter(
+			mediaprovider.AlbumFilterOptions{
+				IncludeReleaseTypes: l.cfg.IncludeReleaseTypes,
+				ExcludeReleaseTypes: l.cfg.ExcludeReleaseTypes,
+			},
+		)
+	}
+	return l.filter
+}
+
+func (l *labelPageAdapter) FilterButton() widgets.FilterButton[mediaprovider.Album, mediaprovider.AlbumFilterOptions] {
+	if l.filterBtn == nil {
+		l.filterBtn = widgets.NewAlbumFilterButton(l.Filter(), l.contr.App.ServerManager.Server.GetGenres)
+	}
+	return l.filterBtn
+}
+
+func (l *labelPageAdapter) PlaceholderResource() fyne.Resource { return myTheme.AlbumIcon }
+
+func (l *labelPageAdapter) Route() controller.Route { return controller.LabelRoute(l.labelID) }
+
+func (l *labelPageAdapter) ActionButton() fyne.CanvasObject { return nil }
+
+func (l *labelPageAdapter) Iter(_ int, filter mediaprovider.AlbumFilter) widgets.GridViewIterator {
+	saveReleaseTypeFilter(l.cfg, filter)
+	return l.iter("", filter)
+}
+
+func (l *labelPageAdapter) SearchIter(query string, filter mediaprovider.AlbumFilter) widgets.GridViewIterator {
+	saveReleaseTypeFilter(l.cfg, filter)
+	return l.iter(query, filter)
+}
+
+func (l *labelPageAdapter) iter(query string, filter mediaprovider.AlbumFilter) widgets.GridViewIterator {
+	query = strings.ToLower(query)
+	var albums []*mediaprovider.Album
+	fetch := func(offset, limit int) ([]*mediaprovider.Album, error) {
+		if albums == nil {
+			al, err := l.contr.GetLabelAlbums(l.labelID)
+			if err != nil {
+				return nil, err
+			}
+			albums = sharedutil.FilterSlice(al, func(a *mediaprovider.Album) bool {
+				return strings.Contains(strings.ToLower(a.Name), query) ||
+					strings.Contains(strings.ToLower(strings.Join(a.ArtistNames, " ")), query)
+			})
+		}
+		return pageSlice(albums, offset, limit), nil
+	}
+	return widgets.NewGridViewAlbumIterator(helpers.NewAlbumIterator(fetch, filter, func(string) {}))
+}
+
+func (l *labelPageAdapter) InitGrid(gv *widgets.GridView) {
+	l.contr.ConnectAlbumGridActions(gv)
+	gv.ShowSuffix = l.cfg.ShowYears
+}
+
+func (l *labelPageAdapter) RefreshGrid(gv *widgets.GridView) {
+	gv.ShowSuffix = l.cfg.ShowYears
+	gv.Refresh()
+}
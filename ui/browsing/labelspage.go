@@ -0,0 +1,77 @@
+package browsing
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/lang"
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/dweymouth/supersonic/ui/controller"
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+	"github.com/dweymouth/supersonic/ui/util"
+	"github.com/dweymouth/supersonic/ui/widgets"
+)
+
+type labelsPageAdapter struct {
+	contr *controller.Controller
+}
+
+func NewLabelsPage(pool *util.WidgetPool, contr *controller.Controller, mp mediaprovider.MediaProvider, im *backend.ImageManager) Page {
+	adapter := &labelsPageAdapter{contr: contr}
+	return NewGridViewPage(adapter, pool, mp, im)
+}
+
+func (a *labelsPageAdapter) Title() string { return lang.L("Labels") }
+
+func (a *labelsPageAdapter) Filter() mediaprovider.MediaFilter[mediaprovider.Label, struct{}] {
+	return nil
+}
+
+func (a *labelsPageAdapter) FilterButton() widgets.FilterButton[mediaprovider.Label, struct{}] {
+	return nil
+}
+
+func (a *labelsPageAdapter) PlaceholderResource() fyne.Resource { return myTheme.AlbumIcon }
+
+func (a *labelsPageAdapter) Route() controller.Route { return controller.LabelsRoute() }
+
+func (a *labelsPageAdapter) ActionButton() fyne.CanvasObject { return nil }
+
+func (a *labelsPageAdapter) Iter(_ int, _ mediaprovider.MediaFilter[mediaprovider.Label, struct{}]) widgets.GridViewIterator {
+	return a.iter("")
+}
+
+func (a *labelsPageAdapter) SearchIter(query string, _ mediaprovider.MediaFilter[mediaprovider.Label, struct{}]) widgets.GridViewIterator {
+	return a.iter(query)
+}
+
+// labels are fetched from the server as a single list,
+// which the iterator then pages through
+func (a *labelsPageAdapter) iter(query string) widgets.GridViewIterator {
+	query = strings.ToLower(query)
+	var labels []*mediaprovider.Label
+	fetch := func(offset, limit int) ([]*mediaprovider.Label, error) {
+		if labels == nil {
+			l, err := a.contr.GetLabels()
+			if err != nil {
+				return nil, err
+			}
+			labels = sharedutil.FilterSlice(l, func(l *mediaprovider.Label) bool {
+				return strings.Contains(strings.ToLower(l.Name), query)
+			})
+		}
+		return pageSlice(labels, offset, limit), nil
+	}
+	return widgets.NewGridViewLabelIterator(helpers.NewLabelIterator(fetch))
+}
+
+func (a *labelsPageAdapter) InitGrid(gv *widgets.GridView) {
+	a.contr.ConnectLabelGridActions(gv)
+}
+
+func (a *labelsPageAdapter) RefreshGrid(gv *widgets.GridView) {
+	gv.Refresh()
+}
@@ -62,8 +62,14 @@ type NowPlayingPage struct {
 	tabs               *container.AppTabs
 	lyricsLoading      *widgets.LoadingDots
 	relatedLoading     *widgets.LoadingDots
+	mainContent        *fyne.Container
+	visualizer         *fyne.Container
+	visualizerBtn      *widgets.IconButton
 	container          *fyne.Container
 
+	// true if the window was already full screen when the visualizer was shown
+	wasFullScreen bool
+
 	// cancel funcs for background fetch tasks
 	imageLoadCancel    context.CancelFunc
 	relatedFetchCancel context.CancelFunc
@@ -243,28 +249,60 @@ func (a *NowPlayingPage) CreateRenderer() fyne.WidgetRenderer {
 		a.backgroundImgA = canvas.NewImageFromImage(nil)
 		a.backgroundImgB = canvas.NewImageFromImage(nil)
 
-		mainContent := container.NewGridWithColumns(2,
+		a.mainContent = container.NewGridWithColumns(2,
 			container.New(paddedLayout, a.card),
 			container.New(paddedLayout,
 				util.AddHeaderBackgroundWithColorName(
 					a.tabs, myTheme.ColorNameNowPlayingPanel)))
+		a.visualizer = container.New(&layout.CustomPaddedLayout{LeftPadding: 20, RightPadding: 20, TopPadding: 20, BottomPadding: 50})
+		a.visualizer.Hidden = true
+		a.visualizerBtn = widgets.NewIconButton(myTheme.VisualizationIcon, a.toggleVisualizer)
+		a.visualizerBtn.IconSize = widgets.IconButtonSizeSmaller
+		a.visualizerBtn.SetToolTip(lang.L("Full-screen visualizer"))
 		a.container = container.NewStack(
 			a.backgroundImgA,
 			a.backgroundImgB,
 			a.backgroundGradient,
-			mainContent,
+			a.mainContent,
+			a.visualizer,
 			container.NewVBox(
 				layout.NewSpacer(),
 				container.NewBorder(nil, nil, util.NewHSpace(1), util.NewHSpace(1),
 					myTheme.NewThemedRectangle(theme.ColorNameInputBorder)),
-				a.statusLabel,
+				container.NewBorder(nil, nil, nil, a.visualizerBtn, a.statusLabel),
 			),
 		)
 	}
 	return widget.NewSimpleRenderer(a.container)
 }
 
+// toggleVisualizer shows or hides the full-screen spectrum analyzer
+func (a *NowPlayingPage) toggleVisualizer() {
+	win := a.contr.MainWindow
+	if a.visualizer.Hidden {
+		a.wasFullScreen = win.FullScreen()
+		a.visualizer.Objects = []fyne.CanvasObject{a.contr.NewFullScreenSpectrumAnalyzer()}
+		a.visualizer.Show()
+		a.mainContent.Hide()
+		a.visualizerBtn.Highlighted = true
+		win.SetFullScreen(true)
+	} else {
+		a.contr.CloseFullScreenSpectrumAnalyzer()
+		a.visualizer.Objects = nil
+		a.visualizer.Hide()
+		a.mainContent.Show()
+		a.visualizerBtn.Highlighted = false
+		if !a.wasFullScreen {
+			win.SetFullScreen(false)
+		}
+	}
+	a.visualizerBtn.Refresh()
+}
+
 func (a *NowPlayingPage) Save() SavedPage {
+	if a.visualizer != nil && !a.visualizer.Hidden {
+		a.toggleVisualizer()
+	}
 	if a.imageLoadCancel != nil {
 		a.imageLoadCancel()
 	}
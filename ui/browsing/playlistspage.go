@@ -37,6 +37,7 @@ type PlaylistsPage struct {
 
 	viewToggle *widgets.ToggleButtonGroup
 	newBtn     *widget.Button
+	smartBtn   *widget.Button
 	searcher   *widgets.SearchEntry
 	titleDisp  *widget.RichText
 	container  *fyne.Container
@@ -90,6 +91,7 @@ func newPlaylistsPage(
 	a.newBtn = widget.NewButtonWithIcon(lang.L("New Playlist"), theme.ContentAddIcon(), func() {
 		a.contr.DoCreatePlaylistWorkflow()
 	})
+	a.smartBtn = widget.NewButtonWithIcon(lang.L("Smart Playlists"), theme.MenuDropDownIcon(), a.showSmartPlaylistsMenu)
 	if activeView == 0 {
 		a.createListView()
 		a.buildContainer(a.listView)
@@ -111,12 +113,60 @@ func (a *PlaylistsPage) buildContainer(initialView fyne.CanvasObject) {
 				container.NewCenter(a.viewToggle),
 				util.NewHSpace(2),
 				container.NewCenter(a.newBtn),
+				container.NewCenter(a.smartBtn),
 				layout.NewSpacer(),
 				searchVbox,
 			),
 			nil, nil, nil, initialView))
 }
 
+func (a *PlaylistsPage) showSmartPlaylistsMenu() {
+	var items []*fyne.MenuItem
+	for _, sp := range a.contr.SmartPlaylists() {
+		play := fyne.NewMenuItem(lang.L("Play"), func() {
+			a.contr.PlaySmartPlaylist(sp, backend.Replace)
+		})
+		play.Icon = theme.MediaPlayIcon()
+		queue := fyne.NewMenuItem(lang.L("Add to queue"), func() {
+			a.contr.PlaySmartPlaylist(sp, backend.Append)
+		})
+		queue.Icon = theme.ContentAddIcon()
+		refresh := fyne.NewMenuItem(lang.L("Update server playlist"), func() {
+			a.contr.RefreshSmartPlaylist(sp)
+		})
+		refresh.Icon = theme.ViewRefreshIcon()
+		edit := fyne.NewMenuItem(lang.L("Edit")+"...", func() {
+			a.contr.DoEditSmartPlaylistWorkflow(sp)
+		})
+		edit.Icon = theme.DocumentCreateIcon()
+		export := fyne.NewMenuItem(lang.L("Export")+"...", func() {
+			a.contr.ExportSmartPlaylist(sp)
+		})
+		export.Icon = theme.DocumentSaveIcon()
+		autoplay := fyne.NewMenuItem(lang.L("Use for autoplay"), func() {
+			a.contr.SetAutoplaySmartPlaylist(sp, !a.contr.IsAutoplaySmartPlaylist(sp))
+		})
+		autoplay.Checked = a.contr.IsAutoplaySmartPlaylist(sp)
+		del := fyne.NewMenuItem(lang.L("Delete"), func() {
+			a.contr.DeleteSmartPlaylist(sp)
+		})
+		del.Icon = theme.DeleteIcon()
+		item := fyne.NewMenuItem(sp.Name, nil)
+		item.ChildMenu = fyne.NewMenu("", play, queue, refresh, edit, export, autoplay, fyne.NewMenuItemSeparator(), del)
+		items = append(items, item)
+	}
+	if len(items) > 0 {
+		items = append(items, fyne.NewMenuItemSeparator())
+	}
+	newItem := fyne.NewMenuItem(lang.L("New Smart Playlist")+"...", a.contr.DoCreateSmartPlaylistWorkflow)
+	newItem.Icon = theme.ContentAddIcon()
+	items = append(items, newItem)
+
+	pop := widget.NewPopUpMenu(fyne.NewMenu("", items...), fyne.CurrentApp().Driver().CanvasForObject(a))
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(a.smartBtn)
+	pop.ShowAtPosition(fyne.NewPos(pos.X, pos.Y+a.smartBtn.Size().Height))
+}
+
 var _ Scrollable = (*PlaylistsPage)(nil)
 
 func (p *PlaylistsPage) Scroll(scrollAmt float32) {
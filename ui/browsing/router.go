@@ -61,6 +61,20 @@ func (r Router) CreatePage(rte controller.Route) Page {
 		var rp mediaprovider.RadioProvider
 		rp, _ = r.App.ServerManager.Server.(mediaprovider.RadioProvider)
 		return NewRadiosPage(r.Controller, rp, r.App.PlaybackManager)
+	case controller.Composer:
+		return NewComposerPage(rte.Arg, &r.App.Config.ComposerPage, r.widgetPool, r.Controller, r.App.PlaybackManager, r.App.ImageManager, canRate, canShare)
+	case controller.Composers:
+		return NewComposersPage(r.widgetPool, r.Controller, r.App.ServerManager.Server, r.App.ImageManager)
+	case controller.Label:
+		return NewLabelPage(rte.Arg, &r.App.Config.AlbumsPage, r.widgetPool, r.Controller, r.App.ServerManager.Server, r.App.ImageManager)
+	case controller.Labels:
+		return NewLabelsPage(r.widgetPool, r.Controller, r.App.ServerManager.Server, r.App.ImageManager)
+	case controller.Statistics:
+		return NewStatisticsPage(&r.App.Config.StatisticsPage, r.Controller, r.App.ListeningHistory, r.App.ServerManager.ServerID.String())
+	case controller.Folders:
+		if fp, ok := r.App.ServerManager.Server.(mediaprovider.FolderBrowsingProvider); ok {
+			return NewFoldersPage(rte.Arg, &r.App.Config.FoldersPage, r.widgetPool, r.Controller, fp, r.App.ImageManager, canRate, canShare)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,243 @@
+package browsing
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/ui/controller"
+	"github.com/dweymouth/supersonic/ui/util"
+	"github.com/dweymouth/supersonic/ui/widgets"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const statsTopEntries = 10
+
+var statisticsPeriods = []string{"Last 7 days", "Last 30 days", "Last 12 months", "All time"}
+
+// StatisticsPage shows statistics computed from the local listening history.
+type StatisticsPage struct {
+	widget.BaseWidget
+
+	statisticsPageState
+
+	disposed bool
+
+	summary   *widget.Label
+	chart     *widgets.BarChart
+	chartFrom *widget.Label
+	chartTo   *widget.Label
+	artists   *fyne.Container
+	albums    *fyne.Container
+	tracks    *fyne.Container
+	genres    *fyne.Container
+	scroller  *container.Scroll
+	container *fyne.Container
+}
+
+type statisticsPageState struct {
+	cfg      *backend.StatisticsPageConfig
+	contr    *controller.Controller
+	history  *backend.ListeningHistory
+	serverID string
+	scroll   float32
+}
+
+func NewStatisticsPage(cfg *backend.StatisticsPageConfig, contr *controller.Controller, history *backend.ListeningHistory, serverID string) *StatisticsPage {
+	return newStatisticsPage(statisticsPageState{
+		cfg:      cfg,
+		contr:    contr,
+		history:  history,
+		serverID: serverID,
+	})
+}
+
+func newStatisticsPage(state statisticsPageState) *StatisticsPage {
+	a := &StatisticsPage{statisticsPageState: state}
+	a.ExtendBaseWidget(a)
+
+	title := widget.NewRichTextWithText(lang.L("Statistics"))
+	title.Segments[0].(*widget.TextSegment).Style.SizeName = theme.SizeNameHeadingText
+	periodSel := widget.NewSelect(util.LocalizeSlice(statisticsPeriods), nil)
+	periodSel.SetSelectedIndex(max(slices.Index(statisticsPeriods, a.cfg.Period), 0))
+	periodSel.OnChanged = func(string) {
+		a.cfg.Period = statisticsPeriods[periodSel.SelectedIndex()]
+		a.Reload()
+	}
+
+	a.summary = widget.NewLabel("")
+	a.chart = widgets.NewBarChart()
+	a.chartFrom = widget.NewLabel("")
+	a.chartTo = widget.NewLabel("")
+	a.artists = container.NewVBox()
+	a.albums = container.NewVBox()
+	a.tracks = container.NewVBox()
+	a.genres = container.NewVBox()
+
+	section := func(name string, content fyne.CanvasObject) fyne.CanvasObject {
+		heading := widget.NewLabel(lang.L(name))
+		heading.TextStyle.Bold = true
+		return container.NewVBox(heading, content)
+	}
+	a.scroller = container.NewVScroll(container.NewVBox(
+		a.summary,
+		section("Listening time per day", container.NewBorder(nil,
+			container.NewHBox(a.chartFrom, layout.NewSpacer(), a.chartTo),
+			nil, nil, a.chart)),
+		container.NewGridWithColumns(2,
+			section("Top artists", a.artists),
+			section("Top albums", a.albums),
+			section("Top tracks", a.tracks),
+			section("Top genres", a.genres),
+		),
+	))
+
+	header := container.NewBorder(nil, nil, title, container.NewCenter(periodSel))
+	a.container = container.New(&layout.CustomPaddedLayout{LeftPadding: 15, RightPadding: 15, TopPadding: 5, BottomPadding: 15},
+		container.NewBorder(header, nil, nil, nil, a.scroller))
+
+	go a.load()
+	return a
+}
+
+func (a *StatisticsPage) periodStart() time.Time {
+	now := time.Now()
+	switch a.cfg.Period {
+	case "Last 7 days":
+		return now.AddDate(0, 0, -7)
+	case "Last 12 months":
+		return now.AddDate(-1, 0, 0)
+	case "All time":
+		return time.Time{}
+	default:
+		return now.AddDate(0, 0, -30)
+	}
+}
+
+// should be called asynchronously
+func (a *StatisticsPage) load() {
+	if a.history == nil {
+		fyne.Do(func() { a.summary.SetText(lang.L("Listening history is unavailable")) })
+		return
+	}
+	plays, err := a.history.Plays(a.serverID, a.periodStart())
+	if err != nil {
+		log.Printf("error loading listening history: %v", err.Error())
+		fyne.Do(func() { a.contr.ToastProvider.ShowErrorToast(lang.L("An error occurred")) })
+		return
+	}
+	stats := backend.ComputeListeningStats(plays, statsTopEntries)
+	if a.disposed {
+		return
+	}
+	fyne.Do(func() {
+		a.update(stats)
+		if a.scroll != 0 {
+			a.scroller.ScrollToOffset(fyne.NewPos(0, a.scroll))
+			a.scroll = 0
+		}
+	})
+}
+
+func (a *StatisticsPage) update(stats *backend.ListeningStats) {
+	if stats.Plays == 0 {
+		a.summary.SetText(lang.L("No plays recorded in this period"))
+	} else {
+		a.summary.SetText(fmt.Sprintf("%d %s · %s · %s",
+			stats.Plays, lang.L("plays"),
+			util.SecondsToTimeString(stats.PlayedTime.Seconds()),
+			skipRateString(&stats.ListeningStatsEntry)))
+	}
+
+	values := make([]float64, len(stats.Daily))
+	for i, d := range stats.Daily {
+		values[i] = d.PlayedTime.Minutes()
+	}
+	a.chart.SetValues(values)
+	a.chartFrom.SetText("")
+	a.chartTo.SetText("")
+	if l := len(stats.Daily); l > 0 {
+		a.chartFrom.SetText(util.FormatDate(stats.Daily[0].Day))
+		a.chartTo.SetText(util.FormatDate(stats.Daily[l-1].Day))
+	}
+
+	a.setEntries(a.artists, stats.TopArtists, func(e *backend.ListeningStatsEntry) *controller.Route {
+		if e.ID == e.Name {
+			return nil // no artist ID was known
+		}
+		r := controller.ArtistRoute(e.ID)
+		return &r
+	})
+	a.setEntries(a.albums, stats.TopAlbums, func(e *backend.ListeningStatsEntry) *controller.Route {
+		r := controller.AlbumRoute(e.ID)
+		return &r
+	})
+	a.setEntries(a.tracks, stats.TopTracks, func(*backend.ListeningStatsEntry) *controller.Route {
+		return nil
+	})
+	a.setEntries(a.genres, stats.TopGenres, func(e *backend.ListeningStatsEntry) *controller.Route {
+		r := controller.GenreRoute(e.Name)
+		return &r
+	})
+}
+
+func (a *StatisticsPage) setEntries(c *fyne.Container, entries []*backend.ListeningStatsEntry, route func(*backend.ListeningStatsEntry) *controller.Route) {
+	c.RemoveAll()
+	for i, e := range entries {
+		var name fyne.CanvasObject
+		if r := route(e); r != nil {
+			h := widget.NewHyperlink(fmt.Sprintf("%d. %s", i+1, e.Name), nil)
+			h.Truncation = fyne.TextTruncateEllipsis
+			h.OnTapped = func() { a.contr.NavigateTo(*r) }
+			name = h
+		} else {
+			l := util.NewTruncatingLabel()
+			l.SetText(fmt.Sprintf("%d. %s", i+1, e.Name))
+			name = l
+		}
+		detail := widget.NewLabel(fmt.Sprintf("%d %s · %s", e.Plays, lang.L("plays"), skipRateString(e)))
+		c.Add(container.NewBorder(nil, nil, nil, detail, name))
+	}
+	c.Refresh()
+}
+
+func skipRateString(e *backend.ListeningStatsEntry) string {
+	return fmt.Sprintf("%.0f%% %s", e.SkipRate()*100, lang.L("skipped"))
+}
+
+func (a *StatisticsPage) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(a.container)
+}
+
+func (a *StatisticsPage) Save() SavedPage {
+	a.disposed = true
+	s := a.statisticsPageState
+	s.scroll = a.scroller.Offset.Y
+	return &s
+}
+
+func (s *statisticsPageState) Restore() Page {
+	return newStatisticsPage(*s)
+}
+
+func (a *StatisticsPage) Route() controller.Route {
+	return controller.StatisticsRoute()
+}
+
+func (a *StatisticsPage) Reload() {
+	go a.load()
+}
+
+var _ Scrollable = (*StatisticsPage)(nil)
+
+func (a *StatisticsPage) Scroll(amount float32) {
+	a.scroller.ScrollToOffset(fyne.NewPos(0, a.scroller.Offset.Y+amount))
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"errors"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/dweymouth/supersonic/ui/widgets"
+
+	"fyne.io/fyne/v2"
+)
+
+var (
+	errComposersNotSupported = errors.New("server does not support browsing by composer")
+	errLabelsNotSupported    = errors.New("server does not support browsing by record label")
+)
+
+// GetComposers returns the composers in the library.
+func (m *Controller) GetComposers() ([]*mediaprovider.Artist, error) {
+	if cp, ok := m.App.ServerManager.Server.(mediaprovider.ComposerProvider); ok {
+		return cp.GetComposers()
+	}
+	return nil, errComposersNotSupported
+}
+
+// GetComposerTracks returns the tracks credited to the given composer.
+func (m *Controller) GetComposerTracks(composerID string) ([]*mediaprovider.Track, error) {
+	if cp, ok := m.App.ServerManager.Server.(mediaprovider.ComposerProvider); ok {
+		return cp.GetComposerTracks(composerID)
+	}
+	return nil, errComposersNotSupported
+}
+
+// GetLabels returns the record labels in the library.
+func (m *Controller) GetLabels() ([]*mediaprovider.Label, error) {
+	if lp, ok := m.App.ServerManager.Server.(mediaprovider.LabelProvider); ok {
+		return lp.GetLabels()
+	}
+	return nil, errLabelsNotSupported
+}
+
+// GetLabelAlbums returns the albums released on the given record label.
+func (m *Controller) GetLabelAlbums(labelID string) ([]*mediaprovider.Album, error) {
+	if lp, ok := m.App.ServerManager.Server.(mediaprovider.LabelProvider); ok {
+		return lp.GetLabelAlbums(labelID)
+	}
+	return nil, errLabelsNotSupported
+}
+
+func (m *Controller) ConnectComposerGridActions(grid *widgets.GridView) {
+	grid.DisableSharing = true
+	grid.OnShowItemPage = func(id string) { m.NavigateTo(ComposerRoute(id)) }
+	grid.OnPlay = func(id string, shuffle bool) {
+		m.loadContributorTracks(m.GetComposerTracks, id, backend.Replace, shuffle)
+	}
+	grid.OnPlayNext = func(id string) {
+		m.loadContributorTracks(m.GetComposerTracks, id, backend.InsertNext, false)
+	}
+	grid.OnAddToQueue = func(id string) {
+		m.loadContributorTracks(m.GetComposerTracks, id, backend.Append, false)
+	}
+	// composers are not favoritable artists on all servers
+	grid.OnFavorite = nil
+	grid.OnAddToPlaylist = func(id string) {
+		m.withContributorTracks(m.GetComposerTracks, id, func(tracks []*mediaprovider.Track) {
+			m.DoAddTracksToPlaylistWorkflow(sharedutil.TracksToIDs(tracks))
+		})
+	}
+	grid.OnDownload = func(id string) {
+		m.withContributorTracks(m.GetComposerTracks, id, func(tracks []*mediaprovider.Track) {
+			m.ShowDownloadDialog(tracks, gridItemName(grid, id))
+		})
+	}
+}
+
+func (m *Controller) ConnectLabelGridActions(grid *widgets.GridView) {
+	grid.DisableSharing = true
+	grid.OnShowItemPage = func(id string) { m.NavigateTo(LabelRoute(id)) }
+	grid.OnPlay = func(id string, shuffle bool) {
+		m.loadContributorTracks(m.getLabelTracks, id, backend.Replace, shuffle)
+	}
+	grid.OnPlayNext = func(id string) {
+		m.loadContributorTracks(m.getLabelTracks, id, backend.InsertNext, false)
+	}
+	grid.OnAddToQueue = func(id string) {
+		m.loadContributorTracks(m.getLabelTracks, id, backend.Append, false)
+	}
+	grid.OnAddToPlaylist = func(id string) {
+		m.withContributorTracks(m.getLabelTracks, id, func(tracks []*mediaprovider.Track) {
+			m.DoAddTracksToPlaylistWorkflow(sharedutil.TracksToIDs(tracks))
+		})
+	}
+	grid.OnDownload = func(id string) {
+		m.withContributorTracks(m.getLabelTracks, id, func(tracks []*mediaprovider.Track) {
+			m.ShowDownloadDialog(tracks, id)
+		})
+	}
+}
+
+func gridItemName(grid *widgets.GridView, id string) string {
+	for _, it := range grid.Items() {
+		if it.ID == id {
+			return it.Name
+		}
+	}
+	return id
+}
+
+func (m *Controller) getLabelTracks(labelID string) ([]*mediaprovider.Track, error) {
+	albums, err := m.GetLabelAlbums(labelID)
+	if err != nil {
+		return nil, err
+	}
+	var tracks []*mediaprovider.Track
+	for _, al := range albums {
+		album, err := m.App.ServerManager.Server.GetAlbum(al.ID)
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, album.Tracks...)
+	}
+	return tracks, nil
+}
+
+func (m *Controller) loadContributorTracks(fetch func(string) ([]*mediaprovider.Track, error), id string, mode backend.InsertQueueMode, shuffle bool) {
+	go func() {
+		tracks, err := fetch(id)
+		if err != nil {
+			m.onTrackLoadError(err)
+			return
+		}
+		m.App.PlaybackManager.LoadTracks(tracks, mode, shuffle)
+		if mode == backend.Replace {
+			m.App.PlaybackManager.PlayFromBeginning()
+		}
+	}()
+}
+
+// withContributorTracks fetches the tracks in the background and
+// invokes fn on the main thread if any tracks were found.
+func (m *Controller) withContributorTracks(fetch func(string) ([]*mediaprovider.Track, error), id string, fn func([]*mediaprovider.Track)) {
+	go func() {
+		tracks, err := fetch(id)
+		if err != nil {
+			m.onTrackLoadError(err)
+			return
+		}
+		if len(tracks) == 0 {
+			return
+		}
+		fyne.Do(func() { fn(tracks) })
+	}()
+}
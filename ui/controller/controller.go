@@ -298,6 +298,15 @@ func (m *Controller) GetArtistTracks(artistID string) []*mediaprovider.Track {
 	return nil
 }
 
+// onTrackLoadError reports an error loading tracks to play or queue.
+// It may be called from any goroutine.
+func (m *Controller) onTrackLoadError(err error) {
+	log.Printf("error loading tracks: %s", err.Error())
+	fyne.Do(func() {
+		m.ToastProvider.ShowErrorToast(lang.L("An error occurred"))
+	})
+}
+
 func (c *Controller) ShowAboutDialog() {
 	dlg := dialogs.NewAboutDialog(c.AppVersion)
 	pop := widget.NewModalPopUp(dlg, c.MainWindow.Canvas())
@@ -328,6 +337,7 @@ func (c *Controller) ShowSettingsDialog(themeUpdateCallbk func(), themeFiles map
 		devs, themeFiles, bands,
 		c.App.ServerManager.Server.ClientDecidesScrobble(),
 		isLocalPlayer, isReplayGainPlayer, isEqualizerPlayer, canSavePlayQueue,
+		c.App.PlaybackManager.AutoplayStrategyNames(),
 		c.App.EQPresetManager,
 		c.MainWindow,
 		c.App.AutoEQManager,
@@ -376,6 +386,7 @@ func (c *Controller) ShowSettingsDialog(themeUpdateCallbk func(), themeFiles map
 	}
 	dlg.OnPageNeedsRefresh = c.RefreshPageFunc
 	dlg.OnClearCaches = func() { go c.App.ClearCaches() }
+	dlg.OnProxySettingChanged = c.App.UpdateProxySettings
 	pop := widget.NewModalPopUp(dlg, c.MainWindow.Canvas())
 	fynetooltip.AddPopUpToolTipLayer(pop)
 	dlg.OnDismiss = func() {
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"errors"
+	"log"
+
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mediaprovider/helpers"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/lang"
+)
+
+// PlayFolder replaces the play queue with all tracks in the folder
+// and its subfolders, and begins playback.
+func (m *Controller) PlayFolder(folderID string, shuffle bool) {
+	go func() {
+		if err := m.App.PlaybackManager.PlayFolder(folderID, shuffle); err != nil {
+			m.onFolderLoadError(err)
+		}
+	}()
+}
+
+// QueueFolder adds all tracks in the folder and its subfolders to the play queue.
+func (m *Controller) QueueFolder(folderID string, next bool) {
+	queueMode := backend.Append
+	if next {
+		queueMode = backend.InsertNext
+	}
+	go func() {
+		if err := m.App.PlaybackManager.LoadFolder(folderID, queueMode, false); err != nil {
+			m.onFolderLoadError(err)
+		}
+	}()
+}
+
+// DownloadFolder shows the download dialog for all tracks in the folder and its subfolders.
+func (m *Controller) DownloadFolder(folderID, folderName string) {
+	fp, ok := m.App.ServerManager.Server.(mediaprovider.FolderBrowsingProvider)
+	if !ok {
+		return
+	}
+	go func() {
+		tracks, err := helpers.GetFolderTracksRecursive(fp, folderID)
+		if err != nil {
+			m.onFolderLoadError(err)
+			if !errors.Is(err, helpers.ErrFolderTruncated) {
+				return
+			}
+		}
+		if len(tracks) == 0 {
+			return
+		}
+		fyne.Do(func() {
+			m.ShowDownloadDialog(tracks, folderName)
+		})
+	}()
+}
+
+func (m *Controller) onFolderLoadError(err error) {
+	if errors.Is(err, helpers.ErrFolderTruncated) {
+		fyne.Do(func() {
+			m.ToastProvider.ShowErrorToast(lang.L("Folder is too large; only some tracks were loaded"))
+		})
+		return
+	}
+	log.Printf("error loading folder: %s", err.Error())
+	fyne.Do(func() {
+		m.ToastProvider.ShowErrorToast(lang.L("An error occurred"))
+	})
+}
@@ -16,6 +16,12 @@ const (
 	Playlists
 	Tracks
 	Radios
+	Folders
+	Composer
+	Composers
+	Label
+	Labels
+	Statistics
 )
 
 func (p PageName) String() string {
@@ -44,6 +50,18 @@ func (p PageName) String() string {
 		return "All Tracks"
 	case Radios:
 		return "Internet Radio Stations"
+	case Folders:
+		return "Folders"
+	case Composer:
+		return "Composer"
+	case Composers:
+		return "Composers"
+	case Label:
+		return "Label"
+	case Labels:
+		return "Labels"
+	case Statistics:
+		return "Statistics"
 	default:
 		return ""
 	}
@@ -102,6 +120,32 @@ func RadiosRoute() Route {
 	return Route{Page: Radios}
 }
 
+// FoldersRoute returns the route to browse the given folder.
+// Use empty string for the top-level folders.
+func FoldersRoute(folderID string) Route {
+	return Route{Page: Folders, Arg: folderID}
+}
+
+func ComposerRoute(composerID string) Route {
+	return Route{Page: Composer, Arg: composerID}
+}
+
+func ComposersRoute() Route {
+	return Route{Page: Composers}
+}
+
+func LabelRoute(labelID string) Route {
+	return Route{Page: Label, Arg: labelID}
+}
+
+func LabelsRoute() Route {
+	return Route{Page: Labels}
+}
+
+func StatisticsRoute() Route {
+	return Route{Page: Statistics}
+}
+
 func NowPlayingRoute() Route {
 	return Route{Page: NowPlaying}
 }
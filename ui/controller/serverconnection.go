@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/widget"
@@ -27,14 +28,22 @@ func (m *Controller) PromptForFirstServer() {
 					m.doModalClosed()
 				})
 				conn := backend.ServerConnection{
-					ServerType:    d.ServerType,
-					Hostname:      d.Host,
-					AltHostname:   d.AltHost,
-					Username:      d.Username,
-					LegacyAuth:    d.LegacyAuth,
-					SkipSSLVerify: d.SkipSSLVerify,
+					ServerType:     d.ServerType,
+					Hostname:       d.Host,
+					AltHostname:    d.AltHost,
+					Username:       d.Username,
+					LegacyAuth:     d.LegacyAuth,
+					APIKeyAuth:     d.APIKeyAuth,
+					SkipSSLVerify:  d.SkipSSLVerify,
+					CustomHeaders:  d.CustomHeaders,
+					ClientCertFile: d.ClientCertFile,
+					ClientKeyFile:  d.ClientKeyFile,
+					CACertFile:     d.CACertFile,
+					ProxyURL:       d.ProxyURL,
 				}
 				server := m.App.ServerManager.AddServer(d.Nickname, conn)
+				server.Transcoding = d.Transcoding
+				server.AltTranscoding = d.AltTranscoding
 				if err := m.trySetPasswordAndConnectToServer(server, d.Password); err != nil {
 					log.Printf("error connecting to server: %s", err.Error())
 				}
@@ -117,7 +126,10 @@ func (m *Controller) PromptForLoginAndConnect() {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 
-			err := m.App.ServerManager.TestConnectionAndAuth(ctx, server.ServerConnection, password)
+			// logging in with a password replaces any previous Quick Connect login
+			conn := server.ServerConnection
+			conn.QuickConnect = false
+			err := m.App.ServerManager.TestConnectionAndAuth(ctx, conn, password)
 			fyne.Do(func() {
 				if err == backend.ErrUnreachable {
 					d.SetErrorText(lang.L("Server unreachable"))
@@ -125,6 +137,7 @@ func (m *Controller) PromptForLoginAndConnect() {
 					d.SetErrorText(lang.L("Authentication failed"))
 				} else {
 					pop.Hide()
+					server.QuickConnect = false
 					m.trySetPasswordAndConnectToServer(server, password)
 					m.doModalClosed()
 				}
@@ -149,7 +162,16 @@ func (m *Controller) PromptForLoginAndConnect() {
 						server.Nickname = editD.Nickname
 						server.Username = editD.Username
 						server.LegacyAuth = editD.LegacyAuth
+						server.APIKeyAuth = editD.APIKeyAuth
 						server.SkipSSLVerify = editD.SkipSSLVerify
+						server.CustomHeaders = editD.CustomHeaders
+						server.ClientCertFile = editD.ClientCertFile
+						server.ClientKeyFile = editD.ClientKeyFile
+						server.CACertFile = editD.CACertFile
+						server.ProxyURL = editD.ProxyURL
+						server.QuickConnect = false
+						server.Transcoding = editD.Transcoding
+						server.AltTranscoding = editD.AltTranscoding
 						m.trySetPasswordAndConnectToServer(server, editD.Password)
 						m.doModalClosed()
 					}
@@ -163,6 +185,16 @@ func (m *Controller) PromptForLoginAndConnect() {
 		}
 		editPop.Show()
 	}
+	d.OnQuickConnect = func(server *backend.ServerConfig) {
+		pop.Hide()
+		m.doQuickConnect(server, func(ok bool) {
+			if !ok {
+				pop.Show()
+				return
+			}
+			m.doModalClosed()
+		})
+	}
 	d.OnNewServer = func() {
 		pop.Hide()
 		newD := dialogs.NewAddEditServerDialog(lang.L("Add Server"), true, nil, m.MainWindow.Canvas().Focus)
@@ -176,14 +208,22 @@ func (m *Controller) PromptForLoginAndConnect() {
 						// connection is good
 						newPop.Hide()
 						conn := backend.ServerConnection{
-							ServerType:    newD.ServerType,
-							Hostname:      newD.Host,
-							AltHostname:   newD.AltHost,
-							Username:      newD.Username,
-							LegacyAuth:    newD.LegacyAuth,
-							SkipSSLVerify: newD.SkipSSLVerify,
+							ServerType:     newD.ServerType,
+							Hostname:       newD.Host,
+							AltHostname:    newD.AltHost,
+							Username:       newD.Username,
+							LegacyAuth:     newD.LegacyAuth,
+							APIKeyAuth:     newD.APIKeyAuth,
+							SkipSSLVerify:  newD.SkipSSLVerify,
+							CustomHeaders:  newD.CustomHeaders,
+							ClientCertFile: newD.ClientCertFile,
+							ClientKeyFile:  newD.ClientKeyFile,
+							CACertFile:     newD.CACertFile,
+							ProxyURL:       newD.ProxyURL,
 						}
 						server := m.App.ServerManager.AddServer(newD.Nickname, conn)
+						server.Transcoding = newD.Transcoding
+						server.AltTranscoding = newD.AltTranscoding
 						m.trySetPasswordAndConnectToServer(server, newD.Password)
 						m.doModalClosed()
 					}
@@ -218,6 +258,60 @@ func (m *Controller) PromptForLoginAndConnect() {
 	pop.Show()
 }
 
+// doQuickConnect shows the Quick Connect code for the user to enter in another
+// Jellyfin client, and once the login is authorized, stores the obtained access
+// token in place of the server's password and connects. onDone is called on the
+// Fyne thread with false if the login was cancelled or failed.
+func (c *Controller) doQuickConnect(server *backend.ServerConfig, onDone func(ok bool)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	codeLabel := widget.NewLabel("")
+	codeLabel.TextStyle.Bold = true
+	codeLabel.TextStyle.Monospace = true
+	codeLabel.Alignment = fyne.TextAlignCenter
+	infoLabel := widget.NewLabel(lang.L("Requesting code") + "...")
+	infoLabel.Alignment = fyne.TextAlignCenter
+	infoLabel.Wrapping = fyne.TextWrapWord
+	dlg := dialog.NewCustom(lang.L("Quick Connect"), lang.L("Cancel"),
+		container.NewVBox(infoLabel, codeLabel), c.MainWindow)
+	dlg.Resize(fyne.NewSize(350, dlg.MinSize().Height))
+	dlg.SetOnClosed(cancel)
+	dlg.Show()
+
+	go func() {
+		defer cancel()
+		token, username, err := c.App.ServerManager.QuickConnect(ctx, server.ServerConnection, func(code string) {
+			fyne.Do(func() {
+				infoLabel.SetText(lang.L("Enter this code in the Quick Connect settings of another logged in Jellyfin client"))
+				codeLabel.SetText(code)
+			})
+		})
+		canceled := ctx.Err() != nil
+		fyne.Do(func() {
+			dlg.SetOnClosed(nil)
+			dlg.Hide()
+			if err != nil {
+				if canceled {
+					onDone(false)
+					return
+				}
+				log.Printf("Quick Connect login failed: %v", err)
+				errDlg := dialog.NewError(err, c.MainWindow)
+				errDlg.SetOnClosed(func() { onDone(false) })
+				errDlg.Show()
+				return
+			}
+			server.QuickConnect = true
+			server.Username = username
+			onDone(true)
+			go func() {
+				if err := c.trySetPasswordAndConnectToServer(server, token); err != nil {
+					log.Printf("error connecting to server: %s", err.Error())
+				}
+			}()
+		})
+	}()
+}
+
 func (c *Controller) trySetPasswordAndConnectToServer(server *backend.ServerConfig, password string) error {
 	if err := c.App.ServerManager.SetServerPassword(server, password); err != nil {
 		log.Printf("error setting keyring credentials: %v", err)
@@ -246,12 +340,18 @@ func (c *Controller) tryConnectToServer(ctx context.Context, server *backend.Ser
 func (c *Controller) testConnectionAndUpdateDialogText(dlg *dialogs.AddEditServerDialog) bool {
 	fyne.Do(func() { dlg.SetInfoText(lang.L("Testing connection") + "...") })
 	conn := backend.ServerConnection{
-		ServerType:    dlg.ServerType,
-		Hostname:      dlg.Host,
-		AltHostname:   dlg.AltHost,
-		Username:      dlg.Username,
-		LegacyAuth:    dlg.LegacyAuth,
-		SkipSSLVerify: dlg.SkipSSLVerify,
+		ServerType:     dlg.ServerType,
+		Hostname:       dlg.Host,
+		AltHostname:    dlg.AltHost,
+		Username:       dlg.Username,
+		LegacyAuth:     dlg.LegacyAuth,
+		APIKeyAuth:     dlg.APIKeyAuth,
+		SkipSSLVerify:  dlg.SkipSSLVerify,
+		CustomHeaders:  dlg.CustomHeaders,
+		ClientCertFile: dlg.ClientCertFile,
+		ClientKeyFile:  dlg.ClientKeyFile,
+		CACertFile:     dlg.CACertFile,
+		ProxyURL:       dlg.ProxyURL,
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"log"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/ui/dialogs"
+)
+
+// SmartPlaylists returns the smart playlists defined for the current server.
+func (m *Controller) SmartPlaylists() []*backend.SmartPlaylist {
+	return m.App.SmartPlaylistManager.Playlists(m.App.ServerManager.ServerID.String())
+}
+
+func (m *Controller) DoCreateSmartPlaylistWorkflow() {
+	m.DoEditSmartPlaylistWorkflow(nil)
+}
+
+// DoEditSmartPlaylistWorkflow shows the smart playlist editor for the given
+// smart playlist, or to create a new one if pl is nil.
+func (m *Controller) DoEditSmartPlaylistWorkflow(pl *backend.SmartPlaylist) {
+	dlg := dialogs.NewSmartPlaylistDialog(pl)
+	pop := widget.NewModalPopUp(dlg, m.MainWindow.Canvas())
+	m.ClosePopUpOnEscape(pop)
+	dlg.OnCanceled = func() {
+		pop.Hide()
+		m.doModalClosed()
+	}
+	dlg.OnSubmit = func() {
+		pop.Hide()
+		m.doModalClosed()
+		edited := dlg.Playlist
+		edited.ServerID = m.App.ServerManager.ServerID.String()
+		if err := m.App.SmartPlaylistManager.Save(edited); err != nil {
+			log.Printf("error saving smart playlist: %s", err.Error())
+			m.ToastProvider.ShowErrorToast(lang.L("An error occurred"))
+		} else if m.CurPageFunc().Page == Playlists {
+			m.ReloadFunc()
+		}
+		if edited.PlaylistID != "" {
+			go func() {
+				if err := m.App.SmartPlaylistManager.StoreOnServer(m.App.ServerManager.Server, edited); err != nil {
+					log.Printf("error storing smart playlist on server: %s", err.Error())
+				}
+			}()
+		}
+	}
+	m.haveModal = true
+	pop.Show()
+}
+
+// PlaySmartPlaylist evaluates the smart playlist's rules against
+// the library and loads the resulting tracks into the play queue.
+func (m *Controller) PlaySmartPlaylist(pl *backend.SmartPlaylist, mode backend.InsertQueueMode) {
+	go func() {
+		tracks, err := pl.Evaluate(m.App.ServerManager.Server)
+		if err != nil {
+			m.onTrackLoadError(err)
+			return
+		}
+		if len(tracks) == 0 {
+			fyne.Do(func() { m.ToastProvider.ShowSuccessToast(lang.L("No tracks match the rules")) })
+			return
+		}
+		m.App.PlaybackManager.LoadTracks(tracks, mode, false)
+		if mode == backend.Replace {
+			m.App.PlaybackManager.PlayFromBeginning()
+		}
+	}()
+}
+
+// RefreshSmartPlaylist materializes the smart playlist into a server playlist.
+func (m *Controller) RefreshSmartPlaylist(pl *backend.SmartPlaylist) {
+	go func() {
+		if err := m.App.SmartPlaylistManager.Refresh(m.App.ServerManager.Server, pl); err != nil {
+			log.Printf("error refreshing smart playlist: %s", err.Error())
+			fyne.Do(func() { m.ToastProvider.ShowErrorToast(lang.L("Error updating playlist")) })
+			return
+		}
+		fyne.Do(func() {
+			if m.CurPageFunc().Page == Playlists {
+				m.ReloadFunc()
+			}
+			m.ToastProvider.ShowSuccessToast(lang.L("Smart playlist refreshed"))
+		})
+	}()
+}
+
+// ExportSmartPlaylist saves the smart playlist as a Navidrome .nsp file.
+func (m *Controller) ExportSmartPlaylist(pl *backend.SmartPlaylist) {
+	b, err := pl.MarshalNSP()
+	if err != nil {
+		log.Printf("error exporting smart playlist: %s", err.Error())
+		m.ToastProvider.ShowErrorToast(lang.L("An error occurred"))
+		return
+	}
+	dg := dialog.NewFileSave(
+		func(file fyne.URIWriteCloser, err error) {
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			if file == nil {
+				return
+			}
+			_, err = file.Write(b)
+			if cerr := file.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				log.Printf("error writing smart playlist file: %s", err.Error())
+				m.ToastProvider.ShowErrorToast(lang.L("An error occurred"))
+			}
+		},
+		m.MainWindow)
+	dg.SetFileName(pl.Name + ".nsp")
+	dg.Show()
+}
+
+func (m *Controller) IsAutoplaySmartPlaylist(pl *backend.SmartPlaylist) bool {
+	cfg := &m.App.Config.Playback
+	return cfg.AutoplaySmartPlaylistID == pl.ID && slices.Contains(cfg.AutoplayStrategies, backend.AutoplaySmartPlaylist)
+}
+
+// SetAutoplaySmartPlaylist sets whether autoplay should prefer tracks matching the smart playlist.
+func (m *Controller) SetAutoplaySmartPlaylist(pl *backend.SmartPlaylist, use bool) {
+	cfg := &m.App.Config.Playback
+	strategies := slices.DeleteFunc(slices.Clone(cfg.AutoplayStrategies), func(s string) bool {
+		return s == backend.AutoplaySmartPlaylist
+	})
+	if use {
+		cfg.AutoplaySmartPlaylistID = pl.ID
+		strategies = append([]string{backend.AutoplaySmartPlaylist}, strategies...)
+	}
+	cfg.AutoplayStrategies = strategies
+}
+
+func (m *Controller) DeleteSmartPlaylist(pl *backend.SmartPlaylist) {
+	dialog.ShowCustomConfirm(lang.L("Confirm Delete Playlist"), lang.L("OK"), lang.L("Cancel"), layout.NewSpacer(), /*custom content*/
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := m.App.SmartPlaylistManager.Delete(pl.ID); err != nil {
+				log.Printf("error deleting smart playlist: %s", err.Error())
+			}
+			if m.CurPageFunc().Page == Playlists {
+				m.ReloadFunc()
+			}
+		}, m.MainWindow)
+}
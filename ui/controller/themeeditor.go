@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"log"
+
+	"github.com/dweymouth/supersonic/ui/dialogs"
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ShowThemeEditor shows the theme editor, starting from the current theme.
+// Edits are previewed live in the app, and on save the theme is written
+// to a new theme file which becomes the active theme.
+func (m *Controller) ShowThemeEditor(th *myTheme.MyTheme) {
+	themeFile := th.CurrentThemeFile()
+	themeFile.SupersonicTheme.Name += " " + lang.L("(Custom)")
+	editDark := th.AppearanceMode() != myTheme.AppearanceLight
+	if th.AppearanceMode() == myTheme.AppearanceAuto {
+		editDark = fyne.CurrentApp().Settings().ThemeVariant() != theme.VariantLight
+	}
+
+	applyTheme := func(t *myTheme.ThemeFile) {
+		th.SetPreviewThemeFile(t)
+		fyne.CurrentApp().Settings().SetTheme(th)
+	}
+	dlg := dialogs.NewThemeEditorDialog(themeFile, editDark)
+	pop := widget.NewModalPopUp(dlg, m.MainWindow.Canvas())
+	closeDialog := func() {
+		pop.Hide()
+		m.doModalClosed()
+		applyTheme(nil)
+	}
+	dlg.OnPreview = applyTheme
+	dlg.OnCanceled = closeDialog
+	dlg.OnSave = func(t *myTheme.ThemeFile) {
+		filename, err := th.SaveThemeFile(t)
+		if err != nil {
+			log.Printf("error saving theme file: %s", err.Error())
+			m.ToastProvider.ShowErrorToast(lang.L("Error saving theme"))
+			return
+		}
+		m.App.Config.Theme.ThemeFile = filename
+		closeDialog()
+		m.ToastProvider.ShowSuccessToast(lang.L("Theme saved"))
+	}
+	m.haveModal = true
+	pop.Show()
+}
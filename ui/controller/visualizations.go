@@ -6,6 +6,7 @@ import (
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/lang"
+	"github.com/dweymouth/supersonic/backend"
 	"github.com/dweymouth/supersonic/backend/player"
 	"github.com/dweymouth/supersonic/backend/player/mpv"
 	"github.com/dweymouth/supersonic/ui/shortcuts"
@@ -19,6 +20,12 @@ type visualizationData struct {
 	peakMeter    *visualizations.PeakMeter
 	peakMeterWin fyne.Window
 
+	spectrum    *visualizations.SpectrumAnalyzer
+	spectrumWin fyne.Window
+	// full-screen spectrum analyzer shown on the Now Playing page
+	fullScreenSpectrum *visualizations.SpectrumAnalyzer
+	spectrumSamples    []float64
+
 	visualizationAnim *fyne.Animation
 }
 
@@ -27,7 +34,7 @@ func (c *Controller) initVisualizations() {
 	c.App.PlaybackManager.OnPaused(c.stopVisualizationAnim)
 	c.App.PlaybackManager.OnPlaying(func() {
 		if _, ok := c.App.PlaybackManager.CurrentPlayer().(*mpv.Player); ok {
-			if c.peakMeter != nil {
+			if c.haveVisualizations() {
 				c.startVisualizationAnim()
 			}
 		}
@@ -42,8 +49,8 @@ func (c *Controller) ShowPeakMeter() {
 	c.peakMeterWin = fyne.CurrentApp().NewWindow(lang.L("Peak Meter"))
 
 	onClose := func() {
-		c.stopVisualizationAnim()
 		c.peakMeter = nil
+		c.onVisualizationClosed()
 		util.SaveWindowSize(c.peakMeterWin,
 			&c.App.Config.PeakMeter.WindowWidth,
 			&c.App.Config.PeakMeter.WindowHeight)
@@ -62,14 +69,92 @@ func (c *Controller) ShowPeakMeter() {
 	}
 	c.peakMeter = visualizations.NewPeakMeter()
 	c.peakMeterWin.SetContent(c.peakMeter)
+	c.onVisualizationOpened()
+	c.peakMeterWin.Show()
+	SetWindowThemeMode(c.peakMeterWin, fyne.CurrentApp().Settings().Theme().(*myTheme.MyTheme).AppearanceMode())
+}
+
+func (c *Controller) ShowSpectrumAnalyzer() {
+	if c.spectrumWin != nil {
+		c.spectrumWin.Show()
+		return
+	}
+	c.spectrumWin = fyne.CurrentApp().NewWindow(lang.L("Spectrum Analyzer"))
+	cfg := &c.App.Config.SpectrumAnalyzer
+
+	onClose := func() {
+		c.spectrum = nil
+		c.onVisualizationClosed()
+		util.SaveWindowSize(c.spectrumWin, &cfg.WindowWidth, &cfg.WindowHeight)
+		c.spectrumWin.Close()
+		c.spectrumWin = nil
+	}
+
+	c.spectrumWin.SetCloseIntercept(onClose)
+	c.spectrumWin.Canvas().AddShortcut(&shortcuts.ShortcutCloseWindow, func(_ fyne.Shortcut) {
+		onClose()
+	})
+	if cfg.WindowHeight > 0 {
+		c.spectrumWin.Resize(fyne.NewSize(float32(cfg.WindowWidth), float32(cfg.WindowHeight)))
+	}
+	c.spectrum = c.newSpectrumAnalyzer()
+	c.spectrumWin.SetContent(c.spectrum)
+	c.onVisualizationOpened()
+	c.spectrumWin.Show()
+	SetWindowThemeMode(c.spectrumWin, fyne.CurrentApp().Settings().Theme().(*myTheme.MyTheme).AppearanceMode())
+}
+
+// NewFullScreenSpectrumAnalyzer returns a spectrum analyzer to be shown
+// full-screen on the Now Playing page. CloseFullScreenSpectrumAnalyzer
+// must be called when it is no longer shown.
+func (c *Controller) NewFullScreenSpectrumAnalyzer() *visualizations.SpectrumAnalyzer {
+	c.fullScreenSpectrum = c.newSpectrumAnalyzer()
+	c.onVisualizationOpened()
+	return c.fullScreenSpectrum
+}
+
+func (c *Controller) CloseFullScreenSpectrumAnalyzer() {
+	if c.fullScreenSpectrum != nil {
+		c.fullScreenSpectrum = nil
+		c.onVisualizationClosed()
+	}
+}
+
+func (c *Controller) newSpectrumAnalyzer() *visualizations.SpectrumAnalyzer {
+	s := visualizations.NewSpectrumAnalyzer(c.App.Config.SpectrumAnalyzer.Style)
+	s.OnStyleChanged = func(style string) {
+		c.App.Config.SpectrumAnalyzer.Style = style
+	}
+	return s
+}
+
+func (c *Controller) haveVisualizations() bool {
+	return c.peakMeter != nil || c.spectrum != nil || c.fullScreenSpectrum != nil
+}
+
+func (c *Controller) onVisualizationOpened() {
+	if c.spectrum != nil || c.fullScreenSpectrum != nil {
+		c.App.PCMTap.SetEnabled(true)
+	}
 	if c.App.LocalPlayer.GetStatus().State == player.Playing {
+		c.stopVisualizationAnim() // restart to enable peaks if needed
 		c.startVisualizationAnim()
 	} else {
 		// TODO: why is this needed?
-		c.peakMeter.Refresh()
+		if c.peakMeter != nil {
+			c.peakMeter.Refresh()
+		}
+	}
+}
+
+func (c *Controller) onVisualizationClosed() {
+	if c.spectrum == nil && c.fullScreenSpectrum == nil {
+		c.App.PCMTap.SetEnabled(false)
+	}
+	c.stopVisualizationAnim()
+	if c.haveVisualizations() && c.App.LocalPlayer.GetStatus().State == player.Playing {
+		c.startVisualizationAnim()
 	}
-	c.peakMeterWin.Show()
-	SetWindowThemeMode(c.peakMeterWin, fyne.CurrentApp().Settings().Theme().(*myTheme.MyTheme).AppearanceMode())
 }
 
 func (c *Controller) stopVisualizationAnim() {
@@ -82,7 +167,7 @@ func (c *Controller) stopVisualizationAnim() {
 
 func (c *Controller) startVisualizationAnim() {
 	if c.visualizationAnim == nil {
-		c.App.LocalPlayer.SetPeaksEnabled(true)
+		c.App.LocalPlayer.SetPeaksEnabled(c.peakMeter != nil)
 		c.visualizationAnim = fyne.NewAnimation(
 			time.Duration(math.MaxInt64), /*until stopped*/
 			c.tickVisualizations)
@@ -91,8 +176,30 @@ func (c *Controller) startVisualizationAnim() {
 }
 
 func (c *Controller) tickVisualizations(_ float32) {
-	lP, rP, lRMS, rRMS := c.App.LocalPlayer.GetPeaks()
 	if c.visualizationData.peakMeter != nil {
+		lP, rP, lRMS, rRMS := c.App.LocalPlayer.GetPeaks()
 		c.visualizationData.peakMeter.UpdatePeaks(lP, rP, lRMS, rRMS)
 	}
+	if c.spectrum != nil || c.fullScreenSpectrum != nil {
+		if c.spectrumSamples == nil {
+			c.spectrumSamples = make([]float64, visualizations.SpectrumFFTSize)
+		}
+		samples := c.spectrumSamples
+		if !c.App.PCMTap.Samples(samples) {
+			samples = nil
+		}
+		message := ""
+		switch c.App.PCMTap.State() {
+		case backend.PCMTapNoAudioCache:
+			message = lang.L("The spectrum analyzer requires the waveform seekbar to be enabled")
+		case backend.PCMTapNotCached, backend.PCMTapFailed:
+			message = lang.L("The spectrum is not available for this track")
+		}
+		for _, s := range []*visualizations.SpectrumAnalyzer{c.spectrum, c.fullScreenSpectrum} {
+			if s != nil {
+				s.SetMessage(message)
+				s.UpdateSamples(samples, backend.PCMTapSampleRate)
+			}
+		}
+	}
 }
@@ -2,8 +2,11 @@ package dialogs
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/sharedutil"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -24,14 +27,28 @@ type AddEditServerDialog struct {
 	Username      string
 	Password      string
 	LegacyAuth    bool
+	APIKeyAuth    bool
 	SkipSSLVerify bool
-	OnSubmit      func()
-	OnCancel      func()
 
-	passField  *widget.Entry
-	submitBtn  *widget.Button
-	promptText *widget.RichText
-	container  *fyne.Container
+	// transcoding overrides for the URL and Alt. URL (nil = use global setting)
+	Transcoding    *backend.TranscodingConfig
+	AltTranscoding *backend.TranscodingConfig
+
+	// custom headers, mutual TLS and custom CA settings
+	CustomHeaders  map[string]string
+	ClientCertFile string
+	ClientKeyFile  string
+	CACertFile     string
+	ProxyURL       string
+
+	OnSubmit func()
+	OnCancel func()
+
+	passField    *widget.Entry
+	headersField *widget.Entry
+	submitBtn    *widget.Button
+	promptText   *widget.RichText
+	container    *fyne.Container
 }
 
 var _ fyne.Widget = (*AddEditServerDialog)(nil)
@@ -46,18 +63,42 @@ func NewAddEditServerDialog(title string, cancelable bool, prefillServer *backen
 		a.AltHost = prefillServer.AltHostname
 		a.Username = prefillServer.Username
 		a.LegacyAuth = prefillServer.LegacyAuth
+		a.APIKeyAuth = prefillServer.APIKeyAuth
 		a.SkipSSLVerify = prefillServer.SkipSSLVerify
+		a.Transcoding = prefillServer.Transcoding
+		a.AltTranscoding = prefillServer.AltTranscoding
+		a.CustomHeaders = prefillServer.CustomHeaders
+		a.ClientCertFile = prefillServer.ClientCertFile
+		a.ClientKeyFile = prefillServer.ClientKeyFile
+		a.CACertFile = prefillServer.CACertFile
+		a.ProxyURL = prefillServer.ProxyURL
 	}
 
 	titleLabel := widget.NewLabel(title)
 	titleLabel.TextStyle.Bold = true
 	legacyAuthCheck := widget.NewCheckWithData(lang.L("Use legacy authentication"), binding.BindBool(&a.LegacyAuth))
+	passLabel := widget.NewLabel(lang.L("Password"))
+	apiKeyCheck := widget.NewCheck(lang.L("Use API key"), func(b bool) {
+		a.APIKeyAuth = b
+		passLabel.SetText(lang.L("Password"))
+		if b {
+			passLabel.SetText(lang.L("API key"))
+			legacyAuthCheck.SetChecked(false)
+			legacyAuthCheck.Disable()
+		} else {
+			legacyAuthCheck.Enable()
+		}
+	})
+	apiKeyCheck.SetChecked(a.APIKeyAuth)
 	serverTypeChoice := widget.NewRadioGroup([]string{"Subsonic", "Jellyfin"}, func(s string) {
 		a.ServerType = backend.ServerType(s)
 		if s == string(backend.ServerTypeSubsonic) {
 			legacyAuthCheck.Show()
+			apiKeyCheck.Show()
 		} else {
 			legacyAuthCheck.Hide()
+			apiKeyCheck.SetChecked(false)
+			apiKeyCheck.Hide()
 		}
 	})
 	skipSSLCheck := widget.NewCheckWithData(lang.L("Skip SSL certificate verification"), binding.BindBool(&a.SkipSSLVerify))
@@ -68,6 +109,7 @@ func NewAddEditServerDialog(title string, cancelable bool, prefillServer *backen
 		selected = backend.ServerTypeJellyfin
 	}
 	serverTypeChoice.Selected = string(selected)
+	apiKeyCheck.Hidden = selected != backend.ServerTypeSubsonic
 	a.passField = widget.NewPasswordEntry()
 	a.passField.OnSubmitted = func(_ string) { a.doSubmit() }
 	userField := widget.NewEntryWithData(binding.BindString(&a.Username))
@@ -81,6 +123,32 @@ func NewAddEditServerDialog(title string, cancelable bool, prefillServer *backen
 	nickField := widget.NewEntryWithData(binding.BindString(&a.Nickname))
 	nickField.SetPlaceHolder(lang.L("My Server"))
 	nickField.OnSubmitted = func(_ string) { focusHandler(hostField) }
+	transcodeSelect := newTranscodingProfileSelect(a.Transcoding, func(t *backend.TranscodingConfig) {
+		a.Transcoding = t
+	})
+	altTranscodeSelect := newTranscodingProfileSelect(a.AltTranscoding, func(t *backend.TranscodingConfig) {
+		a.AltTranscoding = t
+	})
+	a.headersField = widget.NewMultiLineEntry()
+	a.headersField.SetPlaceHolder("CF-Access-Client-Id: ...")
+	a.headersField.SetMinRowsVisible(2)
+	a.headersField.SetText(formatHeaders(a.CustomHeaders))
+	a.headersField.Validator = func(s string) error {
+		_, err := parseHeaders(s)
+		return err
+	}
+	certField := widget.NewEntryWithData(binding.BindString(&a.ClientCertFile))
+	certField.SetPlaceHolder("/path/to/client.crt")
+	keyField := widget.NewEntryWithData(binding.BindString(&a.ClientKeyFile))
+	keyField.SetPlaceHolder("/path/to/client.key")
+	caField := widget.NewEntryWithData(binding.BindString(&a.CACertFile))
+	caField.SetPlaceHolder("/path/to/ca.pem")
+	proxyField := widget.NewEntryWithData(binding.BindString(&a.ProxyURL))
+	proxyField.SetPlaceHolder(fmt.Sprintf("(%s)", lang.L("optional")) + " socks5://host:1080")
+	proxyField.Validator = func(s string) error {
+		_, err := backend.ParseProxyURL(s)
+		return err
+	}
 	a.submitBtn = widget.NewButtonWithIcon(lang.L("Enter"), theme.ConfirmIcon(), a.doSubmit)
 	a.submitBtn.Importance = widget.HighImportance
 	a.promptText = widget.NewRichTextWithText("")
@@ -113,16 +181,111 @@ func NewAddEditServerDialog(title string, cancelable bool, prefillServer *backen
 			altHostField,
 			widget.NewLabel(lang.L("Username")),
 			userField,
-			widget.NewLabel(lang.L("Password")),
+			passLabel,
 			a.passField,
 		),
-		container.NewHBox(layout.NewSpacer(), legacyAuthCheck, skipSSLCheck),
+		container.NewHBox(layout.NewSpacer(), apiKeyCheck, legacyAuthCheck, skipSSLCheck),
+		widget.NewAccordion(widget.NewAccordionItem(lang.L("Transcoding"),
+			container.New(layout.NewFormLayout(),
+				widget.NewLabel(lang.L("URL")),
+				transcodeSelect,
+				widget.NewLabel(lang.L("Alt. URL")),
+				altTranscodeSelect,
+			)),
+			widget.NewAccordionItem(lang.L("Advanced"),
+				container.New(layout.NewFormLayout(),
+					widget.NewLabel(lang.L("HTTP headers")),
+					a.headersField,
+					widget.NewLabel(lang.L("Client certificate")),
+					certField,
+					widget.NewLabel(lang.L("Client key")),
+					keyField,
+					widget.NewLabel(lang.L("CA certificates")),
+					caField,
+					widget.NewLabel(lang.L("Proxy")),
+					proxyField,
+				))),
 		widget.NewSeparator(),
 		bottomRow,
 	)
 	return a
 }
 
+type transcodingProfile struct {
+	label string
+	cfg   *backend.TranscodingConfig
+}
+
+// newTranscodingProfileSelect returns a Select to choose between
+// the global transcoding setting and some common transcoding profiles.
+func newTranscodingProfileSelect(initial *backend.TranscodingConfig, onChanged func(*backend.TranscodingConfig)) *widget.Select {
+	transcode := func(codec string, kbps int) transcodingProfile {
+		return transcodingProfile{
+			label: fmt.Sprintf("%s %dk", strings.ToUpper(codec), kbps),
+			cfg:   &backend.TranscodingConfig{RequestTranscode: true, Codec: codec, MaxBitRateKBPS: kbps},
+		}
+	}
+	profiles := []transcodingProfile{
+		{label: lang.L("Use global setting")},
+		{label: lang.L("Original file"), cfg: &backend.TranscodingConfig{ForceRawFile: true}},
+		{label: lang.L("Server default"), cfg: &backend.TranscodingConfig{}},
+		transcode("opus", 96), transcode("opus", 128), transcode("opus", 192),
+		transcode("mp3", 128), transcode("mp3", 192), transcode("mp3", 320),
+	}
+	selected := 0
+	if initial != nil {
+		selected = slices.IndexFunc(profiles, func(p transcodingProfile) bool {
+			return p.cfg != nil && *p.cfg == *initial
+		})
+		if selected < 0 {
+			// custom profile from the config file
+			p := transcode(initial.Codec, initial.MaxBitRateKBPS)
+			p.cfg = initial
+			profiles = append(profiles, p)
+			selected = len(profiles) - 1
+		}
+	}
+
+	labels := sharedutil.MapSlice(profiles, func(p transcodingProfile) string { return p.label })
+	sel := widget.NewSelect(labels, nil)
+	sel.SetSelectedIndex(selected)
+	sel.OnChanged = func(_ string) {
+		onChanged(profiles[sel.SelectedIndex()].cfg)
+	}
+	return sel
+}
+
+// formatHeaders formats headers as "Name: Value" lines, sorted by name.
+func formatHeaders(headers map[string]string) string {
+	lines := make([]string, 0, len(headers))
+	for name, value := range headers {
+		lines = append(lines, name+": "+value)
+	}
+	slices.Sort(lines)
+	return strings.Join(lines, "\n")
+}
+
+// parseHeaders parses "Name: Value" lines into a header map.
+// Blank lines are ignored. Returns nil if there are no headers.
+func parseHeaders(text string) (map[string]string, error) {
+	var headers map[string]string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf(lang.L("Invalid HTTP header: %s"), strings.TrimSpace(line))
+		}
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[name] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
 func (a *AddEditServerDialog) SetInfoText(text string) {
 	a.doSetPromptText(text, theme.ColorNameForeground)
 }
@@ -142,6 +305,16 @@ func (a *AddEditServerDialog) DisableSubmit() {
 }
 
 func (a *AddEditServerDialog) doSubmit() {
+	headers, err := parseHeaders(a.headersField.Text)
+	if err != nil {
+		a.SetErrorText(err.Error())
+		return
+	}
+	a.CustomHeaders = headers
+	if _, err := backend.ParseProxyURL(a.ProxyURL); err != nil {
+		a.SetErrorText(err.Error())
+		return
+	}
 	a.Password = a.passField.Text
 	if a.OnSubmit != nil {
 		a.OnSubmit()
@@ -22,6 +22,7 @@ type LoginDialog struct {
 	OnEditServer   func(server *backend.ServerConfig)
 	OnDeleteServer func(server *backend.ServerConfig)
 	OnNewServer    func()
+	OnQuickConnect func(server *backend.ServerConfig)
 
 	servers []*backend.ServerConfig
 
@@ -29,6 +30,7 @@ type LoginDialog struct {
 	passField    *widget.Entry
 	promptText   *widget.RichText
 	submitBtn    *widget.Button
+	quickConnBtn *widget.Button
 
 	container *fyne.Container
 }
@@ -44,9 +46,14 @@ func NewLoginDialog(servers []*backend.ServerConfig, pwFetch PasswordFetchFunc)
 	l.passField.OnSubmitted = func(_ string) { l.onSubmit() }
 
 	serverNames := sharedutil.MapSlice(servers, func(s *backend.ServerConfig) string { return s.Nickname })
+	l.quickConnBtn = widget.NewButton(lang.L("Quick Connect"), l.onQuickConnect)
 	l.serverSelect = widget.NewSelect(serverNames, func(_ string) {
-		if pwFetch != nil {
-			if pw, err := pwFetch(servers[l.serverSelect.SelectedIndex()].ID); err == nil {
+		server := l.servers[l.serverSelect.SelectedIndex()]
+		l.quickConnBtn.Hidden = server.ServerType != backend.ServerTypeJellyfin
+		l.quickConnBtn.Refresh()
+		// the stored credential of a Quick Connect login is an access token, not a password
+		if pwFetch != nil && !server.QuickConnect {
+			if pw, err := pwFetch(server.ID); err == nil {
 				l.passField.SetText(pw)
 				return
 			}
@@ -72,7 +79,7 @@ func NewLoginDialog(servers []*backend.ServerConfig, pwFetch PasswordFetchFunc)
 			widget.NewLabel(lang.L("Password")),
 			l.passField),
 		widget.NewSeparator(),
-		container.NewHBox(l.promptText, layout.NewSpacer(), l.submitBtn),
+		container.NewHBox(l.promptText, layout.NewSpacer(), l.quickConnBtn, l.submitBtn),
 	)
 	return l
 }
@@ -96,11 +103,15 @@ func (l *LoginDialog) SetServers(servers []*backend.ServerConfig) {
 func (l *LoginDialog) EnableSubmit() {
 	l.submitBtn.Enable()
 	l.submitBtn.Refresh()
+	l.quickConnBtn.Enable()
+	l.quickConnBtn.Refresh()
 }
 
 func (l *LoginDialog) DisableSubmit() {
 	l.submitBtn.Disable()
 	l.submitBtn.Refresh()
+	l.quickConnBtn.Disable()
+	l.quickConnBtn.Refresh()
 }
 
 func (l *LoginDialog) doSetPromptText(text string, color fyne.ThemeColorName) {
@@ -130,6 +141,12 @@ func (l *LoginDialog) onSubmit() {
 	}
 }
 
+func (l *LoginDialog) onQuickConnect() {
+	if l.OnQuickConnect != nil {
+		l.OnQuickConnect(l.servers[l.serverSelect.SelectedIndex()])
+	}
+}
+
 func (l *LoginDialog) onEditServer() {
 	if l.OnEditServer != nil {
 		l.OnEditServer(l.servers[l.serverSelect.SelectedIndex()])
@@ -0,0 +1,261 @@
+package dialogs
+
+import (
+	"slices"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/dweymouth/supersonic/backend"
+)
+
+// SmartPlaylistDialog edits the name, rules, sort and limit of a smart playlist.
+type SmartPlaylistDialog struct {
+	widget.BaseWidget
+
+	OnCanceled func()
+	OnSubmit   func()
+
+	// The edited smart playlist. Populated when the dialog is submitted.
+	Playlist *backend.SmartPlaylist
+
+	nameEntry  *widget.Entry
+	matchSel   *widget.Select
+	sortSel    *widget.Select
+	limitEntry *widget.Entry
+	rulesBox   *fyne.Container
+	rules      []*smartRuleRow
+	container  *fyne.Container
+}
+
+var (
+	smartFieldNames = map[string]string{
+		backend.SmartFieldTitle:      "Title",
+		backend.SmartFieldArtist:     "Artist",
+		backend.SmartFieldAlbum:      "Album",
+		backend.SmartFieldGenre:      "Genre",
+		backend.SmartFieldYear:       "Year",
+		backend.SmartFieldRating:     "Rating",
+		backend.SmartFieldPlayCount:  "Plays",
+		backend.SmartFieldLastPlayed: "Last played",
+		backend.SmartFieldDateAdded:  "Date added",
+		backend.SmartFieldFavorite:   "Favorite",
+	}
+	smartOpNames = map[string]string{
+		backend.SmartOpIs:           "is",
+		backend.SmartOpIsNot:        "is not",
+		backend.SmartOpContains:     "contains",
+		backend.SmartOpNotContains:  "does not contain",
+		backend.SmartOpGreaterThan:  "greater than",
+		backend.SmartOpLessThan:     "less than",
+		backend.SmartOpInTheLast:    "in the last (days)",
+		backend.SmartOpNotInTheLast: "not in the last (days)",
+	}
+	smartSortNames = map[string]string{
+		backend.SmartSortRandom:     "Random",
+		backend.SmartSortTitle:      "Title",
+		backend.SmartSortArtist:     "Artist",
+		backend.SmartSortAlbum:      "Album",
+		backend.SmartSortYear:       "Year",
+		backend.SmartSortRating:     "Rating",
+		backend.SmartSortPlayCount:  "Plays",
+		backend.SmartSortLastPlayed: "Last played",
+		backend.SmartSortDateAdded:  "Date added",
+	}
+)
+
+// NewSmartPlaylistDialog creates a dialog to edit the given smart playlist,
+// or to create a new one if pl is nil.
+func NewSmartPlaylistDialog(pl *backend.SmartPlaylist) *SmartPlaylistDialog {
+	s := &SmartPlaylistDialog{}
+	s.ExtendBaseWidget(s)
+
+	titleStr := lang.L("Edit Smart Playlist")
+	if pl == nil {
+		titleStr = lang.L("New Smart Playlist")
+		pl = &backend.SmartPlaylist{Sort: backend.SmartSortRandom}
+	}
+	edited := *pl
+	s.Playlist = &edited
+
+	s.nameEntry = widget.NewEntry()
+	s.nameEntry.SetText(pl.Name)
+
+	matchAll, matchAny := lang.L("Match all rules"), lang.L("Match any rule")
+	s.matchSel = widget.NewSelect([]string{matchAll, matchAny}, nil)
+	s.matchSel.SetSelected(matchAll)
+	if pl.MatchAny {
+		s.matchSel.SetSelected(matchAny)
+	}
+
+	s.sortSel = widget.NewSelect(localizedNames(backend.SmartPlaylistSorts, smartSortNames), nil)
+	sortIdx := max(slices.Index(backend.SmartPlaylistSorts, pl.Sort), 0)
+	s.sortSel.SetSelectedIndex(sortIdx)
+
+	s.limitEntry = widget.NewEntry()
+	s.limitEntry.SetPlaceHolder(lang.L("No limit"))
+	if pl.Limit > 0 {
+		s.limitEntry.SetText(strconv.Itoa(pl.Limit))
+	}
+
+	s.rulesBox = container.NewVBox()
+	for _, r := range pl.Rules {
+		s.addRule(r)
+	}
+	rulesScroll := container.NewVScroll(s.rulesBox)
+	rulesScroll.SetMinSize(fyne.NewSize(0, 200))
+	addRuleBtn := widget.NewButtonWithIcon(lang.L("Add rule"), theme.ContentAddIcon(), func() {
+		s.addRule(backend.SmartPlaylistRule{Field: backend.SmartFieldTitle, Operator: backend.SmartOpContains})
+	})
+
+	submitBtn := widget.NewButtonWithIcon(lang.L("OK"), theme.ConfirmIcon(), func() {
+		s.updatePlaylist()
+		if s.OnSubmit != nil {
+			s.OnSubmit()
+		}
+	})
+	submitBtn.Importance = widget.HighImportance
+	cancelBtn := widget.NewButtonWithIcon(lang.L("Cancel"), theme.CancelIcon(), func() {
+		if s.OnCanceled != nil {
+			s.OnCanceled()
+		}
+	})
+	if pl.Name == "" {
+		submitBtn.Disable()
+	}
+	s.nameEntry.OnChanged = func(name string) {
+		if name == "" {
+			submitBtn.Disable()
+		} else {
+			submitBtn.Enable()
+		}
+	}
+
+	title := widget.NewLabel(titleStr)
+	title.Alignment = fyne.TextAlignCenter
+	title.TextStyle.Bold = true
+	s.container = container.NewVBox(
+		title,
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel(lang.L("Name")),
+			s.nameEntry,
+		),
+		container.NewHBox(s.matchSel, layout.NewSpacer(), addRuleBtn),
+		rulesScroll,
+		container.New(layout.NewFormLayout(),
+			widget.NewLabel(lang.L("Sort")),
+			s.sortSel,
+			widget.NewLabel(lang.L("Limit")),
+			s.limitEntry,
+		),
+		widget.NewSeparator(),
+		container.NewHBox(
+			layout.NewSpacer(),
+			cancelBtn, submitBtn),
+	)
+
+	return s
+}
+
+func (s *SmartPlaylistDialog) addRule(rule backend.SmartPlaylistRule) {
+	row := newSmartRuleRow(rule, func(r *smartRuleRow) {
+		s.rules = slices.DeleteFunc(s.rules, func(rr *smartRuleRow) bool { return rr == r })
+		s.rulesBox.Remove(r.container)
+	})
+	s.rules = append(s.rules, row)
+	s.rulesBox.Add(row.container)
+}
+
+func (s *SmartPlaylistDialog) updatePlaylist() {
+	s.Playlist.Name = s.nameEntry.Text
+	s.Playlist.MatchAny = s.matchSel.SelectedIndex() == 1
+	s.Playlist.Sort = backend.SmartPlaylistSorts[max(s.sortSel.SelectedIndex(), 0)]
+	s.Playlist.Limit, _ = strconv.Atoi(s.limitEntry.Text)
+	s.Playlist.Rules = nil
+	for _, r := range s.rules {
+		s.Playlist.Rules = append(s.Playlist.Rules, r.rule())
+	}
+}
+
+func (s *SmartPlaylistDialog) MinSize() fyne.Size {
+	return fyne.NewSize(550, s.BaseWidget.MinSize().Height)
+}
+
+func (s *SmartPlaylistDialog) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(s.container)
+}
+
+type smartRuleRow struct {
+	fieldSel   *widget.Select
+	opSel      *widget.Select
+	valueEntry *widget.Entry
+	boolSel    *widget.Select
+	container  *fyne.Container
+
+	operators []string
+}
+
+func newSmartRuleRow(rule backend.SmartPlaylistRule, onRemove func(*smartRuleRow)) *smartRuleRow {
+	r := &smartRuleRow{}
+	r.opSel = widget.NewSelect(nil, nil)
+	r.valueEntry = widget.NewEntry()
+	r.valueEntry.SetText(rule.Value)
+	r.boolSel = widget.NewSelect([]string{lang.L("Yes"), lang.L("No")}, nil)
+	if b, _ := strconv.ParseBool(rule.Value); b || rule.Value == "" {
+		r.boolSel.SetSelectedIndex(0)
+	} else {
+		r.boolSel.SetSelectedIndex(1)
+	}
+	r.fieldSel = widget.NewSelect(localizedNames(backend.SmartPlaylistFields, smartFieldNames), func(string) {
+		r.onFieldChanged("")
+	})
+	r.fieldSel.SetSelectedIndex(max(slices.Index(backend.SmartPlaylistFields, rule.Field), 0))
+	r.onFieldChanged(rule.Operator)
+
+	removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() { onRemove(r) })
+	removeBtn.Importance = widget.LowImportance
+	r.container = container.NewBorder(nil, nil,
+		container.NewHBox(r.fieldSel, r.opSel), removeBtn,
+		container.NewStack(r.valueEntry, r.boolSel))
+	return r
+}
+
+func (r *smartRuleRow) field() string {
+	return backend.SmartPlaylistFields[max(r.fieldSel.SelectedIndex(), 0)]
+}
+
+func (r *smartRuleRow) onFieldChanged(operator string) {
+	field := r.field()
+	r.operators = backend.SmartPlaylistOperators(field)
+	r.opSel.SetOptions(localizedNames(r.operators, smartOpNames))
+	r.opSel.SetSelectedIndex(max(slices.Index(r.operators, operator), 0))
+	isBool := field == backend.SmartFieldFavorite
+	r.valueEntry.Hidden = isBool
+	r.boolSel.Hidden = !isBool
+	r.valueEntry.Refresh()
+	r.boolSel.Refresh()
+}
+
+func (r *smartRuleRow) rule() backend.SmartPlaylistRule {
+	rule := backend.SmartPlaylistRule{
+		Field:    r.field(),
+		Operator: r.operators[max(r.opSel.SelectedIndex(), 0)],
+		Value:    r.valueEntry.Text,
+	}
+	if rule.Field == backend.SmartFieldFavorite {
+		rule.Value = strconv.FormatBool(r.boolSel.SelectedIndex() == 0)
+	}
+	return rule
+}
+
+func localizedNames(keys []string, names map[string]string) []string {
+	l := make([]string, len(keys))
+	for i, k := range keys {
+		l[i] = lang.L(names[k])
+	}
+	return l
+}
@@ -0,0 +1,185 @@
+package dialogs
+
+import (
+	"image/color"
+
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ThemeEditorDialog edits the colors of a theme file.
+// Empty colors fall back to the default theme.
+type ThemeEditorDialog struct {
+	widget.BaseWidget
+
+	// Called with the edited theme whenever a color is changed
+	OnPreview  func(*myTheme.ThemeFile)
+	OnSave     func(*myTheme.ThemeFile)
+	OnCanceled func()
+
+	themeFile *myTheme.ThemeFile
+	editDark  bool
+
+	colorEntries  []*widget.Entry
+	colorSwatches []*canvas.Rectangle
+
+	container *fyne.Container
+}
+
+func NewThemeEditorDialog(themeFile *myTheme.ThemeFile, editDark bool) *ThemeEditorDialog {
+	e := &ThemeEditorDialog{themeFile: themeFile, editDark: editDark}
+	e.ExtendBaseWidget(e)
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(themeFile.SupersonicTheme.Name)
+	var saveBtn *widget.Button
+	nameEntry.OnChanged = func(name string) {
+		e.themeFile.SupersonicTheme.Name = name
+		if name == "" {
+			saveBtn.Disable()
+		} else {
+			saveBtn.Enable()
+		}
+	}
+	supportsDark := widget.NewCheck(lang.L("Dark"), func(b bool) {
+		e.themeFile.SupersonicTheme.SupportsDark = b
+		e.preview()
+	})
+	supportsDark.Checked = themeFile.SupersonicTheme.SupportsDark
+	supportsLight := widget.NewCheck(lang.L("Light"), func(b bool) {
+		e.themeFile.SupersonicTheme.SupportsLight = b
+		e.preview()
+	})
+	supportsLight.Checked = themeFile.SupersonicTheme.SupportsLight
+
+	variantLabels := []string{lang.L("Dark"), lang.L("Light")}
+	variantSelect := widget.NewRadioGroup(variantLabels, func(v string) {
+		e.editDark = v == variantLabels[0]
+		e.updateColorEntries()
+	})
+	variantSelect.Horizontal = true
+	variantSelect.Required = true
+
+	colorForm := container.New(layout.NewFormLayout())
+	for _, name := range myTheme.ThemeColorNames() {
+		swatch := canvas.NewRectangle(color.Transparent)
+		swatch.SetMinSize(fyne.NewSquareSize(theme.IconInlineSize()))
+		swatch.StrokeColor = theme.Color(theme.ColorNameForeground)
+		swatch.StrokeWidth = 1
+		entry := widget.NewEntry()
+		entry.SetPlaceHolder(lang.L("Default"))
+		entry.Validator = func(s string) error {
+			if s == "" {
+				return nil
+			}
+			_, err := myTheme.ColorStringToColor(s)
+			return err
+		}
+		entry.OnChanged = func(s string) {
+			if entry.Validate() != nil {
+				return
+			}
+			e.editedColors().SetColor(name, s)
+			updateSwatch(swatch, s)
+			e.preview()
+		}
+		e.colorEntries = append(e.colorEntries, entry)
+		e.colorSwatches = append(e.colorSwatches, swatch)
+		colorForm.Add(widget.NewLabel(name))
+		colorForm.Add(container.NewBorder(nil, nil, container.NewCenter(swatch), nil, entry))
+	}
+	colorScroll := container.NewVScroll(colorForm)
+	colorScroll.SetMinSize(fyne.NewSize(0, 350))
+
+	saveBtn = widget.NewButtonWithIcon(lang.L("Save"), theme.DocumentSaveIcon(), func() {
+		if e.OnSave != nil {
+			e.OnSave(e.themeFile)
+		}
+	})
+	saveBtn.Importance = widget.HighImportance
+	cancelBtn := widget.NewButtonWithIcon(lang.L("Cancel"), theme.CancelIcon(), func() {
+		if e.OnCanceled != nil {
+			e.OnCanceled()
+		}
+	})
+
+	title := widget.NewLabel(lang.L("Theme Editor"))
+	title.Alignment = fyne.TextAlignCenter
+	title.TextStyle.Bold = true
+	e.container = container.NewBorder(
+		container.NewVBox(
+			title,
+			container.New(layout.NewFormLayout(),
+				widget.NewLabel(lang.L("Name")), nameEntry,
+				widget.NewLabel(lang.L("Supports")), container.NewHBox(supportsDark, supportsLight),
+				widget.NewLabel(lang.L("Edit colors")), variantSelect,
+			),
+			widget.NewSeparator(),
+		),
+		container.NewVBox(
+			widget.NewSeparator(),
+			container.NewHBox(layout.NewSpacer(), cancelBtn, saveBtn),
+		),
+		nil, nil,
+		colorScroll,
+	)
+
+	if editDark {
+		variantSelect.SetSelected(variantLabels[0])
+	} else {
+		variantSelect.SetSelected(variantLabels[1])
+	}
+	return e
+}
+
+func (e *ThemeEditorDialog) editedColors() *myTheme.ThemeColors {
+	if e.editDark {
+		return &e.themeFile.DarkColors
+	}
+	return &e.themeFile.LightColors
+}
+
+func (e *ThemeEditorDialog) updateColorEntries() {
+	colors := e.editedColors()
+	for i, name := range myTheme.ThemeColorNames() {
+		if i >= len(e.colorEntries) {
+			break
+		}
+		c := colors.Color(name)
+		// set text without triggering OnChanged
+		onChanged := e.colorEntries[i].OnChanged
+		e.colorEntries[i].OnChanged = nil
+		e.colorEntries[i].SetText(c)
+		e.colorEntries[i].OnChanged = onChanged
+		updateSwatch(e.colorSwatches[i], c)
+	}
+}
+
+func (e *ThemeEditorDialog) preview() {
+	if e.OnPreview != nil {
+		e.OnPreview(e.themeFile)
+	}
+}
+
+func updateSwatch(swatch *canvas.Rectangle, colorStr string) {
+	swatch.FillColor = color.Transparent
+	if c, err := myTheme.ColorStringToColor(colorStr); err == nil {
+		swatch.FillColor = c
+	}
+	swatch.Refresh()
+}
+
+func (e *ThemeEditorDialog) MinSize() fyne.Size {
+	return fyne.NewSize(450, e.BaseWidget.MinSize().Height)
+}
+
+func (e *ThemeEditorDialog) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(e.container)
+}
@@ -1,7 +1,9 @@
 package ui
 
 import (
+	"context"
 	"fmt"
+	"image"
 	"log"
 	"os/exec"
 	"runtime"
@@ -41,6 +43,7 @@ type MainWindow struct {
 	Sidebar      *Sidebar
 	Toolbar      *Toolbar
 	BottomPanel  *BottomPanel
+	MiniPlayer   *MiniPlayer
 	ToastOverlay *ToastOverlay
 
 	splitContainer   *uicontainer.Split
@@ -53,6 +56,10 @@ type MainWindow struct {
 	librarySubmenu *fyne.Menu
 
 	isScreensaverDisabled bool
+
+	// cover art the adaptive theme colors are derived from
+	adaptiveCoverID     string
+	adaptiveCoverCancel context.CancelFunc
 }
 
 func NewMainWindow(fyneApp fyne.App, appName, displayAppName, appVersion string, app *backend.App) MainWindow {
@@ -68,6 +75,9 @@ func NewMainWindow(fyneApp fyne.App, appName, displayAppName, appVersion string,
 	m.theme.NormalFont = app.Config.Application.FontNormalTTF
 	m.theme.BoldFont = app.Config.Application.FontBoldTTF
 	fyneApp.Settings().SetTheme(m.theme)
+	if err := m.theme.WatchThemeFiles(util.FyneDoFunc(m.ReloadTheme)); err != nil {
+		log.Printf("failed to watch theme files: %s", err.Error())
+	}
 
 	if app.Config.Application.EnableSystemTray {
 		m.SetupSystemTrayMenu(displayAppName, fyneApp)
@@ -108,6 +118,8 @@ func NewMainWindow(fyneApp fyne.App, appName, displayAppName, appVersion string,
 	}
 
 	m.BottomPanel = NewBottomPanel(app.PlaybackManager, app.ImageManager, m.Controller, app.Config)
+	m.MiniPlayer = NewMiniPlayer(app, m.Controller)
+	m.MiniPlayer.OnShowMainWindow = m.Show
 	app.PlaybackManager.OnSongChange(func(item mediaprovider.MediaItem, _ *mediaprovider.Track) {
 		fyne.Do(func() { m.UpdateOnTrackChange(item) })
 	})
@@ -126,6 +138,21 @@ func NewMainWindow(fyneApp fyne.App, appName, displayAppName, appVersion string,
 	app.ServerManager.OnServerConnected(func(conf *backend.ServerConfig) {
 		go m.RunOnServerConnectedTasks(conf, app, displayAppName)
 	})
+	app.ServerManager.OnConnectionChanged(func(isAltHostname bool) {
+		msg := lang.L("Connection lost; switched to primary hostname")
+		if isAltHostname {
+			msg = lang.L("Connection lost; switched to alternate hostname")
+		}
+		fyne.Do(func() { m.ToastOverlay.ShowSuccessToast(msg) })
+	})
+	app.ServerManager.OnAuthExpired(func(conf *backend.ServerConfig) {
+		fyne.Do(func() {
+			m.ToastOverlay.ShowErrorToast(lang.L("Session expired; please log in again"))
+			// keep the stored password, which the login dialog
+			// is pre-filled with, in case the server was at fault
+			app.ServerManager.Logout(false)
+		})
+	})
 	app.ServerManager.OnLogout(func() {
 		m.Toolbar.DisableNavigationButtons()
 		m.BrowsingPane.SetPage(nil)
@@ -137,11 +164,19 @@ func NewMainWindow(fyneApp fyne.App, appName, displayAppName, appVersion string,
 	m.Toolbar.AddSettingsSubmenu(lang.L("Select Library"), myTheme.LibraryIcon, fyne.NewMenu("",
 		fyne.NewMenuItem(lang.L("All Libraries"), func() { /* dummy - will get replaced on server login */ })))
 	m.Toolbar.AddSettingsMenuItem(lang.L("Rescan Library"), theme.ViewRefreshIcon(), func() { app.ServerManager.Server.RescanLibrary() })
+	m.Toolbar.AddSettingsMenuItem(lang.L("Statistics"), theme.GridIcon(), func() {
+		m.Controller.NavigateTo(controller.StatisticsRoute())
+	})
 	m.Toolbar.AddSettingsMenuSeparator()
 	m.Toolbar.AddSettingsSubmenu(lang.L("Visualizations"), myTheme.VisualizationIcon,
 		fyne.NewMenu("", []*fyne.MenuItem{
 			fyne.NewMenuItem(lang.L("Peak Meter"), m.Controller.ShowPeakMeter),
+			fyne.NewMenuItem(lang.L("Spectrum Analyzer"), m.Controller.ShowSpectrumAnalyzer),
 		}...))
+	m.Toolbar.AddSettingsMenuItem(lang.L("Mini Player"), theme.ViewRestoreIcon(), m.ToggleMiniPlayer)
+	m.Toolbar.AddSettingsMenuItem(lang.L("Theme Editor")+"...", theme.ColorPaletteIcon(), func() {
+		m.Controller.ShowThemeEditor(m.theme)
+	})
 	m.Toolbar.AddSettingsMenuSeparator()
 	m.Toolbar.AddSettingsMenuItem(lang.L("Check for Updates"), theme.DownloadIcon(), func() {
 		go func() {
@@ -197,6 +232,7 @@ func NewMainWindow(fyneApp fyne.App, appName, displayAppName, appVersion string,
 }
 
 func (m *MainWindow) UpdateOnTrackChange(item mediaprovider.MediaItem) {
+	m.updateAdaptiveTheme(item)
 	if item == nil {
 		m.Window.SetTitle(res.DisplayName)
 		m.Sidebar.SetNowPlaying(nil)
@@ -259,6 +295,40 @@ func (m *MainWindow) UpdateOnTrackChange(item mediaprovider.MediaItem) {
 	}
 }
 
+// updateAdaptiveTheme derives the theme colors from the cover art
+// of the given item, if adaptive theme colors are enabled.
+func (m *MainWindow) updateAdaptiveTheme(item mediaprovider.MediaItem) {
+	coverID := ""
+	if item != nil && m.App.Config.Theme.AdaptiveColors {
+		coverID = item.Metadata().CoverArtID
+	}
+	if coverID == m.adaptiveCoverID {
+		return
+	}
+	m.adaptiveCoverID = coverID
+	if m.adaptiveCoverCancel != nil {
+		m.adaptiveCoverCancel()
+		m.adaptiveCoverCancel = nil
+	}
+	if coverID == "" {
+		m.theme.SetAdaptiveThemeFile(nil)
+		return
+	}
+	m.adaptiveCoverCancel = m.App.ImageManager.GetFullSizeCoverArtAsync(coverID, func(img image.Image, err error) {
+		var t *myTheme.ThemeFile
+		if err != nil {
+			log.Printf("error loading cover art for adaptive theme: %v", err)
+		} else if img != nil {
+			t = myTheme.AdaptiveThemeFile(img)
+		}
+		fyne.Do(func() {
+			if m.adaptiveCoverID == coverID {
+				m.theme.SetAdaptiveThemeFile(t)
+			}
+		})
+	})
+}
+
 func (m *MainWindow) DesiredSize() fyne.Size {
 	w := float32(m.App.Config.Application.WindowWidth)
 	if w <= 1 {
@@ -306,6 +376,13 @@ func (m *MainWindow) RunOnServerConnectedTasks(serverConf *backend.ServerConfig,
 		}()
 	}
 
+	go func() {
+		// pick up smart playlists created or edited on other devices
+		if err := app.SmartPlaylistManager.SyncFromServer(app.ServerManager.Server, serverConf.ID.String()); err != nil {
+			log.Printf("failed to sync smart playlists: %s", err.Error())
+		}
+	}()
+
 	doSetLibrary := func(libraryID string, menuIdx int) {
 		serverConf.SelectedLibrary = libraryID
 		fyne.Do(func() {
@@ -366,9 +443,12 @@ func (m *MainWindow) RunOnServerConnectedTasks(serverConf *backend.ServerConfig,
 		m.Router.NavigateTo(m.StartupPage())
 		_, canRate := m.App.ServerManager.Server.(mediaprovider.SupportsRating)
 		m.BottomPanel.NowPlaying.DisableRating = !canRate
+		m.MiniPlayer.NowPlaying.DisableRating = !canRate
 
 		_, supportsRadio := m.App.ServerManager.Server.(mediaprovider.RadioProvider)
 		m.Toolbar.SetRadioButtonVisible(supportsRadio)
+		_, supportsFolders := m.App.ServerManager.Server.(mediaprovider.FolderBrowsingProvider)
+		m.Toolbar.SetFoldersButtonVisible(supportsFolders)
 	})
 
 	m.App.SaveConfigFile()
@@ -523,6 +603,13 @@ func (m *MainWindow) addShortcuts() {
 	m.Canvas().AddShortcut(&fyne.ShortcutSelectAll{}, func(_ fyne.Shortcut) {
 		m.Controller.SelectAll()
 	})
+	m.Canvas().AddShortcut(&shortcuts.ShortcutMiniPlayer, func(_ fyne.Shortcut) {
+		m.ToggleMiniPlayer()
+	})
+	// set loop start, then end, then clear the loop
+	m.Canvas().AddShortcut(&shortcuts.ShortcutABLoop, func(_ fyne.Shortcut) {
+		m.App.PlaybackManager.CycleABLoop()
+	})
 	m.Canvas().AddShortcut(&shortcuts.ShortcutCloseWindow, func(_ fyne.Shortcut) {
 		if runtime.GOOS == "darwin" || (m.App.Config.Application.CloseToSystemTray && m.HaveSystemTray()) {
 			m.Window.Hide()
@@ -563,6 +650,7 @@ func (m *MainWindow) addShortcuts() {
 
 func (m *MainWindow) showSettingsDialog() {
 	m.Controller.ShowSettingsDialog(func() {
+		m.updateAdaptiveTheme(m.App.PlaybackManager.NowPlaying())
 		fyne.CurrentApp().Settings().SetTheme(m.theme)
 		for _, w := range fyne.CurrentApp().Driver().AllWindows() {
 			controller.SetWindowThemeMode(w, m.theme.AppearanceMode())
@@ -570,10 +658,23 @@ func (m *MainWindow) showSettingsDialog() {
 	}, m.theme.ListThemeFiles())
 }
 
+// Show shows the main window, closing the mini player if it is open.
 func (m *MainWindow) Show() {
+	m.MiniPlayer.Close()
 	m.Window.Show()
 }
 
+// ToggleMiniPlayer switches between the main window and the mini player.
+func (m *MainWindow) ToggleMiniPlayer() {
+	if m.MiniPlayer.Visible() {
+		m.Show()
+		return
+	}
+	m.Controller.CloseEscapablePopUp()
+	m.MiniPlayer.Show()
+	m.Window.Hide()
+}
+
 func (m *MainWindow) ShowAndRun() {
 	m.Window.ShowAndRun()
 }
@@ -599,6 +700,7 @@ func (m *MainWindow) SaveWindowSettings() {
 	util.SaveWindowSize(m.Window,
 		&m.App.Config.Application.WindowWidth,
 		&m.App.Config.Application.WindowHeight)
+	m.MiniPlayer.SaveWindowSettings()
 	m.App.Config.Application.ShowSidebar = !m.Sidebar.Hidden
 	m.App.Config.Application.SidebarWidthFraction = m.splitContainer.Offset
 	switch m.Sidebar.SelectedIndex() {
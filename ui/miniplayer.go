@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/res"
+	"github.com/dweymouth/supersonic/ui/controller"
+	"github.com/dweymouth/supersonic/ui/shortcuts"
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+	"github.com/dweymouth/supersonic/ui/util"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// MiniPlayer is a compact, always-on-top window with the now playing
+// card and playback controls, which is shown in place of the main window.
+type MiniPlayer struct {
+	*playerWidgets
+
+	// Called when the user requests to return to the main window
+	OnShowMainWindow func()
+
+	app    *backend.App
+	window fyne.Window
+}
+
+func NewMiniPlayer(app *backend.App, contr *controller.Controller) *MiniPlayer {
+	m := &MiniPlayer{
+		app:           app,
+		playerWidgets: newPlayerWidgets(app.PlaybackManager, app.ImageManager, contr, app.Config),
+	}
+
+	// actions which need the main window return to it first
+	inMainWindow := func(f func()) func() {
+		return func() {
+			m.showMainWindow()
+			f()
+		}
+	}
+	m.NowPlaying.OnCoverTapped = inMainWindow(func() {
+		contr.NavigateTo(controller.NowPlayingRoute())
+	})
+	m.NowPlaying.OnTrackNameTapped = m.NowPlaying.OnCoverTapped
+	m.NowPlaying.OnAlbumNameTapped = func(albumID string) {
+		inMainWindow(func() { contr.NavigateTo(controller.AlbumRoute(albumID)) })()
+	}
+	m.NowPlaying.OnArtistNameTapped = func(artistID string) {
+		inMainWindow(func() { contr.NavigateTo(controller.ArtistRoute(artistID)) })()
+	}
+	m.NowPlaying.OnAddToPlaylist = inMainWindow(func() {
+		if tr, ok := app.PlaybackManager.NowPlaying().(*mediaprovider.Track); ok {
+			contr.DoAddTracksToPlaylistWorkflow([]string{tr.ID})
+		}
+	})
+	m.AuxControls.OnShowPlayQueue(inMainWindow(contr.ShowPopUpPlayQueue))
+	m.AuxControls.OnShowCastMenu(func(onPendingPlayerChange func()) {
+		inMainWindow(func() { contr.ShowCastMenu(onPendingPlayerChange) })()
+	})
+	return m
+}
+
+// Visible returns true if the mini player window is currently shown.
+func (m *MiniPlayer) Visible() bool {
+	return m.window != nil
+}
+
+func (m *MiniPlayer) Show() {
+	if m.window != nil {
+		return
+	}
+	cfg := &m.app.Config.Application
+	m.window = fyne.CurrentApp().NewWindow(res.DisplayName)
+	m.window.SetCloseIntercept(m.showMainWindow)
+	m.window.Canvas().AddShortcut(&shortcuts.ShortcutCloseWindow, func(_ fyne.Shortcut) {
+		m.showMainWindow()
+	})
+	m.window.Canvas().AddShortcut(&shortcuts.ShortcutMiniPlayer, func(_ fyne.Shortcut) {
+		m.showMainWindow()
+	})
+	m.window.Canvas().SetOnTypedKey(func(e *fyne.KeyEvent) {
+		switch e.Name {
+		case fyne.KeySpace:
+			m.app.PlaybackManager.PlayPause()
+		case fyne.KeyLeft:
+			m.app.PlaybackManager.SeekBySeconds(-10)
+		case fyne.KeyRight:
+			m.app.PlaybackManager.SeekBySeconds(10)
+		}
+	})
+
+	m.NowPlaying.ShowAlbumYear = m.app.Config.AlbumsPage.ShowYears
+	m.window.SetContent(container.NewPadded(container.NewBorder(
+		container.NewBorder(nil, nil, nil, m.AuxControls, m.NowPlaying),
+		nil, nil, nil, m.Controls)))
+	if cfg.MiniPlayerHeight > 0 {
+		m.window.Resize(fyne.NewSize(float32(cfg.MiniPlayerWidth), float32(cfg.MiniPlayerHeight)))
+	}
+	if desk, ok := m.window.(desktop.Window); ok {
+		if cfg.MiniPlayerX != 0 || cfg.MiniPlayerY != 0 {
+			desk.RequestPosition(cfg.MiniPlayerX, cfg.MiniPlayerY)
+		}
+		desk.RequestAlwaysOnTop()
+	}
+	m.window.Show()
+	controller.SetWindowThemeMode(m.window, fyne.CurrentApp().Settings().Theme().(*myTheme.MyTheme).AppearanceMode())
+}
+
+// Close saves the mini player window settings and closes the window.
+func (m *MiniPlayer) Close() {
+	if m.window == nil {
+		return
+	}
+	m.SaveWindowSettings()
+	m.window.Close()
+	m.window = nil
+}
+
+func (m *MiniPlayer) SaveWindowSettings() {
+	if m.window == nil {
+		return
+	}
+	cfg := &m.app.Config.Application
+	util.SaveWindowSize(m.window, &cfg.MiniPlayerWidth, &cfg.MiniPlayerHeight)
+	if x, y, ok := windowPosition(m.window); ok {
+		cfg.MiniPlayerX, cfg.MiniPlayerY = x, y
+	}
+}
+
+func (m *MiniPlayer) showMainWindow() {
+	if m.OnShowMainWindow != nil {
+		m.OnShowMainWindow()
+	}
+}
@@ -0,0 +1,146 @@
+package theme
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/cenkalti/dominantcolor"
+)
+
+const (
+	// minimum WCAG contrast ratios
+	minTextContrast   = 4.5
+	minAccentContrast = 3.0
+
+	// clusters with less than this fraction of the image are ignored when choosing the accent
+	minAccentWeight = 0.05
+)
+
+// AdaptiveThemeFile derives a theme from the colors of an image, normally
+// the cover art of the playing track. Backgrounds are tinted with the image's
+// dominant color, and the primary color is its most vivid color, adjusted
+// to contrast sufficiently with the backgrounds in both light and dark variants.
+// Colors not derived from the image fall back to the default theme.
+func AdaptiveThemeFile(img image.Image) *ThemeFile {
+	colors := dominantcolor.FindWeight(img, 5)
+	if len(colors) == 0 {
+		return nil
+	}
+	base := colors[0].RGBA
+
+	var accent color.Color
+	bestScore := 0.0
+	for _, c := range colors {
+		if c.Weight < minAccentWeight {
+			continue
+		}
+		// prefer saturated colors of medium lightness
+		if score := colorfulness(c.RGBA) * math.Sqrt(c.Weight); score > bestScore {
+			accent, bestScore = c.RGBA, score
+		}
+	}
+	if bestScore < 0.05 {
+		// image is essentially grayscale; keep the default primary color
+		accent = nil
+	}
+
+	t := &ThemeFile{
+		SupersonicTheme: ThemeFileHeader{
+			Name:          "Adaptive",
+			Version:       validThemeVersions[len(validThemeVersions)-1],
+			SupportsDark:  true,
+			SupportsLight: true,
+		},
+	}
+	t.DarkColors = adaptiveColors(base, accent,
+		mustParseColor("#0F0F0F"), mustParseColor("#232323"), mustParseColor("#181D25"), mustParseColor("#E6E6E6"))
+	t.LightColors = adaptiveColors(base, accent,
+		mustParseColor("#FAFAFA"), mustParseColor("#E1DFE1"), mustParseColor("#E1DFE1"), mustParseColor("#262626"))
+	return t
+}
+
+func adaptiveColors(base, accent, pageBackground, background, pageHeader, foreground color.Color) ThemeColors {
+	isDark := relativeLuminance(pageBackground) < 0.5
+	tint := 0.12
+	if isDark {
+		tint = 0.2
+	}
+	pageBg := BlendColors(base, pageBackground, tint)
+	bg := BlendColors(base, background, tint*1.5)
+	header := BlendColors(base, pageHeader, tint*2)
+
+	fg := foreground
+	for _, c := range []color.Color{pageBg, bg, header} {
+		fg = ensureContrast(fg, c, minTextContrast, isDark)
+	}
+	colors := ThemeColors{
+		PageBackground: colorToString(pageBg),
+		Background:     colorToString(bg),
+		ListHeader:     colorToString(bg),
+		PageHeader:     colorToString(header),
+		Foreground:     colorToString(fg),
+	}
+	if accent != nil {
+		primary := ensureContrast(accent, pageBg, minAccentContrast, isDark)
+		colors.Primary = colorToString(primary)
+		colors.Hyperlink = colorToString(ensureContrast(primary, bg, minTextContrast, isDark))
+	}
+	return colors
+}
+
+// ensureContrast lightens (or darkens) c until it has at least
+// the given contrast ratio against bg.
+func ensureContrast(c, bg color.Color, ratio float64, lighten bool) color.Color {
+	target := color.Color(color.White)
+	if !lighten {
+		target = color.Black
+	}
+	adjusted := c
+	for f := 0.0; f <= 1 && contrastRatio(adjusted, bg) < ratio; f += 0.05 {
+		adjusted = BlendColors(target, c, f)
+	}
+	return adjusted
+}
+
+// WCAG 2 contrast ratio between two colors, from 1 to 21
+func contrastRatio(a, b color.Color) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// WCAG 2 relative luminance of a color, from 0 to 1
+func relativeLuminance(c color.Color) float64 {
+	r, g, b, _ := c.RGBA()
+	linear := func(v uint32) float64 {
+		s := float64(v) / 0xffff
+		if s <= 0.04045 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(r) + 0.7152*linear(g) + 0.0722*linear(b)
+}
+
+// colorfulness returns the HSL chroma of the color, from 0 to 1
+func colorfulness(c color.RGBA) float64 {
+	maxC := max(c.R, c.G, c.B)
+	minC := min(c.R, c.G, c.B)
+	return float64(maxC-minC) / 255
+}
+
+func colorToString(c color.Color) string {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	return dominantcolor.Hex(rgba)
+}
+
+func mustParseColor(s string) color.Color {
+	c, err := ColorStringToColor(s)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
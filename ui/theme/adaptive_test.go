@@ -0,0 +1,48 @@
+package theme
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestContrastRatio(t *testing.T) {
+	if r := contrastRatio(color.White, color.Black); r < 20.9 || r > 21.1 {
+		t.Errorf("expected contrast ratio 21 for white/black, got %f", r)
+	}
+	if r := contrastRatio(color.White, color.White); r != 1 {
+		t.Errorf("expected contrast ratio 1 for identical colors, got %f", r)
+	}
+}
+
+func TestAdaptiveThemeFile(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	for x := 0; x < 20; x++ {
+		for y := 0; y < 20; y++ {
+			c := color.RGBA{R: 20, G: 30, B: 120, A: 255} // dark blue
+			if x < 5 {
+				c = color.RGBA{R: 240, G: 140, B: 20, A: 255} // orange
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	th := AdaptiveThemeFile(img)
+	if th == nil {
+		t.Fatal("expected adaptive theme")
+	}
+	for _, colors := range []ThemeColors{th.DarkColors, th.LightColors} {
+		bg, _ := ColorStringToColor(colors.PageBackground)
+		fg, _ := ColorStringToColor(colors.Foreground)
+		primary, err := ColorStringToColor(colors.Primary)
+		if err != nil {
+			t.Fatalf("expected primary color, got %q", colors.Primary)
+		}
+		if r := contrastRatio(fg, bg); r < minTextContrast {
+			t.Errorf("foreground contrast %f below minimum", r)
+		}
+		if r := contrastRatio(primary, bg); r < minAccentContrast {
+			t.Errorf("primary contrast %f below minimum", r)
+		}
+	}
+}
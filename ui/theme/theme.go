@@ -3,6 +3,7 @@ package theme
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"image/color"
 	"log"
 	"os"
@@ -97,12 +98,22 @@ type MyTheme struct {
 	loadedThemeFilename string
 	loadedThemeFile     *ThemeFile
 	defaultThemeFile    *ThemeFile
+
+	// adaptive theme colors (nil = loaded theme file), transitioning
+	// from adaptiveFrom to adaptiveTo as adaptiveProgress goes from 0 to 1
+	adaptiveFrom     *ThemeFile
+	adaptiveTo       *ThemeFile
+	adaptiveProgress float32
+	adaptiveAnim     *fyne.Animation
+
+	// theme being edited in the theme editor, if any
+	previewThemeFile *ThemeFile
 }
 
 var _ fyne.Theme = (*MyTheme)(nil)
 
 func NewMyTheme(config *backend.ThemeConfig, themeFileDir string) *MyTheme {
-	m := &MyTheme{config: config, themeFileDir: themeFileDir}
+	m := &MyTheme{config: config, themeFileDir: themeFileDir, adaptiveProgress: 1}
 	var err error
 	if m.defaultThemeFile, err = DecodeThemeFile(bytes.NewReader(res.ResDefaultToml.StaticContent)); err != nil {
 		log.Fatalf("Failed to load builtin theme: %v", err.Error())
@@ -125,8 +136,83 @@ func (m *MyTheme) AppearanceMode() AppearanceMode {
 	return v
 }
 
-func (m *MyTheme) Color(name fyne.ThemeColorName, defVariant fyne.ThemeVariant) color.Color {
-	// load theme file if necessary
+// SetAdaptiveThemeFile smoothly transitions the theme colors to those of
+// the given adaptive theme, or back to the configured theme file if nil.
+// Has no visible effect unless adaptive colors are enabled in the theme config.
+// Must be called on the main goroutine.
+func (m *MyTheme) SetAdaptiveThemeFile(t *ThemeFile) {
+	if m.adaptiveAnim != nil {
+		m.adaptiveAnim.Stop()
+	}
+	// if interrupting a transition, start the new one from its end state
+	m.adaptiveFrom = m.adaptiveTo
+	m.adaptiveTo = t
+	if !m.config.AdaptiveColors {
+		m.adaptiveProgress = 1
+		return
+	}
+
+	// the whole UI refreshes on a theme change, so update in a small number of steps
+	const steps = 8
+	lastStep := -1
+	m.adaptiveProgress = 0
+	m.adaptiveAnim = fyne.NewAnimation(AnimationDurationLong*2, func(f float32) {
+		if step := int(f * steps); step != lastStep {
+			lastStep = step
+			m.adaptiveProgress = float32(step) / steps
+			fyne.CurrentApp().Settings().SetTheme(m)
+		}
+	})
+	m.adaptiveAnim.Start()
+}
+
+// CurrentThemeFile returns a copy of the currently configured theme file.
+func (m *MyTheme) CurrentThemeFile() *ThemeFile {
+	m.loadThemeFileIfNeeded()
+	t := *m.loadedThemeFile
+	return &t
+}
+
+// SetPreviewThemeFile shows the given theme in place of the
+// configured theme file, or stops the preview if nil.
+// The caller must apply the theme to refresh the UI.
+func (m *MyTheme) SetPreviewThemeFile(t *ThemeFile) {
+	m.previewThemeFile = t
+}
+
+// SaveThemeFile saves the theme as a new file in the theme file directory,
+// named after the theme, and returns the file name.
+func (m *MyTheme) SaveThemeFile(t *ThemeFile) (string, error) {
+	if !(t.SupersonicTheme.SupportsDark || t.SupersonicTheme.SupportsLight) {
+		return "", errors.New("theme must support one or both of light/dark")
+	}
+	t.SupersonicTheme.Version = validThemeVersions[len(validThemeVersions)-1]
+	if err := os.MkdirAll(m.themeFileDir, 0o755); err != nil {
+		return "", err
+	}
+	base := strings.Map(func(r rune) rune {
+		if r == ' ' {
+			return '_'
+		}
+		if strings.ContainsRune(`/\:*?"<>|`, r) {
+			return -1
+		}
+		return r
+	}, strings.ToLower(t.SupersonicTheme.Name))
+	if base == "" {
+		base = "theme"
+	}
+	filename := base + ".toml"
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(m.themeFileDir, filename)); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		filename = fmt.Sprintf("%s_%d.toml", base, i)
+	}
+	return filename, WriteThemeFile(filepath.Join(m.themeFileDir, filename), t)
+}
+
+func (m *MyTheme) loadThemeFileIfNeeded() {
 	if m.loadedThemeFile == nil || m.config.ThemeFile != m.loadedThemeFilename {
 		t, err := ReadThemeFile(path.Join(m.themeFileDir, m.config.ThemeFile))
 		if err == nil {
@@ -137,9 +223,32 @@ func (m *MyTheme) Color(name fyne.ThemeColorName, defVariant fyne.ThemeVariant)
 		}
 		m.loadedThemeFilename = m.config.ThemeFile
 	}
+}
 
+func (m *MyTheme) Color(name fyne.ThemeColorName, defVariant fyne.ThemeVariant) color.Color {
+	m.loadThemeFileIfNeeded()
 	variant := m.getVariant(defVariant)
-	thFile := m.loadedThemeFile
+	if m.previewThemeFile != nil {
+		return m.themeFileColor(m.previewThemeFile, name, variant)
+	}
+	if !m.config.AdaptiveColors {
+		return m.themeFileColor(m.loadedThemeFile, name, variant)
+	}
+	orLoaded := func(t *ThemeFile) *ThemeFile {
+		if t == nil {
+			return m.loadedThemeFile
+		}
+		return t
+	}
+	to := m.themeFileColor(orLoaded(m.adaptiveTo), name, variant)
+	if m.adaptiveProgress >= 1 {
+		return to
+	}
+	from := m.themeFileColor(orLoaded(m.adaptiveFrom), name, variant)
+	return BlendColors(to, from, float64(m.adaptiveProgress))
+}
+
+func (m *MyTheme) themeFileColor(thFile *ThemeFile, name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
 	if !thFile.SupportsVariant(variant) {
 		thFile = m.defaultThemeFile
 	}
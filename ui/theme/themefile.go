@@ -7,6 +7,7 @@ import (
 	"image/color"
 	"io"
 	"os"
+	"reflect"
 	"slices"
 	"strings"
 
@@ -120,6 +121,49 @@ func DecodeThemeFile(reader io.Reader) (*ThemeFile, error) {
 	return theme, nil
 }
 
+// EncodeThemeFile writes the theme file in TOML format.
+func EncodeThemeFile(writer io.Writer, theme *ThemeFile) error {
+	return toml.NewEncoder(writer).Encode(theme)
+}
+
+// WriteThemeFile saves the theme file to the given path.
+func WriteThemeFile(filePath string, theme *ThemeFile) error {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if err := EncodeThemeFile(f, theme); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ThemeColorNames returns the names of all the colors in ThemeColors.
+func ThemeColorNames() []string {
+	t := reflect.TypeOf(ThemeColors{})
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		names = append(names, t.Field(i).Name)
+	}
+	return names
+}
+
+// Color returns the color string for the given name from ThemeColorNames.
+func (t *ThemeColors) Color(name string) string {
+	if f := reflect.ValueOf(t).Elem().FieldByName(name); f.IsValid() {
+		return f.String()
+	}
+	return ""
+}
+
+// SetColor sets the color string for the given name from ThemeColorNames.
+func (t *ThemeColors) SetColor(name, colorStr string) {
+	if f := reflect.ValueOf(t).Elem().FieldByName(name); f.IsValid() {
+		f.SetString(colorStr)
+	}
+}
+
 func (t *ThemeFile) SupportsVariant(v fyne.ThemeVariant) bool {
 	if v == theme.VariantDark {
 		return t.SupersonicTheme.SupportsDark
@@ -0,0 +1,67 @@
+package theme
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// wait for writes to settle before reloading, since editors
+// may save a file in several steps
+const themeFileReloadDelay = 300 * time.Millisecond
+
+// WatchThemeFiles watches the theme file directory and calls onChanged
+// when the active theme file is modified. onChanged is called on a
+// background goroutine. The watch lasts for the lifetime of the app.
+func (m *MyTheme) WatchThemeFiles(onChanged func()) error {
+	if err := os.MkdirAll(m.themeFileDir, 0o755); err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	// watch the directory rather than the file, so that
+	// files replaced by editors on save are still tracked
+	if err := watcher.Add(m.themeFileDir); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	var mu sync.Mutex
+	var timer *time.Timer
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+					continue
+				}
+				if active := m.config.ThemeFile; active == "" ||
+					!strings.EqualFold(filepath.Base(event.Name), active) {
+					continue
+				}
+				mu.Lock()
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(themeFileReloadDelay, onChanged)
+				mu.Unlock()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("theme file watcher error: %s", err.Error())
+			}
+		}
+	}()
+	return nil
+}
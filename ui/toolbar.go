@@ -28,6 +28,7 @@ type Toolbar struct {
 	navBtnsContainer *fyne.Container
 	navBtnsPageMap   map[controller.PageName]fyne.Resource
 	radioBtn         fyne.CanvasObject
+	foldersBtn       fyne.CanvasObject
 
 	quickSearchBtn *ttwidget.Button
 	sidebarBtn     *ttwidget.Button
@@ -82,6 +83,15 @@ func (t *Toolbar) SetRadioButtonVisible(vis bool) {
 	}
 }
 
+// SetFoldersButtonVisible sets whether the folders button is visible
+func (t *Toolbar) SetFoldersButtonVisible(vis bool) {
+	if vis {
+		t.foldersBtn.Show()
+	} else {
+		t.foldersBtn.Hide()
+	}
+}
+
 // AddSettingsMenuItem adds an item to the Settings menu
 func (t *Toolbar) AddSettingsMenuItem(label string, icon fyne.Resource, action func()) {
 	item := fyne.NewMenuItem(label, action)
@@ -170,6 +180,15 @@ func (t *Toolbar) setupNavigationButtons(navigateFn func(controller.Route)) {
 	t.radioBtn = t.addNavigationButton(myTheme.RadioIcon, controller.Radios, func() {
 		navigateFn(controller.RadiosRoute())
 	})
+	t.foldersBtn = t.addNavigationButton(theme.FolderIcon(), controller.Folders, func() {
+		navigateFn(controller.FoldersRoute(""))
+	})
+	t.addNavigationButton(theme.DocumentIcon(), controller.Composers, func() {
+		navigateFn(controller.ComposersRoute())
+	})
+	t.addNavigationButton(theme.StorageIcon(), controller.Labels, func() {
+		navigateFn(controller.LabelsRoute())
+	})
 }
 
 func (t *Toolbar) addNavigationButton(icon fyne.Resource, pageName controller.PageName, action func()) *ttwidget.Button {
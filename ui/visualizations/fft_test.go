@@ -0,0 +1,25 @@
+package visualizations
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestFFT(t *testing.T) {
+	const n = 64
+	const bin = 5
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = complex(math.Cos(2*math.Pi*bin*float64(i)/n), 0)
+	}
+	fft(x)
+	for k := range n / 2 {
+		mag := cmplx.Abs(x[k])
+		if k == bin && math.Abs(mag-n/2) > 1e-9 {
+			t.Errorf("expected magnitude %d at bin %d, got %f", n/2, k, mag)
+		} else if k != bin && mag > 1e-9 {
+			t.Errorf("expected no energy at bin %d, got %f", k, mag)
+		}
+	}
+}
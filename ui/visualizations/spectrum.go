@@ -0,0 +1,238 @@
+package visualizations
+
+import (
+	"image/color"
+	"math"
+	"math/cmplx"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// number of samples analyzed per frame (~93 ms at 44.1 kHz)
+	SpectrumFFTSize = 4096
+
+	spectrumBands    = 64
+	spectrumMinFreq  = 30
+	spectrumMaxFreq  = 16000
+	spectrumRangeDB  = 70
+	spectrumFallRate = 0.85 // smoothing factor for falling levels
+)
+
+// Spectrum analyzer display styles
+const (
+	SpectrumStyleBars = "Bars"
+	SpectrumStyleLine = "Line"
+)
+
+var SpectrumStyles = []string{SpectrumStyleBars, SpectrumStyleLine}
+
+// SpectrumAnalyzer displays the frequency spectrum of the audio,
+// computed by FFT and shown with a logarithmic frequency scale.
+type SpectrumAnalyzer struct {
+	widget.BaseWidget
+
+	Style string
+
+	// Called when the user changes the style from the context menu.
+	OnStyleChanged func(style string)
+
+	levels  [spectrumBands]float64 // 0 to 1
+	message string
+	window  []float64
+	fftBuf  []complex128
+
+	// true iff only a layout is needed, rather than a full refresh.
+	// cleared by the renderer
+	refreshLayoutOnly bool
+}
+
+func NewSpectrumAnalyzer(style string) *SpectrumAnalyzer {
+	s := &SpectrumAnalyzer{
+		Style:  style,
+		window: hannWindow(SpectrumFFTSize),
+		fftBuf: make([]complex128, SpectrumFFTSize),
+	}
+	s.ExtendBaseWidget(s)
+	return s
+}
+
+// UpdateSamples analyzes the latest SpectrumFFTSize mono samples and updates the display.
+// If samples is nil, the display decays towards silence.
+// This function is expected to be called from a fyne.Animation callback,
+// running at 60 Hz
+func (s *SpectrumAnalyzer) UpdateSamples(samples []float64, sampleRate int) {
+	var newLevels [spectrumBands]float64
+	if len(samples) >= SpectrumFFTSize {
+		s.computeLevels(samples[len(samples)-SpectrumFFTSize:], sampleRate, &newLevels)
+	}
+	for i, l := range newLevels {
+		if l > s.levels[i] {
+			s.levels[i] = l
+		} else {
+			s.levels[i] = spectrumFallRate*s.levels[i] + (1-spectrumFallRate)*l
+		}
+	}
+	s.refreshLayoutOnly = true
+	s.Refresh()
+}
+
+// SetMessage sets a message to show in place of the spectrum,
+// e.g. why it cannot be shown, or "" to show the spectrum.
+func (s *SpectrumAnalyzer) SetMessage(message string) {
+	if s.message == message {
+		return
+	}
+	s.message = message
+	s.Refresh()
+}
+
+func (s *SpectrumAnalyzer) computeLevels(samples []float64, sampleRate int, levels *[spectrumBands]float64) {
+	for i, v := range samples {
+		s.fftBuf[i] = complex(v*s.window[i], 0)
+	}
+	fft(s.fftBuf)
+
+	binHz := float64(sampleRate) / SpectrumFFTSize
+	maxBin := SpectrumFFTSize / 2
+	// a full scale sine has magnitude N/4 after a Hann window
+	norm := 4.0 / SpectrumFFTSize
+	magAt := func(bin int) float64 {
+		return cmplx.Abs(s.fftBuf[min(max(bin, 1), maxBin-1)]) * norm
+	}
+
+	ratio := math.Pow(spectrumMaxFreq/spectrumMinFreq, 1.0/spectrumBands)
+	lo := float64(spectrumMinFreq)
+	for i := range levels {
+		hi := lo * ratio
+		loBin, hiBin := int(math.Ceil(lo/binHz)), int(math.Floor(hi/binHz))
+		var mag float64
+		if hiBin < loBin {
+			// band is narrower than an FFT bin at low frequencies
+			mag = magAt(int(math.Round(math.Sqrt(lo*hi) / binHz)))
+		}
+		for b := loBin; b <= hiBin; b++ {
+			mag = max(mag, magAt(b))
+		}
+		db := 20 * math.Log10(max(mag, 1e-10))
+		levels[i] = min(max((db+spectrumRangeDB)/spectrumRangeDB, 0), 1)
+		lo = hi
+	}
+}
+
+var _ fyne.SecondaryTappable = (*SpectrumAnalyzer)(nil)
+
+func (s *SpectrumAnalyzer) TappedSecondary(e *fyne.PointEvent) {
+	items := make([]*fyne.MenuItem, len(SpectrumStyles))
+	for i, style := range SpectrumStyles {
+		items[i] = fyne.NewMenuItem(lang.L(style), func() {
+			s.Style = style
+			s.Refresh()
+			if s.OnStyleChanged != nil {
+				s.OnStyleChanged(style)
+			}
+		})
+		items[i].Checked = s.Style == style
+	}
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", items...),
+		fyne.CurrentApp().Driver().CanvasForObject(s), e.AbsolutePosition)
+}
+
+func (s *SpectrumAnalyzer) CreateRenderer() fyne.WidgetRenderer {
+	return newSpectrumRenderer(s)
+}
+
+func (s *SpectrumAnalyzer) Refresh() {
+	s.refreshLayoutOnly = false
+	s.BaseWidget.Refresh()
+}
+
+type spectrumRenderer struct {
+	s *SpectrumAnalyzer
+
+	bars    [spectrumBands]canvas.Rectangle
+	lines   [spectrumBands - 1]canvas.Line
+	message *widget.Label
+
+	objects []fyne.CanvasObject
+}
+
+func newSpectrumRenderer(s *SpectrumAnalyzer) *spectrumRenderer {
+	r := &spectrumRenderer{s: s}
+	r.message = widget.NewLabel("")
+	r.message.Alignment = fyne.TextAlignCenter
+	r.message.Wrapping = fyne.TextWrapWord
+	r.message.Importance = widget.LowImportance
+	r.Refresh()
+	return r
+}
+
+func (r *spectrumRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(275, 75)
+}
+
+func (r *spectrumRenderer) Layout(size fyne.Size) {
+	bandWidth := size.Width / spectrumBands
+	barSpacing := min(bandWidth*0.2, 4)
+	y := func(i int) float32 {
+		return size.Height * float32(1-r.s.levels[i])
+	}
+	for i := range r.bars {
+		top := y(i)
+		r.bars[i].Move(fyne.NewPos(float32(i)*bandWidth, top))
+		r.bars[i].Resize(fyne.NewSize(bandWidth-barSpacing, size.Height-top))
+	}
+	for i := range r.lines {
+		r.lines[i].Position1 = fyne.NewPos((float32(i)+0.5)*bandWidth, y(i))
+		r.lines[i].Position2 = fyne.NewPos((float32(i)+1.5)*bandWidth, y(i+1))
+		r.lines[i].Refresh()
+	}
+	msgSize := r.message.MinSize()
+	r.message.Resize(fyne.NewSize(size.Width, msgSize.Height))
+	r.message.Move(fyne.NewPos(0, (size.Height-msgSize.Height)/2))
+}
+
+func (r *spectrumRenderer) Refresh() {
+	if r.s.refreshLayoutOnly {
+		r.s.refreshLayoutOnly = false
+		r.Layout(r.s.Size())
+		return
+	}
+
+	c := color.NRGBAModel.Convert(theme.PrimaryColor()).(color.NRGBA)
+	isLine := r.s.Style == SpectrumStyleLine
+	hasMessage := r.s.message != ""
+	for i := range r.bars {
+		r.bars[i].FillColor = c
+		r.bars[i].Hidden = isLine || hasMessage
+	}
+	for i := range r.lines {
+		r.lines[i].StrokeColor = c
+		r.lines[i].StrokeWidth = 2
+		r.lines[i].Hidden = !isLine || hasMessage
+	}
+	r.message.SetText(r.s.message)
+	r.message.Hidden = !hasMessage
+	r.Layout(r.s.Size())
+}
+
+func (r *spectrumRenderer) Objects() []fyne.CanvasObject {
+	if r.objects == nil {
+		r.objects = make([]fyne.CanvasObject, 0, len(r.bars)+len(r.lines)+1)
+		for i := range r.bars {
+			r.objects = append(r.objects, &r.bars[i])
+		}
+		for i := range r.lines {
+			r.objects = append(r.objects, &r.lines[i])
+		}
+		r.objects = append(r.objects, r.message)
+	}
+	return r.objects
+}
+
+func (r *spectrumRenderer) Destroy() {
+}
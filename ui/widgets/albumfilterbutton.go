@@ -25,9 +25,10 @@ import (
 type AlbumFilterButton struct {
 	ttwidget.Button
 
-	OnChanged        func()
-	GenreDisabled    bool
-	FavoriteDisabled bool
+	OnChanged           func()
+	GenreDisabled       bool
+	FavoriteDisabled    bool
+	ReleaseTypeDisabled bool
 
 	genreListChan chan []string
 
@@ -82,7 +83,8 @@ func (a *AlbumFilterButton) filterEmpty() bool {
 	filterOptions := a.filter.Options()
 	return filterOptions.MinYear == 0 && filterOptions.MaxYear == 0 &&
 		(a.FavoriteDisabled || !filterOptions.ExcludeFavorited && !filterOptions.ExcludeUnfavorited) &&
-		(a.GenreDisabled || len(filterOptions.Genres) == 0)
+		(a.GenreDisabled || len(filterOptions.Genres) == 0) &&
+		(a.ReleaseTypeDisabled || filterOptions.IncludeReleaseTypes == 0 && filterOptions.ExcludeReleaseTypes == 0)
 }
 
 func (a *AlbumFilterButton) onFilterChanged() {
@@ -107,11 +109,12 @@ type AlbumFilterPopup struct {
 
 	OnChanged func()
 
-	isFavorite    *widget.Check
-	isNotFavorite *widget.Check
-	genreFilter   *GenreFilterSubsection
-	filterBtn     *AlbumFilterButton
-	container     *fyne.Container
+	isFavorite        *widget.Check
+	isNotFavorite     *widget.Check
+	releaseTypeFilter *ReleaseTypeFilterSubsection
+	genreFilter       *GenreFilterSubsection
+	filterBtn         *AlbumFilterButton
+	container         *fyne.Container
 }
 
 func NewAlbumFilterPopup(filter *AlbumFilterButton) *AlbumFilterPopup {
@@ -179,6 +182,16 @@ func NewAlbumFilterPopup(filter *AlbumFilterButton) *AlbumFilterPopup {
 	})
 	a.isNotFavorite.Hidden = a.filterBtn.FavoriteDisabled
 
+	// create release type filter subsection
+	a.releaseTypeFilter = NewReleaseTypeFilterSubsection(func(include, exclude mediaprovider.ReleaseTypes) {
+		filterOptions := a.filterBtn.filter.Options()
+		filterOptions.IncludeReleaseTypes = include
+		filterOptions.ExcludeReleaseTypes = exclude
+		a.filterBtn.filter.SetOptions(filterOptions)
+		debounceOnChanged()
+	}, filterOptions.IncludeReleaseTypes, filterOptions.ExcludeReleaseTypes)
+	a.releaseTypeFilter.Hidden = a.filterBtn.ReleaseTypeDisabled
+
 	// create genre filter subsection
 	a.genreFilter = NewGenreFilterSubsection(func(selectedGenres []string) {
 		filterOptions := a.filterBtn.filter.Options()
@@ -195,6 +208,7 @@ func NewAlbumFilterPopup(filter *AlbumFilterButton) *AlbumFilterPopup {
 		container.NewHBox(layout.NewSpacer(), title, layout.NewSpacer()),
 		container.NewHBox(widget.NewLabel(lang.L("Year from")), minYear, widget.NewLabel(lang.L("to")), maxYear),
 		container.NewHBox(a.isFavorite, a.isNotFavorite),
+		a.releaseTypeFilter,
 		a.genreFilter,
 	)
 
@@ -216,6 +230,7 @@ func (a *AlbumFilterPopup) Tapped(_ *fyne.PointEvent) {
 func (a *AlbumFilterPopup) Refresh() {
 	a.isFavorite.Hidden = a.filterBtn.FavoriteDisabled
 	a.isNotFavorite.Hidden = a.filterBtn.FavoriteDisabled
+	a.releaseTypeFilter.Hidden = a.filterBtn.ReleaseTypeDisabled
 	a.genreFilter.Hidden = a.filterBtn.GenreDisabled
 	a.BaseWidget.Refresh()
 }
@@ -230,6 +245,170 @@ func (a *AlbumFilterPopup) CreateRenderer() fyne.WidgetRenderer {
 	return widget.NewSimpleRenderer(a.container)
 }
 
+// release types shown in the filter popup, in display order
+var filterableReleaseTypes = []struct {
+	Type mediaprovider.ReleaseType
+	Name string // translation key
+}{
+	{mediaprovider.ReleaseTypeAlbum, "Album"},
+	{mediaprovider.ReleaseTypeEP, "EP"},
+	{mediaprovider.ReleaseTypeSingle, "Single"},
+	{mediaprovider.ReleaseTypeCompilation, "Compilation"},
+	{mediaprovider.ReleaseTypeLive, "Live"},
+	{mediaprovider.ReleaseTypeSoundtrack, "Soundtrack"},
+	{mediaprovider.ReleaseTypeRemix, "Remix"},
+	{mediaprovider.ReleaseTypeDemo, "Demo"},
+	{mediaprovider.ReleaseTypeDJMix, "DJ-Mix"},
+	{mediaprovider.ReleaseTypeMixtape, "Mixtape"},
+	{mediaprovider.ReleaseTypeAudiobook, "Audiobook"},
+	{mediaprovider.ReleaseTypeSpokenWord, "Spoken Word"},
+}
+
+// ReleaseTypeFilterSubsection shows a tri-state check for each release type.
+// Checked means only show releases of (one of) the checked types,
+// partially checked (dash) means hide releases of that type.
+type ReleaseTypeFilterSubsection struct {
+	widget.BaseWidget
+
+	onChanged func(include, exclude mediaprovider.ReleaseTypes)
+
+	include mediaprovider.ReleaseTypes
+	exclude mediaprovider.ReleaseTypes
+
+	checks    []*triStateCheck
+	container *fyne.Container
+}
+
+func NewReleaseTypeFilterSubsection(onChanged func(include, exclude mediaprovider.ReleaseTypes), include, exclude mediaprovider.ReleaseTypes) *ReleaseTypeFilterSubsection {
+	r := &ReleaseTypeFilterSubsection{
+		onChanged: onChanged,
+		include:   include,
+		exclude:   exclude,
+	}
+	r.ExtendBaseWidget(r)
+
+	grid := container.NewGridWithColumns(3)
+	for _, rt := range filterableReleaseTypes {
+		c := newTriStateCheck(lang.L(rt.Name))
+		c.OnStateChanged = func(state triState) {
+			r.onReleaseTypeChanged(rt.Type, state)
+		}
+		r.checks = append(r.checks, c)
+		grid.Add(c)
+	}
+	r.updateChecks()
+
+	resetBtn := widget.NewButton(lang.L("Reset"), func() {
+		r.include, r.exclude = 0, 0
+		r.updateChecks()
+		r.onChanged(r.include, r.exclude)
+	})
+	title := widget.NewRichText(
+		&widget.TextSegment{
+			Text: lang.L("Release types") + "  ",
+			Style: widget.RichTextStyle{
+				Inline:    true,
+				TextStyle: fyne.TextStyle{Bold: true},
+			},
+		},
+		&widget.TextSegment{Text: fmt.Sprintf("(%s)", lang.L("check to show only, dash to hide"))},
+	)
+	r.container = container.NewBorder(
+		container.NewBorder(nil, nil, nil, resetBtn, title),
+		nil, nil, nil,
+		container.New(&layout.CustomPaddedLayout{LeftPadding: 5, RightPadding: 5}, grid),
+	)
+	return r
+}
+
+func (r *ReleaseTypeFilterSubsection) onReleaseTypeChanged(rt mediaprovider.ReleaseType, state triState) {
+	r.include &^= rt
+	r.exclude &^= rt
+	switch state {
+	case triStateChecked:
+		r.include |= rt
+	case triStatePartial:
+		r.exclude |= rt
+	}
+	r.onChanged(r.include, r.exclude)
+}
+
+func (r *ReleaseTypeFilterSubsection) updateChecks() {
+	for i, rt := range filterableReleaseTypes {
+		state := triStateUnchecked
+		if r.include&rt.Type != 0 {
+			state = triStateChecked
+		} else if r.exclude&rt.Type != 0 {
+			state = triStatePartial
+		}
+		r.checks[i].SetState(state)
+	}
+}
+
+func (r *ReleaseTypeFilterSubsection) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(r.container)
+}
+
+type triState int
+
+const (
+	triStateUnchecked triState = iota
+	triStateChecked
+	triStatePartial
+)
+
+// triStateCheck is a Check that cycles through unchecked, checked, and partial states
+type triStateCheck struct {
+	widget.Check
+
+	OnStateChanged func(triState)
+}
+
+func newTriStateCheck(label string) *triStateCheck {
+	t := &triStateCheck{}
+	t.Text = label
+	t.ExtendBaseWidget(t)
+	return t
+}
+
+func (t *triStateCheck) State() triState {
+	switch {
+	case t.Partial:
+		return triStatePartial
+	case t.Checked:
+		return triStateChecked
+	default:
+		return triStateUnchecked
+	}
+}
+
+func (t *triStateCheck) SetState(state triState) {
+	t.Checked = state == triStateChecked
+	t.Partial = state == triStatePartial
+	t.Refresh()
+}
+
+func (t *triStateCheck) Tapped(_ *fyne.PointEvent) {
+	if t.Disabled() {
+		return
+	}
+	t.cycleState()
+}
+
+func (t *triStateCheck) TypedRune(r rune) {
+	if r == ' ' && !t.Disabled() {
+		t.cycleState()
+	}
+}
+
+func (t *triStateCheck) cycleState() {
+	state := (t.State() + 1) % 3
+	t.SetState(state)
+	if t.OnStateChanged != nil {
+		t.OnStateChanged(state)
+	}
+}
+
 type GenreFilterSubsection struct {
 	widget.BaseWidget
 
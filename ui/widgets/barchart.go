@@ -0,0 +1,85 @@
+package widgets
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// BarChart is a simple chart of non-negative values drawn as vertical bars
+// scaled to the largest value.
+type BarChart struct {
+	widget.BaseWidget
+
+	values []float64
+}
+
+func NewBarChart() *BarChart {
+	b := &BarChart{}
+	b.ExtendBaseWidget(b)
+	return b
+}
+
+func (b *BarChart) SetValues(values []float64) {
+	b.values = values
+	b.Refresh()
+}
+
+func (b *BarChart) MinSize() fyne.Size {
+	return fyne.NewSize(100, 120)
+}
+
+func (b *BarChart) CreateRenderer() fyne.WidgetRenderer {
+	r := &barChartRenderer{b: b}
+	r.Refresh()
+	return r
+}
+
+type barChartRenderer struct {
+	b    *BarChart
+	bars []fyne.CanvasObject
+}
+
+func (r *barChartRenderer) Layout(size fyne.Size) {
+	n := len(r.bars)
+	if n == 0 {
+		return
+	}
+	maxVal := 0.0
+	for _, v := range r.b.values {
+		maxVal = max(maxVal, v)
+	}
+	slot := size.Width / float32(n)
+	gap := min(slot*0.2, theme.Padding())
+	for i, bar := range r.bars {
+		h := float32(0)
+		if maxVal > 0 {
+			h = size.Height * float32(r.b.values[i]/maxVal)
+		}
+		bar.Resize(fyne.NewSize(max(slot-gap, 1), h))
+		bar.Move(fyne.NewPos(float32(i)*slot, size.Height-h))
+	}
+}
+
+func (r *barChartRenderer) MinSize() fyne.Size {
+	return r.b.MinSize()
+}
+
+func (r *barChartRenderer) Refresh() {
+	for len(r.bars) < len(r.b.values) {
+		r.bars = append(r.bars, canvas.NewRectangle(theme.Color(theme.ColorNamePrimary)))
+	}
+	r.bars = r.bars[:len(r.b.values)]
+	for _, bar := range r.bars {
+		bar.(*canvas.Rectangle).FillColor = theme.Color(theme.ColorNamePrimary)
+		bar.Refresh()
+	}
+	r.Layout(r.b.Size())
+}
+
+func (r *barChartRenderer) Objects() []fyne.CanvasObject {
+	return r.bars
+}
+
+func (r *barChartRenderer) Destroy() {}
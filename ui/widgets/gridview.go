@@ -116,6 +116,32 @@ func NewGridViewArtistIterator(iter mediaprovider.ArtistIterator) GridViewIterat
 	return gridViewArtistIterator{iter: NewBatchingIterator(iter)}
 }
 
+type gridViewLabelIterator struct {
+	iter BatchingIterator[mediaprovider.Label]
+}
+
+func (g gridViewLabelIterator) NextN(n int) []GridViewItemModel {
+	labels := g.iter.NextN(n)
+	return sharedutil.MapSlice(labels, func(l *mediaprovider.Label) GridViewItemModel {
+		albumsLabel := lang.L("albums")
+		if l.AlbumCount == 1 {
+			albumsLabel = lang.L("album")
+		}
+		fallbackAlbumsMsg := fmt.Sprintf("%d %s", l.AlbumCount, albumsLabel)
+		albumsMsg := lang.LocalizePluralKey("{{.albumsCount}} albums",
+			fallbackAlbumsMsg, l.AlbumCount, map[string]string{"albumsCount": strconv.Itoa(l.AlbumCount)})
+		return GridViewItemModel{
+			Name:      l.Name,
+			ID:        l.ID,
+			Secondary: []string{albumsMsg},
+		}
+	})
+}
+
+func NewGridViewLabelIterator(iter mediaprovider.LabelIterator) GridViewIterator {
+	return gridViewLabelIterator{iter: NewBatchingIterator(iter)}
+}
+
 type GridView struct {
 	widget.BaseWidget
 
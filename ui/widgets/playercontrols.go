@@ -22,6 +22,10 @@ type TrackPosSlider struct {
 	// playback position changes
 	IgnoreNextChangeEnded bool
 
+	OnSetLoopPoint func(pos float64, isStart bool)
+	OnClearLoop    func()
+
+	hasLoop    bool
 	isDragging bool
 }
 
@@ -67,6 +71,16 @@ func (t *TrackPosSlider) TypedKey(e *fyne.KeyEvent) {
 	}
 }
 
+var _ fyne.SecondaryTappable = (*TrackPosSlider)(nil)
+
+func (t *TrackPosSlider) TappedSecondary(e *fyne.PointEvent) {
+	if t.Disabled() {
+		return
+	}
+	pos := float64(e.Position.X / t.Size().Width)
+	showLoopMenu(t, e, pos, t.hasLoop, t.OnSetLoopPoint, t.OnClearLoop)
+}
+
 func (t *TrackPosSlider) DragEnd() {
 	t.isDragging = false
 	t.IgnoreNextChangeEnded = false
@@ -196,6 +210,26 @@ func (pc *PlayerControls) OnSeek(f func(float64)) {
 	pc.waveform.OnSeeked = f
 }
 
+// OnSetLoopPoint sets the callback for when the user sets an A-B loop point
+// from the waveform seekbar (pos is the ratio from 0 to 1).
+func (pc *PlayerControls) OnSetLoopPoint(f func(pos float64, isStart bool)) {
+	pc.waveform.OnSetLoopPoint = f
+	pc.slider.OnSetLoopPoint = f
+}
+
+func (pc *PlayerControls) OnClearLoop(f func()) {
+	pc.waveform.OnClearLoop = f
+	pc.slider.OnClearLoop = f
+}
+
+// SetLoopPoints sets the A-B loop points to display
+// (ratios from 0 to 1). Negative values are unset.
+// The standard seekbar does not display the loop points.
+func (pc *PlayerControls) SetLoopPoints(a, b float64) {
+	pc.waveform.SetLoopPoints(a, b)
+	pc.slider.hasLoop = a >= 0 || b >= 0
+}
+
 func (pc *PlayerControls) OnSeekPrevious(f func()) {
 	pc.prev.OnTapped = f
 }
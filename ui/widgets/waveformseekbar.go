@@ -9,6 +9,7 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
@@ -20,15 +21,26 @@ type WaveformSeekbar struct {
 
 	OnSeeked func(float64)
 
+	// Called when the user chooses to set the A (isStart) or B point
+	// of the A-B loop at the given position (ratio from 0 to 1).
+	OnSetLoopPoint func(pos float64, isStart bool)
+	OnClearLoop    func()
+
+	// A-B loop points (ratio from 0 to 1); negative if unset
+	loopA, loopB float64
+
 	imgColorL        color.Color
 	imgColorR        color.Color
 	imgProgressPixel int
 
 	focused bool
 
-	img    *canvas.Image
-	cursor *canvas.Rectangle
-	focus  *canvas.Rectangle
+	img        *canvas.Image
+	cursor     *canvas.Rectangle
+	focus      *canvas.Rectangle
+	loopRegion *canvas.Rectangle
+	loopMarkA  *canvas.Rectangle
+	loopMarkB  *canvas.Rectangle
 }
 
 func NewWaveformSeekbar() *WaveformSeekbar {
@@ -37,15 +49,62 @@ func NewWaveformSeekbar() *WaveformSeekbar {
 			ScaleMode: canvas.ImageScaleFastest,
 			Image:     backend.NewWaveformImage(),
 		},
-		cursor: canvas.NewRectangle(color.Transparent),
-		focus:  canvas.NewRectangle(color.Transparent),
+		cursor:     canvas.NewRectangle(color.Transparent),
+		focus:      canvas.NewRectangle(color.Transparent),
+		loopRegion: canvas.NewRectangle(color.Transparent),
+		loopMarkA:  canvas.NewRectangle(color.Transparent),
+		loopMarkB:  canvas.NewRectangle(color.Transparent),
+		loopA:      -1,
+		loopB:      -1,
 	}
 	w.ExtendBaseWidget(w)
 	w.cursor.Hidden = true
 	w.focus.Hidden = true
+	w.loopRegion.Hidden = true
+	w.loopMarkA.Hidden = true
+	w.loopMarkB.Hidden = true
 	return w
 }
 
+// SetLoopPoints sets the A-B loop markers to display
+// (ratios from 0 to 1). Negative values hide the marker.
+func (w *WaveformSeekbar) SetLoopPoints(a, b float64) {
+	if w.loopA == a && w.loopB == b {
+		return
+	}
+	w.loopA, w.loopB = a, b
+	w.layoutLoopMarkers()
+}
+
+func (w *WaveformSeekbar) Resize(size fyne.Size) {
+	w.DisableableWidget.Resize(size)
+	w.layoutLoopMarkers()
+}
+
+func (w *WaveformSeekbar) layoutLoopMarkers() {
+	prm, _, _ := w.getThemeColors()
+	r, g, b, _ := prm.RGBA()
+	w.loopRegion.FillColor = color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0x40}
+	w.loopMarkA.FillColor = prm
+	w.loopMarkB.FillColor = prm
+
+	size := w.Size()
+	place := func(mark *canvas.Rectangle, pos float64) {
+		mark.Hidden = pos < 0
+		mark.Move(fyne.NewPos(size.Width*float32(pos)-1, 0))
+		mark.Resize(fyne.NewSize(2, size.Height))
+	}
+	place(w.loopMarkA, w.loopA)
+	place(w.loopMarkB, w.loopB)
+	w.loopRegion.Hidden = w.loopA < 0 || w.loopB <= w.loopA
+	w.loopRegion.Move(fyne.NewPos(size.Width*float32(w.loopA), 0))
+	w.loopRegion.Resize(fyne.NewSize(size.Width*float32(w.loopB-w.loopA), size.Height))
+
+	w.loopRegion.Refresh()
+	w.loopMarkA.Refresh()
+	w.loopMarkB.Refresh()
+}
+
 func (w *WaveformSeekbar) UpdateImage(img *backend.WaveformImage) {
 	prm, fg, _ := w.getThemeColors()
 	recolorWaveformImage(img, prm, fg, 0, w.imgProgressPixel, true)
@@ -61,6 +120,7 @@ func (w *WaveformSeekbar) Refresh() {
 	recolorWaveformImage(img, prm, fg, w.imgProgressPixel, w.imgProgressPixel, true)
 	w.recolorCursor(prm, fg, w.cursor.Position().X)
 	w.focus.FillColor = focus
+	w.layoutLoopMarkers()
 
 	w.BaseWidget.Refresh()
 }
@@ -135,11 +195,45 @@ func (w *WaveformSeekbar) Tapped(e *fyne.PointEvent) {
 	}
 }
 
+var _ fyne.SecondaryTappable = (*WaveformSeekbar)(nil)
+
+func (w *WaveformSeekbar) TappedSecondary(e *fyne.PointEvent) {
+	if w.Disabled() {
+		return
+	}
+	pos := float64(e.Position.X / w.Size().Width)
+	showLoopMenu(w, e, pos, w.loopA >= 0 || w.loopB >= 0, w.OnSetLoopPoint, w.OnClearLoop)
+}
+
+// showLoopMenu shows the A-B loop context menu of a seekbar
+// tapped at pos (ratio from 0 to 1).
+func showLoopMenu(seekbar fyne.CanvasObject, e *fyne.PointEvent, pos float64, hasLoop bool, onSetLoopPoint func(float64, bool), onClearLoop func()) {
+	setA := fyne.NewMenuItem(lang.L("Set loop start (A) here"), func() {
+		if onSetLoopPoint != nil {
+			onSetLoopPoint(pos, true)
+		}
+	})
+	setB := fyne.NewMenuItem(lang.L("Set loop end (B) here"), func() {
+		if onSetLoopPoint != nil {
+			onSetLoopPoint(pos, false)
+		}
+	})
+	clearLoop := fyne.NewMenuItem(lang.L("Clear A-B loop"), func() {
+		if onClearLoop != nil {
+			onClearLoop()
+		}
+	})
+	clearLoop.Disabled = !hasLoop
+	menu := widget.NewPopUpMenu(fyne.NewMenu("", setA, setB, clearLoop),
+		fyne.CurrentApp().Driver().CanvasForObject(seekbar))
+	menu.ShowAtPosition(e.AbsolutePosition)
+}
+
 func (w *WaveformSeekbar) CreateRenderer() fyne.WidgetRenderer {
 	return widget.NewSimpleRenderer(
 		container.NewStack(
 			container.New(layout.NewCustomPaddedLayout(4, 4, 0, 0), w.img),
-			container.NewWithoutLayout(w.cursor, w.focus),
+			container.NewWithoutLayout(w.loopRegion, w.loopMarkA, w.loopMarkB, w.cursor, w.focus),
 		),
 	)
 }
@@ -0,0 +1,35 @@
+//go:build darwin
+
+package ui
+
+/*
+int windowPosition(void* windowPtr, int* x, int* y);
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver"
+)
+
+// windowPosition returns the screen position of the window's content area,
+// in the same coordinates used by desktop.Window.RequestPosition.
+func windowPosition(w fyne.Window) (x, y int, ok bool) {
+	nw, isNative := w.(driver.NativeWindow)
+	if !isNative {
+		return 0, 0, false
+	}
+	nw.RunNative(func(ctx any) {
+		mac, isMac := ctx.(driver.MacWindowContext)
+		if !isMac || mac.NSWindow == 0 {
+			return
+		}
+		var cx, cy C.int
+		if C.windowPosition(unsafe.Pointer(mac.NSWindow), &cx, &cy) != 0 {
+			x, y, ok = int(cx), int(cy), true
+		}
+	})
+	return x, y, ok
+}
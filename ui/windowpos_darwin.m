@@ -0,0 +1,16 @@
+//go:build darwin
+
+#import <AppKit/AppKit.h>
+
+// windowPosition gets the top left of the window's content area, with the
+// origin at the top left of the main display, as used by GLFW.
+int windowPosition(void* windowPtr, int* x, int* y) {
+    if (windowPtr == NULL) return 0;
+
+    NSWindow* window = (__bridge NSWindow*)windowPtr;
+    NSRect content = [window contentRectForFrameRect:[window frame]];
+    CGFloat screenHeight = CGDisplayBounds(CGMainDisplayID()).size.height;
+    *x = (int)content.origin.x;
+    *y = (int)(screenHeight - content.origin.y - content.size.height);
+    return 1;
+}
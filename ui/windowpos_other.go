@@ -0,0 +1,10 @@
+//go:build !darwin && !windows && !((linux || freebsd || openbsd || netbsd) && (x11 || !wayland))
+
+package ui
+
+import "fyne.io/fyne/v2"
+
+// windowPosition is not supported without X11, e.g. in Wayland-only builds.
+func windowPosition(fyne.Window) (x, y int, ok bool) {
+	return 0, 0, false
+}
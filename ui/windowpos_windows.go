@@ -0,0 +1,32 @@
+//go:build windows
+
+package ui
+
+import (
+	"syscall"
+	"unsafe"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver"
+)
+
+var (
+	user32         = syscall.NewLazyDLL("user32.dll")
+	clientToScreen = user32.NewProc("ClientToScreen")
+)
+
+// windowPosition returns the screen position of the window's content area,
+// in the same coordinates used by desktop.Window.RequestPosition.
+func windowPosition(w fyne.Window) (x, y int, ok bool) {
+	w.(driver.NativeWindow).RunNative(func(ctx any) {
+		hwnd := ctx.(driver.WindowsWindowContext).HWND
+		if hwnd == 0 {
+			return
+		}
+		var pt struct{ X, Y int32 }
+		if ret, _, _ := clientToScreen.Call(hwnd, uintptr(unsafe.Pointer(&pt))); ret != 0 {
+			x, y, ok = int(pt.X), int(pt.Y), true
+		}
+	})
+	return x, y, ok
+}
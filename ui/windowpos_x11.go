@@ -0,0 +1,45 @@
+//go:build (linux || freebsd || openbsd || netbsd) && (x11 || !wayland)
+
+package ui
+
+/*
+#cgo LDFLAGS: -lX11
+#include <X11/Xlib.h>
+
+// opened on first use and kept open; only used from the main thread
+static Display* display = NULL;
+
+static int windowPosition(unsigned long window, int* x, int* y) {
+	if (display == NULL && (display = XOpenDisplay(NULL)) == NULL) return 0;
+	Window child;
+	return XTranslateCoordinates(display, (Window)window, DefaultRootWindow(display), 0, 0, x, y, &child);
+}
+*/
+import "C"
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver"
+)
+
+// windowPosition returns the screen position of the window's content area,
+// in the same coordinates used by desktop.Window.RequestPosition.
+// Wayland does not let applications position windows, so it is only supported on X11,
+// in builds whose GLFW driver (like Fyne's default Linux and BSD builds) supports it.
+func windowPosition(w fyne.Window) (x, y int, ok bool) {
+	nw, isNative := w.(driver.NativeWindow)
+	if !isNative {
+		return 0, 0, false
+	}
+	nw.RunNative(func(ctx any) {
+		x11, isX11 := ctx.(driver.X11WindowContext)
+		if !isX11 || x11.WindowHandle == 0 {
+			return
+		}
+		var cx, cy C.int
+		if C.windowPosition(C.ulong(x11.WindowHandle), &cx, &cy) != 0 {
+			x, y, ok = int(cx), int(cy), true
+		}
+	})
+	return x, y, ok
+}